@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/config"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/dataset"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/query"
+	"github.com/spf13/cobra"
+)
+
+var datasetCmd = &cobra.Command{
+	Use:   "dataset",
+	Short: "Inspect and check the local dataset",
+	Long: `Inspect and check the dataset a server in this data directory serves, without opening
+DuckDB by hand.`,
+}
+
+var datasetVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check the active dataset against its metadata and run sample queries",
+	Long: `Recompute the SHA256 of the active dataset and compare it and the file size with
+metadata.json, check that the parquet schema can be read and has the columns the server
+queries, then run a barcode lookup, a brand search and a structured query against it.
+
+Exits with an error on the first failed check.`,
+	Args: cobra.NoArgs,
+	RunE: runDatasetVerify,
+}
+
+var datasetInfoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Print what the active dataset contains and where it came from",
+	Long: `Print the size, row count, unique barcodes, download time, ETag, source and schema
+fingerprint of the active dataset, along with applied delta exports and prune filters.
+
+The schema fingerprint is the SHA256 of the column names and types, so two datasets with
+the same fingerprint can be queried the same way.`,
+	Args: cobra.NoArgs,
+	RunE: runDatasetInfo,
+}
+
+func init() {
+	datasetInfoCmd.Flags().Bool("json", false, "Print the information as JSON")
+	datasetCmd.AddCommand(datasetVerifyCmd, datasetInfoCmd)
+	rootCmd.AddCommand(datasetCmd)
+}
+
+// runDatasetVerify checks the active dataset and exits
+func runDatasetVerify(cmd *cobra.Command, args []string) error {
+	logger := config.NewTextLogger(os.Stdout)
+
+	// Load configuration
+	cfg := config.Load()
+
+	logger.Info("🔍 Verifying dataset",
+		"mode", "verify",
+		"parquet_path", cfg.ParquetPath,
+		"metadata_path", cfg.MetadataPath)
+
+	dataManager := dataset.NewManager(
+		cfg.ParquetURL,
+		cfg.ParquetPath,
+		cfg.MetadataPath,
+		cfg.LockFile,
+		cfg,
+		logger,
+	)
+	dataManager.SetValidator(query.ValidateDataset)
+
+	ctx := context.Background()
+	meta, err := dataManager.Verify(ctx)
+	if err != nil {
+		logger.Error("Dataset verification failed", "error", err)
+		return err
+	}
+
+	start := time.Now()
+	if err := query.RunSampleQueries(ctx, cfg.ParquetPath, cfg, logger); err != nil {
+		logger.Error("Sample queries failed", "error", err)
+		return err
+	}
+	logger.Info("Sample queries succeeded", "duration", time.Since(start))
+
+	logger.Info("✅ Dataset verified successfully",
+		"sha256", meta.SHA256,
+		"size", meta.Size,
+		"downloaded_at", meta.DownloadedAt)
+
+	return nil
+}
+
+// datasetInfo is the output of dataset info
+type datasetInfo struct {
+	Path   string `json:"path"`
+	Source string `json:"source"`
+	dataset.Metadata
+	query.DatasetStats
+}
+
+// runDatasetInfo prints information about the active dataset and exits
+func runDatasetInfo(cmd *cobra.Command, args []string) error {
+	// Logs go to stderr so the output can be piped
+	logger := config.NewLogger(true)
+
+	// Load configuration
+	cfg := config.Load()
+
+	dataManager := dataset.NewManager(
+		cfg.ParquetURL,
+		cfg.ParquetPath,
+		cfg.MetadataPath,
+		cfg.LockFile,
+		cfg,
+		logger,
+	)
+
+	meta, err := dataManager.Metadata()
+	if err != nil {
+		logger.Error("Failed to load dataset metadata", "metadata_path", cfg.MetadataPath, "error", err)
+		return err
+	}
+	stats, err := query.InspectDataset(context.Background(), cfg.ParquetPath)
+	if err != nil {
+		logger.Error("Failed to inspect dataset", "parquet_path", cfg.ParquetPath, "error", err)
+		return err
+	}
+
+	info := datasetInfo{Path: cfg.ParquetPath, Source: cfg.ParquetURL, Metadata: *meta, DatasetStats: *stats}
+	if len(meta.ImportedFrom) > 0 {
+		info.Source = "import: " + strings.Join(meta.ImportedFrom, ", ")
+	}
+
+	out := cmd.OutOrStdout()
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(info)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Path:\t%s\n", info.Path)
+	fmt.Fprintf(w, "Source:\t%s\n", info.Source)
+	fmt.Fprintf(w, "SHA256:\t%s\n", meta.SHA256)
+	fmt.Fprintf(w, "Size:\t%d bytes\n", meta.Size)
+	fmt.Fprintf(w, "Downloaded at:\t%s\n", meta.DownloadedAt.Format(time.RFC3339))
+	fmt.Fprintf(w, "ETag:\t%s\n", meta.ETag)
+	fmt.Fprintf(w, "Rows:\t%d\n", stats.Rows)
+	fmt.Fprintf(w, "Unique codes:\t%d\n", stats.UniqueCodes)
+	fmt.Fprintf(w, "Columns:\t%d\n", stats.Columns)
+	fmt.Fprintf(w, "Schema fingerprint:\t%s\n", stats.SchemaFingerprint)
+	if meta.UpstreamSHA256 != "" {
+		fmt.Fprintf(w, "Upstream SHA256:\t%s\n", meta.UpstreamSHA256)
+		fmt.Fprintf(w, "Upstream size:\t%d bytes\n", meta.UpstreamSize)
+	}
+	if len(meta.Deltas) > 0 {
+		fmt.Fprintf(w, "Delta exports:\t%d, latest %s\n", len(meta.Deltas), meta.Deltas[len(meta.Deltas)-1])
+	}
+	if len(meta.RejectedDeltas) > 0 {
+		fmt.Fprintf(w, "Rejected delta exports:\t%d\n", len(meta.RejectedDeltas))
+	}
+	if meta.Pruned != nil {
+		fmt.Fprintf(w, "Pruned:\tcountries=%s languages=%s require_nutrition=%t\n",
+			strings.Join(meta.Pruned.Countries, ","), strings.Join(meta.Pruned.Languages, ","), meta.Pruned.RequireNutrition)
+	}
+	if meta.Rejected {
+		fmt.Fprintf(w, "Rejected:\ttrue\n")
+	}
+	return w.Flush()
+}
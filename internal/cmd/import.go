@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/config"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/dataset"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/query"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <export> [export...]",
+	Short: "Convert Open Food Facts JSONL or CSV exports into the dataset",
+	Long: `Convert the official Open Food Facts exports into the parquet dataset the server queries,
+for environments that cannot download the Hugging Face parquet file.
+
+Accepts the JSON Lines export (openfoodfacts-products.jsonl.gz) or the tab-separated
+CSV export (en.openfoodfacts.org.products.csv.gz), compressed or not. DuckDB streams the
+conversion, so the export does not need to fit in memory.
+
+The result is stored and activated as a new dataset version with a matching metadata.json.
+An imported dataset is not replaced by downloads from PARQUET_URL; run import again to update
+it, or let DELTA_URL apply the daily delta exports on top of it.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runImportMode,
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+}
+
+// runImportMode converts exports into the active dataset and exits
+func runImportMode(cmd *cobra.Command, args []string) error {
+	logger := config.NewTextLogger(os.Stdout)
+
+	// Load configuration
+	cfg := config.Load()
+
+	logger.Info("🗄️  Starting dataset import",
+		"mode", "import",
+		"files", args,
+		"target_dir", cfg.DataDir)
+
+	dataManager := dataset.NewManager(
+		cfg.ParquetURL,
+		cfg.ParquetPath,
+		cfg.MetadataPath,
+		cfg.LockFile,
+		cfg,
+		logger,
+	)
+	dataManager.SetValidator(query.ValidateDataset)
+
+	meta, err := dataManager.Import(context.Background(), args, query.ImportProducts)
+	if err != nil {
+		logger.Error("Failed to import dataset", "error", err)
+		return err
+	}
+
+	logger.Info("✅ Dataset imported successfully",
+		"sha256", meta.SHA256,
+		"size", meta.Size,
+		"parquet_path", cfg.ParquetPath,
+		"metadata_path", cfg.MetadataPath)
+
+	return nil
+}
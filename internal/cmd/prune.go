@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/config"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/dataset"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/query"
+	"github.com/spf13/cobra"
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Build a reduced dataset for memory-constrained deployments",
+	Long: `Replace the active dataset with a reduced copy that keeps only the columns the server
+reads, optionally limited to products sold in some countries, named in some languages or
+with nutrition facts.
+
+Filters default to PRUNE_COUNTRIES, PRUNE_LANGUAGES and PRUNE_REQUIRE_NUTRITION. Countries
+are countries_tags such as en:france; a plain name such as France gets the en: prefix.
+
+The reduced dataset is stored and activated as a new dataset version. Its metadata.json
+keeps the upstream ETag and size, so refreshes still detect new upstream versions. The
+full version stays stored, so running prune again with other filters starts from it.
+Set PRUNE_DATASET=true to prune every download and delta update automatically.`,
+	Args: cobra.NoArgs,
+	RunE: runPruneMode,
+}
+
+func init() {
+	pruneCmd.Flags().StringSlice("country", nil, "Keep products sold in these countries (default: PRUNE_COUNTRIES)")
+	pruneCmd.Flags().StringSlice("language", nil, "Keep products with a name in these languages (default: PRUNE_LANGUAGES)")
+	pruneCmd.Flags().Bool("require-nutrition", false, "Keep only products with nutrition facts (default: PRUNE_REQUIRE_NUTRITION)")
+	rootCmd.AddCommand(pruneCmd)
+}
+
+// runPruneMode prunes the active dataset and exits
+func runPruneMode(cmd *cobra.Command, args []string) error {
+	logger := config.NewTextLogger(os.Stdout)
+
+	// Load configuration
+	cfg := config.Load()
+
+	filter := dataset.PruneFilter{
+		Countries:        cfg.PruneCountries,
+		Languages:        cfg.PruneLanguages,
+		RequireNutrition: cfg.PruneRequireNutrition,
+	}
+	if cmd.Flags().Changed("country") {
+		filter.Countries, _ = cmd.Flags().GetStringSlice("country")
+	}
+	if cmd.Flags().Changed("language") {
+		filter.Languages, _ = cmd.Flags().GetStringSlice("language")
+	}
+	if cmd.Flags().Changed("require-nutrition") {
+		filter.RequireNutrition, _ = cmd.Flags().GetBool("require-nutrition")
+	}
+
+	logger.Info("✂️  Starting dataset prune",
+		"mode", "prune",
+		"countries", filter.Countries,
+		"languages", filter.Languages,
+		"require_nutrition", filter.RequireNutrition,
+		"target_dir", cfg.DataDir)
+
+	dataManager := dataset.NewManager(
+		cfg.ParquetURL,
+		cfg.ParquetPath,
+		cfg.MetadataPath,
+		cfg.LockFile,
+		cfg,
+		logger,
+	)
+	dataManager.SetValidator(query.ValidateDataset)
+	dataManager.SetPruner(pruneDataset)
+
+	meta, err := dataManager.Prune(context.Background(), filter)
+	if err != nil {
+		logger.Error("Failed to prune dataset", "error", err)
+		return err
+	}
+
+	logger.Info("✅ Dataset pruned successfully",
+		"sha256", meta.SHA256,
+		"size", meta.Size,
+		"upstream_size", meta.UpstreamSize,
+		"parquet_path", cfg.ParquetPath,
+		"metadata_path", cfg.MetadataPath)
+
+	return nil
+}
+
+// pruneDataset adapts query.PruneDataset to the dataset manager's Pruner
+func pruneDataset(ctx context.Context, basePath string, filter dataset.PruneFilter, outPath string) (int64, error) {
+	return query.PruneDataset(ctx, basePath, query.PruneOptions(filter), outPath)
+}
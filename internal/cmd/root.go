@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"log/slog"
 	"os"
 	"path/filepath"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/noot-app/openfoodfacts-mcp-server/internal/dataset"
 	"github.com/noot-app/openfoodfacts-mcp-server/internal/mcpgo"
 	"github.com/noot-app/openfoodfacts-mcp-server/internal/query"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/refresh"
 	"github.com/spf13/cobra"
 )
 
@@ -20,7 +22,7 @@ var rootCmd = &cobra.Command{
 	Long: `OpenFoodFacts MCP Server provides access to the Open Food Facts dataset
 via a remote MCP server using DuckDB for fast queries.
 
-The server operates in three modes:
+The server operates in four modes:
 
 1. STDIO Mode (--stdio): For local Claude Desktop integration
    - Uses stdio pipes for communication
@@ -37,14 +39,47 @@ The server operates in three modes:
    - Checks if local dataset is up-to-date with remote
    - Exits after download completion (does not start server)
    - Useful for pre-populating dataset cache
+   - Logs progress (bytes, rate, ETA) and resumes interrupted downloads
+   - Applies new Open Food Facts delta exports when DELTA_URL is set
 
-The server downloads and caches the Open Food Facts Parquet dataset
-and provides MCP-compliant endpoints for product searches, nutrition analysis,
+4. Rollback Mode (--rollback): Reactivate a previous dataset version and exit
+   - Every download is kept in its own directory under DATASET_VERSIONS_DIR
+   - Switches back to the version downloaded before the active one, or to
+     the version given with --rollback-to <sha256 prefix>
+   - Running servers read the switched file on their next query
+   - The rolled-back version is not downloaded again while upstream still publishes it
+
+The import command converts the official JSONL or CSV exports into the dataset
+for environments without access to the Hugging Face parquet file.
+
+The prune command reduces the dataset to the columns the server reads, optionally
+limited to some countries, languages or products with nutrition facts, for
+memory-constrained deployments; PRUNE_DATASET=true applies it to every update.
+
+The dataset info and dataset verify commands show what the local dataset contains
+and check it against its metadata.json with sample queries.
+
+The server downloads and caches the Open Food Facts Parquet dataset,
+re-checks it every REFRESH_INTERVAL_SECONDS (0 disables) and merges the daily
+delta exports from DELTA_URL in between full downloads. When the dataset changes
+it swaps in a new query engine without a restart.
+
+It provides MCP-compliant endpoints for product searches, nutrition analysis,
 and barcode lookups.
 
 Available MCP Tools:
 - search_products_by_brand_and_name: Search products by name and brand
 - search_by_barcode: Find product by barcode (UPC/EAN)
+- query_products: Structured filters over tags, scores and nutriments
+- packaging_summary: Packaging materials and recyclability for one or more barcodes
+- nutrition_for_quantity: Scaled nutrients for grams, millilitres or servings of a product
+- calculate_meal: Nutrient totals for a meal made of several products
+- nutrition_label: US Nutrition Facts or EU nutrition table as text, Markdown or SVG
+- nutri_score: Official or locally computed Nutri-Score (2023) with component points
+- check_diet: Check a product against a diet profile (keto, vegan, halal, ...) with explanations
+- product_history: Fields that changed for a product across retained dataset snapshots
+- changed_products: Paged feed of products changed since a time or since the previous snapshot
+- run_sql: Read-only SELECT against a curated products view (opt-in via ENABLE_SQL_TOOL)
 
 Authentication (HTTP Mode Only):
 Bearer token authentication is required for all MCP endpoints except /health.
@@ -56,6 +91,13 @@ Use the OPENFOODFACTS_MCP_TOKEN environment variable to set the token.`,
 			return runFetchDBMode(cmd, args)
 		}
 
+		// Check if we should roll back to a previous dataset version
+		rollback, _ := cmd.Flags().GetBool("rollback")
+		rollbackTo, _ := cmd.Flags().GetString("rollback-to")
+		if rollback || rollbackTo != "" {
+			return runRollbackMode(cmd, rollbackTo)
+		}
+
 		// Check if we should run in stdio mode (for Claude Desktop)
 		stdio, _ := cmd.Flags().GetBool("stdio")
 
@@ -70,6 +112,8 @@ Use the OPENFOODFACTS_MCP_TOKEN environment variable to set the token.`,
 func init() {
 	rootCmd.Flags().Bool("stdio", false, "Run in stdio mode for local Claude Desktop integration (default: HTTP mode for remote deployment)")
 	rootCmd.Flags().Bool("fetch-db", false, "Fetch the database and exit (useful for downloading the dataset without starting the server)")
+	rootCmd.Flags().Bool("rollback", false, "Reactivate the dataset version downloaded before the active one and exit")
+	rootCmd.Flags().String("rollback-to", "", "Reactivate the dataset version whose SHA256 starts with this prefix and exit")
 }
 
 // runFetchDBMode fetches the database and exits
@@ -87,7 +131,8 @@ func runFetchDBMode(cmd *cobra.Command, args []string) error {
 
 	logger.Info("⚠️  Large dataset warning",
 		"message", "The OpenFoodFacts dataset is approximately 4+ GB in size",
-		"note", "Initial download may take several minutes depending on your internet connection")
+		"note", "Initial download may take several minutes depending on your internet connection",
+		"progress", "Logged every "+dataset.DefaultProgressInterval.String()+"; an interrupted download resumes where it stopped")
 
 	// Initialize dataset manager
 	dataManager := dataset.NewManager(
@@ -98,6 +143,9 @@ func runFetchDBMode(cmd *cobra.Command, args []string) error {
 		cfg,
 		logger,
 	)
+	dataManager.SetValidator(query.ValidateDataset)
+	dataManager.SetDeltaMerger(query.MergeDeltas)
+	dataManager.SetPruner(pruneDataset)
 
 	// Ensure dataset is available (this will download if needed)
 	ctx := context.Background()
@@ -113,6 +161,50 @@ func runFetchDBMode(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// runRollbackMode reactivates a stored dataset version and exits
+func runRollbackMode(cmd *cobra.Command, sha string) error {
+	logger := config.NewTextLogger(os.Stdout)
+
+	// Load configuration
+	cfg := config.Load()
+
+	dataManager := dataset.NewManager(
+		cfg.ParquetURL,
+		cfg.ParquetPath,
+		cfg.MetadataPath,
+		cfg.LockFile,
+		cfg,
+		logger,
+	)
+
+	versions, err := dataManager.Versions()
+	if err != nil {
+		logger.Error("Failed to list dataset versions", "error", err)
+		return err
+	}
+	for _, v := range versions {
+		logger.Info("Stored dataset version",
+			"sha256", v.SHA256,
+			"downloaded_at", v.DownloadedAt,
+			"etag", v.ETag,
+			"active", v.Active,
+			"rejected", v.Rejected)
+	}
+
+	meta, err := dataManager.Rollback(sha)
+	if err != nil {
+		logger.Error("Failed to roll back dataset", "error", err)
+		return err
+	}
+
+	logger.Info("✅ Dataset rolled back successfully",
+		"sha256", meta.SHA256,
+		"downloaded_at", meta.DownloadedAt,
+		"parquet_path", cfg.ParquetPath)
+
+	return nil
+}
+
 // runStdioMode runs the MCP server in stdio mode for Claude Desktop
 func runStdioMode(cmd *cobra.Command, args []string) error {
 	// Use a logger that writes to stderr to avoid interfering with stdio MCP communication
@@ -136,6 +228,9 @@ func runStdioMode(cmd *cobra.Command, args []string) error {
 		cfg,
 		logger,
 	)
+	dataManager.SetValidator(query.ValidateDataset)
+	dataManager.SetDeltaMerger(query.MergeDeltas)
+	dataManager.SetPruner(pruneDataset)
 
 	// Ensure dataset is available
 	ctx := context.Background()
@@ -157,11 +252,18 @@ func runStdioMode(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Serve queries through a swappable engine so background refreshes can replace it
+	swappableEngine := query.NewSwappableEngine(queryEngine, logger)
+
+	// Fingerprint the dataset for product_history
+	recordSnapshot(swappableEngine, dataManager, cfg, logger)
+	startRefreshScheduler(swappableEngine, dataManager, cfg, logger)
+
 	// Create auth (not needed for stdio but required by constructor)
 	authenticator := auth.NewBearerTokenAuth(cfg.AuthToken)
 
 	// Create MCP server
-	mcpSrv := mcpgo.NewServer(queryEngine, authenticator, logger)
+	mcpSrv := mcpgo.NewServer(swappableEngine, authenticator, cfg, logger)
 
 	// Run the MCP server on stdio transport (no auth needed for local use)
 	return mcpSrv.ServeStdio()
@@ -191,6 +293,9 @@ func runHTTPMode(cmd *cobra.Command, args []string) error {
 		cfg,
 		logger,
 	)
+	dataManager.SetValidator(query.ValidateDataset)
+	dataManager.SetDeltaMerger(query.MergeDeltas)
+	dataManager.SetPruner(pruneDataset)
 
 	// Ensure dataset is available
 	ctx := context.Background()
@@ -212,16 +317,57 @@ func runHTTPMode(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Serve queries through a swappable engine so background refreshes can replace it
+	swappableEngine := query.NewSwappableEngine(queryEngine, logger)
+
+	// Fingerprint the dataset for product_history
+	recordSnapshot(swappableEngine, dataManager, cfg, logger)
+	startRefreshScheduler(swappableEngine, dataManager, cfg, logger)
+
 	// Create auth
 	authenticator := auth.NewBearerTokenAuth(cfg.AuthToken)
 
 	// Create MCP server
-	mcpSrv := mcpgo.NewServer(queryEngine, authenticator, logger)
+	mcpSrv := mcpgo.NewServer(swappableEngine, authenticator, cfg, logger)
 
 	// Run the MCP server on HTTP transport with auth
 	return mcpSrv.ServeHTTP(":" + cfg.Port)
 }
 
+// recordSnapshot fingerprints the active dataset in the background so product_history can compare it with earlier ones
+// It runs through the swappable engine so a refresh cannot close the engine while the snapshot is written
+func recordSnapshot(swappableEngine *query.SwappableEngine, dataManager *dataset.Manager, cfg *config.Config, logger *slog.Logger) {
+	if cfg.HistorySnapshots <= 0 {
+		return
+	}
+
+	meta, err := dataManager.Metadata()
+	if err != nil {
+		logger.Warn("Dataset metadata unavailable, snapshot not recorded for product history", "error", err)
+		return
+	}
+
+	go func() {
+		if _, err := swappableEngine.RecordSnapshot(context.Background(), meta.SHA256, meta.DownloadedAt); err != nil {
+			logger.Error("Failed to record dataset snapshot", "error", err)
+		}
+	}()
+}
+
+// startRefreshScheduler checks for a new dataset every REFRESH_INTERVAL_SECONDS and hot-swaps the query engine when it changes
+func startRefreshScheduler(swappableEngine *query.SwappableEngine, dataManager *dataset.Manager, cfg *config.Config, logger *slog.Logger) {
+	newEngine := func() (query.QueryEngine, error) {
+		return query.NewEngine(cfg.ParquetPath, cfg, logger)
+	}
+
+	scheduler := refresh.NewScheduler(dataManager, swappableEngine, newEngine, cfg.RefreshInterval(), logger)
+	scheduler.OnSwap(func(query.QueryEngine) {
+		recordSnapshot(swappableEngine, dataManager, cfg, logger)
+	})
+
+	go scheduler.Run(context.Background())
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() error {
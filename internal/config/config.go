@@ -34,10 +34,32 @@ type Config struct {
 	MetadataPath string
 	LockFile     string
 
+	// S3-compatible dataset source, used when PARQUET_URL is s3://bucket/key
+	S3Endpoint        string // Custom endpoint such as a MinIO server, empty for AWS
+	S3Region          string // Signing region (default: "us-east-1")
+	S3AccessKeyID     string // Empty for anonymous access to public buckets
+	S3SecretAccessKey string
+	S3SessionToken    string
+	S3UsePathStyle    bool // Address buckets as endpoint/bucket/key instead of bucket.endpoint/key
+
+	// Dataset versions
+	VersionsDir     string // Directory holding one subdirectory per downloaded dataset version
+	DatasetVersions int    // Number of dataset versions kept for rollback (default: 3)
+
+	// Daily delta exports applied on top of the full dataset
+	DeltaURL string // index.txt of the Open Food Facts delta exports or a local directory, empty to disable
+
+	// Slim dataset for memory-constrained deployments
+	PruneDataset          bool     // Replace each new dataset version with a pruned copy
+	PruneCountries        []string // Keep products sold in one of these countries, empty for all
+	PruneLanguages        []string // Keep products named in one of these languages, empty for all
+	PruneRequireNutrition bool     // Keep only products with nutrition facts per 100g
+
 	// Refresh behavior
 	RefreshIntervalSeconds int
 	DisableRemoteCheck     bool
 	IgnoreLock             bool
+	LockWaitTimeoutSeconds int // Longest wait for another instance holding the dataset lock (default: 600)
 
 	// Server
 	Port string
@@ -55,6 +77,23 @@ type Config struct {
 	DuckDBMaxOpenConns    int // Maximum open connections (default: 4)
 	DuckDBMaxIdleConns    int // Maximum idle connections (default: 2)
 	DuckDBConnMaxLifetime int // Connection lifetime in minutes (default: 60)
+
+	// Admission control for queries waiting on the connection pool
+	DuckDBMaxQueueDepth  int // Maximum queries waiting for a connection (default: 16)
+	DuckDBQueueTimeoutMs int // Maximum time a query waits for a connection in ms (default: 2000)
+
+	// Read-only SQL tool for power users (disabled by default)
+	EnableSQLTool         bool   // Expose the run_sql MCP tool
+	SQLToolRowLimit       int    // Maximum rows returned per statement (default: 500)
+	SQLToolTimeoutSeconds int    // Statement timeout in seconds (default: 10)
+	SQLToolMemoryLimit    string // DuckDB memory limit for ad-hoc queries (default: "1GB")
+
+	// Diet profiles
+	DietProfilesDir string // Directory of custom check_diet profiles (*.json), loaded at startup
+
+	// Product history
+	HistoryDir       string // Directory of per-snapshot product fingerprints
+	HistorySnapshots int    // Number of dataset snapshots to retain, 0 to disable (default: 30)
 }
 
 // IsDevelopment returns true if running in development mode
@@ -111,6 +150,20 @@ func LoadWithFileReader(fileReader FileReader) *Config {
 		}
 	}
 
+	maxQueueDepth := 16 // Default queue depth before shedding load
+	if env := os.Getenv("DUCKDB_MAX_QUEUE_DEPTH"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed >= 0 {
+			maxQueueDepth = parsed
+		}
+	}
+
+	queueTimeoutMs := 2000 // 2 seconds default
+	if env := os.Getenv("DUCKDB_QUEUE_TIMEOUT_MS"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed >= 0 {
+			queueTimeoutMs = parsed
+		}
+	}
+
 	preserveInsertionOrder := true // Default to true for data integrity
 	if p := os.Getenv("DUCKDB_PRESERVE_INSERTION_ORDER"); p != "" {
 		if parsed, err := strconv.ParseBool(p); err == nil {
@@ -134,6 +187,70 @@ func LoadWithFileReader(fileReader FileReader) *Config {
 		}
 	}
 
+	lockWaitTimeout := 600 // Default to 10 minutes
+	if env := os.Getenv("LOCK_WAIT_TIMEOUT_SECONDS"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			lockWaitTimeout = parsed
+		}
+	}
+
+	// Parse run_sql tool settings
+	enableSQLTool := false // Default to false, must be explicitly enabled
+	if e := os.Getenv("ENABLE_SQL_TOOL"); e != "" {
+		if parsed, err := strconv.ParseBool(e); err == nil {
+			enableSQLTool = parsed
+		}
+	}
+
+	sqlToolRowLimit := 500 // Default row limit
+	if env := os.Getenv("SQL_TOOL_ROW_LIMIT"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 && parsed <= 10000 {
+			sqlToolRowLimit = parsed
+		}
+	}
+
+	sqlToolTimeout := 10 // 10 seconds default
+	if env := os.Getenv("SQL_TOOL_TIMEOUT_SECONDS"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			sqlToolTimeout = parsed
+		}
+	}
+
+	historySnapshots := 30 // Default to about a month of daily refreshes
+	if env := os.Getenv("HISTORY_SNAPSHOTS"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed >= 0 {
+			historySnapshots = parsed
+		}
+	}
+
+	s3UsePathStyle := false
+	if env := os.Getenv("S3_USE_PATH_STYLE"); env != "" {
+		if parsed, err := strconv.ParseBool(env); err == nil {
+			s3UsePathStyle = parsed
+		}
+	}
+
+	datasetVersions := 3 // Active version plus two to roll back to
+	if env := os.Getenv("DATASET_VERSIONS"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			datasetVersions = parsed
+		}
+	}
+
+	pruneDataset := false
+	if env := os.Getenv("PRUNE_DATASET"); env != "" {
+		if parsed, err := strconv.ParseBool(env); err == nil {
+			pruneDataset = parsed
+		}
+	}
+
+	pruneRequireNutrition := false
+	if env := os.Getenv("PRUNE_REQUIRE_NUTRITION"); env != "" {
+		if parsed, err := strconv.ParseBool(env); err == nil {
+			pruneRequireNutrition = parsed
+		}
+	}
+
 	return &Config{
 		AuthToken:              getEnv("OPENFOODFACTS_MCP_TOKEN", "super-secret-token"),
 		ParquetURL:             getEnv("PARQUET_URL", "https://huggingface.co/datasets/openfoodfacts/product-database/resolve/main/product-database.parquet"),
@@ -144,6 +261,7 @@ func LoadWithFileReader(fileReader FileReader) *Config {
 		RefreshIntervalSeconds: refreshSeconds,
 		DisableRemoteCheck:     disableRemoteCheck,
 		IgnoreLock:             ignoreLock,
+		LockWaitTimeoutSeconds: lockWaitTimeout,
 		Port:                   getEnv("PORT", "8080"),
 		Environment:            getEnv("ENV", "production"),
 
@@ -157,6 +275,44 @@ func LoadWithFileReader(fileReader FileReader) *Config {
 		DuckDBMaxOpenConns:    maxOpenConns,
 		DuckDBMaxIdleConns:    maxIdleConns,
 		DuckDBConnMaxLifetime: connMaxLifetime,
+
+		// Admission control settings
+		DuckDBMaxQueueDepth:  maxQueueDepth,
+		DuckDBQueueTimeoutMs: queueTimeoutMs,
+
+		// run_sql tool settings
+		EnableSQLTool:         enableSQLTool,
+		SQLToolRowLimit:       sqlToolRowLimit,
+		SQLToolTimeoutSeconds: sqlToolTimeout,
+		SQLToolMemoryLimit:    getEnv("SQL_TOOL_MEMORY_LIMIT", "1GB"),
+
+		// Custom diet profiles, in addition to the built-in ones
+		DietProfilesDir: getEnv("DIET_PROFILES_DIR", ""),
+
+		// Product history across dataset snapshots
+		HistoryDir:       getEnv("HISTORY_DIR", filepath.Join(dataDir, "history")),
+		HistorySnapshots: historySnapshots,
+
+		// S3-compatible dataset source, with the usual AWS credential variables
+		S3Endpoint:        getEnv("S3_ENDPOINT", ""),
+		S3Region:          getEnv("S3_REGION", getEnv("AWS_REGION", "us-east-1")),
+		S3AccessKeyID:     getEnv("AWS_ACCESS_KEY_ID", ""),
+		S3SecretAccessKey: getEnv("AWS_SECRET_ACCESS_KEY", ""),
+		S3SessionToken:    getEnv("AWS_SESSION_TOKEN", ""),
+		S3UsePathStyle:    s3UsePathStyle,
+
+		// Versioned dataset downloads for atomic activation and rollback
+		VersionsDir:     getEnv("DATASET_VERSIONS_DIR", filepath.Join(dataDir, "versions")),
+		DatasetVersions: datasetVersions,
+
+		// Open Food Facts delta exports, merged into the dataset between full downloads
+		DeltaURL: getEnv("DELTA_URL", ""),
+
+		// Pruned dataset keeping only the columns and products the deployment needs
+		PruneDataset:          pruneDataset,
+		PruneCountries:        getEnvList("PRUNE_COUNTRIES"),
+		PruneLanguages:        getEnvList("PRUNE_LANGUAGES"),
+		PruneRequireNutrition: pruneRequireNutrition,
 	}
 }
 
@@ -193,6 +349,21 @@ func loadEnvFileWithReader(fileReader FileReader) {
 	}
 }
 
+// QueueTimeout returns the maximum time a query waits for a connection as a duration
+func (c *Config) QueueTimeout() time.Duration {
+	return time.Duration(c.DuckDBQueueTimeoutMs) * time.Millisecond
+}
+
+// SQLToolTimeout returns the run_sql statement timeout as a duration
+func (c *Config) SQLToolTimeout() time.Duration {
+	return time.Duration(c.SQLToolTimeoutSeconds) * time.Second
+}
+
+// LockWaitTimeout returns the longest wait for the dataset lock as a duration
+func (c *Config) LockWaitTimeout() time.Duration {
+	return time.Duration(c.LockWaitTimeoutSeconds) * time.Second
+}
+
 // RefreshInterval returns the refresh interval as a duration
 func (c *Config) RefreshInterval() time.Duration {
 	return time.Duration(c.RefreshIntervalSeconds) * time.Second
@@ -204,3 +375,14 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvList splits a comma-separated variable, dropping empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
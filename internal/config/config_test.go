@@ -48,6 +48,7 @@ func TestLoad(t *testing.T) {
 				RefreshIntervalSeconds: 86400,
 				DisableRemoteCheck:     false,
 				IgnoreLock:             false,
+				LockWaitTimeoutSeconds: 600,
 				Port:                   "8080",
 				Environment:            "production",
 				// DuckDB defaults
@@ -59,6 +60,22 @@ func TestLoad(t *testing.T) {
 				DuckDBMaxOpenConns:    4,
 				DuckDBMaxIdleConns:    2,
 				DuckDBConnMaxLifetime: 60,
+				// Admission control defaults
+				DuckDBMaxQueueDepth:  16,
+				DuckDBQueueTimeoutMs: 2000,
+				// run_sql defaults
+				EnableSQLTool:         false,
+				SQLToolRowLimit:       500,
+				SQLToolTimeoutSeconds: 10,
+				SQLToolMemoryLimit:    "1GB",
+				// Product history defaults
+				HistoryDir:       "data/history",
+				HistorySnapshots: 30,
+				// S3 source defaults
+				S3Region: "us-east-1",
+				// Dataset version defaults
+				VersionsDir:     "data/versions",
+				DatasetVersions: 3,
 			},
 		},
 		{
@@ -79,6 +96,7 @@ func TestLoad(t *testing.T) {
 				RefreshIntervalSeconds: 43200,
 				DisableRemoteCheck:     false,
 				IgnoreLock:             false,
+				LockWaitTimeoutSeconds: 600,
 				Port:                   "3000",
 				Environment:            "production",
 				// DuckDB defaults
@@ -90,6 +108,22 @@ func TestLoad(t *testing.T) {
 				DuckDBMaxOpenConns:    4,
 				DuckDBMaxIdleConns:    2,
 				DuckDBConnMaxLifetime: 60,
+				// Admission control defaults
+				DuckDBMaxQueueDepth:  16,
+				DuckDBQueueTimeoutMs: 2000,
+				// run_sql defaults
+				EnableSQLTool:         false,
+				SQLToolRowLimit:       500,
+				SQLToolTimeoutSeconds: 10,
+				SQLToolMemoryLimit:    "1GB",
+				// Product history defaults
+				HistoryDir:       "/custom/data/history",
+				HistorySnapshots: 30,
+				// S3 source defaults
+				S3Region: "us-east-1",
+				// Dataset version defaults
+				VersionsDir:     "/custom/data/versions",
+				DatasetVersions: 3,
 			},
 		},
 		{
@@ -107,6 +141,7 @@ func TestLoad(t *testing.T) {
 				RefreshIntervalSeconds: 0,
 				DisableRemoteCheck:     false,
 				IgnoreLock:             false,
+				LockWaitTimeoutSeconds: 600,
 				Port:                   "8080",
 				Environment:            "production",
 				// DuckDB defaults
@@ -118,6 +153,22 @@ func TestLoad(t *testing.T) {
 				DuckDBMaxOpenConns:    4,
 				DuckDBMaxIdleConns:    2,
 				DuckDBConnMaxLifetime: 60,
+				// Admission control defaults
+				DuckDBMaxQueueDepth:  16,
+				DuckDBQueueTimeoutMs: 2000,
+				// run_sql defaults
+				EnableSQLTool:         false,
+				SQLToolRowLimit:       500,
+				SQLToolTimeoutSeconds: 10,
+				SQLToolMemoryLimit:    "1GB",
+				// Product history defaults
+				HistoryDir:       "data/history",
+				HistorySnapshots: 30,
+				// S3 source defaults
+				S3Region: "us-east-1",
+				// Dataset version defaults
+				VersionsDir:     "data/versions",
+				DatasetVersions: 3,
 			},
 		},
 		{
@@ -135,6 +186,7 @@ func TestLoad(t *testing.T) {
 				RefreshIntervalSeconds: 86400,
 				DisableRemoteCheck:     true,
 				IgnoreLock:             false,
+				LockWaitTimeoutSeconds: 600,
 				Port:                   "8080",
 				Environment:            "production",
 				// DuckDB defaults
@@ -146,12 +198,29 @@ func TestLoad(t *testing.T) {
 				DuckDBMaxOpenConns:    4,
 				DuckDBMaxIdleConns:    2,
 				DuckDBConnMaxLifetime: 60,
+				// Admission control defaults
+				DuckDBMaxQueueDepth:  16,
+				DuckDBQueueTimeoutMs: 2000,
+				// run_sql defaults
+				EnableSQLTool:         false,
+				SQLToolRowLimit:       500,
+				SQLToolTimeoutSeconds: 10,
+				SQLToolMemoryLimit:    "1GB",
+				// Product history defaults
+				HistoryDir:       "data/history",
+				HistorySnapshots: 30,
+				// S3 source defaults
+				S3Region: "us-east-1",
+				// Dataset version defaults
+				VersionsDir:     "data/versions",
+				DatasetVersions: 3,
 			},
 		},
 		{
-			name: "ignore lock",
+			name: "lock settings",
 			envVars: map[string]string{
-				"IGNORE_LOCK": "true",
+				"IGNORE_LOCK":               "true",
+				"LOCK_WAIT_TIMEOUT_SECONDS": "120",
 			},
 			expected: &Config{
 				AuthToken:              "super-secret-token",
@@ -163,6 +232,7 @@ func TestLoad(t *testing.T) {
 				RefreshIntervalSeconds: 86400,
 				DisableRemoteCheck:     false,
 				IgnoreLock:             true,
+				LockWaitTimeoutSeconds: 120,
 				Port:                   "8080",
 				Environment:            "production",
 				// DuckDB defaults
@@ -174,6 +244,411 @@ func TestLoad(t *testing.T) {
 				DuckDBMaxOpenConns:    4,
 				DuckDBMaxIdleConns:    2,
 				DuckDBConnMaxLifetime: 60,
+				// Admission control defaults
+				DuckDBMaxQueueDepth:  16,
+				DuckDBQueueTimeoutMs: 2000,
+				// run_sql defaults
+				EnableSQLTool:         false,
+				SQLToolRowLimit:       500,
+				SQLToolTimeoutSeconds: 10,
+				SQLToolMemoryLimit:    "1GB",
+				// Product history defaults
+				HistoryDir:       "data/history",
+				HistorySnapshots: 30,
+				// S3 source defaults
+				S3Region: "us-east-1",
+				// Dataset version defaults
+				VersionsDir:     "data/versions",
+				DatasetVersions: 3,
+			},
+		},
+		{
+			name: "admission control settings",
+			envVars: map[string]string{
+				"DUCKDB_MAX_QUEUE_DEPTH":  "0",
+				"DUCKDB_QUEUE_TIMEOUT_MS": "500",
+			},
+			expected: &Config{
+				AuthToken:              "super-secret-token",
+				ParquetURL:             "https://huggingface.co/datasets/openfoodfacts/product-database/resolve/main/product-database.parquet",
+				DataDir:                "./data",
+				ParquetPath:            "data/product-database.parquet", // filepath.Join result
+				MetadataPath:           "data/metadata.json",            // filepath.Join result
+				LockFile:               "data/refresh.lock",             // filepath.Join result
+				RefreshIntervalSeconds: 86400,
+				DisableRemoteCheck:     false,
+				IgnoreLock:             false,
+				LockWaitTimeoutSeconds: 600,
+				Port:                   "8080",
+				Environment:            "production",
+				// DuckDB defaults
+				DuckDBMemoryLimit:            "4GB",
+				DuckDBThreads:                4,
+				DuckDBCheckpointThreshold:    "1GB",
+				DuckDBPreserveInsertionOrder: true,
+				// Connection pool defaults
+				DuckDBMaxOpenConns:    4,
+				DuckDBMaxIdleConns:    2,
+				DuckDBConnMaxLifetime: 60,
+				// Admission control overrides
+				DuckDBMaxQueueDepth:  0,
+				DuckDBQueueTimeoutMs: 500,
+				// run_sql defaults
+				EnableSQLTool:         false,
+				SQLToolRowLimit:       500,
+				SQLToolTimeoutSeconds: 10,
+				SQLToolMemoryLimit:    "1GB",
+				// Product history defaults
+				HistoryDir:       "data/history",
+				HistorySnapshots: 30,
+				// S3 source defaults
+				S3Region: "us-east-1",
+				// Dataset version defaults
+				VersionsDir:     "data/versions",
+				DatasetVersions: 3,
+			},
+		},
+		{
+			name: "run_sql tool enabled",
+			envVars: map[string]string{
+				"ENABLE_SQL_TOOL":          "true",
+				"SQL_TOOL_ROW_LIMIT":       "100",
+				"SQL_TOOL_TIMEOUT_SECONDS": "5",
+				"SQL_TOOL_MEMORY_LIMIT":    "256MB",
+			},
+			expected: &Config{
+				AuthToken:              "super-secret-token",
+				ParquetURL:             "https://huggingface.co/datasets/openfoodfacts/product-database/resolve/main/product-database.parquet",
+				DataDir:                "./data",
+				ParquetPath:            "data/product-database.parquet", // filepath.Join result
+				MetadataPath:           "data/metadata.json",            // filepath.Join result
+				LockFile:               "data/refresh.lock",             // filepath.Join result
+				RefreshIntervalSeconds: 86400,
+				DisableRemoteCheck:     false,
+				IgnoreLock:             false,
+				LockWaitTimeoutSeconds: 600,
+				Port:                   "8080",
+				Environment:            "production",
+				// DuckDB defaults
+				DuckDBMemoryLimit:            "4GB",
+				DuckDBThreads:                4,
+				DuckDBCheckpointThreshold:    "1GB",
+				DuckDBPreserveInsertionOrder: true,
+				// Connection pool defaults
+				DuckDBMaxOpenConns:    4,
+				DuckDBMaxIdleConns:    2,
+				DuckDBConnMaxLifetime: 60,
+				// Admission control defaults
+				DuckDBMaxQueueDepth:  16,
+				DuckDBQueueTimeoutMs: 2000,
+				// run_sql overrides
+				EnableSQLTool:         true,
+				SQLToolRowLimit:       100,
+				SQLToolTimeoutSeconds: 5,
+				SQLToolMemoryLimit:    "256MB",
+				// Product history defaults
+				HistoryDir:       "data/history",
+				HistorySnapshots: 30,
+				// S3 source defaults
+				S3Region: "us-east-1",
+				// Dataset version defaults
+				VersionsDir:     "data/versions",
+				DatasetVersions: 3,
+			},
+		},
+		{
+			name: "custom diet profiles",
+			envVars: map[string]string{
+				"OPENFOODFACTS_MCP_TOKEN": "super-secret-token",
+				"DIET_PROFILES_DIR":       "/etc/openfoodfacts/diets",
+			},
+			expected: &Config{
+				AuthToken:              "super-secret-token",
+				ParquetURL:             "https://huggingface.co/datasets/openfoodfacts/product-database/resolve/main/product-database.parquet",
+				DataDir:                "./data",
+				ParquetPath:            "data/product-database.parquet", // filepath.Join result
+				MetadataPath:           "data/metadata.json",            // filepath.Join result
+				LockFile:               "data/refresh.lock",             // filepath.Join result
+				RefreshIntervalSeconds: 86400,
+				DisableRemoteCheck:     false,
+				IgnoreLock:             false,
+				LockWaitTimeoutSeconds: 600,
+				Port:                   "8080",
+				Environment:            "production",
+				// DuckDB defaults
+				DuckDBMemoryLimit:            "4GB",
+				DuckDBThreads:                4,
+				DuckDBCheckpointThreshold:    "1GB",
+				DuckDBPreserveInsertionOrder: true,
+				// Connection pool defaults
+				DuckDBMaxOpenConns:    4,
+				DuckDBMaxIdleConns:    2,
+				DuckDBConnMaxLifetime: 60,
+				// Admission control defaults
+				DuckDBMaxQueueDepth:  16,
+				DuckDBQueueTimeoutMs: 2000,
+				// run_sql defaults
+				EnableSQLTool:         false,
+				SQLToolRowLimit:       500,
+				SQLToolTimeoutSeconds: 10,
+				SQLToolMemoryLimit:    "1GB",
+				// Diet profiles
+				DietProfilesDir: "/etc/openfoodfacts/diets",
+				// Product history defaults
+				HistoryDir:       "data/history",
+				HistorySnapshots: 30,
+				// S3 source defaults
+				S3Region: "us-east-1",
+				// Dataset version defaults
+				VersionsDir:     "data/versions",
+				DatasetVersions: 3,
+			},
+		},
+		{
+			name: "product history settings",
+			envVars: map[string]string{
+				"OPENFOODFACTS_MCP_TOKEN": "super-secret-token",
+				"HISTORY_DIR":             "/var/lib/openfoodfacts/history",
+				"HISTORY_SNAPSHOTS":       "7",
+			},
+			expected: &Config{
+				AuthToken:              "super-secret-token",
+				ParquetURL:             "https://huggingface.co/datasets/openfoodfacts/product-database/resolve/main/product-database.parquet",
+				DataDir:                "./data",
+				ParquetPath:            "data/product-database.parquet", // filepath.Join result
+				MetadataPath:           "data/metadata.json",            // filepath.Join result
+				LockFile:               "data/refresh.lock",             // filepath.Join result
+				RefreshIntervalSeconds: 86400,
+				DisableRemoteCheck:     false,
+				IgnoreLock:             false,
+				LockWaitTimeoutSeconds: 600,
+				Port:                   "8080",
+				Environment:            "production",
+				// DuckDB defaults
+				DuckDBMemoryLimit:            "4GB",
+				DuckDBThreads:                4,
+				DuckDBCheckpointThreshold:    "1GB",
+				DuckDBPreserveInsertionOrder: true,
+				// Connection pool defaults
+				DuckDBMaxOpenConns:    4,
+				DuckDBMaxIdleConns:    2,
+				DuckDBConnMaxLifetime: 60,
+				// Admission control defaults
+				DuckDBMaxQueueDepth:  16,
+				DuckDBQueueTimeoutMs: 2000,
+				// run_sql defaults
+				EnableSQLTool:         false,
+				SQLToolRowLimit:       500,
+				SQLToolTimeoutSeconds: 10,
+				SQLToolMemoryLimit:    "1GB",
+				// Product history overrides
+				HistoryDir:       "/var/lib/openfoodfacts/history",
+				HistorySnapshots: 7,
+				// S3 source defaults
+				S3Region: "us-east-1",
+				// Dataset version defaults
+				VersionsDir:     "data/versions",
+				DatasetVersions: 3,
+			},
+		},
+		{
+			name: "dataset version settings",
+			envVars: map[string]string{
+				"DATASET_VERSIONS_DIR": "/mnt/datasets",
+				"DATASET_VERSIONS":     "5",
+			},
+			expected: &Config{
+				AuthToken:              "super-secret-token",
+				ParquetURL:             "https://huggingface.co/datasets/openfoodfacts/product-database/resolve/main/product-database.parquet",
+				DataDir:                "./data",
+				ParquetPath:            "data/product-database.parquet", // filepath.Join result
+				MetadataPath:           "data/metadata.json",            // filepath.Join result
+				LockFile:               "data/refresh.lock",             // filepath.Join result
+				RefreshIntervalSeconds: 86400,
+				DisableRemoteCheck:     false,
+				IgnoreLock:             false,
+				LockWaitTimeoutSeconds: 600,
+				Port:                   "8080",
+				Environment:            "production",
+				// DuckDB defaults
+				DuckDBMemoryLimit:            "4GB",
+				DuckDBThreads:                4,
+				DuckDBCheckpointThreshold:    "1GB",
+				DuckDBPreserveInsertionOrder: true,
+				// Connection pool defaults
+				DuckDBMaxOpenConns:    4,
+				DuckDBMaxIdleConns:    2,
+				DuckDBConnMaxLifetime: 60,
+				// Admission control defaults
+				DuckDBMaxQueueDepth:  16,
+				DuckDBQueueTimeoutMs: 2000,
+				// run_sql defaults
+				EnableSQLTool:         false,
+				SQLToolRowLimit:       500,
+				SQLToolTimeoutSeconds: 10,
+				SQLToolMemoryLimit:    "1GB",
+				// Product history defaults
+				HistoryDir:       "data/history",
+				HistorySnapshots: 30,
+				// S3 source defaults
+				S3Region: "us-east-1",
+				// Dataset version overrides
+				VersionsDir:     "/mnt/datasets",
+				DatasetVersions: 5,
+			},
+		},
+		{
+			name: "s3 source settings",
+			envVars: map[string]string{
+				"PARQUET_URL":           "s3://datasets/openfoodfacts/food.parquet",
+				"S3_ENDPOINT":           "http://minio:9000",
+				"AWS_REGION":            "eu-west-1",
+				"AWS_ACCESS_KEY_ID":     "minio",
+				"AWS_SECRET_ACCESS_KEY": "minio-secret",
+				"S3_USE_PATH_STYLE":     "true",
+			},
+			expected: &Config{
+				AuthToken:              "super-secret-token",
+				ParquetURL:             "s3://datasets/openfoodfacts/food.parquet",
+				DataDir:                "./data",
+				ParquetPath:            "data/product-database.parquet", // filepath.Join result
+				MetadataPath:           "data/metadata.json",            // filepath.Join result
+				LockFile:               "data/refresh.lock",             // filepath.Join result
+				RefreshIntervalSeconds: 86400,
+				DisableRemoteCheck:     false,
+				IgnoreLock:             false,
+				LockWaitTimeoutSeconds: 600,
+				Port:                   "8080",
+				Environment:            "production",
+				// DuckDB defaults
+				DuckDBMemoryLimit:            "4GB",
+				DuckDBThreads:                4,
+				DuckDBCheckpointThreshold:    "1GB",
+				DuckDBPreserveInsertionOrder: true,
+				// Connection pool defaults
+				DuckDBMaxOpenConns:    4,
+				DuckDBMaxIdleConns:    2,
+				DuckDBConnMaxLifetime: 60,
+				// Admission control defaults
+				DuckDBMaxQueueDepth:  16,
+				DuckDBQueueTimeoutMs: 2000,
+				// run_sql defaults
+				EnableSQLTool:         false,
+				SQLToolRowLimit:       500,
+				SQLToolTimeoutSeconds: 10,
+				SQLToolMemoryLimit:    "1GB",
+				// Product history defaults
+				HistoryDir:       "data/history",
+				HistorySnapshots: 30,
+				// S3 source overrides
+				S3Endpoint:        "http://minio:9000",
+				S3Region:          "eu-west-1",
+				S3AccessKeyID:     "minio",
+				S3SecretAccessKey: "minio-secret",
+				S3UsePathStyle:    true,
+				// Dataset version defaults
+				VersionsDir:     "data/versions",
+				DatasetVersions: 3,
+			},
+		},
+		{
+			name: "delta exports",
+			envVars: map[string]string{
+				"DELTA_URL": "https://static.openfoodfacts.org/data/delta/index.txt",
+			},
+			expected: &Config{
+				AuthToken:              "super-secret-token",
+				ParquetURL:             "https://huggingface.co/datasets/openfoodfacts/product-database/resolve/main/product-database.parquet",
+				DataDir:                "./data",
+				ParquetPath:            "data/product-database.parquet", // filepath.Join result
+				MetadataPath:           "data/metadata.json",            // filepath.Join result
+				LockFile:               "data/refresh.lock",             // filepath.Join result
+				RefreshIntervalSeconds: 86400,
+				DisableRemoteCheck:     false,
+				IgnoreLock:             false,
+				LockWaitTimeoutSeconds: 600,
+				Port:                   "8080",
+				Environment:            "production",
+				// DuckDB defaults
+				DuckDBMemoryLimit:            "4GB",
+				DuckDBThreads:                4,
+				DuckDBCheckpointThreshold:    "1GB",
+				DuckDBPreserveInsertionOrder: true,
+				// Connection pool defaults
+				DuckDBMaxOpenConns:    4,
+				DuckDBMaxIdleConns:    2,
+				DuckDBConnMaxLifetime: 60,
+				// Admission control defaults
+				DuckDBMaxQueueDepth:  16,
+				DuckDBQueueTimeoutMs: 2000,
+				// run_sql defaults
+				EnableSQLTool:         false,
+				SQLToolRowLimit:       500,
+				SQLToolTimeoutSeconds: 10,
+				SQLToolMemoryLimit:    "1GB",
+				// Product history defaults
+				HistoryDir:       "data/history",
+				HistorySnapshots: 30,
+				// S3 source defaults
+				S3Region: "us-east-1",
+				// Dataset version defaults
+				VersionsDir:     "data/versions",
+				DatasetVersions: 3,
+				// Delta exports
+				DeltaURL: "https://static.openfoodfacts.org/data/delta/index.txt",
+			},
+		},
+		{
+			name: "pruned dataset",
+			envVars: map[string]string{
+				"PRUNE_DATASET":           "true",
+				"PRUNE_COUNTRIES":         "en:france, en:belgium,",
+				"PRUNE_LANGUAGES":         "fr",
+				"PRUNE_REQUIRE_NUTRITION": "true",
+			},
+			expected: &Config{
+				AuthToken:              "super-secret-token",
+				ParquetURL:             "https://huggingface.co/datasets/openfoodfacts/product-database/resolve/main/product-database.parquet",
+				DataDir:                "./data",
+				ParquetPath:            "data/product-database.parquet", // filepath.Join result
+				MetadataPath:           "data/metadata.json",            // filepath.Join result
+				LockFile:               "data/refresh.lock",             // filepath.Join result
+				RefreshIntervalSeconds: 86400,
+				DisableRemoteCheck:     false,
+				IgnoreLock:             false,
+				LockWaitTimeoutSeconds: 600,
+				Port:                   "8080",
+				Environment:            "production",
+				// DuckDB defaults
+				DuckDBMemoryLimit:            "4GB",
+				DuckDBThreads:                4,
+				DuckDBCheckpointThreshold:    "1GB",
+				DuckDBPreserveInsertionOrder: true,
+				// Connection pool defaults
+				DuckDBMaxOpenConns:    4,
+				DuckDBMaxIdleConns:    2,
+				DuckDBConnMaxLifetime: 60,
+				// Admission control defaults
+				DuckDBMaxQueueDepth:  16,
+				DuckDBQueueTimeoutMs: 2000,
+				// run_sql defaults
+				EnableSQLTool:         false,
+				SQLToolRowLimit:       500,
+				SQLToolTimeoutSeconds: 10,
+				SQLToolMemoryLimit:    "1GB",
+				// Product history defaults
+				HistoryDir:       "data/history",
+				HistorySnapshots: 30,
+				// S3 source defaults
+				S3Region: "us-east-1",
+				// Dataset version defaults
+				VersionsDir:     "data/versions",
+				DatasetVersions: 3,
+				// Pruned dataset
+				PruneDataset:          true,
+				PruneCountries:        []string{"en:france", "en:belgium"},
+				PruneLanguages:        []string{"fr"},
+				PruneRequireNutrition: true,
 			},
 		},
 	}
@@ -184,10 +659,25 @@ func TestLoad(t *testing.T) {
 			envVarsToClean := []string{
 				"OPENFOODFACTS_MCP_TOKEN", "PARQUET_URL", "DATA_DIR", "PARQUET_PATH",
 				"METADATA_PATH", "LOCK_FILE", "REFRESH_INTERVAL_SECONDS",
-				"PORT", "ENV", "DISABLE_REMOTE_CHECK", "IGNORE_LOCK",
+				"PORT", "ENV", "DISABLE_REMOTE_CHECK", "IGNORE_LOCK", "LOCK_WAIT_TIMEOUT_SECONDS",
 				// DuckDB configuration variables
 				"DUCKDB_MEMORY_LIMIT", "DUCKDB_THREADS", "DUCKDB_CHECKPOINT_THRESHOLD",
 				"DUCKDB_PRESERVE_INSERTION_ORDER", "DUCKDB_MAX_OPEN_CONNS", "DUCKDB_MAX_IDLE_CONNS", "DUCKDB_CONN_MAX_LIFETIME",
+				"DUCKDB_MAX_QUEUE_DEPTH", "DUCKDB_QUEUE_TIMEOUT_MS",
+				// run_sql tool variables
+				"ENABLE_SQL_TOOL", "SQL_TOOL_ROW_LIMIT", "SQL_TOOL_TIMEOUT_SECONDS", "SQL_TOOL_MEMORY_LIMIT",
+				// Diet profiles
+				"DIET_PROFILES_DIR",
+				// Product history
+				"HISTORY_DIR", "HISTORY_SNAPSHOTS",
+				// Dataset versions
+				"DATASET_VERSIONS_DIR", "DATASET_VERSIONS",
+				// S3 dataset source
+				"S3_ENDPOINT", "S3_REGION", "AWS_REGION", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "S3_USE_PATH_STYLE",
+				// Delta exports
+				"DELTA_URL",
+				// Pruned dataset
+				"PRUNE_DATASET", "PRUNE_COUNTRIES", "PRUNE_LANGUAGES", "PRUNE_REQUIRE_NUTRITION",
 			}
 
 			// Save original values
@@ -226,6 +716,19 @@ func TestRefreshInterval(t *testing.T) {
 	assert.Equal(t, "0s", config.RefreshInterval().String())
 }
 
+func TestQueueTimeout(t *testing.T) {
+	config := &Config{DuckDBQueueTimeoutMs: 2000}
+	assert.Equal(t, "2s", config.QueueTimeout().String())
+
+	config = &Config{DuckDBQueueTimeoutMs: 0}
+	assert.Equal(t, "0s", config.QueueTimeout().String())
+}
+
+func TestSQLToolTimeout(t *testing.T) {
+	config := &Config{SQLToolTimeoutSeconds: 10}
+	assert.Equal(t, "10s", config.SQLToolTimeout().String())
+}
+
 func TestIsDevelopment(t *testing.T) {
 	tests := []struct {
 		name        string
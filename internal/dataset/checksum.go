@@ -0,0 +1,121 @@
+package dataset
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrChecksumMismatch is returned when a download does not match the checksum published by the source
+	ErrChecksumMismatch = errors.New("dataset checksum mismatch")
+	// ErrInvalidDataset is returned when a downloaded file fails validation
+	ErrInvalidDataset = errors.New("invalid dataset file")
+)
+
+// Validator checks that a downloaded file is a usable dataset before it is activated
+type Validator func(ctx context.Context, path string) error
+
+// SetValidator sets the check a download must pass before it replaces the current dataset
+func (m *Manager) SetValidator(validate Validator) {
+	m.validate = validate
+}
+
+// verifyDownload compares a download with the checksum published by the source and runs the validator
+func (m *Manager) verifyDownload(ctx context.Context, path, sha string) error {
+	source, err := m.remote()
+	if err != nil {
+		return err
+	}
+
+	expected, from := source.ExpectedSHA256(ctx)
+	switch {
+	case expected == "":
+		m.log.Info("Source publishes no checksum, skipping checksum verification")
+	case expected != sha:
+		return fmt.Errorf("%w: %s has %s, download has %s", ErrChecksumMismatch, from, expected, sha)
+	default:
+		m.log.Info("Dataset checksum verified", "source", from, "sha256", sha[:16]+"...")
+	}
+
+	if m.validate != nil {
+		start := time.Now()
+		if err := m.validate(ctx, path); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidDataset, err)
+		}
+		m.log.Info("Dataset file validated", "duration", time.Since(start))
+	}
+	return nil
+}
+
+// Verify recomputes the SHA256 of the active dataset, compares it and the file size with metadata.json
+// and runs the validator. It returns the metadata it checked against
+func (m *Manager) Verify(ctx context.Context) (*Metadata, error) {
+	meta, err := m.loadMetadata()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load metadata: %w", err)
+	}
+
+	stat, err := os.Stat(m.parquetPath)
+	if err != nil {
+		return meta, fmt.Errorf("failed to stat dataset: %w", err)
+	}
+	if stat.Size() != meta.Size {
+		return meta, fmt.Errorf("%w: metadata.json has size %d, dataset file has %d bytes", ErrChecksumMismatch, meta.Size, stat.Size())
+	}
+
+	start := time.Now()
+	sha, err := computeSHA256(m.parquetPath)
+	if err != nil {
+		return meta, fmt.Errorf("failed to compute SHA256: %w", err)
+	}
+	if sha != meta.SHA256 {
+		return meta, fmt.Errorf("%w: metadata.json has %s, dataset file has %s", ErrChecksumMismatch, meta.SHA256, sha)
+	}
+	m.log.Info("Dataset checksum matches metadata", "sha256", sha[:16]+"...", "duration", time.Since(start))
+
+	if m.validate != nil {
+		start := time.Now()
+		if err := m.validate(ctx, m.parquetPath); err != nil {
+			return meta, fmt.Errorf("%w: %v", ErrInvalidDataset, err)
+		}
+		m.log.Info("Dataset file validated", "duration", time.Since(start))
+	}
+	return meta, nil
+}
+
+// readChecksumFile reads the digest from a file in sha256sum format: "<hex>  <file name>"
+func readChecksumFile(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("empty checksum file")
+	}
+	sha := parseSHA256(fields[0])
+	if sha == "" {
+		return "", fmt.Errorf("not a SHA256 digest: %q", fields[0])
+	}
+	return sha, nil
+}
+
+// parseSHA256 returns the lowercase hex digest in an ETag or checksum value, or "" if it is not a SHA256
+func parseSHA256(value string) string {
+	value = strings.ToLower(strings.Trim(strings.TrimPrefix(value, "W/"), `"`))
+	if len(value) != 64 {
+		return ""
+	}
+	for _, c := range value {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return ""
+		}
+	}
+	return value
+}
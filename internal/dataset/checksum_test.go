@@ -0,0 +1,187 @@
+package dataset
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_VerifyDownload(t *testing.T) {
+	content := "new parquet data"
+	sum := sha256.Sum256([]byte(content))
+	goodSHA := hex.EncodeToString(sum[:])
+	badSHA := "0000000000000000000000000000000000000000000000000000000000000000"
+
+	tests := []struct {
+		name          string
+		linkedETag    string // X-Linked-ETag sent on the redirect to the file
+		sidecar       string // Body of the .sha256 file, none when empty
+		validateErr   error
+		expectedError error
+	}{
+		{
+			name: "no published checksum",
+		},
+		{
+			name:       "matching X-Linked-ETag",
+			linkedETag: `"` + goodSHA + `"`,
+		},
+		{
+			name:          "mismatching X-Linked-ETag",
+			linkedETag:    `"` + badSHA + `"`,
+			expectedError: ErrChecksumMismatch,
+		},
+		{
+			name:    "matching sidecar file",
+			sidecar: goodSHA + "  food.parquet\n",
+		},
+		{
+			name:          "mismatching sidecar file",
+			sidecar:       badSHA + "  food.parquet\n",
+			expectedError: ErrChecksumMismatch,
+		},
+		{
+			name:          "file fails validation",
+			validateErr:   errors.New("parquet file is missing columns: code"),
+			expectedError: ErrInvalidDataset,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Serve the file behind a redirect, as Hugging Face does
+			mux := http.NewServeMux()
+			mux.HandleFunc("/resolve/food.parquet", func(w http.ResponseWriter, r *http.Request) {
+				if tt.linkedETag != "" {
+					w.Header().Set("X-Linked-ETag", tt.linkedETag)
+				}
+				http.Redirect(w, r, "/cdn/food.parquet", http.StatusFound)
+			})
+			mux.HandleFunc("/cdn/food.parquet", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("ETag", `"v2"`)
+				w.Write([]byte(content))
+			})
+			mux.HandleFunc("/resolve/food.parquet.sha256", func(w http.ResponseWriter, r *http.Request) {
+				if tt.sidecar == "" {
+					http.NotFound(w, r)
+					return
+				}
+				w.Write([]byte(tt.sidecar))
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			tmpDir := t.TempDir()
+			parquetPath := filepath.Join(tmpDir, "product-database.parquet")
+			manager := NewManager(
+				server.URL+"/resolve/food.parquet",
+				parquetPath,
+				filepath.Join(tmpDir, "metadata.json"),
+				filepath.Join(tmpDir, "refresh.lock"),
+				createTestConfig(),
+				config.NewTestLogger(io.Discard, "DEBUG"),
+			)
+			manager.SetValidator(func(ctx context.Context, path string) error { return tt.validateErr })
+
+			// The current dataset stays active when the download is rejected
+			require.NoError(t, os.WriteFile(parquetPath, []byte("old parquet data"), 0644))
+			require.NoError(t, manager.saveMetadata(&Metadata{SHA256: "old", ETag: `"v1"`, Size: 16}))
+
+			refreshed, err := manager.Refresh(context.Background())
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.False(t, refreshed)
+				assert.Equal(t, "old parquet data", activeContent(t, manager))
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, refreshed)
+			assert.Equal(t, content, activeContent(t, manager))
+		})
+	}
+}
+
+func TestParseSHA256(t *testing.T) {
+	sha := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+	assert.Equal(t, sha, parseSHA256(sha))
+	assert.Equal(t, sha, parseSHA256(`"`+sha+`"`))
+	assert.Equal(t, sha, parseSHA256(`W/"`+sha+`"`))
+	assert.Equal(t, sha, parseSHA256("9F86D081884C7D659A2FEAA0C55AD015A3BF4F1B2B0B822CD15D6C15B0F00A08"))
+	assert.Empty(t, parseSHA256(`"abc123"`))
+	assert.Empty(t, parseSHA256("zf86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"))
+	assert.Empty(t, parseSHA256(""))
+}
+
+func TestManager_Verify(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		tamper      func(t *testing.T, m *Manager)
+		validateErr error
+		expectedErr error
+	}{
+		{name: "intact dataset"},
+		{
+			name: "file changed on disk",
+			tamper: func(t *testing.T, m *Manager) {
+				require.NoError(t, os.WriteFile(m.parquetPath, []byte("BASE1"), 0644))
+			},
+			expectedErr: ErrChecksumMismatch,
+		},
+		{
+			name: "truncated file",
+			tamper: func(t *testing.T, m *Manager) {
+				require.NoError(t, os.Truncate(m.parquetPath, 2))
+			},
+			expectedErr: ErrChecksumMismatch,
+		},
+		{
+			name:        "unreadable dataset",
+			validateErr: errors.New("missing columns"),
+			expectedErr: ErrInvalidDataset,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager, remote := newVersionedManager(t, 3)
+			remote.publish(`"v1"`, "base1")
+			require.NoError(t, manager.EnsureDataset(ctx))
+
+			if tt.tamper != nil {
+				tt.tamper(t, manager)
+			}
+			manager.SetValidator(func(ctx context.Context, path string) error {
+				return tt.validateErr
+			})
+
+			meta, err := manager.Verify(ctx)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, sha256Hex("base1"), meta.SHA256)
+		})
+	}
+
+	t.Run("no dataset", func(t *testing.T) {
+		manager, _ := newVersionedManager(t, 3)
+		_, err := manager.Verify(ctx)
+		assert.Error(t, err)
+	})
+}
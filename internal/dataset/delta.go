@@ -0,0 +1,292 @@
+package dataset
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNoDeltaMerger is returned when DELTA_URL is set but nothing can merge delta exports into the dataset
+var ErrNoDeltaMerger = errors.New("no delta merger configured")
+
+// deltaSuffixes are the file names accepted as delta exports in a local directory
+var deltaSuffixes = []string{".json", ".jsonl", ".json.gz", ".jsonl.gz"}
+
+// DeltaMerger writes the dataset at basePath to outPath with the products in the delta files applied on top
+type DeltaMerger func(ctx context.Context, basePath string, deltaPaths []string, outPath string) error
+
+// SetDeltaMerger sets how delta exports are merged into the dataset
+func (m *Manager) SetDeltaMerger(merge DeltaMerger) {
+	m.mergeDeltas = merge
+}
+
+// deltaFile is one delta export listed at DELTA_URL
+type deltaFile struct {
+	name string
+	url  string
+}
+
+// listDeltas returns the delta exports listed by the index.txt at DELTA_URL, or found in the local directory it names,
+// sorted by name, which for Open Food Facts exports is also by date
+func (m *Manager) listDeltas(ctx context.Context) ([]deltaFile, error) {
+	var deltas []deltaFile
+	var err error
+	if strings.HasPrefix(m.config.DeltaURL, "http://") || strings.HasPrefix(m.config.DeltaURL, "https://") {
+		deltas, err = m.fetchDeltaIndex(ctx)
+	} else {
+		deltas, err = listDeltaDir(strings.TrimPrefix(m.config.DeltaURL, "file://"))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(deltas, func(i, j int) bool {
+		return deltas[i].name < deltas[j].name
+	})
+	return deltas, nil
+}
+
+// fetchDeltaIndex reads an index.txt with one delta file name, or URL relative to the index, per line
+func (m *Manager) fetchDeltaIndex(ctx context.Context) ([]deltaFile, error) {
+	index, err := url.Parse(m.config.DeltaURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DELTA_URL: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", m.config.DeltaURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("delta index request failed with status: %d", resp.StatusCode)
+	}
+
+	var deltas []deltaFile
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ref, err := url.Parse(line)
+		if err != nil {
+			m.log.Warn("Skipping invalid delta index entry", "entry", line, "error", err)
+			continue
+		}
+		resolved := index.ResolveReference(ref)
+		deltas = append(deltas, deltaFile{name: path.Base(resolved.Path), url: resolved.String()})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read delta index: %w", err)
+	}
+	return deltas, nil
+}
+
+// listDeltaDir lists the delta exports in a local directory
+func listDeltaDir(dir string) ([]deltaFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list delta directory: %w", err)
+	}
+
+	var deltas []deltaFile
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		for _, suffix := range deltaSuffixes {
+			if strings.HasSuffix(entry.Name(), suffix) {
+				absPath, err := filepath.Abs(filepath.Join(dir, entry.Name()))
+				if err != nil {
+					return nil, err
+				}
+				deltas = append(deltas, deltaFile{name: entry.Name(), url: absPath})
+				break
+			}
+		}
+	}
+	return deltas, nil
+}
+
+// ApplyDeltas merges the delta exports not yet applied to the active version into a new version and activates it
+// It reports whether a new version was activated. The new version keeps the upstream ETag and size, so freshness
+// checks still compare against the full upstream file, and the next full download starts again without deltas
+func (m *Manager) ApplyDeltas(ctx context.Context) (bool, error) {
+	if m.config.DeltaURL == "" {
+		return false, nil
+	}
+	if m.mergeDeltas == nil {
+		return false, ErrNoDeltaMerger
+	}
+	start := time.Now()
+
+	active, err := m.loadMetadata()
+	if err != nil {
+		return false, fmt.Errorf("no active dataset to apply delta exports to: %w", err)
+	}
+
+	listed, err := m.listDeltas(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to list delta exports: %w", err)
+	}
+
+	// Only names still listed are remembered, so the applied list does not grow forever
+	applied := make(map[string]bool, len(active.Deltas))
+	for _, name := range active.Deltas {
+		applied[name] = true
+	}
+	// Deltas of a version rolled back from stay skipped, otherwise the next refresh would merge them again
+	rejected := make(map[string]bool, len(active.RejectedDeltas))
+	for _, name := range active.RejectedDeltas {
+		rejected[name] = true
+	}
+	var kept, skipped []string
+	var pending []deltaFile
+	for _, delta := range listed {
+		switch {
+		case applied[delta.name]:
+			kept = append(kept, delta.name)
+		case rejected[delta.name]:
+			skipped = append(skipped, delta.name)
+		default:
+			pending = append(pending, delta)
+		}
+	}
+	if len(skipped) > 0 {
+		m.log.Debug("Skipping delta exports of a rolled-back version", "count", len(skipped))
+	}
+	if len(pending) == 0 {
+		m.log.Debug("No new delta exports", "listed", len(listed))
+		return false, nil
+	}
+
+	lock, err := acquireLock(m.lockPath, m.lockOpts, m.log)
+	if err != nil {
+		if !m.config.IgnoreLock {
+			m.log.Info("Another instance is updating the dataset, skipping delta exports", "lock_path", m.lockPath, "error", err)
+			return false, nil
+		}
+		m.log.Warn("IGNORE_LOCK enabled but still failed to acquire lock, proceeding anyway", "error", err)
+	}
+	if lock != nil {
+		defer lock.release()
+	}
+
+	m.log.Info("Applying delta exports", "count", len(pending), "first", pending[0].name, "last", pending[len(pending)-1].name)
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return false, fmt.Errorf("failed to get current working directory: %w", err)
+	}
+	tmpDataDir := filepath.Join(cwd, "tmp-data")
+	deltaDir := filepath.Join(tmpDataDir, "deltas")
+	if err := os.MkdirAll(deltaDir, 0755); err != nil {
+		return false, fmt.Errorf("failed to create delta directory: %w", err)
+	}
+
+	var paths []string
+	for _, delta := range pending {
+		deltaPath := filepath.Join(deltaDir, delta.name)
+		source, err := newSource(delta.url, m.config, m.download, m.log)
+		if err != nil {
+			return false, err
+		}
+		if err := source.Download(ctx, deltaPath); err != nil {
+			return false, fmt.Errorf("failed to download delta export %s: %w", delta.name, err)
+		}
+		os.Remove(etagPath(deltaPath))
+		defer os.Remove(deltaPath)
+		paths = append(paths, deltaPath)
+	}
+
+	// A pruned dataset gets the deltas merged into the full version it came from and is pruned again afterwards
+	base, basePath, err := m.fullVersion(active)
+	if err != nil {
+		m.log.Warn("Merging delta exports into the pruned dataset", "error", err)
+		base, basePath = active, m.parquetPath
+	}
+
+	mergedPath := filepath.Join(tmpDataDir, "product-database.parquet.merged")
+	if err := m.mergeDeltas(ctx, basePath, paths, mergedPath); err != nil {
+		os.Remove(mergedPath)
+		return false, err
+	}
+
+	sha, err := computeSHA256(mergedPath)
+	if err != nil {
+		os.Remove(mergedPath)
+		return false, fmt.Errorf("failed to compute SHA256: %w", err)
+	}
+	if m.validate != nil {
+		if err := m.validate(ctx, mergedPath); err != nil {
+			os.Remove(mergedPath)
+			return false, fmt.Errorf("%w: %v", ErrInvalidDataset, err)
+		}
+	}
+	stat, err := os.Stat(mergedPath)
+	if err != nil {
+		os.Remove(mergedPath)
+		return false, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	meta := *base
+	meta.SHA256 = sha
+	meta.Size = stat.Size()
+	meta.DownloadedAt = time.Now().UTC()
+	meta.Rejected = false
+	if meta.UpstreamSHA256 == "" {
+		meta.UpstreamSHA256 = base.SHA256
+		meta.UpstreamSize = base.Size
+	}
+	meta.Pruned = nil
+	meta.PrunedFrom = ""
+	meta.Deltas = kept
+	meta.RejectedDeltas = skipped
+	for _, delta := range pending {
+		meta.Deltas = append(meta.Deltas, delta.name)
+	}
+
+	if err := m.storeVersion(mergedPath, &meta); err != nil {
+		os.Remove(mergedPath)
+		return false, fmt.Errorf("failed to store dataset version: %w", err)
+	}
+	if active.Pruned != nil {
+		// pruneVersion activates the pruned copy instead of the full merged version
+		if _, err := m.pruneVersion(ctx, &meta, filepath.Join(m.versionDir(sha), versionFileName), *active.Pruned); err != nil {
+			return false, fmt.Errorf("failed to prune dataset after applying delta exports: %w", err)
+		}
+	} else {
+		if err := m.activateVersion(&meta); err != nil {
+			return false, fmt.Errorf("failed to activate dataset version: %w", err)
+		}
+		m.pruneVersions()
+	}
+
+	m.log.Info("Delta exports applied", "count", len(pending), "sha256", sha[:16]+"...", "duration", time.Since(start))
+	return true, nil
+}
+
+// applyPendingDeltas applies new delta exports, logging rather than failing: the full dataset is still usable without them
+func (m *Manager) applyPendingDeltas(ctx context.Context) bool {
+	applied, err := m.ApplyDeltas(ctx)
+	if err != nil {
+		m.log.Warn("Failed to apply delta exports, keeping the current dataset", "error", err)
+	}
+	return applied
+}
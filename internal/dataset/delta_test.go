@@ -0,0 +1,213 @@
+package dataset
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// appendMerger is a fake DeltaMerger that appends the delta contents to the dataset
+func appendMerger(ctx context.Context, basePath string, deltaPaths []string, outPath string) error {
+	merged, err := os.ReadFile(basePath)
+	if err != nil {
+		return err
+	}
+	for _, deltaPath := range deltaPaths {
+		delta, err := os.ReadFile(deltaPath)
+		if err != nil {
+			return err
+		}
+		merged = append(merged, '+')
+		merged = append(merged, delta...)
+	}
+	return os.WriteFile(outPath, merged, 0644)
+}
+
+// sha256Hex returns the hex SHA256 of a string
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// writeDelta adds a fixture delta export to a directory
+func writeDelta(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+}
+
+func TestManager_ApplyDeltas(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("applies new deltas from a local directory", func(t *testing.T) {
+		deltaDir := t.TempDir()
+		writeDelta(t, deltaDir, "openfoodfacts_products_1700000000_1700086400.json.gz", "d1")
+		writeDelta(t, deltaDir, "README.txt", "not a delta")
+
+		manager, remote := newVersionedManager(t, 5)
+		manager.config.DeltaURL = deltaDir
+		manager.SetDeltaMerger(appendMerger)
+		remote.publish(`"v1"`, "base1")
+
+		require.NoError(t, manager.EnsureDataset(ctx))
+		assert.Equal(t, "base1+d1", activeContent(t, manager))
+
+		meta, err := manager.Metadata()
+		require.NoError(t, err)
+		assert.Equal(t, `"v1"`, meta.ETag)
+		assert.Equal(t, sha256Hex("base1"), meta.UpstreamSHA256)
+		assert.Equal(t, int64(5), meta.UpstreamSize)
+		assert.Equal(t, []string{"openfoodfacts_products_1700000000_1700086400.json.gz"}, meta.Deltas)
+
+		// Nothing new to apply, and upstream is unchanged
+		refreshed, err := manager.Refresh(ctx)
+		require.NoError(t, err)
+		assert.False(t, refreshed)
+
+		// The next day's delta is applied on top of the merged version
+		writeDelta(t, deltaDir, "openfoodfacts_products_1700086400_1700172800.json.gz", "d2")
+		refreshed, err = manager.Refresh(ctx)
+		require.NoError(t, err)
+		assert.True(t, refreshed)
+		assert.Equal(t, "base1+d1+d2", activeContent(t, manager))
+
+		meta, err = manager.Metadata()
+		require.NoError(t, err)
+		assert.Equal(t, sha256Hex("base1"), meta.UpstreamSHA256)
+		assert.Len(t, meta.Deltas, 2)
+
+		// A new full download starts over with the deltas still listed
+		require.NoError(t, os.Remove(filepath.Join(deltaDir, "openfoodfacts_products_1700000000_1700086400.json.gz")))
+		remote.publish(`"v2"`, "base2")
+		refreshed, err = manager.Refresh(ctx)
+		require.NoError(t, err)
+		assert.True(t, refreshed)
+		assert.Equal(t, "base2+d2", activeContent(t, manager))
+
+		meta, err = manager.Metadata()
+		require.NoError(t, err)
+		assert.Equal(t, `"v2"`, meta.ETag)
+		assert.Equal(t, sha256Hex("base2"), meta.UpstreamSHA256)
+		assert.Equal(t, []string{"openfoodfacts_products_1700086400_1700172800.json.gz"}, meta.Deltas)
+
+		// Merged versions can be rolled back like downloads
+		_, err = manager.Rollback("")
+		require.NoError(t, err)
+		assert.Equal(t, "base2", activeContent(t, manager))
+	})
+
+	t.Run("rolled-back deltas are not applied again", func(t *testing.T) {
+		deltaDir := t.TempDir()
+		writeDelta(t, deltaDir, "openfoodfacts_products_1700000000_1700086400.json.gz", "d1")
+
+		manager, remote := newVersionedManager(t, 5)
+		manager.config.DeltaURL = deltaDir
+		manager.SetDeltaMerger(appendMerger)
+		remote.publish(`"v1"`, "base1")
+
+		require.NoError(t, manager.EnsureDataset(ctx))
+		assert.Equal(t, "base1+d1", activeContent(t, manager))
+
+		meta, err := manager.Rollback("")
+		require.NoError(t, err)
+		assert.Equal(t, "base1", activeContent(t, manager))
+		assert.Equal(t, []string{"openfoodfacts_products_1700000000_1700086400.json.gz"}, meta.RejectedDeltas)
+
+		refreshed, err := manager.Refresh(ctx)
+		require.NoError(t, err)
+		assert.False(t, refreshed)
+		assert.Equal(t, "base1", activeContent(t, manager))
+
+		require.NoError(t, manager.EnsureDataset(ctx))
+		assert.Equal(t, "base1", activeContent(t, manager))
+
+		// Later deltas are still applied, and the rejected one stays skipped
+		writeDelta(t, deltaDir, "openfoodfacts_products_1700086400_1700172800.json.gz", "d2")
+		refreshed, err = manager.Refresh(ctx)
+		require.NoError(t, err)
+		assert.True(t, refreshed)
+		assert.Equal(t, "base1+d2", activeContent(t, manager))
+
+		meta, err = manager.Metadata()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"openfoodfacts_products_1700086400_1700172800.json.gz"}, meta.Deltas)
+		assert.Equal(t, []string{"openfoodfacts_products_1700000000_1700086400.json.gz"}, meta.RejectedDeltas)
+
+		refreshed, err = manager.Refresh(ctx)
+		require.NoError(t, err)
+		assert.False(t, refreshed)
+		assert.Equal(t, "base1+d2", activeContent(t, manager))
+	})
+
+	t.Run("reads the delta index over HTTP", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/data/delta/index.txt":
+				w.Write([]byte("openfoodfacts_products_1700086400_1700172800.json.gz\n\nopenfoodfacts_products_1700000000_1700086400.json.gz\n"))
+			case "/data/delta/openfoodfacts_products_1700000000_1700086400.json.gz":
+				w.Write([]byte("d1"))
+			case "/data/delta/openfoodfacts_products_1700086400_1700172800.json.gz":
+				w.Write([]byte("d2"))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer server.Close()
+
+		manager, remote := newVersionedManager(t, 3)
+		manager.config.DeltaURL = server.URL + "/data/delta/index.txt"
+		manager.SetDeltaMerger(appendMerger)
+		remote.publish(`"v1"`, "base1")
+
+		require.NoError(t, manager.EnsureDataset(ctx))
+		assert.Equal(t, "base1+d1+d2", activeContent(t, manager))
+	})
+
+	t.Run("failed merge keeps the current dataset", func(t *testing.T) {
+		deltaDir := t.TempDir()
+		writeDelta(t, deltaDir, "openfoodfacts_products_1700000000_1700086400.json", "d1")
+
+		manager, remote := newVersionedManager(t, 3)
+		manager.SetValidator(func(ctx context.Context, path string) error {
+			content, err := os.ReadFile(path)
+			if err == nil && strings.Contains(string(content), "+") {
+				return assert.AnError
+			}
+			return err
+		})
+		remote.publish(`"v1"`, "base1")
+		require.NoError(t, manager.EnsureDataset(ctx))
+
+		manager.config.DeltaURL = deltaDir
+		_, err := manager.ApplyDeltas(ctx)
+		assert.ErrorIs(t, err, ErrNoDeltaMerger)
+
+		manager.SetDeltaMerger(appendMerger)
+		_, err = manager.ApplyDeltas(ctx)
+		assert.ErrorIs(t, err, ErrInvalidDataset)
+		assert.Equal(t, "base1", activeContent(t, manager))
+
+		// Refresh reports the failure in the log but still succeeds
+		refreshed, err := manager.Refresh(ctx)
+		require.NoError(t, err)
+		assert.False(t, refreshed)
+	})
+
+	t.Run("disabled without DELTA_URL", func(t *testing.T) {
+		manager, remote := newVersionedManager(t, 3)
+		remote.publish(`"v1"`, "base1")
+		require.NoError(t, manager.EnsureDataset(ctx))
+
+		applied, err := manager.ApplyDeltas(ctx)
+		require.NoError(t, err)
+		assert.False(t, applied)
+	})
+}
@@ -0,0 +1,331 @@
+package dataset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultDownloadAttempts is how many times a download is tried before giving up
+	DefaultDownloadAttempts = 5
+	// DefaultRetryBaseDelay is the delay before the first retry, doubled on every further one
+	DefaultRetryBaseDelay = 2 * time.Second
+	// maxRetryDelay caps the exponential backoff between attempts
+	maxRetryDelay = time.Minute
+	// DefaultProgressInterval is how often download progress is logged
+	DefaultProgressInterval = 10 * time.Second
+	// DefaultStallTimeout aborts an attempt that has received no data for this long
+	DefaultStallTimeout = 2 * time.Minute
+)
+
+// statusError is an unexpected HTTP status from the download server
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("download failed with status: %d", e.code)
+}
+
+// retryable reports whether the status may succeed on a later attempt
+func (e *statusError) retryable() bool {
+	return e.code >= 500 || e.code == http.StatusRequestTimeout || e.code == http.StatusTooManyRequests
+}
+
+// etagPath returns the file that stores the ETag a partial download was started against
+func etagPath(filePath string) string {
+	return filePath + ".etag"
+}
+
+// rangeDownloader downloads a file over HTTP, resuming a partial file left by an earlier attempt
+// with a Range request. Interrupted attempts are retried with exponential backoff
+type rangeDownloader struct {
+	client *http.Client
+	// newRequest builds the GET request with the given extra headers, signing it if the source needs to
+	newRequest func(ctx context.Context, header http.Header) (*http.Request, error)
+	// resumeHeader carries the partial file's ETag: If-Range, or If-Match for servers without If-Range support
+	resumeHeader string
+	opts         *downloadOptions
+	log          *slog.Logger
+}
+
+// newRangeDownloader creates a downloader with no overall timeout: a full download can take much longer
+// than any sensible limit, so stalls are detected from the data flow instead
+func newRangeDownloader(newRequest func(ctx context.Context, header http.Header) (*http.Request, error), resumeHeader string, opts *downloadOptions, logger *slog.Logger) *rangeDownloader {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = 30 * time.Second
+
+	return &rangeDownloader{
+		client:       &http.Client{Transport: transport},
+		newRequest:   newRequest,
+		resumeHeader: resumeHeader,
+		opts:         opts,
+		log:          logger,
+	}
+}
+
+// download downloads to filePath, retrying interrupted attempts
+func (d *rangeDownloader) download(ctx context.Context, filePath string) error {
+	start := time.Now()
+
+	var lastErr error
+	for attempt := 1; attempt <= d.opts.attempts; attempt++ {
+		lastErr = d.attempt(ctx, filePath)
+		if lastErr == nil {
+			d.log.Info("Download completed", "attempts", attempt, "duration", time.Since(start))
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var statusErr *statusError
+		if errors.As(lastErr, &statusErr) && !statusErr.retryable() {
+			return lastErr
+		}
+		if attempt == d.opts.attempts {
+			break
+		}
+
+		delay := d.opts.retryBaseDelay << (attempt - 1)
+		if delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
+		d.log.Warn("Download interrupted, retrying",
+			"attempt", attempt,
+			"max_attempts", d.opts.attempts,
+			"delay", delay,
+			"error", lastErr)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+
+	return fmt.Errorf("download failed after %d attempts: %w", d.opts.attempts, lastErr)
+}
+
+// attempt makes one request, appending to the partial file when the server still has the same version
+func (d *rangeDownloader) attempt(ctx context.Context, filePath string) error {
+	attemptCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Resume only when the ETag the partial file was started against is known
+	var offset int64
+	header := http.Header{}
+	if info, err := os.Stat(filePath); err == nil && info.Size() > 0 {
+		if etag, err := os.ReadFile(etagPath(filePath)); err == nil && len(etag) > 0 {
+			offset = info.Size()
+			header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+			header.Set(d.resumeHeader, string(etag))
+		}
+	}
+
+	req, err := d.newRequest(attemptCtx, header)
+	if err != nil {
+		return err
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var file *os.File
+	total := resp.ContentLength
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		start, size, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err != nil || start != offset {
+			discardPartial(filePath)
+			return fmt.Errorf("unexpected Content-Range %q for offset %d", resp.Header.Get("Content-Range"), offset)
+		}
+		total = size
+		d.log.Info("Resuming download", "offset", offset, "total_bytes", total)
+		file, err = os.OpenFile(filePath, os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+	case http.StatusOK:
+		if offset > 0 {
+			d.log.Info("Remote file changed since the partial download, restarting from the beginning", "discarded_bytes", offset)
+			offset = 0
+		}
+		file, err = os.Create(filePath)
+		if err != nil {
+			return err
+		}
+		if etag := resp.Header.Get("ETag"); etag != "" {
+			if err := os.WriteFile(etagPath(filePath), []byte(etag), 0644); err != nil {
+				d.log.Warn("Failed to record ETag, an interrupted download will restart from the beginning", "error", err)
+			}
+		} else {
+			os.Remove(etagPath(filePath))
+		}
+	case http.StatusRequestedRangeNotSatisfiable, http.StatusPreconditionFailed:
+		discardPartial(filePath)
+		return fmt.Errorf("partial download does not match the remote file")
+	default:
+		return &statusError{code: resp.StatusCode}
+	}
+	defer file.Close()
+
+	progress := newDownloadProgress(offset, total)
+	stop := watchProgress(attemptCtx, cancel, progress, d.opts, d.log)
+	_, err = io.Copy(file, io.TeeReader(resp.Body, progress))
+	stop()
+	if err != nil {
+		if progress.stalled.Load() {
+			return fmt.Errorf("no data received for %s", d.opts.stallTimeout)
+		}
+		return err
+	}
+
+	if total >= 0 && progress.written.Load() != total {
+		return fmt.Errorf("download ended at %d of %d bytes: %w", progress.written.Load(), total, io.ErrUnexpectedEOF)
+	}
+	return nil
+}
+
+// discardPartial removes a partial download that cannot be resumed
+func discardPartial(filePath string) {
+	os.Remove(filePath)
+	os.Remove(etagPath(filePath))
+}
+
+// watchProgress logs progress periodically and cancels the transfer when no data arrives for the stall timeout
+// The returned function stops watching
+func watchProgress(ctx context.Context, cancel context.CancelFunc, progress *downloadProgress, opts *downloadOptions, logger *slog.Logger) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	interval := opts.progressInterval
+	if opts.stallTimeout < interval {
+		interval = opts.stallTimeout
+	}
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		lastLog := time.Now()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if time.Since(time.Unix(0, progress.lastWrite.Load())) > opts.stallTimeout {
+					logger.Warn("Download stalled, aborting attempt", "stall_timeout", opts.stallTimeout, "bytes", progress.written.Load())
+					progress.stalled.Store(true)
+					cancel()
+					return
+				}
+				if time.Since(lastLog) >= opts.progressInterval {
+					logProgress(progress, logger)
+					lastLog = time.Now()
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// logProgress logs downloaded bytes, transfer rate and estimated time remaining
+func logProgress(progress *downloadProgress, logger *slog.Logger) {
+	written := progress.written.Load()
+	elapsed := time.Since(progress.start)
+	rate := float64(written-progress.offset) / elapsed.Seconds()
+
+	attrs := []any{"bytes", formatBytes(written), "rate", formatBytes(int64(rate)) + "/s"}
+	if progress.total > 0 {
+		attrs = append(attrs, "total", formatBytes(progress.total), "percent", fmt.Sprintf("%.1f", float64(written)*100/float64(progress.total)))
+		if rate > 0 {
+			eta := time.Duration(float64(progress.total-written) / rate * float64(time.Second))
+			attrs = append(attrs, "eta", eta.Round(time.Second))
+		}
+	}
+	logger.Info("Download progress", attrs...)
+}
+
+// downloadProgress counts downloaded bytes for progress logging and stall detection
+type downloadProgress struct {
+	start     time.Time
+	offset    int64 // Bytes already on disk when the attempt started
+	total     int64 // Full file size, -1 when unknown
+	written   atomic.Int64
+	lastWrite atomic.Int64 // Unix nanoseconds of the last received data
+	stalled   atomic.Bool
+}
+
+// newDownloadProgress starts counting from the bytes already on disk
+func newDownloadProgress(offset, total int64) *downloadProgress {
+	progress := &downloadProgress{start: time.Now(), offset: offset, total: total}
+	progress.written.Store(offset)
+	progress.lastWrite.Store(time.Now().UnixNano())
+	return progress
+}
+
+func (p *downloadProgress) Write(b []byte) (int, error) {
+	p.written.Add(int64(len(b)))
+	p.lastWrite.Store(time.Now().UnixNano())
+	return len(b), nil
+}
+
+// parseContentRange parses a "bytes start-end/size" header, returning -1 for an unknown size
+func parseContentRange(header string) (start, size int64, err error) {
+	spec, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", header)
+	}
+	rangePart, sizePart, ok := strings.Cut(spec, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", header)
+	}
+	startPart, _, ok := strings.Cut(rangePart, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", header)
+	}
+	if start, err = strconv.ParseInt(startPart, 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q: %w", header, err)
+	}
+	if sizePart == "*" {
+		return start, -1, nil
+	}
+	if size, err = strconv.ParseInt(sizePart, 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q: %w", header, err)
+	}
+	return start, size, nil
+}
+
+// formatBytes formats a byte count with a binary unit
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,302 @@
+package dataset
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyServer serves a file with Range support, dropping the connection after a number of bytes on the first requests
+type flakyServer struct {
+	mu       sync.Mutex
+	etag     string
+	content  []byte
+	dropAt   []int // Bytes written before the connection drops, one entry per request
+	status   int   // Fixed status returned instead of the file when non-zero
+	requests []http.Header
+}
+
+func (s *flakyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Header.Clone())
+	request := len(s.requests)
+	etag, content, status := s.etag, s.content, s.status
+	limit := -1
+	if request <= len(s.dropAt) {
+		limit = s.dropAt[request-1]
+	}
+	s.mu.Unlock()
+
+	if status != 0 {
+		w.WriteHeader(status)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	if limit >= 0 {
+		w = &droppingWriter{ResponseWriter: w, remaining: limit}
+	}
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+}
+
+func (s *flakyServer) requestHeaders() []http.Header {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// droppingWriter aborts the response after writing a number of body bytes
+type droppingWriter struct {
+	http.ResponseWriter
+	remaining int
+}
+
+func (w *droppingWriter) Write(b []byte) (int, error) {
+	if len(b) > w.remaining {
+		w.ResponseWriter.Write(b[:w.remaining])
+		w.ResponseWriter.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+	w.remaining -= len(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// newDownloadManager creates a manager with fast retries against the given server
+func newDownloadManager(t *testing.T, url string, output io.Writer) *Manager {
+	t.Helper()
+	tmpDir := t.TempDir()
+	manager := NewManager(
+		url,
+		filepath.Join(tmpDir, "product-database.parquet"),
+		filepath.Join(tmpDir, "metadata.json"),
+		filepath.Join(tmpDir, "refresh.lock"),
+		createTestConfig(),
+		config.NewTestLogger(output, "DEBUG"),
+	)
+	manager.download.retryBaseDelay = time.Millisecond
+	manager.download.attempts = 3
+	return manager
+}
+
+func TestHTTPSource_DownloadResume(t *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 1000))
+
+	tests := []struct {
+		name             string
+		partial          []byte // Partial file left by an earlier run
+		partialETag      string
+		dropAt           []int
+		expectedRequests int
+		expectedRanges   []string
+	}{
+		{
+			name:             "uninterrupted download",
+			expectedRequests: 1,
+			expectedRanges:   []string{""},
+		},
+		{
+			name:             "resumes after dropped connections",
+			dropAt:           []int{3000, 4000},
+			expectedRequests: 3,
+			expectedRanges:   []string{"", "bytes=3000-", "bytes=7000-"},
+		},
+		{
+			name:             "resumes partial file from an earlier run",
+			partial:          content[:5000],
+			partialETag:      `"v1"`,
+			expectedRequests: 1,
+			expectedRanges:   []string{"bytes=5000-"},
+		},
+		{
+			name:             "restarts when the remote file changed",
+			partial:          []byte(strings.Repeat("x", 5000)),
+			partialETag:      `"v0"`,
+			expectedRequests: 1,
+			expectedRanges:   []string{"bytes=5000-"},
+		},
+		{
+			name:             "restarts partial file without a known ETag",
+			partial:          []byte(strings.Repeat("x", 5000)),
+			expectedRequests: 1,
+			expectedRanges:   []string{""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := &flakyServer{etag: `"v1"`, content: content, dropAt: tt.dropAt}
+			server := httptest.NewServer(remote)
+			defer server.Close()
+
+			manager := newDownloadManager(t, server.URL, io.Discard)
+			tmpPath := filepath.Join(t.TempDir(), "product-database.parquet.tmp")
+			if tt.partial != nil {
+				require.NoError(t, os.WriteFile(tmpPath, tt.partial, 0644))
+			}
+			if tt.partialETag != "" {
+				require.NoError(t, os.WriteFile(etagPath(tmpPath), []byte(tt.partialETag), 0644))
+			}
+
+			require.NoError(t, manager.source.Download(context.Background(), tmpPath))
+
+			downloaded, err := os.ReadFile(tmpPath)
+			require.NoError(t, err)
+			assert.Equal(t, content, downloaded)
+
+			requests := remote.requestHeaders()
+			require.Len(t, requests, tt.expectedRequests)
+			for i, expectedRange := range tt.expectedRanges {
+				assert.Equal(t, expectedRange, requests[i].Get("Range"), "request %d", i)
+				if expectedRange != "" {
+					assert.NotEmpty(t, requests[i].Get("If-Range"), "request %d", i)
+				}
+			}
+		})
+	}
+}
+
+func TestHTTPSource_DownloadFailures(t *testing.T) {
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		remote := &flakyServer{etag: `"v1"`, content: []byte(strings.Repeat("a", 1000)), dropAt: []int{100, 100, 100}}
+		server := httptest.NewServer(remote)
+		defer server.Close()
+
+		manager := newDownloadManager(t, server.URL, io.Discard)
+		tmpPath := filepath.Join(t.TempDir(), "product-database.parquet.tmp")
+
+		err := manager.source.Download(context.Background(), tmpPath)
+		assert.ErrorContains(t, err, "after 3 attempts")
+		assert.Len(t, remote.requestHeaders(), 3)
+
+		// The partial file is kept for the next run
+		info, statErr := os.Stat(tmpPath)
+		require.NoError(t, statErr)
+		assert.Equal(t, int64(300), info.Size())
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		remote := &flakyServer{status: http.StatusNotFound}
+		server := httptest.NewServer(remote)
+		defer server.Close()
+
+		manager := newDownloadManager(t, server.URL, io.Discard)
+		err := manager.source.Download(context.Background(), filepath.Join(t.TempDir(), "product-database.parquet.tmp"))
+		assert.ErrorContains(t, err, "status: 404")
+		assert.Len(t, remote.requestHeaders(), 1)
+	})
+
+	t.Run("retries server errors", func(t *testing.T) {
+		remote := &flakyServer{status: http.StatusBadGateway}
+		server := httptest.NewServer(remote)
+		defer server.Close()
+
+		manager := newDownloadManager(t, server.URL, io.Discard)
+		err := manager.source.Download(context.Background(), filepath.Join(t.TempDir(), "product-database.parquet.tmp"))
+		assert.ErrorContains(t, err, "status: 502")
+		assert.Len(t, remote.requestHeaders(), 3)
+	})
+}
+
+func TestHTTPSource_DownloadStallAndProgress(t *testing.T) {
+	content := []byte(strings.Repeat("z", 2000))
+	release := make(chan struct{})
+	defer close(release)
+
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		first := requests == 1
+		mu.Unlock()
+
+		w.Header().Set("ETag", `"v1"`)
+		if !first {
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+			return
+		}
+
+		// Send half the file, then hang without closing the connection
+		w.Header().Set("Content-Length", "2000")
+		w.Write(content[:1000])
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+
+	var logs bytes.Buffer
+	manager := newDownloadManager(t, server.URL, &syncWriter{w: &logs})
+	manager.download.progressInterval = 10 * time.Millisecond
+	manager.download.stallTimeout = 100 * time.Millisecond
+	tmpPath := filepath.Join(t.TempDir(), "product-database.parquet.tmp")
+
+	require.NoError(t, manager.source.Download(context.Background(), tmpPath))
+
+	downloaded, err := os.ReadFile(tmpPath)
+	require.NoError(t, err)
+	assert.Equal(t, content, downloaded)
+	assert.Contains(t, logs.String(), "Download stalled")
+	assert.Contains(t, logs.String(), "Download progress")
+	assert.Contains(t, logs.String(), "percent=50.0")
+}
+
+// syncWriter serializes writes from the logger and the progress goroutine
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(b []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(b)
+}
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		header        string
+		expectedStart int64
+		expectedSize  int64
+		expectError   bool
+	}{
+		{header: "bytes 100-199/200", expectedStart: 100, expectedSize: 200},
+		{header: "bytes 0-99/*", expectedStart: 0, expectedSize: -1},
+		{header: "bytes */200", expectError: true},
+		{header: "items 0-1/2", expectError: true},
+		{header: "", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			start, size, err := parseContentRange(tt.header)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStart, start)
+			assert.Equal(t, tt.expectedSize, size)
+		})
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "1.5 KiB", formatBytes(1536))
+	assert.Equal(t, "3.8 GiB", formatBytes(4080218931))
+}
@@ -0,0 +1,87 @@
+package dataset
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Converter converts dataset exports into a parquet file at outPath and returns its row count
+type Converter func(ctx context.Context, paths []string, outPath string) (int64, error)
+
+// Import converts exports into a new dataset version and activates it like a download
+// The metadata records the export files, and the imported version is not replaced by downloads from PARQUET_URL
+func (m *Manager) Import(ctx context.Context, paths []string, convert Converter) (*Metadata, error) {
+	start := time.Now()
+
+	lock, err := acquireLock(m.lockPath, m.lockOpts, m.log)
+	if err != nil {
+		if !m.config.IgnoreLock {
+			return nil, fmt.Errorf("failed to acquire lock, another instance may be downloading: %w", err)
+		}
+		m.log.Warn("IGNORE_LOCK enabled but still failed to acquire lock, proceeding anyway", "error", err)
+	}
+	if lock != nil {
+		defer lock.release()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(m.parquetPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current working directory: %w", err)
+	}
+	tmpDataDir := filepath.Join(cwd, "tmp-data")
+	if err := os.MkdirAll(tmpDataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create tmp-data directory: %w", err)
+	}
+	outPath := filepath.Join(tmpDataDir, "product-database.parquet.import")
+
+	m.log.Info("Converting dataset exports", "files", paths)
+	rows, err := convert(ctx, paths, outPath)
+	if err != nil {
+		os.Remove(outPath)
+		return nil, err
+	}
+
+	sha, err := computeSHA256(outPath)
+	if err != nil {
+		os.Remove(outPath)
+		return nil, fmt.Errorf("failed to compute SHA256: %w", err)
+	}
+	if m.validate != nil {
+		if err := m.validate(ctx, outPath); err != nil {
+			os.Remove(outPath)
+			return nil, fmt.Errorf("%w: %v", ErrInvalidDataset, err)
+		}
+	}
+	stat, err := os.Stat(outPath)
+	if err != nil {
+		os.Remove(outPath)
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	meta := &Metadata{
+		SHA256:       sha,
+		DownloadedAt: time.Now().UTC(),
+		Size:         stat.Size(),
+	}
+	for _, path := range paths {
+		meta.ImportedFrom = append(meta.ImportedFrom, filepath.Base(path))
+	}
+
+	if err := m.storeVersion(outPath, meta); err != nil {
+		os.Remove(outPath)
+		return nil, fmt.Errorf("failed to store dataset version: %w", err)
+	}
+	if err := m.activateVersion(meta); err != nil {
+		return nil, fmt.Errorf("failed to activate dataset version: %w", err)
+	}
+	m.pruneVersions()
+
+	m.log.Info("Dataset imported", "rows", rows, "size", stat.Size(), "sha256", sha[:16]+"...", "duration", time.Since(start))
+	return meta, nil
+}
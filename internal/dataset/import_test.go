@@ -0,0 +1,82 @@
+package dataset
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// joinConverter is a fake Converter that joins the export contents
+func joinConverter(ctx context.Context, paths []string, outPath string) (int64, error) {
+	var parts []string
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return 0, err
+		}
+		parts = append(parts, string(content))
+	}
+	return int64(len(parts)), os.WriteFile(outPath, []byte(strings.Join(parts, "|")), 0644)
+}
+
+func TestManager_Import(t *testing.T) {
+	ctx := context.Background()
+	exportDir := t.TempDir()
+	export := exportDir + "/openfoodfacts-products.jsonl.gz"
+	require.NoError(t, os.WriteFile(export, []byte("exported products"), 0644))
+
+	t.Run("activates the converted export", func(t *testing.T) {
+		manager, remote := newVersionedManager(t, 3)
+		remote.publish(`"v1"`, "downloaded")
+		require.NoError(t, manager.EnsureDataset(ctx))
+
+		meta, err := manager.Import(ctx, []string{export}, joinConverter)
+		require.NoError(t, err)
+		assert.Equal(t, "exported products", activeContent(t, manager))
+		assert.Equal(t, []string{"openfoodfacts-products.jsonl.gz"}, meta.ImportedFrom)
+		assert.Equal(t, sha256Hex("exported products"), meta.SHA256)
+		assert.Empty(t, meta.ETag)
+
+		saved, err := manager.Metadata()
+		require.NoError(t, err)
+		assert.Equal(t, meta, saved)
+
+		// Upstream publishing a new version does not replace the import
+		remote.publish(`"v2"`, "downloaded again")
+		refreshed, err := manager.Refresh(ctx)
+		require.NoError(t, err)
+		assert.False(t, refreshed)
+		require.NoError(t, manager.EnsureDataset(ctx))
+		assert.Equal(t, "exported products", activeContent(t, manager))
+
+		// The download it replaced can still be rolled back to
+		_, err = manager.Rollback("")
+		require.NoError(t, err)
+		assert.Equal(t, "downloaded", activeContent(t, manager))
+	})
+
+	t.Run("failed conversion keeps the current dataset", func(t *testing.T) {
+		manager, remote := newVersionedManager(t, 3)
+		remote.publish(`"v1"`, "downloaded")
+		require.NoError(t, manager.EnsureDataset(ctx))
+
+		conversionErr := errors.New("malformed export")
+		_, err := manager.Import(ctx, []string{export}, func(ctx context.Context, paths []string, outPath string) (int64, error) {
+			return 0, conversionErr
+		})
+		assert.ErrorIs(t, err, conversionErr)
+		assert.Equal(t, "downloaded", activeContent(t, manager))
+
+		manager.SetValidator(func(ctx context.Context, path string) error {
+			return assert.AnError
+		})
+		_, err = manager.Import(ctx, []string{export}, joinConverter)
+		assert.ErrorIs(t, err, ErrInvalidDataset)
+		assert.Equal(t, "downloaded", activeContent(t, manager))
+	})
+}
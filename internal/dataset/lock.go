@@ -0,0 +1,241 @@
+package dataset
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultLockHeartbeat is how often the lock holder records that it is still alive
+	DefaultLockHeartbeat = 10 * time.Second
+	// DefaultLockStaleAfter is how long a lock may go without a heartbeat before another process takes it over
+	DefaultLockStaleAfter = time.Minute
+	// DefaultLockPollInterval is how often a waiting process checks the lock
+	DefaultLockPollInterval = 2 * time.Second
+	// DefaultLockMaxWait is how long a process waits for a live holder to release the lock
+	DefaultLockMaxWait = 10 * time.Minute
+)
+
+// ErrLockHeld is returned when another process holds the dataset lock and keeps renewing it
+var ErrLockHeld = errors.New("dataset lock held by another process")
+
+// ErrLockWaitTimeout is returned when a live holder keeps the dataset lock longer than the maximum wait
+var ErrLockWaitTimeout = errors.New("timed out waiting for dataset lock")
+
+// lockOptions controls the heartbeat of a held lock and when an abandoned lock is taken over
+type lockOptions struct {
+	heartbeat  time.Duration
+	staleAfter time.Duration
+	poll       time.Duration
+	maxWait    time.Duration
+}
+
+// defaultLockOptions returns the lock settings used outside tests
+func defaultLockOptions() *lockOptions {
+	return &lockOptions{
+		heartbeat:  DefaultLockHeartbeat,
+		staleAfter: DefaultLockStaleAfter,
+		poll:       DefaultLockPollInterval,
+		maxWait:    DefaultLockMaxWait,
+	}
+}
+
+// lockHolder is the process recorded in a lock file
+type lockHolder struct {
+	PID        int       `json:"pid"`
+	Host       string    `json:"host"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	Heartbeat  time.Time `json:"heartbeat"`
+}
+
+// String describes the holder for logs
+func (h *lockHolder) String() string {
+	if h.PID == 0 {
+		return fmt.Sprintf("unknown process, last modified %s ago", time.Since(h.Heartbeat).Round(time.Second))
+	}
+	return fmt.Sprintf("pid %d on %s, heartbeat %s ago", h.PID, h.Host, time.Since(h.Heartbeat).Round(time.Second))
+}
+
+// datasetLock is a held lock file whose heartbeat is renewed in the background until it is released
+type datasetLock struct {
+	path   string
+	file   *os.File
+	holder lockHolder
+	log    *slog.Logger
+
+	stop    chan struct{}
+	stopped sync.WaitGroup
+}
+
+// acquireLock takes the lock file at lockPath, taking it over when its holder stopped renewing its heartbeat
+// Other processes may run on other hosts sharing the data directory, so liveness is judged by the heartbeat only
+func acquireLock(lockPath string, opts *lockOptions, logger *slog.Logger) (*datasetLock, error) {
+	// Ensure the directory exists for the lock file
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+
+	// One attempt, and another after taking over a stale lock
+	for attempt := 0; attempt < 2; attempt++ {
+		// O_CREATE|O_EXCL will fail if file exists
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0644)
+		if err == nil {
+			return startLock(lockPath, f, opts, logger)
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		holder, stale, err := readLock(lockPath, opts)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read lock file: %w", err)
+		}
+		if !stale {
+			return nil, fmt.Errorf("%w: %s", ErrLockHeld, holder)
+		}
+
+		logger.Warn("Taking over stale dataset lock", "lock_path", lockPath, "holder", holder.String())
+		if err := takeOverLock(lockPath, opts); err != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("%w: lock was taken by another process during takeover", ErrLockHeld)
+}
+
+// readLock reads the holder of a lock file and reports whether its heartbeat has expired
+func readLock(lockPath string, opts *lockOptions) (*lockHolder, bool, error) {
+	info, err := os.Stat(lockPath)
+	if err != nil {
+		return nil, false, err
+	}
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Heartbeats overwrite the record in place, so a shorter record may still be followed by the end of the previous one
+	var holder lockHolder
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(&holder); err != nil || holder.Heartbeat.IsZero() {
+		// Lock files from older releases are empty, and a heartbeat may be half written: use the modification time
+		holder = lockHolder{Heartbeat: info.ModTime()}
+	}
+	return &holder, time.Since(holder.Heartbeat) > opts.staleAfter, nil
+}
+
+// takeOverLock removes a stale lock file, unless another process replaced it with a live one in the meantime
+func takeOverLock(lockPath string, opts *lockOptions) error {
+	// Renaming is atomic, so only one process moves a given lock file aside
+	aside := fmt.Sprintf("%s.stale-%d-%d", lockPath, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(lockPath, aside); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to take over stale lock: %w", err)
+	}
+	defer os.Remove(aside)
+
+	if _, stale, err := readLock(aside, opts); err == nil && !stale {
+		// Put the live lock back; if yet another process created one, that one wins
+		os.Link(aside, lockPath)
+		return fmt.Errorf("%w: lock was renewed during takeover", ErrLockHeld)
+	}
+	return nil
+}
+
+// startLock records this process in a newly created lock file and starts renewing its heartbeat
+func startLock(lockPath string, f *os.File, opts *lockOptions, logger *slog.Logger) (*datasetLock, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	now := time.Now().UTC()
+	lock := &datasetLock{
+		path:   lockPath,
+		file:   f,
+		holder: lockHolder{PID: os.Getpid(), Host: host, AcquiredAt: now, Heartbeat: now},
+		log:    logger,
+		stop:   make(chan struct{}),
+	}
+	if err := lock.write(); err != nil {
+		f.Close()
+		os.Remove(lockPath)
+		return nil, fmt.Errorf("failed to write lock file: %w", err)
+	}
+
+	lock.stopped.Add(1)
+	go lock.renew(opts.heartbeat)
+	return lock, nil
+}
+
+// write records the holder in the lock file
+func (l *datasetLock) write() error {
+	data, err := json.Marshal(l.holder)
+	if err != nil {
+		return err
+	}
+	// Overwriting before truncating means readers never see an empty lock file
+	if _, err := l.file.WriteAt(data, 0); err != nil {
+		return err
+	}
+	if err := l.file.Truncate(int64(len(data))); err != nil {
+		return err
+	}
+	return l.file.Sync()
+}
+
+// renew updates the heartbeat until the lock is released
+func (l *datasetLock) renew(interval time.Duration) {
+	defer l.stopped.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	warned := false
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			if !l.owned() && !warned {
+				l.log.Warn("Dataset lock was taken over by another process", "lock_path", l.path)
+				warned = true
+			}
+			l.holder.Heartbeat = time.Now().UTC()
+			if err := l.write(); err != nil {
+				l.log.Warn("Failed to renew dataset lock heartbeat", "lock_path", l.path, "error", err)
+			}
+		}
+	}
+}
+
+// owned reports whether the lock path still refers to this lock's file
+func (l *datasetLock) owned() bool {
+	held, err := l.file.Stat()
+	if err != nil {
+		return false
+	}
+	current, err := os.Stat(l.path)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(held, current)
+}
+
+// release stops the heartbeat and removes the lock file, unless another process has taken it over
+func (l *datasetLock) release() {
+	close(l.stop)
+	l.stopped.Wait()
+	if l.owned() {
+		os.Remove(l.path)
+	}
+	l.file.Close()
+}
@@ -0,0 +1,204 @@
+package dataset
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fastLockOptions renews and expires locks within milliseconds
+func fastLockOptions() *lockOptions {
+	return &lockOptions{heartbeat: 10 * time.Millisecond, staleAfter: 200 * time.Millisecond, poll: 10 * time.Millisecond, maxWait: 10 * time.Second}
+}
+
+// writeLockHolder writes a lock file as another process would
+func writeLockHolder(t *testing.T, lockPath string, holder lockHolder) {
+	t.Helper()
+	data, err := json.Marshal(holder)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(lockPath, data, 0644))
+}
+
+func TestAcquireLock_RecordsHolder(t *testing.T) {
+	lockPath := filepath.Join(t.TempDir(), "refresh.lock")
+	logger := config.NewTestLogger(os.Stdout, "ERROR")
+
+	lock, err := acquireLock(lockPath, fastLockOptions(), logger)
+	require.NoError(t, err)
+	defer lock.release()
+
+	holder, stale, err := readLock(lockPath, fastLockOptions())
+	require.NoError(t, err)
+	assert.False(t, stale)
+	assert.Equal(t, os.Getpid(), holder.PID)
+	assert.NotEmpty(t, holder.Host)
+
+	// The heartbeat keeps the lock from going stale while it is held
+	time.Sleep(3 * fastLockOptions().staleAfter)
+	_, err = acquireLock(lockPath, fastLockOptions(), logger)
+	assert.ErrorIs(t, err, ErrLockHeld)
+	assert.Contains(t, err.Error(), holder.Host)
+}
+
+func TestAcquireLock_TakesOverStaleLock(t *testing.T) {
+	logger := config.NewTestLogger(os.Stdout, "ERROR")
+	old := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name      string
+		writeLock func(t *testing.T, lockPath string)
+		expectErr bool
+	}{
+		{
+			name: "crashed holder stopped its heartbeat",
+			writeLock: func(t *testing.T, lockPath string) {
+				writeLockHolder(t, lockPath, lockHolder{PID: 4242, Host: "crashed-pod", AcquiredAt: old, Heartbeat: old})
+			},
+		},
+		{
+			name: "empty lock file from an older release",
+			writeLock: func(t *testing.T, lockPath string) {
+				require.NoError(t, os.WriteFile(lockPath, nil, 0644))
+				require.NoError(t, os.Chtimes(lockPath, old, old))
+			},
+		},
+		{
+			name: "live holder",
+			writeLock: func(t *testing.T, lockPath string) {
+				writeLockHolder(t, lockPath, lockHolder{PID: 4242, Host: "other-pod", AcquiredAt: old, Heartbeat: time.Now()})
+			},
+			expectErr: true,
+		},
+		{
+			name: "recent empty lock file",
+			writeLock: func(t *testing.T, lockPath string) {
+				require.NoError(t, os.WriteFile(lockPath, nil, 0644))
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			lockPath := filepath.Join(dir, "refresh.lock")
+			tt.writeLock(t, lockPath)
+
+			lock, err := acquireLock(lockPath, fastLockOptions(), logger)
+			if tt.expectErr {
+				assert.ErrorIs(t, err, ErrLockHeld)
+				return
+			}
+			require.NoError(t, err)
+			defer lock.release()
+
+			holder, _, err := readLock(lockPath, fastLockOptions())
+			require.NoError(t, err)
+			assert.Equal(t, os.Getpid(), holder.PID)
+
+			// The stale lock moved aside during the takeover is cleaned up
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			assert.Len(t, entries, 1)
+		})
+	}
+}
+
+func TestDatasetLock_ReleaseAfterTakeover(t *testing.T) {
+	lockPath := filepath.Join(t.TempDir(), "refresh.lock")
+	logger := config.NewTestLogger(os.Stdout, "ERROR")
+
+	lock, err := acquireLock(lockPath, fastLockOptions(), logger)
+	require.NoError(t, err)
+
+	// Another process considered the lock stale and replaced it
+	require.NoError(t, os.Remove(lockPath))
+	other, err := acquireLock(lockPath, fastLockOptions(), logger)
+	require.NoError(t, err)
+	defer other.release()
+
+	lock.release()
+	_, err = os.Stat(lockPath)
+	assert.NoError(t, err, "releasing a taken over lock must not remove the new holder's lock")
+}
+
+func TestManager_DownloadWaitsForLock(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("downloads after the holder releases the lock", func(t *testing.T) {
+		manager, remote := newVersionedManager(t, 3)
+		manager.lockOpts = fastLockOptions()
+		remote.publish(`"v1"`, "base1")
+
+		holder, err := acquireLock(manager.lockPath, fastLockOptions(), manager.log)
+		require.NoError(t, err)
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			holder.release()
+		}()
+
+		require.NoError(t, manager.EnsureDataset(ctx))
+		assert.Equal(t, "base1", activeContent(t, manager))
+		_, err = os.Stat(manager.lockPath)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("takes over the lock of a crashed download", func(t *testing.T) {
+		manager, remote := newVersionedManager(t, 3)
+		manager.lockOpts = fastLockOptions()
+		remote.publish(`"v1"`, "base1")
+
+		require.NoError(t, os.MkdirAll(filepath.Dir(manager.lockPath), 0755))
+		writeLockHolder(t, manager.lockPath, lockHolder{PID: 4242, Host: "crashed-pod", AcquiredAt: time.Now(), Heartbeat: time.Now()})
+
+		waitCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		require.NoError(t, manager.EnsureDataset(waitCtx))
+		assert.Equal(t, "base1", activeContent(t, manager))
+	})
+
+	t.Run("gives up when a live holder keeps the lock too long", func(t *testing.T) {
+		manager, remote := newVersionedManager(t, 3)
+		manager.lockOpts = fastLockOptions()
+		manager.lockOpts.maxWait = 100 * time.Millisecond
+		remote.publish(`"v1"`, "base1")
+
+		holder, err := acquireLock(manager.lockPath, fastLockOptions(), manager.log)
+		require.NoError(t, err)
+		defer holder.release()
+
+		err = manager.EnsureDataset(ctx)
+		assert.ErrorIs(t, err, ErrLockWaitTimeout)
+		assert.Contains(t, err.Error(), holder.holder.Host)
+		_, err = os.Stat(manager.parquetPath)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("skips the download another instance just completed", func(t *testing.T) {
+		manager, remote := newVersionedManager(t, 3)
+		manager.lockOpts = fastLockOptions()
+		remote.publish(`"v1"`, "base1")
+		require.NoError(t, manager.EnsureDataset(ctx))
+		before, err := manager.Metadata()
+		require.NoError(t, err)
+
+		holder, err := acquireLock(manager.lockPath, fastLockOptions(), manager.log)
+		require.NoError(t, err)
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			holder.release()
+		}()
+
+		require.NoError(t, manager.downloadWithLock(ctx))
+		after, err := manager.Metadata()
+		require.NoError(t, err)
+		assert.Equal(t, before.DownloadedAt, after.DownloadedAt)
+	})
+}
@@ -8,10 +8,8 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
-	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/noot-app/openfoodfacts-mcp-server/internal/config"
@@ -28,43 +26,89 @@ type Metadata struct {
 	DownloadedAt time.Time `json:"downloaded_at"`
 	ETag         string    `json:"etag,omitempty"`
 	Size         int64     `json:"size"`
+	Rejected     bool      `json:"rejected,omitempty"` // Rolled back from; not downloaded again
+
+	// Set when the file was built from the upstream download, e.g. by applying delta exports.
+	// ETag stays the upstream one, so freshness checks still compare against the upstream file
+	UpstreamSHA256 string   `json:"upstream_sha256,omitempty"`
+	UpstreamSize   int64    `json:"upstream_size,omitempty"`
+	Deltas         []string `json:"deltas,omitempty"` // Delta exports applied, by file name
+
+	// Delta exports of versions rolled back from, by file name; they are not applied again
+	RejectedDeltas []string `json:"rejected_deltas,omitempty"`
+
+	// Export files the dataset was converted from by Import; imported datasets are not replaced by downloads
+	ImportedFrom []string `json:"imported_from,omitempty"`
+
+	// Set when the file is a reduced copy of a full version; ETag and the upstream fields still describe the full file
+	Pruned     *PruneFilter `json:"pruned,omitempty"`
+	PrunedFrom string       `json:"pruned_from,omitempty"` // SHA256 of the stored full version
+}
+
+// upstreamSize returns the size of the upstream file the dataset was built from
+func (meta *Metadata) upstreamSize() int64 {
+	if meta.UpstreamSHA256 != "" {
+		return meta.UpstreamSize
+	}
+	return meta.Size
 }
 
 // Manager handles dataset downloading and metadata management
 type Manager struct {
-	parquetURL   string
+	source       Source
+	sourceErr    error
 	parquetPath  string
 	metadataPath string
 	lockPath     string
 	log          *slog.Logger
 	config       *config.Config
+
+	// Download retry and progress settings, shared with the source
+	download *downloadOptions
+
+	// Check run on a download before it is activated, nil to skip
+	validate Validator
+
+	// Heartbeat and takeover settings of the lock file
+	lockOpts *lockOptions
+
+	// Merges delta exports into the dataset, required when DELTA_URL is set
+	mergeDeltas DeltaMerger
+
+	// Builds reduced datasets, required when PRUNE_DATASET is set
+	prune Pruner
 }
 
 // NewManager creates a new dataset manager
 func NewManager(parquetURL, parquetPath, metadataPath, lockPath string, cfg *config.Config, logger *slog.Logger) *Manager {
+	download := defaultDownloadOptions()
+	source, err := newSource(parquetURL, cfg, download, logger)
+
+	lockOpts := defaultLockOptions()
+	if cfg.LockWaitTimeoutSeconds > 0 {
+		lockOpts.maxWait = cfg.LockWaitTimeout()
+	}
+
 	return &Manager{
-		parquetURL:   parquetURL,
+		source:       source,
+		sourceErr:    err,
 		parquetPath:  parquetPath,
 		metadataPath: metadataPath,
 		lockPath:     lockPath,
 		log:          logger,
 		config:       cfg,
-	}
-}
 
-// discoverDownloadURL discovers the actual download URL from HuggingFace API
-func (m *Manager) discoverDownloadURL(ctx context.Context) (string, error) {
-	// If the URL doesn't look like a HuggingFace dataset URL, use it directly
-	if !strings.Contains(m.parquetURL, "huggingface.co/datasets/") {
-		return m.parquetURL, nil
+		download: download,
+		lockOpts: lockOpts,
 	}
+}
 
-	// For OpenFoodFacts, we know the structure - use the direct food.parquet file
-	downloadURL := "https://huggingface.co/datasets/openfoodfacts/product-database/resolve/main/food.parquet"
+// remote returns the dataset source, or why PARQUET_URL could not be used
+func (m *Manager) remote() (Source, error) {
+	return m.source, m.sourceErr
+}
 
-	m.log.Debug("Using direct download URL for OpenFoodFacts dataset", "download_url", downloadURL)
-	return downloadURL, nil
-} // EnsureDataset ensures the dataset is available and up-to-date
+// EnsureDataset ensures the dataset is available and up-to-date
 func (m *Manager) EnsureDataset(ctx context.Context) error {
 	start := time.Now()
 	m.log.Info("Ensuring dataset is available", "parquet_path", m.parquetPath)
@@ -84,6 +128,8 @@ func (m *Manager) EnsureDataset(ctx context.Context) error {
 		}
 		if upToDate {
 			m.log.Info("Dataset is up-to-date", "duration", time.Since(start))
+			m.applyPendingDeltas(ctx)
+			m.applyPruneFilter(ctx)
 			return nil
 		}
 	}
@@ -92,11 +138,51 @@ func (m *Manager) EnsureDataset(ctx context.Context) error {
 	if err := m.downloadWithLock(ctx); err != nil {
 		return fmt.Errorf("failed to download dataset: %w", err)
 	}
+	m.applyPendingDeltas(ctx)
+	m.applyPruneFilter(ctx)
 
 	m.log.Info("Dataset ensured", "duration", time.Since(start))
 	return nil
 }
 
+// Refresh downloads the dataset if the remote has a newer version and reports whether the local file changed
+func (m *Manager) Refresh(ctx context.Context) (bool, error) {
+	if m.config.DisableRemoteCheck {
+		m.log.Debug("Remote checks disabled, skipping dataset refresh")
+		return false, nil
+	}
+
+	upToDate, err := m.isUpToDate(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to verify dataset freshness: %w", err)
+	}
+	if upToDate {
+		m.log.Info("Dataset is up-to-date with the source")
+		applied := m.applyPendingDeltas(ctx)
+		pruned := m.applyPruneFilter(ctx)
+		return applied || pruned, nil
+	}
+
+	before, _ := m.loadMetadata()
+	if err := m.downloadWithLock(ctx); err != nil {
+		return false, fmt.Errorf("failed to download dataset: %w", err)
+	}
+
+	// Compare versions rather than assume a change: waiting on another instance's download also ends here
+	after, err := m.loadMetadata()
+	if err != nil {
+		return false, fmt.Errorf("failed to load metadata after refresh: %w", err)
+	}
+	changed := before == nil || before.SHA256 != after.SHA256
+	if m.applyPendingDeltas(ctx) {
+		changed = true
+	}
+	if m.applyPruneFilter(ctx) {
+		changed = true
+	}
+	return changed, nil
+}
+
 // isUpToDate checks if the local dataset is up-to-date with the remote
 func (m *Manager) isUpToDate(ctx context.Context) (bool, error) {
 	start := time.Now()
@@ -132,6 +218,11 @@ func (m *Manager) isUpToDate(ctx context.Context) (bool, error) {
 		}
 	}
 
+	if len(localMeta.ImportedFrom) > 0 {
+		m.log.Info("Dataset was imported from exports, skipping remote check", "imported_from", localMeta.ImportedFrom)
+		return true, nil
+	}
+
 	// Get remote metadata
 	remoteMeta, err := m.getRemoteMetadata(ctx)
 	if err != nil {
@@ -139,6 +230,12 @@ func (m *Manager) isUpToDate(ctx context.Context) (bool, error) {
 		return false, err
 	}
 
+	// Don't download a version that was rolled back while upstream still publishes it
+	if remoteMeta.ETag != "" && m.isRejected(remoteMeta.ETag) {
+		m.log.Warn("Remote still publishes a rolled-back dataset version, keeping the active one", "etag", remoteMeta.ETag)
+		return true, nil
+	}
+
 	// Compare ETag if available
 	if remoteMeta.ETag != "" && localMeta.ETag != "" {
 		upToDate := remoteMeta.ETag == localMeta.ETag
@@ -147,46 +244,18 @@ func (m *Manager) isUpToDate(ctx context.Context) (bool, error) {
 	}
 
 	// Fallback to size comparison
-	upToDate := remoteMeta.Size == localMeta.Size
-	m.log.Debug("Size comparison", "local", localMeta.Size, "remote", remoteMeta.Size, "up_to_date", upToDate, "duration", time.Since(start))
+	upToDate := remoteMeta.Size == localMeta.upstreamSize()
+	m.log.Debug("Size comparison", "local", localMeta.upstreamSize(), "remote", remoteMeta.Size, "up_to_date", upToDate, "duration", time.Since(start))
 	return upToDate, nil
 }
 
-// getRemoteMetadata fetches metadata from the remote URL using HEAD request
+// getRemoteMetadata fetches the ETag and size of the version the source publishes
 func (m *Manager) getRemoteMetadata(ctx context.Context) (*Metadata, error) {
-	start := time.Now()
-
-	// Discover the actual download URL
-	downloadURL, err := m.discoverDownloadURL(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to discover download URL: %w", err)
-	}
-
-	m.log.Debug("Fetching remote metadata", "url", downloadURL)
-
-	req, err := http.NewRequestWithContext(ctx, "HEAD", downloadURL, nil)
+	source, err := m.remote()
 	if err != nil {
 		return nil, err
 	}
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HEAD request failed with status: %d", resp.StatusCode)
-	}
-
-	meta := &Metadata{
-		ETag: resp.Header.Get("ETag"),
-		Size: resp.ContentLength,
-	}
-
-	m.log.Debug("Remote metadata fetched", "etag", meta.ETag, "size", meta.Size, "duration", time.Since(start))
-	return meta, nil
+	return source.Stat(ctx)
 }
 
 // downloadWithLock downloads the dataset with file locking
@@ -204,21 +273,36 @@ func (m *Manager) downloadWithLock(ctx context.Context) error {
 		}
 	}
 
-	// Try to acquire lock
-	lockFile, err := acquireLock(m.lockPath)
+	// Try to acquire lock, waiting while another instance holds it and keeps its heartbeat fresh
+	waited := false
+	lock, err := acquireLock(m.lockPath, m.lockOpts, m.log)
 	if err != nil {
 		if m.config.IgnoreLock {
 			m.log.Warn("IGNORE_LOCK enabled but still failed to acquire lock, proceeding anyway", "error", err)
 			// Continue with download without lock
 		} else {
-			m.log.Info("Another instance is downloading, waiting", "lock_path", m.lockPath)
-			return m.waitForDownload(ctx)
+			m.log.Info("Another instance is downloading, waiting", "lock_path", m.lockPath, "error", err)
+			if err := m.waitForLock(ctx); err != nil {
+				return fmt.Errorf("failed waiting for download by other instance: %w", err)
+			}
+			if lock, err = acquireLock(m.lockPath, m.lockOpts, m.log); err != nil {
+				return fmt.Errorf("failed to acquire lock after waiting: %w", err)
+			}
+			waited = true
 		}
 	}
 
 	// Only defer lock release if we successfully acquired it
-	if lockFile != nil {
-		defer releaseLock(lockFile, m.lockPath)
+	if lock != nil {
+		defer lock.release()
+	}
+
+	// The other instance has usually just downloaded the same version; if it died instead, download it here
+	if waited {
+		if upToDate, _ := m.isUpToDate(ctx); upToDate {
+			m.log.Info("Dataset now available after other instance completed", "duration", time.Since(start))
+			return nil
+		}
 	}
 
 	m.log.Info("Lock acquired, starting download", "duration", time.Since(start))
@@ -240,10 +324,15 @@ func (m *Manager) downloadWithLock(ctx context.Context) error {
 		return fmt.Errorf("failed to create tmp-data directory: %w", err)
 	}
 	tmpPath := filepath.Join(tmpDataDir, "product-database.parquet.tmp")
-	if err := m.downloadFile(ctx, tmpPath); err != nil {
-		os.Remove(tmpPath)
+	source, err := m.remote()
+	if err != nil {
+		return err
+	}
+	if err := source.Download(ctx, tmpPath); err != nil {
+		// Keep the partial file so the next attempt can resume it
 		return err
 	}
+	os.Remove(etagPath(tmpPath))
 
 	// Compute SHA256
 	sha, err := computeSHA256(tmpPath)
@@ -252,6 +341,12 @@ func (m *Manager) downloadWithLock(ctx context.Context) error {
 		return fmt.Errorf("failed to compute SHA256: %w", err)
 	}
 
+	// A corrupt or invalid download never replaces the current dataset, and is not resumed
+	if err := m.verifyDownload(ctx, tmpPath, sha); err != nil {
+		discardPartial(tmpPath)
+		return err
+	}
+
 	// Get file size
 	stat, err := os.Stat(tmpPath)
 	if err != nil {
@@ -266,109 +361,82 @@ func (m *Manager) downloadWithLock(ctx context.Context) error {
 		etag = remoteMeta.ETag
 	}
 
-	// Save metadata
 	meta := &Metadata{
 		SHA256:       sha,
 		DownloadedAt: time.Now().UTC(),
 		ETag:         etag,
 		Size:         stat.Size(),
 	}
-	if err := m.saveMetadata(meta); err != nil {
-		m.log.Warn("Failed to save metadata", "error", err)
-	}
 
-	// Atomic file replacement: remove old file and copy new one
-	// This minimizes the time window where the file doesn't exist
-	if _, err := os.Stat(m.parquetPath); err == nil {
-		// Remove existing file first
-		if err := os.Remove(m.parquetPath); err != nil {
-			os.Remove(tmpPath)
-			return fmt.Errorf("failed to remove existing file: %w", err)
-		}
-	}
-
-	// Copy from temp location to final location
-	if err := m.copyFile(tmpPath, m.parquetPath); err != nil {
+	// Store the download as its own version, then atomically point the dataset path at it
+	if err := m.storeVersion(tmpPath, meta); err != nil {
 		os.Remove(tmpPath)
-		return fmt.Errorf("failed to copy file: %w", err)
-	}
-
-	// Clean up temporary file
-	os.Remove(tmpPath)
-
-	m.log.Info("Dataset downloaded successfully", "size", stat.Size(), "sha256", sha[:16]+"...", "duration", time.Since(start))
-	return nil
-}
-
-// downloadFile downloads the file from the remote URL
-func (m *Manager) downloadFile(ctx context.Context, filePath string) error {
-	start := time.Now()
-
-	// Discover the actual download URL
-	downloadURL, err := m.discoverDownloadURL(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to discover download URL: %w", err)
-	}
-
-	m.log.Info("Downloading dataset", "url", downloadURL, "path", filePath)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", downloadURL, nil)
-	if err != nil {
-		return err
+		return fmt.Errorf("failed to store dataset version: %w", err)
 	}
-
-	client := &http.Client{Timeout: 30 * time.Minute}
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
+	if err := m.activateVersion(meta); err != nil {
+		return fmt.Errorf("failed to activate dataset version: %w", err)
 	}
-	defer resp.Body.Close()
+	m.pruneVersions()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("download failed with status: %d", resp.StatusCode)
-	}
-
-	file, err := os.Create(filePath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	// Copy with progress logging
-	written, err := io.Copy(file, resp.Body)
-	if err != nil {
-		return err
-	}
-
-	m.log.Info("Download completed", "bytes", written, "duration", time.Since(start))
+	m.log.Info("Dataset downloaded successfully", "size", stat.Size(), "sha256", sha[:16]+"...", "duration", time.Since(start))
 	return nil
 }
 
-// waitForDownload waits for another instance to complete the download
-func (m *Manager) waitForDownload(ctx context.Context) error {
-	ticker := time.NewTicker(2 * time.Second)
+// waitForLock waits until the dataset lock is released or its holder stops renewing it, logging the holder
+// A holder that stays alive longer than the maximum wait fails the wait with ErrLockWaitTimeout
+func (m *Manager) waitForLock(ctx context.Context) error {
+	ticker := time.NewTicker(m.lockOpts.poll)
 	defer ticker.Stop()
+	timeout := time.NewTimer(m.lockOpts.maxWait)
+	defer timeout.Stop()
 
-	timeout := time.After(10 * time.Minute)
-
+	last := ""
+	var holder *lockHolder
 	for {
+		current, stale, err := readLock(m.lockPath, m.lockOpts)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return fmt.Errorf("failed to read lock file: %w", err)
+		}
+		if stale {
+			return nil
+		}
+		holder = current
+		if holder.PID != 0 && fmt.Sprint(holder.PID, holder.Host) != last {
+			m.log.Info("Waiting for dataset lock", "lock_path", m.lockPath, "holder_pid", holder.PID, "holder_host", holder.Host, "acquired_at", holder.AcquiredAt)
+			last = fmt.Sprint(holder.PID, holder.Host)
+		}
+
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case <-timeout:
-			return fmt.Errorf("timeout waiting for download by other instance")
+		case <-timeout.C:
+			return fmt.Errorf("%w after %s, held by %s; raise LOCK_WAIT_TIMEOUT_SECONDS to wait longer", ErrLockWaitTimeout, m.lockOpts.maxWait, holder)
 		case <-ticker.C:
-			if _, err := os.Stat(m.parquetPath); err == nil {
-				m.log.Info("Dataset now available after other instance completed")
-				return nil
-			}
 		}
 	}
 }
 
+// Metadata returns the metadata of the local dataset
+func (m *Manager) Metadata() (*Metadata, error) {
+	return m.loadMetadata()
+}
+
 // loadMetadata loads metadata from the metadata file
 func (m *Manager) loadMetadata() (*Metadata, error) {
-	data, err := os.ReadFile(m.metadataPath)
+	return readMetadataFile(m.metadataPath)
+}
+
+// saveMetadata saves metadata to the metadata file
+func (m *Manager) saveMetadata(meta *Metadata) error {
+	return writeMetadataFile(m.metadataPath, meta)
+}
+
+// readMetadataFile reads metadata from a JSON file
+func readMetadataFile(path string) (*Metadata, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
@@ -381,35 +449,18 @@ func (m *Manager) loadMetadata() (*Metadata, error) {
 	return &meta, nil
 }
 
-// saveMetadata saves metadata to the metadata file
-func (m *Manager) saveMetadata(meta *Metadata) error {
+// writeMetadataFile writes metadata to a JSON file, replacing it atomically
+func writeMetadataFile(path string, meta *Metadata) error {
 	data, err := json.MarshalIndent(meta, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(m.metadataPath, data, 0644)
-}
-
-// acquireLock attempts to acquire an exclusive lock
-func acquireLock(lockPath string) (*os.File, error) {
-	// Ensure the directory exists for the lock file
-	if err := os.MkdirAll(filepath.Dir(lockPath), 0755); err != nil {
-		return nil, fmt.Errorf("failed to create lock directory: %w", err)
-	}
-
-	// O_CREATE|O_EXCL will fail if file exists
-	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
-	if err != nil {
-		return nil, err
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
 	}
-	return f, nil
-}
-
-// releaseLock releases the lock file
-func releaseLock(f *os.File, lockPath string) {
-	f.Close()
-	os.Remove(lockPath)
+	return os.Rename(tmp, path)
 }
 
 // copyFile copies a file from src to dst
@@ -430,6 +481,17 @@ func (m *Manager) copyFile(src, dst string) error {
 	return err
 }
 
+// moveFile renames src to dst, copying when they are on different filesystems
+func (m *Manager) moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := m.copyFile(src, dst); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
 // computeSHA256 computes the SHA256 hash of a file
 func computeSHA256(filePath string) (string, error) {
 	file, err := os.Open(filePath)
@@ -3,6 +3,7 @@ package dataset
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -219,25 +220,27 @@ func TestManager_AcquireReleaseLock(t *testing.T) {
 
 	lockPath := filepath.Join(tmpDir, "test.lock")
 
+	logger := config.NewTestLogger(os.Stdout, "ERROR")
+
 	// First acquisition should succeed
-	lock1, err := acquireLock(lockPath)
+	lock1, err := acquireLock(lockPath, defaultLockOptions(), logger)
 	assert.NoError(t, err)
 	assert.NotNil(t, lock1)
 
 	// Second acquisition should fail
-	lock2, err := acquireLock(lockPath)
-	assert.Error(t, err)
+	lock2, err := acquireLock(lockPath, defaultLockOptions(), logger)
+	assert.ErrorIs(t, err, ErrLockHeld)
 	assert.Nil(t, lock2)
 
 	// Release first lock
-	releaseLock(lock1, lockPath)
+	lock1.release()
 
 	// Third acquisition should succeed again
-	lock3, err := acquireLock(lockPath)
+	lock3, err := acquireLock(lockPath, defaultLockOptions(), logger)
 	assert.NoError(t, err)
 	assert.NotNil(t, lock3)
 
-	releaseLock(lock3, lockPath)
+	lock3.release()
 }
 
 func TestComputeSHA256(t *testing.T) {
@@ -293,6 +296,10 @@ func TestMetadata_SaveLoad(t *testing.T) {
 	assert.Equal(t, originalMeta.ETag, loadedMeta.ETag)
 	assert.Equal(t, originalMeta.Size, loadedMeta.Size)
 	assert.True(t, originalMeta.DownloadedAt.Equal(loadedMeta.DownloadedAt))
+
+	exportedMeta, err := manager.Metadata()
+	require.NoError(t, err)
+	assert.Equal(t, loadedMeta, exportedMeta)
 }
 
 func TestManager_IgnoreLock(t *testing.T) {
@@ -432,3 +439,72 @@ func TestManager_EnsureDataset_ExistingFileWithoutMetadata(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, testContent, string(content))
 }
+
+func TestManager_Refresh(t *testing.T) {
+	tests := []struct {
+		name                   string
+		remoteETag             string
+		useDisabledRemoteCheck bool
+		expectRefreshed        bool
+		expectedContent        string
+	}{
+		{
+			name:            "remote unchanged",
+			remoteETag:      "v1",
+			expectRefreshed: false,
+			expectedContent: "old parquet data",
+		},
+		{
+			name:            "remote has a newer version",
+			remoteETag:      "v2",
+			expectRefreshed: true,
+			expectedContent: "new parquet data",
+		},
+		{
+			name:                   "remote checks disabled",
+			remoteETag:             "v2",
+			useDisabledRemoteCheck: true,
+			expectRefreshed:        false,
+			expectedContent:        "old parquet data",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			parquetPath := filepath.Join(tmpDir, "product-database.parquet")
+			metadataPath := filepath.Join(tmpDir, "metadata.json")
+			require.NoError(t, os.WriteFile(parquetPath, []byte("old parquet data"), 0644))
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("ETag", tt.remoteETag)
+				if r.Method == "HEAD" {
+					w.Header().Set("Content-Length", "16")
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+				w.Write([]byte("new parquet data"))
+			}))
+			defer server.Close()
+
+			testConfig := createTestConfig()
+			if tt.useDisabledRemoteCheck {
+				testConfig = createTestConfigWithDisabledRemoteCheck()
+			}
+			logger := config.NewTestLogger(io.Discard, "DEBUG")
+			manager := NewManager(server.URL, parquetPath, metadataPath, filepath.Join(tmpDir, "refresh.lock"), testConfig, logger)
+
+			sha, err := computeSHA256(parquetPath)
+			require.NoError(t, err)
+			require.NoError(t, manager.saveMetadata(&Metadata{SHA256: sha, ETag: "v1", Size: 16, DownloadedAt: time.Now()}))
+
+			refreshed, err := manager.Refresh(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectRefreshed, refreshed)
+
+			content, err := os.ReadFile(parquetPath)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedContent, string(content))
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package dataset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+var (
+	// ErrNoPruner is returned when a dataset should be pruned but nothing can prune it
+	ErrNoPruner = errors.New("no dataset pruner configured")
+	// ErrFullVersionMissing is returned when a pruned dataset has to be rebuilt but the full version it came from is gone
+	ErrFullVersionMissing = errors.New("full dataset version no longer stored, refresh or fetch the dataset to prune it again")
+)
+
+// PruneFilter selects the products kept in a pruned dataset. Empty lists keep every product
+type PruneFilter struct {
+	Countries        []string `json:"countries,omitempty"`
+	Languages        []string `json:"languages,omitempty"`
+	RequireNutrition bool     `json:"require_nutrition,omitempty"`
+}
+
+// equal reports whether two filters keep the same products
+func (f PruneFilter) equal(other PruneFilter) bool {
+	return slices.Equal(f.Countries, other.Countries) &&
+		slices.Equal(f.Languages, other.Languages) &&
+		f.RequireNutrition == other.RequireNutrition
+}
+
+// Pruner writes the products of the dataset at basePath matching filter to outPath, keeping only the columns
+// the server reads, and returns the number of products written
+type Pruner func(ctx context.Context, basePath string, filter PruneFilter, outPath string) (int64, error)
+
+// SetPruner sets how reduced datasets are built
+func (m *Manager) SetPruner(prune Pruner) {
+	m.prune = prune
+}
+
+// configPruneFilter returns the filter set by the PRUNE_* variables
+func (m *Manager) configPruneFilter() PruneFilter {
+	return PruneFilter{
+		Countries:        m.config.PruneCountries,
+		Languages:        m.config.PruneLanguages,
+		RequireNutrition: m.config.PruneRequireNutrition,
+	}
+}
+
+// Prune replaces the active dataset with a reduced copy and activates it as a new version
+// A pruned dataset is always built from the full version it came from, so filters can be widened again
+// while that version is stored. The metadata keeps the upstream ETag and size, so refreshes still compare
+// against the full upstream file
+func (m *Manager) Prune(ctx context.Context, filter PruneFilter) (*Metadata, error) {
+	if m.prune == nil {
+		return nil, ErrNoPruner
+	}
+
+	lock, err := acquireLock(m.lockPath, m.lockOpts, m.log)
+	if err != nil {
+		if !m.config.IgnoreLock {
+			return nil, fmt.Errorf("failed to acquire lock, another instance may be downloading: %w", err)
+		}
+		m.log.Warn("IGNORE_LOCK enabled but still failed to acquire lock, proceeding anyway", "error", err)
+	}
+	if lock != nil {
+		defer lock.release()
+	}
+
+	active, err := m.loadMetadata()
+	if err != nil {
+		return nil, fmt.Errorf("no active dataset to prune: %w", err)
+	}
+	base, basePath, err := m.fullVersion(active)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.pruneVersion(ctx, base, basePath, filter)
+}
+
+// fullVersion returns the metadata and file of the unpruned version behind the active dataset
+func (m *Manager) fullVersion(active *Metadata) (*Metadata, string, error) {
+	if active.Pruned == nil {
+		return active, m.parquetPath, nil
+	}
+
+	dir := m.versionDir(active.PrunedFrom)
+	meta, err := readMetadataFile(filepath.Join(dir, versionMetadataName))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %s", ErrFullVersionMissing, active.PrunedFrom)
+	}
+	path := filepath.Join(dir, versionFileName)
+	if _, err := os.Stat(path); err != nil {
+		return nil, "", fmt.Errorf("%w: %s", ErrFullVersionMissing, active.PrunedFrom)
+	}
+	return meta, path, nil
+}
+
+// pruneVersion builds a pruned copy of the full version at basePath, then stores and activates it
+// The caller holds the lock
+func (m *Manager) pruneVersion(ctx context.Context, base *Metadata, basePath string, filter PruneFilter) (*Metadata, error) {
+	if m.prune == nil {
+		return nil, ErrNoPruner
+	}
+	start := time.Now()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current working directory: %w", err)
+	}
+	tmpDataDir := filepath.Join(cwd, "tmp-data")
+	if err := os.MkdirAll(tmpDataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create tmp-data directory: %w", err)
+	}
+	outPath := filepath.Join(tmpDataDir, "product-database.parquet.pruned")
+
+	m.log.Info("Pruning dataset", "countries", filter.Countries, "languages", filter.Languages,
+		"require_nutrition", filter.RequireNutrition, "full_sha256", base.SHA256[:16]+"...")
+	rows, err := m.prune(ctx, basePath, filter, outPath)
+	if err != nil {
+		os.Remove(outPath)
+		return nil, err
+	}
+
+	sha, err := computeSHA256(outPath)
+	if err != nil {
+		os.Remove(outPath)
+		return nil, fmt.Errorf("failed to compute SHA256: %w", err)
+	}
+	if m.validate != nil {
+		if err := m.validate(ctx, outPath); err != nil {
+			os.Remove(outPath)
+			return nil, fmt.Errorf("%w: %v", ErrInvalidDataset, err)
+		}
+	}
+	stat, err := os.Stat(outPath)
+	if err != nil {
+		os.Remove(outPath)
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	meta := *base
+	meta.SHA256 = sha
+	meta.Size = stat.Size()
+	meta.DownloadedAt = time.Now().UTC()
+	meta.Rejected = false
+	if meta.UpstreamSHA256 == "" {
+		meta.UpstreamSHA256 = base.SHA256
+		meta.UpstreamSize = base.Size
+	}
+	meta.Pruned = &filter
+	meta.PrunedFrom = base.SHA256
+
+	if err := m.storeVersion(outPath, &meta); err != nil {
+		os.Remove(outPath)
+		return nil, fmt.Errorf("failed to store dataset version: %w", err)
+	}
+	if err := m.activateVersion(&meta); err != nil {
+		return nil, fmt.Errorf("failed to activate dataset version: %w", err)
+	}
+	m.pruneVersions()
+
+	m.log.Info("Dataset pruned", "rows", rows, "size", stat.Size(), "full_size", base.Size,
+		"sha256", sha[:16]+"...", "duration", time.Since(start))
+	return &meta, nil
+}
+
+// applyPruneFilter prunes the active dataset when PRUNE_DATASET is set and it is not pruned with the configured
+// filter yet, logging rather than failing: the full dataset is still usable. It reports whether a new version was activated
+func (m *Manager) applyPruneFilter(ctx context.Context) bool {
+	if !m.config.PruneDataset {
+		return false
+	}
+
+	filter := m.configPruneFilter()
+	active, err := m.loadMetadata()
+	if err != nil {
+		m.log.Warn("No active dataset to prune", "error", err)
+		return false
+	}
+	if active.Pruned != nil && active.Pruned.equal(filter) {
+		m.log.Debug("Dataset is already pruned with the configured filter")
+		return false
+	}
+
+	if _, err := m.Prune(ctx, filter); err != nil {
+		m.log.Warn("Failed to prune dataset, keeping the current one", "error", err)
+		return false
+	}
+	return true
+}
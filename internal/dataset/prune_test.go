@@ -0,0 +1,193 @@
+package dataset
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tagPruner is a fake Pruner that appends the filter's countries to the dataset
+func tagPruner(ctx context.Context, basePath string, filter PruneFilter, outPath string) (int64, error) {
+	content, err := os.ReadFile(basePath)
+	if err != nil {
+		return 0, err
+	}
+	pruned := string(content) + "|" + strings.Join(filter.Countries, ",")
+	return 1, os.WriteFile(outPath, []byte(pruned), 0644)
+}
+
+func TestManager_Prune(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("PRUNE_DATASET prunes each download", func(t *testing.T) {
+		manager, remote := newVersionedManager(t, 3)
+		manager.config.PruneDataset = true
+		manager.config.PruneCountries = []string{"en:france"}
+		manager.SetPruner(tagPruner)
+		remote.publish(`"v1"`, "base1")
+
+		require.NoError(t, manager.EnsureDataset(ctx))
+		assert.Equal(t, "base1|en:france", activeContent(t, manager))
+
+		meta, err := manager.Metadata()
+		require.NoError(t, err)
+		assert.Equal(t, &PruneFilter{Countries: []string{"en:france"}}, meta.Pruned)
+		assert.Equal(t, sha256Hex("base1"), meta.PrunedFrom)
+		assert.Equal(t, `"v1"`, meta.ETag)
+		assert.Equal(t, sha256Hex("base1"), meta.UpstreamSHA256)
+		assert.Equal(t, int64(5), meta.UpstreamSize)
+
+		// Already pruned with the configured filter, and upstream is unchanged
+		refreshed, err := manager.Refresh(ctx)
+		require.NoError(t, err)
+		assert.False(t, refreshed)
+
+		remote.publish(`"v2"`, "base22")
+		refreshed, err = manager.Refresh(ctx)
+		require.NoError(t, err)
+		assert.True(t, refreshed)
+		assert.Equal(t, "base22|en:france", activeContent(t, manager))
+
+		meta, err = manager.Metadata()
+		require.NoError(t, err)
+		assert.Equal(t, int64(6), meta.UpstreamSize)
+	})
+
+	t.Run("changed filters prune the full version again", func(t *testing.T) {
+		manager, remote := newVersionedManager(t, 3)
+		manager.SetPruner(tagPruner)
+		remote.publish(`"v1"`, "base1")
+		require.NoError(t, manager.EnsureDataset(ctx))
+
+		_, err := manager.Prune(ctx, PruneFilter{Countries: []string{"en:france"}})
+		require.NoError(t, err)
+		assert.Equal(t, "base1|en:france", activeContent(t, manager))
+
+		meta, err := manager.Prune(ctx, PruneFilter{Countries: []string{"en:france", "en:belgium"}})
+		require.NoError(t, err)
+		assert.Equal(t, "base1|en:france,en:belgium", activeContent(t, manager))
+		assert.Equal(t, sha256Hex("base1"), meta.PrunedFrom)
+
+		// Without PRUNE_DATASET a refresh keeps the pruned dataset
+		refreshed, err := manager.Refresh(ctx)
+		require.NoError(t, err)
+		assert.False(t, refreshed)
+		assert.Equal(t, "base1|en:france,en:belgium", activeContent(t, manager))
+	})
+
+	t.Run("delta exports are merged into the full version and pruned again", func(t *testing.T) {
+		deltaDir := t.TempDir()
+		writeDelta(t, deltaDir, "openfoodfacts_products_1700000000_1700086400.json.gz", "d1")
+
+		manager, remote := newVersionedManager(t, 5)
+		manager.config.DeltaURL = deltaDir
+		manager.config.PruneDataset = true
+		manager.config.PruneCountries = []string{"en:france"}
+		manager.SetDeltaMerger(appendMerger)
+		manager.SetPruner(tagPruner)
+		remote.publish(`"v1"`, "base1")
+
+		require.NoError(t, manager.EnsureDataset(ctx))
+		assert.Equal(t, "base1+d1|en:france", activeContent(t, manager))
+
+		writeDelta(t, deltaDir, "openfoodfacts_products_1700086400_1700172800.json.gz", "d2")
+		refreshed, err := manager.Refresh(ctx)
+		require.NoError(t, err)
+		assert.True(t, refreshed)
+		assert.Equal(t, "base1+d1+d2|en:france", activeContent(t, manager))
+
+		meta, err := manager.Metadata()
+		require.NoError(t, err)
+		assert.Equal(t, sha256Hex("base1+d1+d2"), meta.PrunedFrom)
+		assert.Equal(t, sha256Hex("base1"), meta.UpstreamSHA256)
+		assert.Len(t, meta.Deltas, 2)
+	})
+
+	t.Run("keeps the full version beyond DATASET_VERSIONS", func(t *testing.T) {
+		manager, remote := newVersionedManager(t, 1)
+		manager.SetPruner(tagPruner)
+		remote.publish(`"v1"`, "base1")
+		require.NoError(t, manager.EnsureDataset(ctx))
+
+		_, err := manager.Prune(ctx, PruneFilter{Countries: []string{"en:france"}})
+		require.NoError(t, err)
+		_, err = manager.Prune(ctx, PruneFilter{Countries: []string{"en:belgium"}})
+		require.NoError(t, err)
+		assert.Equal(t, "base1|en:belgium", activeContent(t, manager))
+
+		// The earlier pruned copy is removed, the full version it was made from stays
+		versions, err := manager.Versions()
+		require.NoError(t, err)
+		shas := []string{}
+		for _, v := range versions {
+			shas = append(shas, v.SHA256)
+		}
+		assert.ElementsMatch(t, []string{sha256Hex("base1"), sha256Hex("base1|en:belgium")}, shas)
+	})
+
+	t.Run("rollback skips the full version of the pruned dataset", func(t *testing.T) {
+		manager, remote := newVersionedManager(t, 5)
+		manager.config.PruneDataset = true
+		manager.config.PruneCountries = []string{"en:france"}
+		manager.SetPruner(tagPruner)
+		remote.publish(`"v1"`, "base1")
+		require.NoError(t, manager.EnsureDataset(ctx))
+		remote.publish(`"v2"`, "base22")
+		refreshed, err := manager.Refresh(ctx)
+		require.NoError(t, err)
+		require.True(t, refreshed)
+		assert.Equal(t, "base22|en:france", activeContent(t, manager))
+
+		meta, err := manager.Rollback("")
+		require.NoError(t, err)
+		assert.Equal(t, "base1|en:france", activeContent(t, manager))
+		assert.Equal(t, sha256Hex("base1"), meta.PrunedFrom)
+
+		// Neither a refresh nor a restart prunes the rolled-back version again
+		refreshed, err = manager.Refresh(ctx)
+		require.NoError(t, err)
+		assert.False(t, refreshed)
+		assert.Equal(t, "base1|en:france", activeContent(t, manager))
+
+		require.NoError(t, manager.EnsureDataset(ctx))
+		assert.Equal(t, "base1|en:france", activeContent(t, manager))
+	})
+
+	t.Run("failed prune keeps the current dataset", func(t *testing.T) {
+		manager, remote := newVersionedManager(t, 3)
+		manager.config.PruneDataset = true
+		pruneErr := errors.New("out of disk")
+		manager.SetPruner(func(ctx context.Context, basePath string, filter PruneFilter, outPath string) (int64, error) {
+			return 0, pruneErr
+		})
+		remote.publish(`"v1"`, "base1")
+
+		require.NoError(t, manager.EnsureDataset(ctx))
+		assert.Equal(t, "base1", activeContent(t, manager))
+
+		_, err := manager.Prune(ctx, PruneFilter{})
+		assert.ErrorIs(t, err, pruneErr)
+
+		manager.SetPruner(tagPruner)
+		manager.SetValidator(func(ctx context.Context, path string) error {
+			return assert.AnError
+		})
+		_, err = manager.Prune(ctx, PruneFilter{})
+		assert.ErrorIs(t, err, ErrInvalidDataset)
+		assert.Equal(t, "base1", activeContent(t, manager))
+	})
+
+	t.Run("requires a pruner", func(t *testing.T) {
+		manager, remote := newVersionedManager(t, 3)
+		remote.publish(`"v1"`, "base1")
+		require.NoError(t, manager.EnsureDataset(ctx))
+
+		_, err := manager.Prune(ctx, PruneFilter{})
+		assert.ErrorIs(t, err, ErrNoPruner)
+	})
+}
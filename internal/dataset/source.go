@@ -0,0 +1,78 @@
+package dataset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/config"
+)
+
+// ErrUnsupportedSource is returned for a PARQUET_URL with an unknown scheme
+var ErrUnsupportedSource = errors.New("unsupported dataset source")
+
+// Source is where the dataset is fetched from
+type Source interface {
+	// Stat returns the ETag and size of the version the source currently publishes
+	Stat(ctx context.Context) (*Metadata, error)
+	// Download writes the published file to path, resuming a partial file where the source allows it
+	Download(ctx context.Context, path string) error
+	// ExpectedSHA256 returns the SHA256 the source publishes for the file and where it was found,
+	// or an empty string when it publishes none
+	ExpectedSHA256(ctx context.Context) (string, string)
+	// String describes the source for logs
+	String() string
+}
+
+// downloadOptions controls retries, stall detection and progress logging of downloads
+type downloadOptions struct {
+	attempts         int
+	retryBaseDelay   time.Duration
+	progressInterval time.Duration
+	stallTimeout     time.Duration
+}
+
+// defaultDownloadOptions returns the download settings used outside tests
+func defaultDownloadOptions() *downloadOptions {
+	return &downloadOptions{
+		attempts:         DefaultDownloadAttempts,
+		retryBaseDelay:   DefaultRetryBaseDelay,
+		progressInterval: DefaultProgressInterval,
+		stallTimeout:     DefaultStallTimeout,
+	}
+}
+
+// NewSource returns the source for a PARQUET_URL: http(s)://, file:// (or a plain path) or s3://bucket/key
+func NewSource(rawURL string, cfg *config.Config, logger *slog.Logger) (Source, error) {
+	return newSource(rawURL, cfg, defaultDownloadOptions(), logger)
+}
+
+func newSource(rawURL string, cfg *config.Config, opts *downloadOptions, logger *slog.Logger) (Source, error) {
+	if strings.HasPrefix(rawURL, "/") {
+		return newFileSource(rawURL, opts, logger), nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedSource, err)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		return newHTTPSource(rawURL, opts, logger), nil
+	case "file":
+		return newFileSource(u.Path, opts, logger), nil
+	case "s3":
+		key := strings.TrimPrefix(u.Path, "/")
+		if u.Host == "" || key == "" {
+			return nil, fmt.Errorf("%w: %s must be s3://bucket/key", ErrUnsupportedSource, rawURL)
+		}
+		return newS3Source(u.Host, key, cfg, opts, logger), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedSource, rawURL)
+	}
+}
@@ -0,0 +1,106 @@
+package dataset
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// fileSource copies the dataset from a local path, such as a mounted volume
+type fileSource struct {
+	path string
+	opts *downloadOptions
+	log  *slog.Logger
+}
+
+// newFileSource creates a source for a local file
+func newFileSource(path string, opts *downloadOptions, logger *slog.Logger) *fileSource {
+	return &fileSource{path: path, opts: opts, log: logger}
+}
+
+// String returns the file URL
+func (s *fileSource) String() string {
+	return "file://" + s.path
+}
+
+// Stat derives an ETag from the file's modification time and size, which change whenever the file is replaced
+func (s *fileSource) Stat(ctx context.Context) (*Metadata, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return nil, err
+	}
+	return &Metadata{
+		ETag: fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()),
+		Size: info.Size(),
+	}, nil
+}
+
+// Download copies the file; a local copy is fast enough to restart rather than resume
+func (s *fileSource) Download(ctx context.Context, path string) error {
+	start := time.Now()
+	s.log.Info("Copying dataset", "source", s.path, "path", path)
+
+	src, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	dst, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+	os.Remove(etagPath(path))
+
+	copyCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	progress := newDownloadProgress(0, info.Size())
+	stop := watchProgress(copyCtx, cancel, progress, s.opts, s.log)
+	_, err = io.Copy(dst, io.TeeReader(&contextReader{ctx: copyCtx, r: src}, progress))
+	stop()
+	if err != nil {
+		return err
+	}
+
+	s.log.Info("Copy completed", "bytes", progress.written.Load(), "duration", time.Since(start))
+	return nil
+}
+
+// ExpectedSHA256 reads a .sha256 file next to the dataset, if there is one
+func (s *fileSource) ExpectedSHA256(ctx context.Context) (string, string) {
+	sidecar := s.path + ".sha256"
+	f, err := os.Open(sidecar)
+	if err != nil {
+		return "", ""
+	}
+	defer f.Close()
+
+	sha, err := readChecksumFile(f)
+	if err != nil {
+		s.log.Warn("Ignoring malformed checksum file", "path", sidecar, "error", err)
+		return "", ""
+	}
+	return sha, sidecar
+}
+
+// contextReader stops reading once its context is cancelled
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
@@ -0,0 +1,128 @@
+package dataset
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// httpSource fetches the dataset from an http(s) URL
+type httpSource struct {
+	url        string
+	downloader *rangeDownloader
+	log        *slog.Logger
+}
+
+// newHTTPSource creates a source for an http(s) URL, resolving Hugging Face dataset URLs to the file itself
+func newHTTPSource(rawURL string, opts *downloadOptions, logger *slog.Logger) *httpSource {
+	s := &httpSource{url: rawURL, log: logger}
+
+	// For OpenFoodFacts, we know the structure - use the direct food.parquet file
+	if strings.Contains(rawURL, "huggingface.co/datasets/") {
+		s.url = "https://huggingface.co/datasets/openfoodfacts/product-database/resolve/main/food.parquet"
+		logger.Debug("Using direct download URL for OpenFoodFacts dataset", "download_url", s.url)
+	}
+
+	s.downloader = newRangeDownloader(func(ctx context.Context, header http.Header) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", s.url, nil)
+		if err != nil {
+			return nil, err
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		return req, nil
+	}, "If-Range", opts, logger)
+
+	return s
+}
+
+// String returns the download URL
+func (s *httpSource) String() string {
+	return s.url
+}
+
+// Stat fetches the remote ETag and size with a HEAD request
+func (s *httpSource) Stat(ctx context.Context) (*Metadata, error) {
+	start := time.Now()
+	s.log.Debug("Fetching remote metadata", "url", s.url)
+
+	req, err := http.NewRequestWithContext(ctx, "HEAD", s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HEAD request failed with status: %d", resp.StatusCode)
+	}
+
+	meta := &Metadata{
+		ETag: resp.Header.Get("ETag"),
+		Size: resp.ContentLength,
+	}
+
+	s.log.Debug("Remote metadata fetched", "etag", meta.ETag, "size", meta.Size, "duration", time.Since(start))
+	return meta, nil
+}
+
+// Download downloads the file, resuming a partial file with a Range request
+func (s *httpSource) Download(ctx context.Context, path string) error {
+	s.log.Info("Downloading dataset", "url", s.url, "path", path)
+	return s.downloader.download(ctx, path)
+}
+
+// ExpectedSHA256 reads the X-Linked-ETag header, falling back to a .sha256 file next to the dataset
+func (s *httpSource) ExpectedSHA256(ctx context.Context) (string, string) {
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	// Hugging Face sends the LFS object's SHA256 as X-Linked-ETag on the redirect to the CDN
+	req, err := http.NewRequestWithContext(ctx, "HEAD", s.url, nil)
+	if err != nil {
+		return "", ""
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		s.log.Warn("Failed to fetch checksum headers", "error", err)
+	} else {
+		resp.Body.Close()
+		for r := resp; r != nil; r = r.Request.Response {
+			if sha := parseSHA256(r.Header.Get("X-Linked-ETag")); sha != "" {
+				return sha, "X-Linked-ETag"
+			}
+		}
+	}
+
+	// Otherwise look for a sidecar file next to the dataset
+	sidecarURL := s.url + ".sha256"
+	req, err = http.NewRequestWithContext(ctx, "GET", sidecarURL, nil)
+	if err != nil {
+		return "", ""
+	}
+	resp, err = client.Do(req)
+	if err != nil {
+		s.log.Warn("Failed to fetch checksum file", "url", sidecarURL, "error", err)
+		return "", ""
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		s.log.Debug("No checksum file published", "url", sidecarURL, "status", resp.StatusCode)
+		return "", ""
+	}
+
+	sha, err := readChecksumFile(resp.Body)
+	if err != nil {
+		s.log.Warn("Ignoring malformed checksum file", "url", sidecarURL, "error", err)
+		return "", ""
+	}
+	return sha, sidecarURL
+}
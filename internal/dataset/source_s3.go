@@ -0,0 +1,298 @@
+package dataset
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/config"
+)
+
+// emptyPayloadSHA256 is the SHA256 of an empty request body, sent with every S3 request
+const emptyPayloadSHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// s3Credentials are the keys requests are signed with; an empty access key means anonymous requests
+type s3Credentials struct {
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+}
+
+// s3Source fetches the dataset from an S3 bucket or S3-compatible storage such as MinIO
+type s3Source struct {
+	bucket     string
+	key        string
+	endpoint   *url.URL
+	region     string
+	pathStyle  bool
+	creds      s3Credentials
+	client     *http.Client
+	downloader *rangeDownloader
+	log        *slog.Logger
+}
+
+// newS3Source creates a source for s3://bucket/key using the S3_* and AWS_* settings
+func newS3Source(bucket, key string, cfg *config.Config, opts *downloadOptions, logger *slog.Logger) *s3Source {
+	region := cfg.S3Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	endpoint := cfg.S3Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	} else if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		endpointURL = &url.URL{Scheme: "https", Host: endpoint}
+	}
+
+	s := &s3Source{
+		bucket:    bucket,
+		key:       key,
+		endpoint:  endpointURL,
+		region:    region,
+		pathStyle: cfg.S3UsePathStyle,
+		creds: s3Credentials{
+			accessKeyID:     cfg.S3AccessKeyID,
+			secretAccessKey: cfg.S3SecretAccessKey,
+			sessionToken:    cfg.S3SessionToken,
+		},
+		client: &http.Client{Timeout: 30 * time.Second},
+		log:    logger,
+	}
+
+	// S3 does not support If-Range, If-Match makes it refuse a range of a replaced object instead
+	s.downloader = newRangeDownloader(func(ctx context.Context, header http.Header) (*http.Request, error) {
+		return s.newRequest(ctx, "GET", s.key, header)
+	}, "If-Match", opts, logger)
+
+	return s
+}
+
+// String returns the s3:// URL
+func (s *s3Source) String() string {
+	return "s3://" + s.bucket + "/" + s.key
+}
+
+// objectURL returns the URL of an object in the bucket
+func (s *s3Source) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	path := "/" + key
+	if s.pathStyle {
+		path = "/" + s.bucket + path
+	} else {
+		u.Host = s.bucket + "." + u.Host
+	}
+	u.Path = path
+	u.RawPath = s3EscapePath(path)
+	return &u
+}
+
+// newRequest builds a signed request for an object
+func (s *s3Source) newRequest(ctx context.Context, method, key string, header http.Header) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if s.creds.accessKeyID != "" {
+		signV4(req, s.creds, s.region, time.Now())
+	}
+	return req, nil
+}
+
+// Stat fetches the object's ETag and size with a HEAD request
+func (s *s3Source) Stat(ctx context.Context) (*Metadata, error) {
+	start := time.Now()
+	s.log.Debug("Fetching remote metadata", "source", s.String())
+
+	req, err := s.newRequest(ctx, "HEAD", s.key, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HEAD %s failed with status: %d", s, resp.StatusCode)
+	}
+
+	meta := &Metadata{
+		ETag: resp.Header.Get("ETag"),
+		Size: resp.ContentLength,
+	}
+
+	s.log.Debug("Remote metadata fetched", "etag", meta.ETag, "size", meta.Size, "duration", time.Since(start))
+	return meta, nil
+}
+
+// Download downloads the object, resuming a partial file with a Range request
+func (s *s3Source) Download(ctx context.Context, path string) error {
+	s.log.Info("Downloading dataset", "source", s.String(), "path", path)
+	return s.downloader.download(ctx, path)
+}
+
+// ExpectedSHA256 reads the object's SHA256 checksum, falling back to a .sha256 object next to it
+func (s *s3Source) ExpectedSHA256(ctx context.Context) (string, string) {
+	// Present when the object was uploaded with a full-object SHA256 checksum
+	req, err := s.newRequest(ctx, "HEAD", s.key, http.Header{"X-Amz-Checksum-Mode": {"ENABLED"}})
+	if err != nil {
+		return "", ""
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		s.log.Warn("Failed to fetch object checksum", "error", err)
+	} else {
+		resp.Body.Close()
+		if checksum := resp.Header.Get("X-Amz-Checksum-Sha256"); checksum != "" && !strings.Contains(checksum, "-") {
+			if digest, err := base64.StdEncoding.DecodeString(checksum); err == nil && len(digest) == sha256.Size {
+				return hex.EncodeToString(digest), "x-amz-checksum-sha256"
+			}
+		}
+	}
+
+	sidecarKey := s.key + ".sha256"
+	req, err = s.newRequest(ctx, "GET", sidecarKey, nil)
+	if err != nil {
+		return "", ""
+	}
+	resp, err = s.client.Do(req)
+	if err != nil {
+		s.log.Warn("Failed to fetch checksum object", "key", sidecarKey, "error", err)
+		return "", ""
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		s.log.Debug("No checksum object published", "key", sidecarKey, "status", resp.StatusCode)
+		return "", ""
+	}
+
+	sha, err := readChecksumFile(resp.Body)
+	if err != nil {
+		s.log.Warn("Ignoring malformed checksum object", "key", sidecarKey, "error", err)
+		return "", ""
+	}
+	return sha, "s3://" + s.bucket + "/" + sidecarKey
+}
+
+// signV4 signs an S3 request with AWS Signature Version 4, covering the host and every header already set
+func signV4(req *http.Request, creds s3Credentials, region string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadSHA256)
+	if creds.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.sessionToken)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		trimmed := make([]string, len(values))
+		for i, v := range values {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		headers[strings.ToLower(name)] = strings.Join(trimmed, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		emptyPayloadSHA256,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+creds.secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.accessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3CanonicalQuery encodes query parameters sorted by name, as SigV4 requires
+func s3CanonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var parts []string
+	for _, name := range names {
+		values := query[name]
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, s3Escape(name, true)+"="+s3Escape(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3EscapePath escapes an object path, keeping the slashes between segments
+func s3EscapePath(path string) string {
+	return s3Escape(path, false)
+}
+
+// s3Escape percent-encodes everything except unreserved characters, and slashes unless encodeSlash is set
+func s3Escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
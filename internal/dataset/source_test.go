@@ -0,0 +1,93 @@
+package dataset
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSource(t *testing.T) {
+	logger := config.NewTestLogger(io.Discard, "DEBUG")
+
+	tests := []struct {
+		name          string
+		url           string
+		expected      string
+		expectedError bool
+	}{
+		{name: "https", url: "https://example.com/food.parquet", expected: "https://example.com/food.parquet"},
+		{name: "hugging face dataset", url: "https://huggingface.co/datasets/openfoodfacts/product-database", expected: "https://huggingface.co/datasets/openfoodfacts/product-database/resolve/main/food.parquet"},
+		{name: "file URL", url: "file:///mnt/datasets/food.parquet", expected: "file:///mnt/datasets/food.parquet"},
+		{name: "plain path", url: "/mnt/datasets/food.parquet", expected: "file:///mnt/datasets/food.parquet"},
+		{name: "s3", url: "s3://datasets/openfoodfacts/food.parquet", expected: "s3://datasets/openfoodfacts/food.parquet"},
+		{name: "s3 without key", url: "s3://datasets", expectedError: true},
+		{name: "unknown scheme", url: "ftp://example.com/food.parquet", expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := NewSource(tt.url, createTestConfig(), logger)
+			if tt.expectedError {
+				assert.ErrorIs(t, err, ErrUnsupportedSource)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, source.String())
+		})
+	}
+
+	t.Run("manager reports unsupported source", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		manager := NewManager("ftp://example.com/food.parquet", filepath.Join(tmpDir, "product-database.parquet"),
+			filepath.Join(tmpDir, "metadata.json"), filepath.Join(tmpDir, "refresh.lock"), createTestConfig(), logger)
+		assert.ErrorIs(t, manager.EnsureDataset(context.Background()), ErrUnsupportedSource)
+	})
+}
+
+func TestFileSource(t *testing.T) {
+	mountDir := t.TempDir()
+	published := filepath.Join(mountDir, "food.parquet")
+	require.NoError(t, os.WriteFile(published, []byte("mounted data v1"), 0644))
+
+	tmpDir := t.TempDir()
+	manager := NewManager("file://"+published, filepath.Join(tmpDir, "product-database.parquet"),
+		filepath.Join(tmpDir, "metadata.json"), filepath.Join(tmpDir, "refresh.lock"), createTestConfig(),
+		config.NewTestLogger(io.Discard, "DEBUG"))
+	ctx := context.Background()
+
+	require.NoError(t, manager.EnsureDataset(ctx))
+	assert.Equal(t, "mounted data v1", activeContent(t, manager))
+
+	// Unchanged file is up to date
+	refreshed, err := manager.Refresh(ctx)
+	require.NoError(t, err)
+	assert.False(t, refreshed)
+
+	// A replaced file is picked up, and checked against its .sha256 file
+	require.NoError(t, os.WriteFile(published, []byte("mounted data v2"), 0644))
+	require.NoError(t, os.Chtimes(published, time.Now(), time.Now().Add(time.Minute)))
+	sum := sha256.Sum256([]byte("mounted data v2"))
+	require.NoError(t, os.WriteFile(published+".sha256", []byte(hex.EncodeToString(sum[:])+"  food.parquet\n"), 0644))
+
+	refreshed, err = manager.Refresh(ctx)
+	require.NoError(t, err)
+	assert.True(t, refreshed)
+	assert.Equal(t, "mounted data v2", activeContent(t, manager))
+
+	// A file that does not match its checksum is rejected
+	require.NoError(t, os.WriteFile(published, []byte("corrupted data"), 0644))
+	require.NoError(t, os.Chtimes(published, time.Now(), time.Now().Add(2*time.Minute)))
+
+	_, err = manager.Refresh(ctx)
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+	assert.Equal(t, "mounted data v2", activeContent(t, manager))
+}
@@ -0,0 +1,355 @@
+package dataset
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// versionFileName is the parquet file name inside each version directory
+	versionFileName = "product-database.parquet"
+	// versionMetadataName is the metadata file name inside each version directory
+	versionMetadataName = "metadata.json"
+)
+
+var (
+	// ErrVersionNotFound is returned when no stored version matches the requested SHA256
+	ErrVersionNotFound = errors.New("dataset version not found")
+	// ErrNoPreviousVersion is returned when there is no older version to roll back to
+	ErrNoPreviousVersion = errors.New("no previous dataset version to roll back to")
+)
+
+// Version is one stored dataset download
+type Version struct {
+	Metadata
+	Active bool `json:"active"`
+}
+
+// versionsDir returns the directory holding one subdirectory per stored version
+func (m *Manager) versionsDir() string {
+	if m.config.VersionsDir != "" {
+		return m.config.VersionsDir
+	}
+	return filepath.Join(filepath.Dir(m.parquetPath), "versions")
+}
+
+// versionDir returns the directory of the version with the given SHA256
+func (m *Manager) versionDir(sha string) string {
+	return filepath.Join(m.versionsDir(), sha)
+}
+
+// keepVersions returns how many versions are kept, including the active one
+func (m *Manager) keepVersions() int {
+	if m.config.DatasetVersions > 0 {
+		return m.config.DatasetVersions
+	}
+	return 1
+}
+
+// storeVersion moves a downloaded file into its version directory and records its metadata there
+func (m *Manager) storeVersion(srcPath string, meta *Metadata) error {
+	dir := m.versionDir(meta.SHA256)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create version directory: %w", err)
+	}
+
+	// A partially copied file never appears under the final name
+	dst := filepath.Join(dir, versionFileName)
+	tmp := dst + ".tmp"
+	if err := m.moveFile(srcPath, tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return writeMetadataFile(filepath.Join(dir, versionMetadataName), meta)
+}
+
+// activateVersion atomically points the parquet path at a stored version and makes its metadata current
+// Queries opening the path see either the old or the new file, never a missing one
+func (m *Manager) activateVersion(meta *Metadata) error {
+	if err := m.adoptLegacyFile(); err != nil {
+		m.log.Warn("Failed to keep existing dataset file as a version, it cannot be rolled back to", "error", err)
+	}
+
+	target, err := m.linkTarget(filepath.Join(m.versionDir(meta.SHA256), versionFileName))
+	if err != nil {
+		return err
+	}
+
+	nextLink := m.parquetPath + ".next"
+	os.Remove(nextLink)
+	if err := os.Symlink(target, nextLink); err != nil {
+		return fmt.Errorf("failed to create dataset link: %w", err)
+	}
+	if err := os.Rename(nextLink, m.parquetPath); err != nil {
+		os.Remove(nextLink)
+		return fmt.Errorf("failed to switch dataset link: %w", err)
+	}
+
+	if err := m.saveMetadata(meta); err != nil {
+		return fmt.Errorf("failed to save metadata: %w", err)
+	}
+
+	m.log.Info("Dataset version activated", "sha256", meta.SHA256[:16]+"...", "target", target)
+	return nil
+}
+
+// linkTarget returns the symlink target for a version file, relative to the parquet path when possible
+// so the data directory can be mounted elsewhere
+func (m *Manager) linkTarget(versionFile string) (string, error) {
+	absFile, err := filepath.Abs(versionFile)
+	if err != nil {
+		return "", err
+	}
+	absDir, err := filepath.Abs(filepath.Dir(m.parquetPath))
+	if err != nil {
+		return "", err
+	}
+	if rel, err := filepath.Rel(absDir, absFile); err == nil {
+		return rel, nil
+	}
+	return absFile, nil
+}
+
+// adoptLegacyFile stores a plain parquet file left by older releases as a version before it is replaced by the link
+func (m *Manager) adoptLegacyFile() error {
+	info, err := os.Lstat(m.parquetPath)
+	if err != nil || info.Mode()&os.ModeSymlink != 0 {
+		return nil
+	}
+
+	sha, err := computeSHA256(m.parquetPath)
+	if err != nil {
+		return fmt.Errorf("failed to compute SHA256: %w", err)
+	}
+
+	meta := &Metadata{SHA256: sha, DownloadedAt: info.ModTime().UTC(), Size: info.Size()}
+	if local, err := m.loadMetadata(); err == nil && local.SHA256 == sha {
+		meta = local
+	}
+
+	dir := m.versionDir(sha)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create version directory: %w", err)
+	}
+
+	// Link rather than move: the file must stay in place until the link replaces it
+	dst := filepath.Join(dir, versionFileName)
+	if err := os.Link(m.parquetPath, dst); err != nil && !os.IsExist(err) {
+		if err := m.copyFile(m.parquetPath, dst); err != nil {
+			os.Remove(dst)
+			return err
+		}
+	}
+
+	m.log.Info("Kept existing dataset file as a version", "sha256", sha[:16]+"...")
+	return writeMetadataFile(filepath.Join(dir, versionMetadataName), meta)
+}
+
+// activeSHA returns the SHA256 of the version the parquet path points at
+func (m *Manager) activeSHA() string {
+	target, err := os.Readlink(m.parquetPath)
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(filepath.Dir(target))
+}
+
+// Versions lists the stored dataset versions, newest download first
+func (m *Manager) Versions() ([]Version, error) {
+	entries, err := os.ReadDir(m.versionsDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	active := m.activeSHA()
+	var versions []Version
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(m.versionsDir(), entry.Name())
+		meta, err := readMetadataFile(filepath.Join(dir, versionMetadataName))
+		if err != nil {
+			m.log.Debug("Skipping dataset version without metadata", "dir", dir, "error", err)
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, versionFileName)); err != nil {
+			m.log.Debug("Skipping dataset version without parquet file", "dir", dir, "error", err)
+			continue
+		}
+		versions = append(versions, Version{Metadata: *meta, Active: meta.SHA256 == active})
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].DownloadedAt.After(versions[j].DownloadedAt)
+	})
+	return versions, nil
+}
+
+// pruneVersions removes the oldest versions beyond DATASET_VERSIONS, never the active one
+// or the full version a pruned active version was made from, which later prunes and deltas start from
+func (m *Manager) pruneVersions() {
+	versions, err := m.Versions()
+	if err != nil {
+		m.log.Warn("Failed to list dataset versions for pruning", "error", err)
+		return
+	}
+
+	prunedFrom := ""
+	for _, v := range versions {
+		if v.Active {
+			prunedFrom = v.PrunedFrom
+		}
+	}
+
+	kept := 1 // The active version always stays
+	for _, v := range versions {
+		if v.Active {
+			continue
+		}
+		if prunedFrom != "" && v.SHA256 == prunedFrom {
+			kept++
+			continue
+		}
+		if kept < m.keepVersions() {
+			kept++
+			continue
+		}
+		if err := os.RemoveAll(m.versionDir(v.SHA256)); err != nil {
+			m.log.Warn("Failed to remove old dataset version", "sha256", v.SHA256, "error", err)
+			continue
+		}
+		m.log.Info("Removed old dataset version", "sha256", v.SHA256[:16]+"...", "downloaded_at", v.DownloadedAt)
+	}
+}
+
+// isRejected reports whether a rolled-back version has the given ETag
+func (m *Manager) isRejected(etag string) bool {
+	versions, err := m.Versions()
+	if err != nil {
+		return false
+	}
+	for _, v := range versions {
+		if v.Rejected && v.ETag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// Rollback reactivates a stored version, by default the newest one downloaded before the active version
+// sha may be a prefix. The version rolled back from is marked rejected so refreshes do not download it again
+func (m *Manager) Rollback(sha string) (*Metadata, error) {
+	start := time.Now()
+
+	lock, err := acquireLock(m.lockPath, m.lockOpts, m.log)
+	if err != nil {
+		if !m.config.IgnoreLock {
+			return nil, fmt.Errorf("failed to acquire lock, another instance may be downloading: %w", err)
+		}
+		m.log.Warn("IGNORE_LOCK enabled but still failed to acquire lock, proceeding anyway", "error", err)
+	}
+	if lock != nil {
+		defer lock.release()
+	}
+
+	versions, err := m.Versions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dataset versions: %w", err)
+	}
+
+	target, err := rollbackTarget(versions, sha)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range versions {
+		if v.Active {
+			target.RejectedDeltas = rejectedDeltas(&v.Metadata, &target.Metadata)
+			v.Rejected = true
+			if err := writeMetadataFile(filepath.Join(m.versionDir(v.SHA256), versionMetadataName), &v.Metadata); err != nil {
+				m.log.Warn("Failed to mark rolled-back version as rejected", "sha256", v.SHA256, "error", err)
+			}
+		}
+	}
+
+	target.Rejected = false
+	if err := writeMetadataFile(filepath.Join(m.versionDir(target.SHA256), versionMetadataName), &target.Metadata); err != nil {
+		return nil, fmt.Errorf("failed to update version metadata: %w", err)
+	}
+	if err := m.activateVersion(&target.Metadata); err != nil {
+		return nil, err
+	}
+
+	m.log.Info("Dataset rolled back", "sha256", target.SHA256[:16]+"...", "downloaded_at", target.DownloadedAt, "duration", time.Since(start))
+	return &target.Metadata, nil
+}
+
+// rejectedDeltas returns the delta exports to skip after rolling back from one version to another: those already
+// rejected on the target plus those applied on the rolled-back version but not on the target
+func rejectedDeltas(from, to *Metadata) []string {
+	rejected := append([]string(nil), to.RejectedDeltas...)
+	skip := make(map[string]bool, len(to.Deltas)+len(to.RejectedDeltas))
+	for _, name := range to.Deltas {
+		skip[name] = true
+	}
+	for _, name := range to.RejectedDeltas {
+		skip[name] = true
+	}
+	for _, name := range from.Deltas {
+		if !skip[name] {
+			rejected = append(rejected, name)
+			skip[name] = true
+		}
+	}
+	return rejected
+}
+
+// rollbackTarget picks the version to reactivate from versions sorted newest first
+func rollbackTarget(versions []Version, sha string) (*Version, error) {
+	if sha != "" {
+		var matches []Version
+		for _, v := range versions {
+			if strings.HasPrefix(v.SHA256, sha) {
+				matches = append(matches, v)
+			}
+		}
+		switch {
+		case len(matches) == 0:
+			return nil, fmt.Errorf("%w: %s", ErrVersionNotFound, sha)
+		case len(matches) > 1:
+			return nil, fmt.Errorf("%w: %s matches %d versions", ErrVersionNotFound, sha, len(matches))
+		case matches[0].Active:
+			return nil, fmt.Errorf("dataset version %s is already active", sha)
+		}
+		return &matches[0], nil
+	}
+
+	// The full version a pruned dataset was made from holds the same data, and would be pruned into it again
+	seenActive := false
+	prunedFrom := ""
+	for i, v := range versions {
+		if v.Active {
+			seenActive = true
+			prunedFrom = v.PrunedFrom
+			continue
+		}
+		if seenActive && !v.Rejected && v.SHA256 != prunedFrom {
+			return &versions[i], nil
+		}
+	}
+	return nil, ErrNoPreviousVersion
+}
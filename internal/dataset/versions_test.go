@@ -0,0 +1,196 @@
+package dataset
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// publisher is a fake remote whose published file can be replaced between downloads
+type publisher struct {
+	mu      sync.Mutex
+	etag    string
+	content string
+}
+
+func (p *publisher) publish(etag, content string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.etag, p.content = etag, content
+}
+
+func (p *publisher) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	w.Header().Set("ETag", p.etag)
+	if r.Method == "HEAD" {
+		w.Header().Set("Content-Length", "0")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.Write([]byte(p.content))
+}
+
+// newVersionedManager creates a manager against a fake remote in a temporary data directory
+func newVersionedManager(t *testing.T, keep int) (*Manager, *publisher) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	remote := &publisher{}
+	server := httptest.NewServer(remote)
+	t.Cleanup(server.Close)
+
+	cfg := createTestConfig()
+	cfg.DatasetVersions = keep
+	manager := NewManager(
+		server.URL,
+		filepath.Join(tmpDir, "product-database.parquet"),
+		filepath.Join(tmpDir, "metadata.json"),
+		filepath.Join(tmpDir, "refresh.lock"),
+		cfg,
+		config.NewTestLogger(io.Discard, "DEBUG"),
+	)
+	return manager, remote
+}
+
+// activeContent reads the dataset through the parquet path
+func activeContent(t *testing.T, m *Manager) string {
+	t.Helper()
+	content, err := os.ReadFile(m.parquetPath)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestManager_VersionedDownloads(t *testing.T) {
+	manager, remote := newVersionedManager(t, 2)
+	ctx := context.Background()
+
+	for i, content := range []string{"version one", "version two", "version three"} {
+		remote.publish("etag-"+content, content)
+		refreshed, err := manager.Refresh(ctx)
+		require.NoError(t, err)
+		assert.True(t, refreshed, "download %d", i)
+		assert.Equal(t, content, activeContent(t, manager))
+	}
+
+	// The parquet path is a link to the active version
+	info, err := os.Lstat(manager.parquetPath)
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&os.ModeSymlink)
+
+	// Only the newest DATASET_VERSIONS are kept
+	versions, err := manager.Versions()
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.True(t, versions[0].Active)
+	assert.Equal(t, "etag-version three", versions[0].ETag)
+	assert.False(t, versions[1].Active)
+	assert.Equal(t, "etag-version two", versions[1].ETag)
+
+	meta, err := manager.Metadata()
+	require.NoError(t, err)
+	assert.Equal(t, versions[0].SHA256, meta.SHA256)
+}
+
+func TestManager_VersionedDownloads_AdoptsLegacyFile(t *testing.T) {
+	manager, remote := newVersionedManager(t, 3)
+	require.NoError(t, os.WriteFile(manager.parquetPath, []byte("legacy data"), 0644))
+	require.NoError(t, manager.saveMetadata(&Metadata{SHA256: "legacy-sha", ETag: "etag-legacy", Size: 11}))
+
+	remote.publish("etag-new", "new data")
+	refreshed, err := manager.Refresh(context.Background())
+	require.NoError(t, err)
+	assert.True(t, refreshed)
+	assert.Equal(t, "new data", activeContent(t, manager))
+
+	// The file from before versioning can be rolled back to
+	versions, err := manager.Versions()
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	content, err := os.ReadFile(filepath.Join(manager.versionDir(versions[1].SHA256), versionFileName))
+	require.NoError(t, err)
+	assert.Equal(t, "legacy data", string(content))
+}
+
+func TestManager_Rollback(t *testing.T) {
+	t.Run("previous version", func(t *testing.T) {
+		manager, remote := newVersionedManager(t, 3)
+		ctx := context.Background()
+
+		remote.publish("etag-good", "good data")
+		_, err := manager.Refresh(ctx)
+		require.NoError(t, err)
+		remote.publish("etag-bad", "bad data")
+		_, err = manager.Refresh(ctx)
+		require.NoError(t, err)
+
+		meta, err := manager.Rollback("")
+		require.NoError(t, err)
+		assert.Equal(t, "etag-good", meta.ETag)
+		assert.Equal(t, "good data", activeContent(t, manager))
+
+		current, err := manager.Metadata()
+		require.NoError(t, err)
+		assert.Equal(t, meta.SHA256, current.SHA256)
+
+		// The bad version is not downloaded again while upstream still publishes it
+		refreshed, err := manager.Refresh(ctx)
+		require.NoError(t, err)
+		assert.False(t, refreshed)
+		assert.Equal(t, "good data", activeContent(t, manager))
+
+		// A newer upstream version is picked up again
+		remote.publish("etag-fixed", "fixed data")
+		refreshed, err = manager.Refresh(ctx)
+		require.NoError(t, err)
+		assert.True(t, refreshed)
+		assert.Equal(t, "fixed data", activeContent(t, manager))
+	})
+
+	t.Run("by SHA256 prefix", func(t *testing.T) {
+		manager, remote := newVersionedManager(t, 3)
+		ctx := context.Background()
+
+		for _, content := range []string{"first", "second", "third"} {
+			remote.publish("etag-"+content, content)
+			_, err := manager.Refresh(ctx)
+			require.NoError(t, err)
+		}
+		versions, err := manager.Versions()
+		require.NoError(t, err)
+		require.Len(t, versions, 3)
+
+		meta, err := manager.Rollback(versions[2].SHA256[:12])
+		require.NoError(t, err)
+		assert.Equal(t, "etag-first", meta.ETag)
+		assert.Equal(t, "first", activeContent(t, manager))
+
+		_, err = manager.Rollback(versions[2].SHA256[:12])
+		assert.ErrorContains(t, err, "already active")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		manager, remote := newVersionedManager(t, 3)
+
+		_, err := manager.Rollback("")
+		assert.ErrorIs(t, err, ErrNoPreviousVersion)
+
+		remote.publish("etag-only", "only data")
+		_, err = manager.Refresh(context.Background())
+		require.NoError(t, err)
+
+		_, err = manager.Rollback("")
+		assert.ErrorIs(t, err, ErrNoPreviousVersion)
+
+		_, err = manager.Rollback("ffffffff")
+		assert.ErrorIs(t, err, ErrVersionNotFound)
+	})
+}
@@ -0,0 +1,336 @@
+package diet
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/types"
+)
+
+// Overall outcomes of a check
+const (
+	StatusCompliant    = "compliant"     // Every rule passed with the available data
+	StatusNonCompliant = "non_compliant" // At least one violation
+	StatusUncertain    = "uncertain"     // No violation, but warnings or rules that could not be checked
+)
+
+// saltPerSodium lets salt and sodium rules fall back on each other
+const saltPerSodium = 2.5
+
+// Finding is one rule outcome with its explanation
+type Finding struct {
+	Rule     string   `json:"rule"`               // e.g. nutrient:carbohydrates, ingredients, labels, allergens or vegan
+	Severity string   `json:"severity"`           // violation or warning; unknown for rules without data
+	Message  string   `json:"message"`            // What was found and why it matters for the diet
+	Evidence []string `json:"evidence,omitempty"` // Matching ingredients, tags or values
+}
+
+// Result is a product checked against one profile
+type Result struct {
+	Profile     string    `json:"profile"`
+	ProfileName string    `json:"profile_name"`
+	Status      string    `json:"status"`
+	Compliant   bool      `json:"compliant"`
+	Violations  []Finding `json:"violations"`
+	Warnings    []Finding `json:"warnings"`
+	Unverified  []Finding `json:"unverified"` // Rules the product's data is not sufficient for
+}
+
+// severityUnknown marks findings for rules that could not be evaluated
+const severityUnknown = "unknown"
+
+// Check evaluates a product against every rule of the profile
+func (p *Profile) Check(product *types.Product) Result {
+	result := Result{
+		Profile:     p.ID,
+		ProfileName: p.Name,
+		Violations:  []Finding{},
+		Warnings:    []Finding{},
+		Unverified:  []Finding{},
+	}
+	add := func(finding Finding) {
+		switch finding.Severity {
+		case SeverityViolation:
+			result.Violations = append(result.Violations, finding)
+		case SeverityWarning:
+			result.Warnings = append(result.Warnings, finding)
+		default:
+			result.Unverified = append(result.Unverified, finding)
+		}
+	}
+
+	nutriments := product.ParsedNutriments()
+	for _, rule := range p.Nutrients {
+		if finding, ok := checkNutrient(rule, nutriments); ok {
+			add(finding)
+		}
+	}
+
+	ingredients := types.FlattenIngredients(product.ParsedIngredients())
+	for _, rule := range p.Ingredients {
+		if finding, ok := checkIngredients(rule, ingredients); ok {
+			add(finding)
+		}
+	}
+	for _, rule := range p.IngredientAttributes {
+		for _, finding := range checkAttribute(rule, ingredients) {
+			add(finding)
+		}
+	}
+
+	for _, rule := range p.Labels {
+		if finding, ok := checkTags("labels", "label", rule, product.LabelsTags, true); ok {
+			add(finding)
+		}
+	}
+	// Products without allergen tags or ingredients have not been analyzed, rather than being allergen-free
+	allergensKnown := len(product.AllergensTags) > 0 || len(ingredients) > 0
+	for _, rule := range p.Allergens {
+		if finding, ok := checkTags("allergens", "allergen", rule, product.AllergensTags, allergensKnown); ok {
+			add(finding)
+		}
+	}
+
+	switch {
+	case len(result.Violations) > 0:
+		result.Status = StatusNonCompliant
+	case len(result.Warnings) > 0 || len(result.Unverified) > 0:
+		result.Status = StatusUncertain
+	default:
+		result.Status = StatusCompliant
+		result.Compliant = true
+	}
+	return result
+}
+
+// checkNutrient compares a nutrient per 100 g with the rule's bounds
+func checkNutrient(rule NutrientRule, nutriments map[string]types.Nutriment) (Finding, bool) {
+	name := "nutrient:" + rule.Nutrient
+	value, derived, ok := nutrientPer100(nutriments, rule.Nutrient)
+	if !ok {
+		return Finding{
+			Rule:     name,
+			Severity: severityUnknown,
+			Message:  fmt.Sprintf("%s is not reported for this product: %s", rule.Nutrient, rule.Reason),
+		}, true
+	}
+
+	factor := thresholdUnits[rule.Unit]
+	unit := rule.Unit
+	if unit == "" {
+		unit = "g"
+	}
+	display := formatAmount(value/factor) + " " + unit
+	if derived {
+		display += " (derived from " + derivedFrom(rule.Nutrient) + ")"
+	}
+
+	switch {
+	case rule.Max != nil && value > *rule.Max*factor:
+		return Finding{
+			Rule:     name,
+			Severity: rule.Severity,
+			Message: fmt.Sprintf("%s is %s per 100 g, above the limit of %s %s: %s",
+				rule.Nutrient, display, formatAmount(*rule.Max), unit, rule.Reason),
+			Evidence: []string{rule.Nutrient + "=" + formatAmount(value/factor) + unit},
+		}, true
+	case rule.Min != nil && value < *rule.Min*factor:
+		return Finding{
+			Rule:     name,
+			Severity: rule.Severity,
+			Message: fmt.Sprintf("%s is %s per 100 g, below the minimum of %s %s: %s",
+				rule.Nutrient, display, formatAmount(*rule.Min), unit, rule.Reason),
+			Evidence: []string{rule.Nutrient + "=" + formatAmount(value/factor) + unit},
+		}, true
+	}
+	return Finding{}, false
+}
+
+// nutrientPer100 reads a nutrient per 100 g, deriving salt and sodium from each other
+func nutrientPer100(nutriments map[string]types.Nutriment, name string) (float64, bool, bool) {
+	if nutriment, ok := nutriments[name]; ok && nutriment.Per100g != nil {
+		return *nutriment.Per100g, false, true
+	}
+	switch name {
+	case "salt":
+		if sodium, ok := nutriments["sodium"]; ok && sodium.Per100g != nil {
+			return *sodium.Per100g * saltPerSodium, true, true
+		}
+	case "sodium":
+		if salt, ok := nutriments["salt"]; ok && salt.Per100g != nil {
+			return *salt.Per100g / saltPerSodium, true, true
+		}
+	}
+	return 0, false, false
+}
+
+// derivedFrom names the nutrient a derived value was computed from
+func derivedFrom(name string) string {
+	if name == "salt" {
+		return "sodium"
+	}
+	return "salt"
+}
+
+// describe names an ingredient with its text and the ingredient it belongs to
+func describe(ingredient types.FlatIngredient) string {
+	description := ingredient.ID
+	if ingredient.Text != "" {
+		description += " (" + ingredient.Text + ")"
+	}
+	if ingredient.Parent != "" {
+		description += " in " + ingredient.Parent
+	}
+	return description
+}
+
+// checkIngredients looks for forbidden ingredient IDs anywhere in the ingredient tree
+func checkIngredients(rule IngredientRule, ingredients []types.FlatIngredient) (Finding, bool) {
+	if len(ingredients) == 0 {
+		return Finding{
+			Rule:     "ingredients",
+			Severity: severityUnknown,
+			Message:  "The ingredient list is not available, so forbidden ingredients could not be checked: " + rule.Reason,
+		}, true
+	}
+
+	var evidence []string
+	for _, ingredient := range ingredients {
+		if matchesAny(strings.ToLower(ingredient.ID), rule.Forbidden) {
+			evidence = append(evidence, describe(ingredient))
+		}
+	}
+	if len(evidence) == 0 {
+		return Finding{}, false
+	}
+	return Finding{
+		Rule:     "ingredients",
+		Severity: rule.Severity,
+		Message:  "Contains " + strings.Join(evidence, ", ") + ": " + rule.Reason,
+		Evidence: evidence,
+	}, true
+}
+
+// checkAttribute reads a computed attribute such as vegan on every ingredient
+// An ingredient without the attribute is covered by its sub-ingredients when it has any
+func checkAttribute(rule AttributeRule, ingredients []types.FlatIngredient) []Finding {
+	if len(ingredients) == 0 {
+		return []Finding{{
+			Rule:     rule.Attribute,
+			Severity: severityUnknown,
+			Message:  "The ingredient list is not available, so " + rule.Attribute + " status could not be checked: " + rule.Reason,
+		}}
+	}
+
+	var forbidden, uncertain, unknown []string
+	for _, ingredient := range ingredients {
+		value := attributeValue(ingredient, rule.Attribute)
+		switch {
+		case value != "" && slices.Contains(rule.Forbidden, value):
+			forbidden = append(forbidden, describe(ingredient))
+		case value != "" && slices.Contains(rule.Uncertain, value):
+			uncertain = append(uncertain, describe(ingredient))
+		case value == "" && !ingredient.HasChildren:
+			unknown = append(unknown, describe(ingredient))
+		}
+	}
+
+	var findings []Finding
+	if len(forbidden) > 0 {
+		findings = append(findings, Finding{
+			Rule:     rule.Attribute,
+			Severity: SeverityViolation,
+			Message:  fmt.Sprintf("Ingredients marked %s: %s %s: %s", rule.Attribute, strings.Join(rule.Forbidden, "/"), strings.Join(forbidden, ", "), rule.Reason),
+			Evidence: forbidden,
+		})
+	}
+	if len(uncertain) > 0 {
+		findings = append(findings, Finding{
+			Rule:     rule.Attribute,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("Ingredients marked %s: %s %s: %s", rule.Attribute, strings.Join(rule.Uncertain, "/"), strings.Join(uncertain, ", "), rule.Reason),
+			Evidence: uncertain,
+		})
+	}
+	if len(unknown) > 0 {
+		findings = append(findings, Finding{
+			Rule:     rule.Attribute,
+			Severity: severityUnknown,
+			Message:  fmt.Sprintf("No %s status for %s: %s", rule.Attribute, strings.Join(unknown, ", "), rule.Reason),
+			Evidence: unknown,
+		})
+	}
+	return findings
+}
+
+// attributeValue returns an ingredient's computed attribute
+func attributeValue(ingredient types.FlatIngredient, attribute string) string {
+	switch attribute {
+	case AttributeVegan:
+		return ingredient.Vegan
+	case AttributeVegetarian:
+		return ingredient.Vegetarian
+	case AttributeFromPalmOil:
+		return ingredient.FromPalmOil
+	}
+	return ""
+}
+
+// checkTags applies a label or allergen rule to a product's tags
+func checkTags(rule, noun string, tagRule TagRule, tags []string, known bool) (Finding, bool) {
+	if len(tagRule.Forbidden) > 0 && !known {
+		return Finding{
+			Rule:     rule,
+			Severity: severityUnknown,
+			Message:  "The product has no " + noun + " information, so it could not be checked: " + tagRule.Reason,
+		}, true
+	}
+
+	var forbidden []string
+	present := false
+	for _, tag := range tags {
+		tag = strings.ToLower(tag)
+		if matchesAny(tag, tagRule.Forbidden) {
+			forbidden = append(forbidden, tag)
+		}
+		if matchesAny(tag, tagRule.RequiredAny) {
+			present = true
+		}
+	}
+
+	switch {
+	case len(forbidden) > 0:
+		return Finding{
+			Rule:     rule,
+			Severity: tagRule.Severity,
+			Message:  "Has " + noun + " " + strings.Join(forbidden, ", ") + ": " + tagRule.Reason,
+			Evidence: forbidden,
+		}, true
+	case len(tagRule.RequiredAny) > 0 && !present:
+		return Finding{
+			Rule:     rule,
+			Severity: tagRule.Severity,
+			Message:  "Missing " + noun + " " + strings.Join(tagRule.RequiredAny, " or ") + ": " + tagRule.Reason,
+		}, true
+	}
+	return Finding{}, false
+}
+
+// matchesAny reports whether a tag matches one of the patterns
+func matchesAny(tag string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, tag); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// formatAmount prints a value rounded to three decimals, without trailing zeros
+func formatAmount(value float64) string {
+	return strconv.FormatFloat(math.Round(value*1000)/1000, 'f', -1, 64)
+}
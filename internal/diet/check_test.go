@@ -0,0 +1,175 @@
+package diet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/types"
+)
+
+// spread is a hazelnut spread with nested ingredients and analysis attributes
+func spread() *types.Product {
+	return &types.Product{
+		Code: "3017620422003",
+		Nutriments: map[string]interface{}{
+			"carbohydrates": map[string]interface{}{"100g": 57.5},
+			"sugars":        map[string]interface{}{"100g": 56.3},
+			"salt":          map[string]interface{}{"100g": 0.107},
+		},
+		Ingredients: []interface{}{
+			map[string]interface{}{"id": "en:sugar", "text": "sugar", "vegan": "yes", "vegetarian": "yes"},
+			map[string]interface{}{"id": "en:palm-oil", "text": "palm oil", "vegan": "yes", "vegetarian": "yes", "from_palm_oil": "yes"},
+			map[string]interface{}{
+				"id":   "en:chocolate",
+				"text": "chocolate",
+				"ingredients": []interface{}{
+					map[string]interface{}{"id": "en:cocoa", "text": "cocoa", "vegan": "yes", "vegetarian": "yes"},
+					map[string]interface{}{"id": "en:skimmed-milk-powder", "text": "skimmed milk powder", "vegan": "no", "vegetarian": "yes"},
+				},
+			},
+			map[string]interface{}{"id": "en:emulsifier", "text": "emulsifier", "vegan": "maybe", "vegetarian": "maybe"},
+			map[string]interface{}{"id": "en:vanillin", "text": "vanillin"},
+		},
+		LabelsTags:    []string{"en:no-gluten"},
+		AllergensTags: []string{"en:milk", "en:nuts"},
+	}
+}
+
+func lookup(t *testing.T, id string) *Profile {
+	t.Helper()
+	registry, err := LoadRegistry("")
+	require.NoError(t, err)
+	profile, err := registry.Lookup(id)
+	require.NoError(t, err)
+	return profile
+}
+
+func rules(findings []Finding) []string {
+	names := []string{}
+	for _, finding := range findings {
+		names = append(names, finding.Rule)
+	}
+	return names
+}
+
+func TestProfile_Check(t *testing.T) {
+	tests := []struct {
+		name               string
+		profile            string
+		product            *types.Product
+		expectedStatus     string
+		expectedViolations []string
+		expectedWarnings   []string
+		expectedUnverified []string
+	}{
+		{
+			name:               "vegan spread with milk",
+			profile:            "vegan",
+			product:            spread(),
+			expectedStatus:     StatusNonCompliant,
+			expectedViolations: []string{"vegan", "allergens"},
+			expectedWarnings:   []string{"vegan"},
+			expectedUnverified: []string{"vegan"},
+		},
+		{
+			name:               "keto spread",
+			profile:            "keto",
+			product:            spread(),
+			expectedStatus:     StatusNonCompliant,
+			expectedViolations: []string{"nutrient:carbohydrates", "nutrient:sugars"},
+			expectedWarnings:   []string{"ingredients"},
+			expectedUnverified: []string{},
+		},
+		{
+			name:               "vegetarian spread",
+			profile:            "vegetarian",
+			product:            spread(),
+			expectedStatus:     StatusUncertain,
+			expectedViolations: []string{},
+			expectedWarnings:   []string{"vegetarian"},
+			expectedUnverified: []string{"vegetarian"},
+		},
+		{
+			name:               "halal without certification",
+			profile:            "halal",
+			product:            spread(),
+			expectedStatus:     StatusUncertain,
+			expectedViolations: []string{},
+			expectedWarnings:   []string{"labels"},
+			expectedUnverified: []string{},
+		},
+		{
+			name:    "low sodium from salt",
+			profile: "low-sodium",
+			product: &types.Product{Nutriments: map[string]interface{}{
+				"salt": map[string]interface{}{"100g": 0.107},
+			}},
+			expectedStatus:     StatusCompliant,
+			expectedViolations: []string{},
+			expectedWarnings:   []string{},
+			expectedUnverified: []string{},
+		},
+		{
+			name:               "no data",
+			profile:            "pescatarian",
+			product:            &types.Product{},
+			expectedStatus:     StatusUncertain,
+			expectedViolations: []string{},
+			expectedWarnings:   []string{},
+			expectedUnverified: []string{"ingredients", "ingredients"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := lookup(t, tt.profile).Check(tt.product)
+
+			assert.Equal(t, tt.profile, result.Profile)
+			assert.Equal(t, tt.expectedStatus, result.Status)
+			assert.Equal(t, tt.expectedStatus == StatusCompliant, result.Compliant)
+			assert.Equal(t, tt.expectedViolations, rules(result.Violations))
+			assert.Equal(t, tt.expectedWarnings, rules(result.Warnings))
+			assert.Equal(t, tt.expectedUnverified, rules(result.Unverified))
+		})
+	}
+}
+
+func TestProfile_Check_Explanations(t *testing.T) {
+	result := lookup(t, "vegan").Check(spread())
+	require.Len(t, result.Violations, 2)
+
+	milk := result.Violations[0]
+	assert.Equal(t, SeverityViolation, milk.Severity)
+	assert.Equal(t, []string{"en:skimmed-milk-powder (skimmed milk powder) in en:chocolate"}, milk.Evidence)
+	assert.Contains(t, milk.Message, "vegan diets exclude every ingredient of animal origin")
+
+	assert.Equal(t, []string{"en:milk"}, result.Violations[1].Evidence)
+	assert.Equal(t, []string{"en:emulsifier (emulsifier)"}, result.Warnings[0].Evidence)
+	assert.Equal(t, []string{"en:vanillin (vanillin)"}, result.Unverified[0].Evidence)
+
+	keto := lookup(t, "keto").Check(spread())
+	assert.Equal(t, "carbohydrates is 57.5 g per 100 g, above the limit of 10 g: "+
+		"more than 10 g of carbohydrates per 100 g uses up a large share of a keto day's carbohydrate allowance in a single portion",
+		keto.Violations[0].Message)
+	assert.Equal(t, []string{"en:sugar (sugar)"}, keto.Warnings[0].Evidence)
+}
+
+func TestProfile_Check_NutrientUnits(t *testing.T) {
+	profile, err := ParseProfile([]byte(`{"id": "x", "name": "X", "nutrients": [
+		{"nutrient": "sodium", "max": 120, "unit": "mg", "reason": "too salty"},
+		{"nutrient": "fiber", "min": 3, "severity": "warning", "reason": "not enough fiber"}
+	]}`))
+	require.NoError(t, err)
+
+	result := profile.Check(&types.Product{Nutriments: map[string]interface{}{
+		"salt":  map[string]interface{}{"100g": 1.5},
+		"fiber": map[string]interface{}{"100g": 1.2},
+	}})
+
+	require.Len(t, result.Violations, 1)
+	assert.Equal(t, "sodium is 600 mg (derived from salt) per 100 g, above the limit of 120 mg: too salty", result.Violations[0].Message)
+	require.Len(t, result.Warnings, 1)
+	assert.Equal(t, "fiber is 1.2 g per 100 g, below the minimum of 3 g: not enough fiber", result.Warnings[0].Message)
+}
@@ -0,0 +1,316 @@
+package diet
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ErrUnknownProfile is returned for profile IDs that are not loaded
+var ErrUnknownProfile = errors.New("unknown diet profile")
+
+// ErrInvalidProfile is returned for profile files that cannot be used
+var ErrInvalidProfile = errors.New("invalid diet profile")
+
+// Severities of a rule
+const (
+	SeverityViolation = "violation" // The product does not fit the diet
+	SeverityWarning   = "warning"   // The product may not fit the diet, e.g. a certification is missing
+)
+
+// Ingredient attributes the dataset computes for every ingredient
+const (
+	AttributeVegan       = "vegan"
+	AttributeVegetarian  = "vegetarian"
+	AttributeFromPalmOil = "from_palm_oil"
+)
+
+// SourceBuiltin marks profiles shipped with the server
+const SourceBuiltin = "builtin"
+
+// builtinProfiles are the profiles shipped with the server, overridable from the profiles directory
+//
+//go:embed profiles/*.json
+var builtinProfiles embed.FS
+
+// profileIDPattern keeps IDs usable as tool arguments and file names
+var profileIDPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// thresholdUnits convert nutrient rule thresholds to the dataset's grams
+var thresholdUnits = map[string]float64{
+	"":   1,
+	"g":  1,
+	"mg": 0.001,
+	"µg": 0.000001,
+	"ug": 0.000001,
+}
+
+// Profile is a diet defined by declarative rules, loaded from a JSON file
+type Profile struct {
+	ID                   string           `json:"id"`
+	Name                 string           `json:"name"`
+	Description          string           `json:"description,omitempty"`
+	Source               string           `json:"source,omitempty"` // builtin or the file the profile was loaded from
+	Nutrients            []NutrientRule   `json:"nutrients,omitempty"`
+	Ingredients          []IngredientRule `json:"ingredients,omitempty"`
+	IngredientAttributes []AttributeRule  `json:"ingredient_attributes,omitempty"`
+	Labels               []TagRule        `json:"labels,omitempty"`
+	Allergens            []TagRule        `json:"allergens,omitempty"`
+}
+
+// NutrientRule bounds a nutrient per 100 g or 100 ml
+type NutrientRule struct {
+	Nutrient string   `json:"nutrient"`           // Dataset name, e.g. carbohydrates or sodium
+	Max      *float64 `json:"max,omitempty"`      // Highest allowed value, inclusive
+	Min      *float64 `json:"min,omitempty"`      // Lowest allowed value, inclusive
+	Unit     string   `json:"unit,omitempty"`     // Unit of Max and Min: g (default), mg or µg; energy uses the dataset unit
+	Severity string   `json:"severity,omitempty"` // violation (default) or warning
+	Reason   string   `json:"reason"`
+}
+
+// IngredientRule rejects products containing any of the given ingredient IDs
+type IngredientRule struct {
+	Forbidden []string `json:"forbidden"` // Taxonomy IDs; * matches any characters, e.g. en:pork*
+	Severity  string   `json:"severity,omitempty"`
+	Reason    string   `json:"reason"`
+}
+
+// AttributeRule checks an attribute the dataset computes per ingredient, e.g. vegan: no
+type AttributeRule struct {
+	Attribute string   `json:"attribute"`           // vegan, vegetarian or from_palm_oil
+	Forbidden []string `json:"forbidden"`           // Values that violate the diet, e.g. no
+	Uncertain []string `json:"uncertain,omitempty"` // Values that only warn, e.g. maybe
+	Reason    string   `json:"reason"`
+}
+
+// TagRule checks label or allergen tags
+type TagRule struct {
+	RequiredAny []string `json:"required_any,omitempty"` // At least one of these tags must be present
+	Forbidden   []string `json:"forbidden,omitempty"`    // None of these tags may be present
+	Severity    string   `json:"severity,omitempty"`
+	Reason      string   `json:"reason"`
+}
+
+// Registry holds the loaded profiles by ID
+type Registry struct {
+	profiles map[string]*Profile
+}
+
+// LoadRegistry loads the built-in profiles and then every *.json file in dir, if set
+// Files in dir add profiles or replace built-in ones with the same ID. Files that fail to
+// load are skipped and reported in the returned error; the registry is always usable.
+func LoadRegistry(dir string) (*Registry, error) {
+	registry := &Registry{profiles: map[string]*Profile{}}
+
+	builtins, err := fs.Glob(builtinProfiles, "profiles/*.json")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range builtins {
+		data, err := builtinProfiles.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		profile, err := ParseProfile(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		profile.Source = SourceBuiltin
+		registry.profiles[profile.ID] = profile
+	}
+
+	if dir == "" {
+		return registry, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return registry, err
+	}
+	sort.Strings(files)
+
+	var errs []error
+	loaded := map[string]string{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		profile, err := ParseProfile(data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", file, err))
+			continue
+		}
+		if previous, ok := loaded[profile.ID]; ok {
+			errs = append(errs, fmt.Errorf("%s: %w: id %q is already defined in %s", file, ErrInvalidProfile, profile.ID, previous))
+			continue
+		}
+		loaded[profile.ID] = file
+		profile.Source = file
+		registry.profiles[profile.ID] = profile
+	}
+	return registry, errors.Join(errs...)
+}
+
+// ParseProfile decodes and validates one profile file
+// Unknown fields are rejected so that typos do not silently disable a rule
+func ParseProfile(data []byte) (*Profile, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var profile Profile
+	if err := decoder.Decode(&profile); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProfile, err)
+	}
+	if err := profile.validate(); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// IDs returns the loaded profile IDs, sorted
+func (r *Registry) IDs() []string {
+	ids := make([]string, 0, len(r.profiles))
+	for id := range r.profiles {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Lookup returns the profile with the given ID
+func (r *Registry) Lookup(id string) (*Profile, error) {
+	profile, ok := r.profiles[strings.ToLower(strings.TrimSpace(id))]
+	if !ok {
+		return nil, fmt.Errorf("%w %q: must be one of %s", ErrUnknownProfile, id, strings.Join(r.IDs(), ", "))
+	}
+	return profile, nil
+}
+
+// validate checks a decoded profile and normalizes its IDs and severities
+func (p *Profile) validate() error {
+	p.ID = strings.ToLower(strings.TrimSpace(p.ID))
+	if !profileIDPattern.MatchString(p.ID) {
+		return fmt.Errorf("%w: id %q must be lowercase letters, digits and dashes", ErrInvalidProfile, p.ID)
+	}
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("%w %q: name is required", ErrInvalidProfile, p.ID)
+	}
+	if len(p.Nutrients)+len(p.Ingredients)+len(p.IngredientAttributes)+len(p.Labels)+len(p.Allergens) == 0 {
+		return fmt.Errorf("%w %q: at least one rule is required", ErrInvalidProfile, p.ID)
+	}
+
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w %q: %s", ErrInvalidProfile, p.ID, fmt.Sprintf(format, args...))
+	}
+
+	for i := range p.Nutrients {
+		rule := &p.Nutrients[i]
+		rule.Nutrient = strings.ToLower(strings.TrimSpace(rule.Nutrient))
+		rule.Unit = strings.TrimSpace(rule.Unit)
+		if rule.Nutrient == "" {
+			return invalid("nutrients[%d]: nutrient is required", i)
+		}
+		if rule.Max == nil && rule.Min == nil {
+			return invalid("nutrients[%d]: max or min is required", i)
+		}
+		if _, ok := thresholdUnits[rule.Unit]; !ok {
+			return invalid("nutrients[%d]: unit must be g, mg or µg", i)
+		}
+		if err := normalizeSeverity(&rule.Severity); err != nil {
+			return invalid("nutrients[%d]: %v", i, err)
+		}
+	}
+
+	for i := range p.Ingredients {
+		rule := &p.Ingredients[i]
+		if len(rule.Forbidden) == 0 {
+			return invalid("ingredients[%d]: forbidden is required", i)
+		}
+		if err := normalizePatterns(rule.Forbidden); err != nil {
+			return invalid("ingredients[%d]: %v", i, err)
+		}
+		if err := normalizeSeverity(&rule.Severity); err != nil {
+			return invalid("ingredients[%d]: %v", i, err)
+		}
+	}
+
+	for i := range p.IngredientAttributes {
+		rule := &p.IngredientAttributes[i]
+		switch rule.Attribute {
+		case AttributeVegan, AttributeVegetarian, AttributeFromPalmOil:
+		default:
+			return invalid("ingredient_attributes[%d]: attribute must be vegan, vegetarian or from_palm_oil", i)
+		}
+		if len(rule.Forbidden) == 0 {
+			return invalid("ingredient_attributes[%d]: forbidden is required", i)
+		}
+		for _, values := range [][]string{rule.Forbidden, rule.Uncertain} {
+			for j := range values {
+				values[j] = strings.ToLower(strings.TrimSpace(values[j]))
+			}
+		}
+	}
+
+	tagRules := []struct {
+		name  string
+		rules []TagRule
+	}{{"labels", p.Labels}, {"allergens", p.Allergens}}
+	for _, group := range tagRules {
+		name := group.name
+		for i := range group.rules {
+			rule := &group.rules[i]
+			if len(rule.RequiredAny) == 0 && len(rule.Forbidden) == 0 {
+				return invalid("%s[%d]: required_any or forbidden is required", name, i)
+			}
+			if err := normalizePatterns(rule.RequiredAny); err != nil {
+				return invalid("%s[%d]: %v", name, i, err)
+			}
+			if err := normalizePatterns(rule.Forbidden); err != nil {
+				return invalid("%s[%d]: %v", name, i, err)
+			}
+			if err := normalizeSeverity(&rule.Severity); err != nil {
+				return invalid("%s[%d]: %v", name, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// normalizeSeverity defaults an empty severity to violation
+func normalizeSeverity(severity *string) error {
+	*severity = strings.ToLower(strings.TrimSpace(*severity))
+	switch *severity {
+	case "":
+		*severity = SeverityViolation
+	case SeverityViolation, SeverityWarning:
+	default:
+		return fmt.Errorf("severity must be %s or %s", SeverityViolation, SeverityWarning)
+	}
+	return nil
+}
+
+// normalizePatterns lowercases tag patterns and checks their syntax
+func normalizePatterns(patterns []string) error {
+	for i, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			return errors.New("empty tag")
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("tag %q: %v", pattern, err)
+		}
+		patterns[i] = pattern
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package diet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadRegistry_Builtin(t *testing.T) {
+	registry, err := LoadRegistry("")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"diabetic-friendly", "halal", "keto", "kosher", "low-fodmap",
+		"low-sodium", "pescatarian", "vegan", "vegetarian",
+	}, registry.IDs())
+
+	profile, err := registry.Lookup(" Vegan ")
+	require.NoError(t, err)
+	assert.Equal(t, "Vegan", profile.Name)
+	assert.Equal(t, SourceBuiltin, profile.Source)
+
+	_, err = registry.Lookup("paleo")
+	assert.ErrorIs(t, err, ErrUnknownProfile)
+}
+
+func TestLoadRegistry_Directory(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	write("a-paleo.json", `{"id": "paleo", "name": "Paleo", "ingredients": [{"forbidden": ["en:wheat*"], "reason": "no grains"}]}`)
+	write("b-keto.json", `{"id": "keto", "name": "Strict keto", "nutrients": [{"nutrient": "carbohydrates", "max": 5, "reason": "strict"}]}`)
+	write("c-broken.json", `{"id": "broken", "name": "Broken", "nutrients": [{"nutrient": "sugars", "maximum": 5}]}`)
+	write("d-paleo-again.json", `{"id": "paleo", "name": "Paleo 2", "labels": [{"required_any": ["en:organic"], "reason": "organic"}]}`)
+	write("notes.txt", `not a profile`)
+
+	registry, err := LoadRegistry(dir)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrInvalidProfile)
+	assert.Contains(t, err.Error(), "c-broken.json")
+	assert.Contains(t, err.Error(), "d-paleo-again.json")
+
+	// Valid files are still loaded and override built-ins with the same ID
+	paleo, err := registry.Lookup("paleo")
+	require.NoError(t, err)
+	assert.Equal(t, "Paleo", paleo.Name)
+	assert.Equal(t, filepath.Join(dir, "a-paleo.json"), paleo.Source)
+
+	keto, err := registry.Lookup("keto")
+	require.NoError(t, err)
+	assert.Equal(t, "Strict keto", keto.Name)
+
+	_, err = registry.Lookup("broken")
+	assert.ErrorIs(t, err, ErrUnknownProfile)
+	_, err = registry.Lookup("vegan")
+	assert.NoError(t, err)
+}
+
+func TestParseProfile(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		expectedErr string
+	}{
+		{
+			name: "valid with defaults",
+			data: `{"id": "Low-Sugar", "name": "Low sugar", "nutrients": [{"nutrient": "Sugars", "max": 5, "reason": "r"}]}`,
+		},
+		{name: "malformed JSON", data: `{"id": `, expectedErr: "unexpected EOF"},
+		{name: "unknown field", data: `{"id": "x", "name": "X", "rules": []}`, expectedErr: `unknown field "rules"`},
+		{name: "bad id", data: `{"id": "low sugar", "name": "X"}`, expectedErr: "must be lowercase letters"},
+		{name: "missing name", data: `{"id": "x"}`, expectedErr: "name is required"},
+		{name: "no rules", data: `{"id": "x", "name": "X"}`, expectedErr: "at least one rule"},
+		{
+			name:        "nutrient without bounds",
+			data:        `{"id": "x", "name": "X", "nutrients": [{"nutrient": "sugars", "reason": "r"}]}`,
+			expectedErr: "max or min is required",
+		},
+		{
+			name:        "unknown unit",
+			data:        `{"id": "x", "name": "X", "nutrients": [{"nutrient": "sugars", "max": 1, "unit": "oz", "reason": "r"}]}`,
+			expectedErr: "unit must be",
+		},
+		{
+			name:        "unknown severity",
+			data:        `{"id": "x", "name": "X", "ingredients": [{"forbidden": ["en:sugar"], "severity": "fatal", "reason": "r"}]}`,
+			expectedErr: "severity must be",
+		},
+		{
+			name:        "bad pattern",
+			data:        `{"id": "x", "name": "X", "ingredients": [{"forbidden": ["en:[sugar"], "reason": "r"}]}`,
+			expectedErr: "syntax error in pattern",
+		},
+		{
+			name:        "unknown attribute",
+			data:        `{"id": "x", "name": "X", "ingredient_attributes": [{"attribute": "organic", "forbidden": ["no"], "reason": "r"}]}`,
+			expectedErr: "attribute must be",
+		},
+		{
+			name:        "empty tag rule",
+			data:        `{"id": "x", "name": "X", "allergens": [{"reason": "r"}]}`,
+			expectedErr: "allergens[0]: required_any or forbidden is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, err := ParseProfile([]byte(tt.data))
+			if tt.expectedErr != "" {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, ErrInvalidProfile)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "low-sugar", profile.ID)
+			assert.Equal(t, "sugars", profile.Nutrients[0].Nutrient)
+			assert.Equal(t, SeverityViolation, profile.Nutrients[0].Severity)
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package label
+
+import (
+	"strings"
+)
+
+// euFootnote explains the %RI column (Regulation (EU) No 1169/2011, Article 32(5))
+const euFootnote = "* Reference intake of an average adult (8400 kJ / 2000 kcal)"
+
+// euNutrient describes one row of the EU table
+type euNutrient struct {
+	label     string
+	name      string
+	level     int
+	mandatory bool
+	format    func(float64) string // Applies the EU rounding guidance and unit
+}
+
+// euNutrients are the rows of the nutrition declaration in Annex XV order
+var euNutrients = []euNutrient{
+	{label: "Fat", name: "fat", mandatory: true, format: euMacronutrient},
+	{label: "of which saturates", name: "saturated-fat", level: 1, mandatory: true, format: euSaturates},
+	{label: "Carbohydrate", name: "carbohydrates", mandatory: true, format: euMacronutrient},
+	{label: "of which sugars", name: "sugars", level: 1, mandatory: true, format: euMacronutrient},
+	{label: "Fibre", name: "fiber", format: euMacronutrient},
+	{label: "Protein", name: "proteins", mandatory: true, format: euMacronutrient},
+	{label: "Salt", name: "salt", mandatory: true, format: euSalt},
+}
+
+// buildEU lays out an EU nutrition declaration, always per 100 g/ml and optionally per serving
+func buildEU(label *Label, values productValues) {
+	label.Title = "Nutrition declaration"
+	label.Footnote = euFootnote
+
+	sets := []nutrientSet{values.per100}
+	label.Columns = []string{"", "Per 100 " + values.basisUnit, "%RI*"}
+	if label.Basis == BasisServing {
+		sets = append(sets, *values.perServing)
+		label.Columns = []string{"", "Per 100 " + values.basisUnit, "Per serving (" + servingDescription(values) + ")", "%RI* per serving"}
+	}
+	// The %RI column describes the last amount shown
+	riSet := sets[len(sets)-1]
+
+	energyRow := Row{Cells: []string{"Energy"}}
+	for _, set := range sets {
+		energyRow.Cells = append(energyRow.Cells, euEnergy(set))
+	}
+	energyRow.Cells = append(energyRow.Cells, percentCell(riSet, "energy-kcal"))
+	label.Rows = append(label.Rows, energyRow)
+
+	var missing []string
+	if _, ok := values.per100.amount("energy-kcal"); !ok {
+		missing = append(missing, "energy")
+	}
+
+	for _, nutrient := range euNutrients {
+		if _, ok := values.per100.amount(nutrient.name); !ok {
+			if !nutrient.mandatory {
+				continue
+			}
+			missing = append(missing, nutrient.name)
+		}
+
+		row := Row{Cells: []string{nutrient.label}, Level: nutrient.level}
+		for _, set := range sets {
+			text := "n/a"
+			if amount, ok := set.amount(nutrient.name); ok {
+				text = nutrient.format(amount)
+			}
+			row.Cells = append(row.Cells, text)
+		}
+		row.Cells = append(row.Cells, percentCell(riSet, nutrient.name))
+		label.Rows = append(label.Rows, row)
+	}
+
+	if len(missing) > 0 {
+		label.Warnings = append(label.Warnings, "not reported in the dataset: "+strings.Join(missing, ", "))
+	}
+}
+
+// percentCell formats a %RI cell, empty for nutrients without a reference intake
+func percentCell(set nutrientSet, name string) string {
+	if percent, ok := set.percent(name); ok {
+		return formatPercent(percent)
+	}
+	return ""
+}
+
+// euEnergy formats energy in kJ and kcal, each to the nearest whole number
+func euEnergy(set nutrientSet) string {
+	kj, hasKj := set.amount("energy")
+	kcal, hasKcal := set.amount("energy-kcal")
+	if !hasKj || !hasKcal {
+		return "n/a"
+	}
+	return formatNumber(roundTo(kj, 1)) + " kJ / " + formatNumber(roundTo(kcal, 1)) + " kcal"
+}
+
+// euMacronutrient rounds to 1 g from 10 g, to 0.1 g above 0.5 g and declares "<0.5 g" below
+// following the European Commission guidance on tolerances and rounding (2012)
+func euMacronutrient(grams float64) string {
+	switch {
+	case grams == 0:
+		return "0 g"
+	case grams < 0.5:
+		return "<0.5 g"
+	case grams < 10:
+		return formatNumber(roundTo(grams, 0.1)) + " g"
+	default:
+		return formatNumber(roundTo(grams, 1)) + " g"
+	}
+}
+
+// euSaturates rounds like euMacronutrient but declares "<0.1 g" below 0.1 g
+func euSaturates(grams float64) string {
+	switch {
+	case grams == 0:
+		return "0 g"
+	case grams < 0.1:
+		return "<0.1 g"
+	case grams < 10:
+		return formatNumber(roundTo(grams, 0.1)) + " g"
+	default:
+		return formatNumber(roundTo(grams, 1)) + " g"
+	}
+}
+
+// euSalt rounds to 0.1 g from 1 g, to 0.01 g above 0.0125 g and declares "<0.01 g" below
+func euSalt(grams float64) string {
+	switch {
+	case grams == 0:
+		return "0 g"
+	case grams < 0.0125:
+		return "<0.01 g"
+	case grams < 1:
+		return formatNumber(roundTo(grams, 0.01)) + " g"
+	default:
+		return formatNumber(roundTo(grams, 0.1)) + " g"
+	}
+}
@@ -0,0 +1,197 @@
+package label
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/nutrition"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/types"
+)
+
+// ErrInvalidOption is returned for unknown styles, formats or bases
+var ErrInvalidOption = errors.New("invalid label option")
+
+// Label styles
+const (
+	StyleUS = "us" // FDA Nutrition Facts panel
+	StyleEU = "eu" // EU nutrition declaration table
+)
+
+// Output formats
+const (
+	FormatText     = "text"
+	FormatMarkdown = "markdown"
+	FormatSVG      = "svg"
+)
+
+// Label bases
+const (
+	BasisServing = "serving"
+	BasisPer100  = "100g"
+)
+
+// Styles, Formats and Bases list the accepted option values
+var (
+	Styles  = []string{StyleUS, StyleEU}
+	Formats = []string{FormatText, FormatMarkdown, FormatSVG}
+	Bases   = []string{BasisServing, BasisPer100}
+)
+
+// Options select the label style and the amount of food it describes
+type Options struct {
+	Style string
+	Basis string
+}
+
+// Row is one line of the nutrient table
+type Row struct {
+	Cells     []string `json:"cells"`               // First cell is the nutrient, the rest line up with Columns
+	Level     int      `json:"level,omitempty"`     // Indentation, e.g. 1 for "of which saturates"
+	Bold      bool     `json:"bold,omitempty"`      // Main nutrients are bold on US panels
+	Separator bool     `json:"separator,omitempty"` // Draw a heavy rule above this row
+}
+
+// Label is a nutrition label laid out independently of the output format
+type Label struct {
+	Style       string   `json:"style"`
+	Basis       string   `json:"basis"` // serving or 100g; per 100 g when the serving is unknown
+	Title       string   `json:"title"`
+	ProductName string   `json:"product_name,omitempty"`
+	Header      []string `json:"header,omitempty"` // Serving and calorie lines above the table
+	Columns     []string `json:"columns"`
+	Rows        []Row    `json:"rows"`
+	Footnote    string   `json:"footnote,omitempty"`
+	Warnings    []string `json:"warnings,omitempty"`
+}
+
+// Build lays out a nutrition label for a product
+func Build(p *types.Product, opts Options) (*Label, error) {
+	style := normalizeOption(opts.Style, StyleUS)
+	basis := normalizeOption(opts.Basis, BasisServing)
+	if !slices.Contains(Bases, basis) {
+		return nil, fmt.Errorf("%w: basis must be one of %s", ErrInvalidOption, strings.Join(Bases, ", "))
+	}
+
+	var referenceID string
+	switch style {
+	case StyleUS:
+		referenceID = nutrition.ReferenceUSFDA2016
+	case StyleEU:
+		referenceID = nutrition.ReferenceEURI
+	default:
+		return nil, fmt.Errorf("%w: style must be one of %s", ErrInvalidOption, strings.Join(Styles, ", "))
+	}
+
+	reference, err := nutrition.LookupReference(referenceID)
+	if err != nil {
+		return nil, err
+	}
+
+	values := newProductValues(p, reference)
+	label := &Label{Style: style, Basis: basis, ProductName: p.ProductName}
+	if basis == BasisServing && values.perServing == nil {
+		label.Basis = BasisPer100
+		label.Warnings = append(label.Warnings, "serving size could not be resolved: "+values.serving.Reason+"; showing values per 100 "+values.basisUnit)
+	}
+
+	if style == StyleUS {
+		buildUS(label, values)
+	} else {
+		buildEU(label, values)
+	}
+	return label, nil
+}
+
+// Render formats the label as text, markdown or svg
+func (l *Label) Render(format string) (string, error) {
+	switch normalizeOption(format, FormatText) {
+	case FormatText:
+		return renderText(l), nil
+	case FormatMarkdown:
+		return renderMarkdown(l), nil
+	case FormatSVG:
+		return renderSVG(l), nil
+	default:
+		return "", fmt.Errorf("%w: format must be one of %s", ErrInvalidOption, strings.Join(Formats, ", "))
+	}
+}
+
+// productValues holds a product's nutrients per 100 and per serving
+type productValues struct {
+	basisUnit  string
+	serving    nutrition.Serving
+	per100     nutrientSet
+	perServing *nutrientSet
+}
+
+// nutrientSet is the nutrients for one amount of food
+type nutrientSet struct {
+	daily  map[string]nutrition.DailyValue // Nutrients in the reference table, in the table's units
+	scaled map[string]nutrition.Amount     // Every nutrient, in grams or energy units
+}
+
+// newProductValues scales a product's nutrients per 100 and per serving
+func newProductValues(p *types.Product, reference *nutrition.ReferenceTable) productValues {
+	dailyValues := nutrition.DailyValuesForProduct(p, reference)
+	nutriments := p.ParsedNutriments()
+
+	values := productValues{
+		basisUnit: nutrition.BasisUnit(p),
+		serving:   nutrition.ResolveServing(p),
+		per100:    nutrientSet{daily: dailyValues.Per100.Nutrients, scaled: nutrition.Scale(nutriments, 100)},
+	}
+	if dailyValues.PerServing != nil {
+		values.perServing = &nutrientSet{
+			daily:  dailyValues.PerServing.Nutrients,
+			scaled: nutrition.Scale(nutriments, values.serving.Amount),
+		}
+	}
+	return values
+}
+
+// amount returns a nutrient in the reference table's unit, or in grams when it has no reference intake
+func (n nutrientSet) amount(name string) (float64, bool) {
+	if value, ok := n.daily[name]; ok {
+		return value.Amount, true
+	}
+	if value, ok := n.scaled[name]; ok {
+		return value.Amount, true
+	}
+	return 0, false
+}
+
+// percent returns a nutrient's percentage of its reference intake
+func (n nutrientSet) percent(name string) (float64, bool) {
+	value, ok := n.daily[name]
+	return value.Percent, ok
+}
+
+// formatNumber prints a rounded amount without trailing zeros
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// roundTo rounds a value to the nearest multiple of step
+func roundTo(value, step float64) float64 {
+	rounded := math.Round(value/step) * step
+	// Trim binary noise such as 0.30000000000000004
+	return math.Round(rounded*1000) / 1000
+}
+
+// formatPercent prints a percentage rounded to a whole number
+func formatPercent(percent float64) string {
+	return formatNumber(math.Round(percent)) + "%"
+}
+
+// normalizeOption lowercases an option, using fallback when it is empty
+func normalizeOption(value, fallback string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,219 @@
+package label
+
+import (
+	"testing"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nutella is a product with the per-100 g values printed on a Nutella jar
+func nutella() *types.Product {
+	return &types.Product{
+		Code:                "3017620422003",
+		ProductName:         "Nutella",
+		ServingSize:         "15 g",
+		ServingQuantity:     15.0,
+		ServingQuantityUnit: "g",
+		Nutriments: map[string]interface{}{
+			"energy":        2252.0,
+			"energy-kcal":   539.0,
+			"fat":           30.9,
+			"saturated-fat": 10.6,
+			"trans-fat":     0.0,
+			"carbohydrates": 57.5,
+			"sugars":        56.3,
+			"fiber":         0.0,
+			"proteins":      6.3,
+			"salt":          0.107,
+			"sodium":        0.0428,
+		},
+	}
+}
+
+func TestBuild_US(t *testing.T) {
+	tests := []struct {
+		name           string
+		basis          string
+		product        *types.Product
+		expectedBasis  string
+		expectedHeader []string
+		expectedRows   map[string]string // First cell -> % Daily Value
+		expectWarnings int
+	}{
+		{
+			name:           "per serving",
+			basis:          BasisServing,
+			product:        nutella(),
+			expectedBasis:  BasisServing,
+			expectedHeader: []string{"Serving size 15 g", "Amount per serving", "Calories 80"},
+			expectedRows: map[string]string{
+				"Total Fat 4.5g":        "6%",
+				"Saturated Fat 1.5g":    "8%",
+				"Trans Fat 0g":          "",
+				"Sodium 5mg":            "0%",
+				"Total Carbohydrate 9g": "3%",
+				"Total Sugars 8g":       "",
+				"Protein less than 1g":  "",
+				"Cholesterol n/a":       "",
+			},
+			expectWarnings: 1,
+		},
+		{
+			name:           "per 100 g",
+			basis:          BasisPer100,
+			product:        nutella(),
+			expectedBasis:  BasisPer100,
+			expectedHeader: []string{"Serving size 100 g", "Amount per 100 g", "Calories 540"},
+			expectedRows: map[string]string{
+				"Total Fat 31g":          "40%",
+				"Saturated Fat 11g":      "53%",
+				"Sodium 45mg":            "2%",
+				"Total Carbohydrate 58g": "21%",
+				"Total Sugars 56g":       "",
+				"Protein 6g":             "",
+			},
+			expectWarnings: 1,
+		},
+		{
+			name:  "unknown serving falls back to 100 g",
+			basis: BasisServing,
+			product: &types.Product{
+				Code:       "123",
+				Nutriments: map[string]interface{}{"energy": 418.4, "added-sugars": 12.0},
+			},
+			expectedBasis:  BasisPer100,
+			expectedHeader: []string{"Serving size 100 g", "Amount per 100 g", "Calories 100"},
+			expectedRows:   map[string]string{"Includes 12g Added Sugars": "24%"},
+			expectWarnings: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			label, err := Build(tt.product, Options{Style: StyleUS, Basis: tt.basis})
+			require.NoError(t, err)
+
+			assert.Equal(t, "Nutrition Facts", label.Title)
+			assert.Equal(t, tt.expectedBasis, label.Basis)
+			assert.Equal(t, tt.expectedHeader, label.Header)
+			assert.Equal(t, []string{"", "% Daily Value*"}, label.Columns)
+			assert.Len(t, label.Warnings, tt.expectWarnings)
+
+			rows := map[string]string{}
+			for _, row := range label.Rows {
+				rows[row.Cells[0]] = row.Cells[1]
+			}
+			for name, percent := range tt.expectedRows {
+				assert.Contains(t, rows, name)
+				assert.Equal(t, percent, rows[name], name)
+			}
+		})
+	}
+}
+
+func TestBuild_EU(t *testing.T) {
+	label, err := Build(nutella(), Options{Style: "EU", Basis: BasisServing})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Nutrition declaration", label.Title)
+	assert.Equal(t, []string{"", "Per 100 g", "Per serving (15 g)", "%RI* per serving"}, label.Columns)
+	assert.Empty(t, label.Warnings)
+
+	expected := [][]string{
+		{"Energy", "2252 kJ / 539 kcal", "338 kJ / 81 kcal", "4%"},
+		{"Fat", "31 g", "4.6 g", "7%"},
+		{"of which saturates", "11 g", "1.6 g", "8%"},
+		{"Carbohydrate", "58 g", "8.6 g", "3%"},
+		{"of which sugars", "56 g", "8.4 g", "9%"},
+		{"Fibre", "0 g", "0 g", ""},
+		{"Protein", "6.3 g", "0.9 g", "2%"},
+		{"Salt", "0.11 g", "0.02 g", "0%"},
+	}
+	require.Len(t, label.Rows, len(expected))
+	for i, row := range label.Rows {
+		assert.Equal(t, expected[i], row.Cells)
+	}
+	assert.Equal(t, 1, label.Rows[2].Level)
+
+	per100, err := Build(nutella(), Options{Style: StyleEU, Basis: BasisPer100})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "Per 100 g", "%RI*"}, per100.Columns)
+	assert.Equal(t, []string{"of which sugars", "56 g", "63%"}, per100.Rows[4].Cells)
+}
+
+func TestBuild_SaltDerivedFromSodiumAndMissingFibre(t *testing.T) {
+	product := &types.Product{
+		Code:       "123",
+		Nutriments: map[string]interface{}{"energy-kcal": 42.0, "sodium": 0.004, "fat": 0.0},
+	}
+
+	label, err := Build(product, Options{Style: StyleEU, Basis: BasisPer100})
+	require.NoError(t, err)
+
+	cells := map[string][]string{}
+	for _, row := range label.Rows {
+		cells[row.Cells[0]] = row.Cells
+	}
+	assert.Equal(t, []string{"Energy", "176 kJ / 42 kcal", "2%"}, cells["Energy"])
+	assert.Equal(t, []string{"Salt", "<0.01 g", "0%"}, cells["Salt"])
+	assert.Equal(t, []string{"Fat", "0 g", "0%"}, cells["Fat"])
+	assert.Equal(t, []string{"Protein", "n/a", ""}, cells["Protein"])
+	assert.NotContains(t, cells, "Fibre")
+	require.Len(t, label.Warnings, 1)
+	assert.Contains(t, label.Warnings[0], "proteins")
+}
+
+func TestBuild_InvalidOptions(t *testing.T) {
+	_, err := Build(nutella(), Options{Style: "jp"})
+	assert.ErrorIs(t, err, ErrInvalidOption)
+
+	_, err = Build(nutella(), Options{Basis: "portion"})
+	assert.ErrorIs(t, err, ErrInvalidOption)
+
+	label, err := Build(nutella(), Options{})
+	require.NoError(t, err)
+	_, err = label.Render("pdf")
+	assert.ErrorIs(t, err, ErrInvalidOption)
+}
+
+func TestRounding(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   func(float64) string
+		value    float64
+		expected string
+	}{
+		{"calories below 5", usCalories, 4.9, "0"},
+		{"calories to 5", usCalories, 47, "45"},
+		{"calories to 10", usCalories, 539, "540"},
+		{"fat below 0.5", usFat, 0.4, "0g"},
+		{"fat to 0.5", usFat, 4.6, "4.5g"},
+		{"fat to 1", usFat, 30.9, "31g"},
+		{"cholesterol below 2", usCholesterol, 1.9, "0mg"},
+		{"cholesterol less than 5", usCholesterol, 4, "less than 5mg"},
+		{"cholesterol to 5", usCholesterol, 33, "35mg"},
+		{"sodium to 5", usSodium, 42.8, "45mg"},
+		{"sodium to 10", usSodium, 146, "150mg"},
+		{"carbohydrate less than 1", usCarbohydrate, 0.7, "less than 1g"},
+		{"carbohydrate to 1", usCarbohydrate, 8.6, "9g"},
+		{"vitamin d", usVitaminD, 2.46, "2.5mcg"},
+		{"calcium", usCalcium, 134, "130mg"},
+		{"iron", usIron, 0.27, "0.3mg"},
+		{"eu macronutrient below 0.5", euMacronutrient, 0.3, "<0.5 g"},
+		{"eu macronutrient to 0.1", euMacronutrient, 6.34, "6.3 g"},
+		{"eu macronutrient to 1", euMacronutrient, 57.5, "58 g"},
+		{"eu saturates below 0.1", euSaturates, 0.05, "<0.1 g"},
+		{"eu saturates to 0.1", euSaturates, 0.55, "0.6 g"},
+		{"eu salt below 0.01", euSalt, 0.01, "<0.01 g"},
+		{"eu salt to 0.01", euSalt, 0.107, "0.11 g"},
+		{"eu salt to 0.1", euSalt, 1.26, "1.3 g"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.format(tt.value))
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package label
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Layout constants shared by the renderers
+const (
+	minTextWidth  = 40 // Narrowest plain-text label, in characters
+	indentWidth   = 2  // Characters per indentation level in plain text
+	columnSpacing = 2  // Spaces between plain-text columns
+
+	svgPadding     = 12
+	svgCharWidth   = 7.2 // Approximate width of a 13px sans-serif character
+	svgIndentWidth = 14
+	svgRowHeight   = 20
+	svgMinWidth    = 320
+)
+
+// columnWidths returns the width of each column in characters, including row indentation
+func (l *Label) columnWidths() []int {
+	widths := make([]int, len(l.Columns))
+	for i, column := range l.Columns {
+		widths[i] = utf8.RuneCountInString(column)
+	}
+	for _, row := range l.Rows {
+		for i, cell := range row.Cells {
+			if i >= len(widths) {
+				break
+			}
+			width := utf8.RuneCountInString(cell)
+			if i == 0 {
+				width += row.Level * indentWidth
+			}
+			if width > widths[i] {
+				widths[i] = width
+			}
+		}
+	}
+	return widths
+}
+
+// hasColumnHeaders reports whether any column has a heading
+func (l *Label) hasColumnHeaders() bool {
+	for _, column := range l.Columns {
+		if column != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// renderText draws the label with box-drawing-free ASCII so it survives any terminal or chat client
+func renderText(l *Label) string {
+	widths := l.columnWidths()
+	total := 0
+	for _, width := range widths {
+		total += width
+	}
+	total += columnSpacing * (len(widths) - 1)
+	if total < minTextWidth {
+		widths[0] += minTextWidth - total
+		total = minTextWidth
+	}
+
+	var b strings.Builder
+	heavy, light := strings.Repeat("=", total), strings.Repeat("-", total)
+
+	b.WriteString(l.Title + "\n")
+	if l.ProductName != "" {
+		b.WriteString(l.ProductName + "\n")
+	}
+	b.WriteString(heavy + "\n")
+	for _, line := range l.Header {
+		b.WriteString(line + "\n")
+	}
+	if len(l.Header) > 0 {
+		b.WriteString(light + "\n")
+	}
+
+	if l.hasColumnHeaders() {
+		b.WriteString(textRow(l.Columns, 0, widths) + "\n")
+	}
+	for _, row := range l.Rows {
+		if row.Separator {
+			b.WriteString(heavy + "\n")
+		}
+		b.WriteString(textRow(row.Cells, row.Level, widths) + "\n")
+	}
+	b.WriteString(heavy + "\n")
+
+	for _, line := range wrapText(l.Footnote, total) {
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// textRow left-aligns the first cell and right-aligns the others
+func textRow(cells []string, level int, widths []int) string {
+	parts := make([]string, len(widths))
+	for i, width := range widths {
+		cell := ""
+		if i < len(cells) {
+			cell = cells[i]
+		}
+		if i == 0 {
+			cell = strings.Repeat(" ", level*indentWidth) + cell
+			parts[i] = cell + strings.Repeat(" ", max(width-utf8.RuneCountInString(cell), 0))
+			continue
+		}
+		parts[i] = strings.Repeat(" ", max(width-utf8.RuneCountInString(cell), 0)) + cell
+	}
+	return strings.TrimRight(strings.Join(parts, strings.Repeat(" ", columnSpacing)), " ")
+}
+
+// renderMarkdown draws the label as a heading, header lines and a GitHub-flavored table
+func renderMarkdown(l *Label) string {
+	var b strings.Builder
+
+	b.WriteString("### " + markdownEscape(l.Title) + "\n\n")
+	if l.ProductName != "" {
+		b.WriteString("_" + markdownEscape(l.ProductName) + "_\n\n")
+	}
+	if len(l.Header) > 0 {
+		for i, line := range l.Header {
+			b.WriteString(markdownEscape(line))
+			if i < len(l.Header)-1 {
+				b.WriteString("  ")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	header := make([]string, len(l.Columns))
+	align := make([]string, len(l.Columns))
+	for i, column := range l.Columns {
+		header[i] = markdownEscape(column)
+		align[i] = "---:"
+	}
+	if len(align) > 0 {
+		header[0], align[0] = "Nutrient", ":---"
+	}
+	b.WriteString("| " + strings.Join(header, " | ") + " |\n")
+	b.WriteString("|" + strings.Join(align, "|") + "|\n")
+
+	for _, row := range l.Rows {
+		cells := make([]string, len(l.Columns))
+		for i := range cells {
+			if i < len(row.Cells) {
+				cells[i] = markdownEscape(row.Cells[i])
+			}
+		}
+		if len(cells) > 0 {
+			if row.Bold {
+				cells[0] = "**" + cells[0] + "**"
+			}
+			cells[0] = strings.Repeat("&nbsp;&nbsp;", row.Level) + cells[0]
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+
+	if l.Footnote != "" {
+		b.WriteString("\n" + markdownEscape(l.Footnote) + "\n")
+	}
+	return b.String()
+}
+
+// markdownEscape escapes characters that would change table layout or emphasis
+func markdownEscape(text string) string {
+	return strings.NewReplacer(`|`, `\|`, `*`, `\*`, `_`, `\_`).Replace(text)
+}
+
+// renderSVG draws the label as a standalone SVG document sized to its content
+func renderSVG(l *Label) string {
+	widths := l.columnWidths()
+	chars := 0
+	for _, width := range widths {
+		chars += width + columnSpacing
+	}
+	width := max(float64(svgMinWidth), float64(chars)*svgCharWidth+2*svgPadding)
+	right := width - svgPadding
+
+	// Right edge of each numeric column, laid out from the right
+	edges := make([]float64, len(widths))
+	x := right
+	for i := len(widths) - 1; i > 0; i-- {
+		edges[i] = x
+		x -= float64(widths[i]+columnSpacing) * svgCharWidth
+	}
+
+	var body bytes.Buffer
+	y := float64(svgPadding)
+
+	y += 28
+	svgText(&body, svgPadding, y, 26, "start", true, l.Title)
+	if l.ProductName != "" {
+		y += 18
+		svgText(&body, svgPadding, y, 13, "start", false, l.ProductName)
+	}
+	y += 8
+	svgRule(&body, y, width, 6)
+	y += 6
+
+	for i, line := range l.Header {
+		y += svgRowHeight
+		// The last header line carries the headline figure, e.g. calories
+		svgText(&body, svgPadding, y, 13, "start", i == len(l.Header)-1, line)
+	}
+	if len(l.Header) > 0 {
+		y += 6
+		svgRule(&body, y, width, 3)
+	}
+
+	if l.hasColumnHeaders() {
+		y += svgRowHeight
+		for i, column := range l.Columns {
+			if i > 0 {
+				svgText(&body, edges[i], y, 11, "end", true, column)
+			}
+		}
+		y += 5
+		svgRule(&body, y, width, 1)
+	}
+
+	for _, row := range l.Rows {
+		if row.Separator {
+			y += 2
+			svgRule(&body, y, width, 6)
+			y += 4
+		}
+		y += svgRowHeight
+		for i, cell := range row.Cells {
+			if i >= len(widths) {
+				break
+			}
+			switch {
+			case i == 0:
+				svgText(&body, svgPadding+float64(row.Level)*svgIndentWidth, y, 13, "start", row.Bold, cell)
+			case cell != "":
+				svgText(&body, edges[i], y, 13, "end", false, cell)
+			}
+		}
+		y += 5
+		svgRule(&body, y, width, 1)
+	}
+	y += 2
+	svgRule(&body, y, width, 6)
+	y += 6
+
+	for _, line := range wrapText(l.Footnote, int((width-2*svgPadding)/(svgCharWidth*0.8))) {
+		y += 14
+		svgText(&body, svgPadding, y, 10, "start", false, line)
+	}
+	y += svgPadding
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%g" viewBox="0 0 %g %g" font-family="Helvetica, Arial, sans-serif">`+"\n",
+		width, y, width, y)
+	fmt.Fprintf(&b, `<rect x="0.5" y="0.5" width="%g" height="%g" fill="#fff" stroke="#000"/>`+"\n", width-1, y-1)
+	b.Write(body.Bytes())
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// svgText writes an escaped text element
+func svgText(b *bytes.Buffer, x, y, size float64, anchor string, bold bool, text string) {
+	weight := "normal"
+	if bold {
+		weight = "bold"
+	}
+	fmt.Fprintf(b, `<text x="%g" y="%g" font-size="%g" font-weight="%s" text-anchor="%s">`, x, y, size, weight, anchor)
+	_ = xml.EscapeText(b, []byte(text))
+	b.WriteString("</text>\n")
+}
+
+// svgRule writes a horizontal rule across the label
+func svgRule(b *bytes.Buffer, y, width, thickness float64) {
+	fmt.Fprintf(b, `<rect x="%d" y="%g" width="%g" height="%g" fill="#000"/>`+"\n", svgPadding, y, width-2*svgPadding, thickness)
+}
+
+// wrapText splits text into lines of at most width characters at word boundaries
+func wrapText(text string, width int) []string {
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(text) {
+		if line != "" && utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
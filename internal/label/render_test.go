@@ -0,0 +1,86 @@
+package label
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender_Text(t *testing.T) {
+	label, err := Build(nutella(), Options{Style: StyleEU, Basis: BasisServing})
+	require.NoError(t, err)
+
+	text, err := label.Render(FormatText)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	assert.Equal(t, "Nutrition declaration", lines[0])
+	assert.Equal(t, "Nutella", lines[1])
+	assert.Contains(t, text, "\n  of which saturates ")
+	assert.Contains(t, text, "* Reference intake of an average adult")
+
+	// Rows with a %RI are as wide as the rules, so the numeric columns line up
+	width := len(lines[2])
+	for _, line := range lines[3:12] {
+		assert.LessOrEqual(t, len(line), width, line)
+		if strings.HasSuffix(line, "%") {
+			assert.Len(t, line, width, line)
+		}
+	}
+}
+
+func TestRender_Markdown(t *testing.T) {
+	label, err := Build(nutella(), Options{Style: StyleUS, Basis: BasisServing})
+	require.NoError(t, err)
+
+	markdown, err := label.Render(FormatMarkdown)
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(markdown, "### Nutrition Facts\n\n_Nutella_\n"))
+	assert.Contains(t, markdown, "| Nutrient | % Daily Value\\* |\n|:---|---:|\n")
+	assert.Contains(t, markdown, "| **Total Fat 4.5g** | 6% |\n")
+	assert.Contains(t, markdown, "| &nbsp;&nbsp;Saturated Fat 1.5g | 8% |\n")
+	assert.Contains(t, markdown, "\n\\* The % Daily Value (DV)")
+}
+
+func TestRender_SVG(t *testing.T) {
+	product := nutella()
+	product.ProductName = `Nutella <Biscuits> & "Friends"`
+
+	for _, style := range Styles {
+		t.Run(style, func(t *testing.T) {
+			label, err := Build(product, Options{Style: style, Basis: BasisServing})
+			require.NoError(t, err)
+
+			svg, err := label.Render(FormatSVG)
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg"`))
+
+			// The document must be well-formed XML with the product name escaped
+			var texts []string
+			decoder := xml.NewDecoder(strings.NewReader(svg))
+			for {
+				token, err := decoder.Token()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				require.NoError(t, err)
+				if data, ok := token.(xml.CharData); ok && strings.TrimSpace(string(data)) != "" {
+					texts = append(texts, string(data))
+				}
+			}
+			assert.Contains(t, texts, label.Title)
+			assert.Contains(t, texts, product.ProductName)
+		})
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	assert.Equal(t, []string{"one two", "three", "four five"}, wrapText("one two three four five", 9))
+	assert.Empty(t, wrapText("", 10))
+}
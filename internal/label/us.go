@@ -0,0 +1,176 @@
+package label
+
+import (
+	"strings"
+)
+
+// usFootnote is the footnote required on the 2016 Nutrition Facts panel
+const usFootnote = "* The % Daily Value (DV) tells you how much a nutrient in a serving of food contributes to a daily diet. " +
+	"2,000 calories a day is used for general nutrition advice."
+
+// usNutrient describes one row of the US panel
+type usNutrient struct {
+	label     string
+	name      string
+	level     int
+	bold      bool
+	dv        bool // Show % Daily Value
+	mandatory bool // Shown as n/a when the dataset has no value
+	separator bool
+	format    func(float64) string // Applies the FDA rounding rule and unit
+}
+
+// usNutrients are the rows of the panel in FDA order (21 CFR 101.9(d))
+var usNutrients = []usNutrient{
+	{label: "Total Fat", name: "fat", bold: true, dv: true, mandatory: true, format: usFat},
+	{label: "Saturated Fat", name: "saturated-fat", level: 1, dv: true, mandatory: true, format: usFat},
+	{label: "Trans Fat", name: "trans-fat", level: 1, mandatory: true, format: usFat},
+	{label: "Cholesterol", name: "cholesterol", bold: true, dv: true, mandatory: true, format: usCholesterol},
+	{label: "Sodium", name: "sodium", bold: true, dv: true, mandatory: true, format: usSodium},
+	{label: "Total Carbohydrate", name: "carbohydrates", bold: true, dv: true, mandatory: true, format: usCarbohydrate},
+	{label: "Dietary Fiber", name: "fiber", level: 1, dv: true, mandatory: true, format: usCarbohydrate},
+	{label: "Total Sugars", name: "sugars", level: 1, mandatory: true, format: usCarbohydrate},
+	{label: "Added Sugars", name: "added-sugars", level: 2, dv: true, format: usCarbohydrate},
+	{label: "Protein", name: "proteins", bold: true, mandatory: true, format: usCarbohydrate},
+	{label: "Vitamin D", name: "vitamin-d", dv: true, mandatory: true, separator: true, format: usVitaminD},
+	{label: "Calcium", name: "calcium", dv: true, mandatory: true, format: usCalcium},
+	{label: "Iron", name: "iron", dv: true, mandatory: true, format: usIron},
+	{label: "Potassium", name: "potassium", dv: true, mandatory: true, format: usSodium},
+}
+
+// buildUS lays out an FDA-style Nutrition Facts panel
+func buildUS(label *Label, values productValues) {
+	set, servingText, amountText := values.per100, "100 "+values.basisUnit, "Amount per 100 "+values.basisUnit
+	if label.Basis == BasisServing {
+		set, servingText, amountText = *values.perServing, servingDescription(values), "Amount per serving"
+	}
+
+	calories := "n/a"
+	if kcal, ok := set.amount("energy-kcal"); ok {
+		calories = usCalories(kcal)
+	}
+
+	label.Title = "Nutrition Facts"
+	label.Header = []string{"Serving size " + servingText, amountText, "Calories " + calories}
+	label.Columns = []string{"", "% Daily Value*"}
+	label.Footnote = usFootnote
+
+	var missing []string
+	for _, nutrient := range usNutrients {
+		amount, ok := set.amount(nutrient.name)
+		if !ok && !nutrient.mandatory {
+			continue
+		}
+
+		text := "n/a"
+		if ok {
+			text = nutrient.format(amount)
+		} else {
+			missing = append(missing, nutrient.name)
+		}
+
+		name := nutrient.label + " " + text
+		if nutrient.name == "added-sugars" {
+			name = "Includes " + text + " " + nutrient.label
+		}
+
+		percent := ""
+		if value, hasPercent := set.percent(nutrient.name); nutrient.dv && hasPercent {
+			percent = formatPercent(value)
+		}
+
+		label.Rows = append(label.Rows, Row{
+			Cells:     []string{name, percent},
+			Level:     nutrient.level,
+			Bold:      nutrient.bold,
+			Separator: nutrient.separator,
+		})
+	}
+
+	if len(missing) > 0 {
+		label.Warnings = append(label.Warnings, "not reported in the dataset: "+strings.Join(missing, ", "))
+	}
+}
+
+// servingDescription prefers the dataset's own serving text, e.g. "2 biscuits (25 g)"
+func servingDescription(values productValues) string {
+	if values.serving.Text != "" {
+		return values.serving.Text
+	}
+	return formatNumber(values.serving.Amount) + " " + values.serving.Unit
+}
+
+// usCalories rounds to 0 below 5, to 5 up to 50 and to 10 above (21 CFR 101.9(c)(1))
+func usCalories(kcal float64) string {
+	switch {
+	case kcal < 5:
+		return "0"
+	case kcal <= 50:
+		return formatNumber(roundTo(kcal, 5))
+	default:
+		return formatNumber(roundTo(kcal, 10))
+	}
+}
+
+// usFat rounds to 0 below 0.5 g, to 0.5 g below 5 g and to 1 g above (21 CFR 101.9(c)(2))
+func usFat(grams float64) string {
+	switch {
+	case grams < 0.5:
+		return "0g"
+	case grams < 5:
+		return formatNumber(roundTo(grams, 0.5)) + "g"
+	default:
+		return formatNumber(roundTo(grams, 1)) + "g"
+	}
+}
+
+// usCholesterol rounds to 0 below 2 mg, "less than 5mg" up to 5 mg and to 5 mg above (21 CFR 101.9(c)(3))
+func usCholesterol(mg float64) string {
+	switch {
+	case mg < 2:
+		return "0mg"
+	case mg <= 5:
+		return "less than 5mg"
+	default:
+		return formatNumber(roundTo(mg, 5)) + "mg"
+	}
+}
+
+// usSodium rounds to 0 below 5 mg, to 5 mg up to 140 mg and to 10 mg above (21 CFR 101.9(c)(4))
+func usSodium(mg float64) string {
+	switch {
+	case mg < 5:
+		return "0mg"
+	case mg <= 140:
+		return formatNumber(roundTo(mg, 5)) + "mg"
+	default:
+		return formatNumber(roundTo(mg, 10)) + "mg"
+	}
+}
+
+// usCarbohydrate rounds to 0 below 0.5 g, "less than 1g" below 1 g and to 1 g above (21 CFR 101.9(c)(6))
+func usCarbohydrate(grams float64) string {
+	switch {
+	case grams < 0.5:
+		return "0g"
+	case grams < 1:
+		return "less than 1g"
+	default:
+		return formatNumber(roundTo(grams, 1)) + "g"
+	}
+}
+
+// usVitaminD rounds to 0.1 mcg
+func usVitaminD(mcg float64) string {
+	return formatNumber(roundTo(mcg, 0.1)) + "mcg"
+}
+
+// usCalcium rounds to 10 mg
+func usCalcium(mg float64) string {
+	return formatNumber(roundTo(mg, 10)) + "mg"
+}
+
+// usIron rounds to 0.1 mg
+func usIron(mg float64) string {
+	return formatNumber(roundTo(mg, 0.1)) + "mg"
+}
@@ -0,0 +1,109 @@
+package mcpgo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/diet"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/nutrition"
+)
+
+// CheckDietResponse represents the response from check_diet
+type CheckDietResponse struct {
+	Found   bool                     `json:"found"`
+	Result  *diet.Result             `json:"result,omitempty"`
+	Quality *nutrition.QualityReport `json:"quality,omitempty"`
+}
+
+// loadDietProfiles loads the built-in profiles and those in dir, logging files that fail to load
+func loadDietProfiles(dir string, logger *slog.Logger) *diet.Registry {
+	registry, err := diet.LoadRegistry(dir)
+	if registry == nil {
+		logger.Error("Failed to load diet profiles, check_diet is disabled", "error", err)
+		return nil
+	}
+	if err != nil {
+		logger.Error("Some diet profiles failed to load and were skipped", "dir", dir, "error", err)
+	}
+	logger.Info("Diet profiles loaded", "dir", dir, "profiles", registry.IDs())
+	return registry
+}
+
+// addDietTools registers the tools that check products against diet profiles
+func (s *Server) addDietTools() {
+	if s.diets == nil {
+		return
+	}
+
+	checkDietTool := mcp.NewTool("check_diet",
+		mcp.WithDescription("Check whether a product fits a diet profile and explain every violation. "+
+			"Rules combine nutrient limits per 100 g, ingredient IDs, the dataset's vegan/vegetarian ingredient analysis, labels and allergens. "+
+			"Returns status compliant, non_compliant or uncertain (warnings, missing certifications or data the rules could not be checked against)."),
+		mcp.WithString("barcode",
+			mcp.Required(),
+			mcp.Description("The barcode (UPC/EAN) of the product"),
+		),
+		mcp.WithString("profile",
+			mcp.Required(),
+			mcp.Description("Diet profile: "+strings.Join(s.diets.IDs(), ", ")),
+			mcp.Enum(s.diets.IDs()...),
+		),
+		mcp.WithOutputSchema[CheckDietResponse](),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(true),
+	)
+
+	s.mcpServer.AddTool(checkDietTool, s.handleCheckDiet)
+}
+
+func (s *Server) handleCheckDiet(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.log.Debug("handleCheckDiet: Starting tool call",
+		"arguments", request.GetArguments())
+
+	barcode, err := request.RequireString("barcode")
+	if err != nil {
+		s.log.Warn("handleCheckDiet: Missing 'barcode' parameter", "error", err)
+		return mcp.NewToolResultError(fmt.Sprintf("Missing required parameter 'barcode': %v", err)), nil
+	}
+
+	profileID, err := request.RequireString("profile")
+	if err != nil {
+		s.log.Warn("handleCheckDiet: Missing 'profile' parameter", "error", err)
+		return mcp.NewToolResultError(fmt.Sprintf("Missing required parameter 'profile': %v", err)), nil
+	}
+
+	profile, err := s.diets.Lookup(profileID)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	product, err := s.queryEngine.SearchByBarcode(ctx, barcode)
+	if err != nil {
+		s.log.Error("Barcode search failed", "error", err)
+		return s.queryErrorResult("Barcode search failed", err), nil
+	}
+
+	response := CheckDietResponse{Found: product != nil, Quality: qualityFor(product)}
+	if product != nil {
+		result := profile.Check(product)
+		response.Result = &result
+	}
+
+	// Create fallback text for backwards compatibility
+	responseJSON, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		s.log.Error("handleCheckDiet: Failed to marshal response", "error", err)
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal response: %v", err)), nil
+	}
+
+	s.log.Debug("handleCheckDiet: Returning structured result",
+		"found", response.Found,
+		"profile", profile.ID,
+		"response_size", len(responseJSON))
+
+	return mcp.NewToolResultStructured(response, string(responseJSON)), nil
+}
@@ -0,0 +1,119 @@
+package mcpgo
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/auth"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/config"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/diet"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/query"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_CheckDietTool(t *testing.T) {
+	profilesDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(profilesDir, "no-palm-oil.json"), []byte(`{
+		"id": "no-palm-oil",
+		"name": "No palm oil",
+		"ingredient_attributes": [{"attribute": "from_palm_oil", "forbidden": ["yes"], "uncertain": ["maybe"], "reason": "palm oil is excluded"}]
+	}`), 0o644))
+
+	spread := types.Product{
+		Code:       "3017620422003",
+		Nutriments: map[string]interface{}{"carbohydrates": 57.5, "sugars": 56.3, "salt": 0.107},
+		Ingredients: []interface{}{
+			map[string]interface{}{"id": "en:sugar", "text": "sugar", "vegan": "yes", "from_palm_oil": "no"},
+			map[string]interface{}{"id": "en:palm-oil", "text": "palm oil", "vegan": "yes", "from_palm_oil": "yes"},
+			map[string]interface{}{"id": "en:skimmed-milk-powder", "text": "skimmed milk powder", "vegan": "no", "from_palm_oil": "no"},
+		},
+		AllergensTags: []string{"en:milk", "en:nuts"},
+	}
+
+	tests := []struct {
+		name               string
+		arguments          map[string]interface{}
+		expectError        bool
+		expectFound        bool
+		expectedStatus     string
+		expectedViolations []string
+	}{
+		{
+			name:               "vegan violations",
+			arguments:          map[string]interface{}{"barcode": "3017620422003", "profile": "vegan"},
+			expectFound:        true,
+			expectedStatus:     diet.StatusNonCompliant,
+			expectedViolations: []string{"vegan", "allergens"},
+		},
+		{
+			name:               "low sodium compliant",
+			arguments:          map[string]interface{}{"barcode": "3017620422003", "profile": "low-sodium"},
+			expectFound:        true,
+			expectedStatus:     diet.StatusCompliant,
+			expectedViolations: []string{},
+		},
+		{
+			name:               "custom profile from directory",
+			arguments:          map[string]interface{}{"barcode": "3017620422003", "profile": "no-palm-oil"},
+			expectFound:        true,
+			expectedStatus:     diet.StatusNonCompliant,
+			expectedViolations: []string{"from_palm_oil"},
+		},
+		{
+			name:      "product not found",
+			arguments: map[string]interface{}{"barcode": "404", "profile": "keto"},
+		},
+		{
+			name:        "unknown profile",
+			arguments:   map[string]interface{}{"barcode": "3017620422003", "profile": "paleo"},
+			expectError: true,
+		},
+		{
+			name:        "missing profile",
+			arguments:   map[string]interface{}{"barcode": "3017620422003"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := config.NewTestLogger(io.Discard, "debug")
+			engine := query.NewMockEngine(logger)
+			engine.SetProducts([]types.Product{spread})
+			server := NewServer(engine, auth.NewBearerTokenAuth("test-token"), &config.Config{DietProfilesDir: profilesDir}, logger)
+
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tt.arguments
+
+			result, err := server.handleCheckDiet(context.Background(), request)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectError, result.IsError)
+			if tt.expectError {
+				return
+			}
+
+			response, ok := result.StructuredContent.(CheckDietResponse)
+			require.True(t, ok)
+			assert.Equal(t, tt.expectFound, response.Found)
+			if !tt.expectFound {
+				assert.Nil(t, response.Result)
+				return
+			}
+
+			require.NotNil(t, response.Result)
+			assert.Equal(t, tt.expectedStatus, response.Result.Status)
+			rules := []string{}
+			for _, violation := range response.Result.Violations {
+				rules = append(rules, violation.Rule)
+				assert.NotEmpty(t, violation.Message)
+			}
+			assert.Equal(t, tt.expectedViolations, rules)
+		})
+	}
+}
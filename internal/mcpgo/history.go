@@ -0,0 +1,141 @@
+package mcpgo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/query"
+)
+
+// addHistoryTools registers the tools that track product changes between dataset versions
+func (s *Server) addHistoryTools() {
+	changesTool := mcp.NewTool("changed_products",
+		mcp.WithDescription("List products that changed, one page at a time, so downstream caches can sync without re-reading the whole dataset. "+
+			"With since, returns products whose last_modified_t is after that time, oldest edit first. "+
+			"With since_previous_snapshot, returns products added, removed or with changed fingerprinted fields between the two latest dataset snapshots. "+
+			"Pass next_cursor back as cursor to fetch the next page; it is empty on the last page."),
+		mcp.WithString("since",
+			mcp.Description("RFC 3339 timestamp, e.g. 2025-01-31T00:00:00Z. Set either this or since_previous_snapshot."),
+		),
+		mcp.WithBoolean("since_previous_snapshot",
+			mcp.Description("Compare the two latest dataset snapshots instead of using a timestamp"),
+		),
+		mcp.WithString("cursor",
+			mcp.Description("next_cursor from the previous page, with the same since or since_previous_snapshot"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("Page size (default: %d, max: %d)", query.DefaultChangesLimit, query.MaxChangesLimit)),
+			mcp.DefaultNumber(query.DefaultChangesLimit),
+			mcp.Min(1),
+			mcp.Max(query.MaxChangesLimit),
+		),
+		mcp.WithOutputSchema[query.ChangesPage](),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(true),
+	)
+
+	s.mcpServer.AddTool(changesTool, s.handleChangedProducts)
+
+	// Product history needs retained snapshots
+	if s.config.HistorySnapshots <= 0 {
+		return
+	}
+
+	historyTool := mcp.NewTool("product_history",
+		mcp.WithDescription(fmt.Sprintf("Show how a product changed across the last %d dataset snapshots: "+
+			"which of product_name, brands, nutriments, ingredients, nutriscore_grade, nutriscore_score and nova_group changed, "+
+			"in which snapshot, and when the product was last edited on Open Food Facts. "+
+			"Use it to explain why a product's values or scores differ from an earlier reading.", s.config.HistorySnapshots)),
+		mcp.WithString("barcode",
+			mcp.Required(),
+			mcp.Description("The barcode (UPC/EAN) of the product"),
+		),
+		mcp.WithOutputSchema[query.ProductHistory](),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(true),
+	)
+
+	s.mcpServer.AddTool(historyTool, s.handleProductHistory)
+}
+
+func (s *Server) handleProductHistory(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.log.Debug("handleProductHistory: Starting tool call",
+		"arguments", request.GetArguments())
+
+	barcode, err := request.RequireString("barcode")
+	if err != nil {
+		s.log.Warn("handleProductHistory: Missing 'barcode' parameter", "error", err)
+		return mcp.NewToolResultError(fmt.Sprintf("Missing required parameter 'barcode': %v", err)), nil
+	}
+
+	history, err := s.queryEngine.ProductHistory(ctx, barcode)
+	if err != nil {
+		if errors.Is(err, query.ErrHistoryDisabled) {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		s.log.Error("Product history failed", "error", err)
+		return s.queryErrorResult("Product history failed", err), nil
+	}
+
+	// Create fallback text for backwards compatibility
+	responseJSON, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		s.log.Error("handleProductHistory: Failed to marshal response", "error", err)
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal response: %v", err)), nil
+	}
+
+	s.log.Debug("handleProductHistory: Returning structured result",
+		"barcode", barcode,
+		"snapshots", len(history.Snapshots),
+		"changes", len(history.Changes),
+		"response_size", len(responseJSON))
+
+	return mcp.NewToolResultStructured(history, string(responseJSON)), nil
+}
+
+func (s *Server) handleChangedProducts(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.log.Debug("handleChangedProducts: Starting tool call",
+		"arguments", request.GetArguments())
+
+	q := query.ChangesQuery{
+		SincePreviousSnapshot: request.GetBool("since_previous_snapshot", false),
+		Cursor:                request.GetString("cursor", ""),
+		Limit:                 int(request.GetFloat("limit", query.DefaultChangesLimit)),
+	}
+	if since := request.GetString("since", ""); since != "" {
+		parsed, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			s.log.Warn("handleChangedProducts: Invalid 'since' parameter", "error", err)
+			return mcp.NewToolResultError(fmt.Sprintf("Parameter 'since' must be an RFC 3339 timestamp: %v", err)), nil
+		}
+		q.Since = &parsed
+	}
+
+	page, err := s.queryEngine.ChangedProducts(ctx, q)
+	if err != nil {
+		if errors.Is(err, query.ErrInvalidChangesQuery) || errors.Is(err, query.ErrHistoryDisabled) || errors.Is(err, query.ErrNoPreviousSnapshot) {
+			s.log.Warn("handleChangedProducts: Query rejected", "error", err)
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		s.log.Error("Changed products query failed", "error", err)
+		return s.queryErrorResult("Changed products query failed", err), nil
+	}
+
+	// Create fallback text for backwards compatibility
+	responseJSON, err := json.MarshalIndent(page, "", "  ")
+	if err != nil {
+		s.log.Error("handleChangedProducts: Failed to marshal response", "error", err)
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal response: %v", err)), nil
+	}
+
+	s.log.Debug("handleChangedProducts: Returning structured result",
+		"count", page.Count,
+		"more", page.NextCursor != "",
+		"response_size", len(responseJSON))
+
+	return mcp.NewToolResultStructured(page, string(responseJSON)), nil
+}
@@ -0,0 +1,159 @@
+package mcpgo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/auth"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/config"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_ProductHistoryTool(t *testing.T) {
+	t.Run("registered only when snapshots are retained", func(t *testing.T) {
+		logger := config.NewTestLogger(io.Discard, "debug")
+
+		disabled := NewServer(query.NewMockEngine(logger), auth.NewBearerTokenAuth("test-token"), &config.Config{}, logger)
+		assert.NotContains(t, listToolNames(t, disabled), "product_history")
+
+		enabled := NewServer(query.NewMockEngine(logger), auth.NewBearerTokenAuth("test-token"), &config.Config{HistorySnapshots: 30}, logger)
+		assert.Contains(t, listToolNames(t, enabled), "product_history")
+	})
+
+	snapshotAt := time.Date(2025, 1, 2, 6, 0, 0, 0, time.UTC)
+	history := &query.ProductHistory{
+		Barcode:   "3017620422003",
+		Snapshots: []query.Snapshot{{ID: "20250102T060000Z-bbbbbbbbbbbb", TakenAt: snapshotAt, Dataset: "bbbbbbbbbbbb"}},
+		Changes: []query.ProductChange{{
+			Snapshot:   "20250102T060000Z-bbbbbbbbbbbb",
+			SnapshotAt: snapshotAt,
+			Event:      query.EventChanged,
+			Fields:     []query.FieldChange{{Field: "nutriscore_grade", Before: "e", After: "d"}},
+		}},
+	}
+
+	tests := []struct {
+		name            string
+		arguments       map[string]interface{}
+		engineErr       error
+		expectError     bool
+		expectedChanges int
+	}{
+		{
+			name:            "recorded changes",
+			arguments:       map[string]interface{}{"barcode": "3017620422003"},
+			expectedChanges: 1,
+		},
+		{
+			name:            "no history",
+			arguments:       map[string]interface{}{"barcode": "404"},
+			expectedChanges: 0,
+		},
+		{
+			name:        "missing barcode",
+			arguments:   map[string]interface{}{},
+			expectError: true,
+		},
+		{
+			name:        "history disabled",
+			arguments:   map[string]interface{}{"barcode": "3017620422003"},
+			engineErr:   query.ErrHistoryDisabled,
+			expectError: true,
+		},
+		{
+			name:        "engine error",
+			arguments:   map[string]interface{}{"barcode": "3017620422003"},
+			engineErr:   errors.New("boom"),
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := config.NewTestLogger(io.Discard, "debug")
+			mockEngine := query.NewMockEngine(logger)
+			mockEngine.SetHistory(history)
+			mockEngine.SetError(tt.engineErr)
+			server := NewServer(mockEngine, auth.NewBearerTokenAuth("test-token"), &config.Config{HistorySnapshots: 30}, logger)
+
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tt.arguments
+
+			result, err := server.handleProductHistory(context.Background(), request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectError, result.IsError)
+
+			if !tt.expectError {
+				response, ok := result.StructuredContent.(*query.ProductHistory)
+				require.True(t, ok)
+				assert.Equal(t, tt.arguments["barcode"], response.Barcode)
+				assert.Len(t, response.Changes, tt.expectedChanges)
+			}
+		})
+	}
+}
+
+func TestServer_ChangedProductsTool(t *testing.T) {
+	tests := []struct {
+		name          string
+		arguments     map[string]interface{}
+		engineErr     error
+		expectError   bool
+		expectedCount int
+	}{
+		{
+			name:          "since timestamp",
+			arguments:     map[string]interface{}{"since": "2025-01-31T00:00:00Z", "limit": 1},
+			expectedCount: 1,
+		},
+		{
+			name:          "since previous snapshot",
+			arguments:     map[string]interface{}{"since_previous_snapshot": true},
+			expectedCount: 2,
+		},
+		{
+			name:        "malformed timestamp",
+			arguments:   map[string]interface{}{"since": "yesterday"},
+			expectError: true,
+		},
+		{
+			name:        "neither since nor snapshot",
+			arguments:   map[string]interface{}{},
+			expectError: true,
+		},
+		{
+			name:        "no previous snapshot",
+			arguments:   map[string]interface{}{"since_previous_snapshot": true},
+			engineErr:   query.ErrNoPreviousSnapshot,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := config.NewTestLogger(io.Discard, "debug")
+			mockEngine := query.NewMockEngine(logger)
+			mockEngine.SetError(tt.engineErr)
+			server := NewServer(mockEngine, auth.NewBearerTokenAuth("test-token"), &config.Config{}, logger)
+
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tt.arguments
+
+			result, err := server.handleChangedProducts(context.Background(), request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectError, result.IsError)
+
+			if !tt.expectError {
+				response, ok := result.StructuredContent.(*query.ChangesPage)
+				require.True(t, ok)
+				assert.Equal(t, tt.expectedCount, response.Count)
+			}
+		})
+	}
+}
@@ -0,0 +1,406 @@
+package mcpgo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/label"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/nutriscore"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/nutrition"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/query"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/types"
+)
+
+// NutritionForQuantityResponse represents the response from nutrition_for_quantity
+type NutritionForQuantityResponse struct {
+	Found     bool                         `json:"found"`
+	Nutrition *nutrition.QuantityNutrition `json:"nutrition,omitempty"`
+	Quality   *nutrition.QualityReport     `json:"quality,omitempty"`
+}
+
+// NutritionLabelResponse represents the response from nutrition_label
+type NutritionLabelResponse struct {
+	Found    bool         `json:"found"`
+	Format   string       `json:"format,omitempty"`
+	Rendered string       `json:"rendered,omitempty"` // The label in the requested format
+	Label    *label.Label `json:"label,omitempty"`    // Layout the rendered label was drawn from
+
+	Quality *nutrition.QualityReport `json:"quality,omitempty"`
+}
+
+// Nutri-Score sources
+const (
+	NutriScoreSourceDataset  = "dataset"
+	NutriScoreSourceComputed = "computed"
+)
+
+// NutriScoreResponse represents the response from nutri_score
+type NutriScoreResponse struct {
+	Found         bool               `json:"found"`
+	Grade         string             `json:"grade,omitempty"`          // The official grade if the dataset has one, otherwise the computed grade
+	Source        string             `json:"source,omitempty"`         // dataset or computed
+	OfficialGrade string             `json:"official_grade,omitempty"` // Grade published in the dataset
+	OfficialScore *int               `json:"official_score,omitempty"`
+	Computed      *nutriscore.Result `json:"computed,omitempty"` // Local computation with component points
+	Reason        string             `json:"reason,omitempty"`   // Why no score could be computed
+
+	Quality *nutrition.QualityReport `json:"quality,omitempty"`
+}
+
+// CalculateMealRequest represents the arguments of calculate_meal
+type CalculateMealRequest struct {
+	Items       []nutrition.MealItem `json:"items"`
+	DailyValues string               `json:"daily_values,omitempty"`
+}
+
+// qualityFor assesses a product's data quality, or returns nil when it was not found
+func qualityFor(product *types.Product) *nutrition.QualityReport {
+	if product == nil {
+		return nil
+	}
+	report := nutrition.AssessQuality(product)
+	return &report
+}
+
+// withDailyValues adds the optional daily_values parameter selecting a reference intake table
+func withDailyValues() mcp.ToolOption {
+	return mcp.WithString("daily_values",
+		mcp.Description("Also return each nutrient as a percentage of daily reference intakes from this table: "+
+			"us-fda-2016 (US %DV), eu-ri (EU Regulation 1169/2011 reference intakes) or uk-child (UK guideline daily amounts for children aged 5-10). "+
+			"Omit for no percentages."),
+		mcp.Enum(nutrition.ReferenceIDs()...),
+	)
+}
+
+// dailyValuesReference returns the reference table named by the daily_values argument, or nil when it is absent
+func dailyValuesReference(id string) (*nutrition.ReferenceTable, *mcp.CallToolResult) {
+	if id == "" {
+		return nil, nil
+	}
+	table, err := nutrition.LookupReference(id)
+	if err != nil {
+		return nil, mcp.NewToolResultError(err.Error())
+	}
+	return table, nil
+}
+
+// addNutritionTools registers the tools that compute nutrition server-side
+func (s *Server) addNutritionTools() {
+	quantityTool := mcp.NewTool("nutrition_for_quantity",
+		mcp.WithDescription("Compute the nutrients in a given amount of a product: grams, millilitres or a number of servings. "+
+			"Per-100 values from the dataset are scaled server-side; the product's free-text serving size (e.g. \"2 biscuits (25 g)\") "+
+			"is parsed for servings, and the result is flagged as unresolved when the serving size is unknown."),
+		mcp.WithString("barcode",
+			mcp.Required(),
+			mcp.Description("The barcode (UPC/EAN) of the product"),
+		),
+		mcp.WithNumber("quantity",
+			mcp.Required(),
+			mcp.Description("Amount eaten, in the given unit. Must be greater than 0."),
+		),
+		mcp.WithString("unit",
+			mcp.Description("Unit of quantity (default: g)"),
+			mcp.Enum(nutrition.UnitGram, nutrition.UnitMillilitre, nutrition.UnitServing),
+			mcp.DefaultString(nutrition.UnitGram),
+		),
+		mcp.WithOutputSchema[NutritionForQuantityResponse](),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(true),
+	)
+
+	s.mcpServer.AddTool(quantityTool, s.handleNutritionForQuantity)
+
+	mealTool := mcp.NewTool("calculate_meal",
+		mcp.WithDescription("Compute combined nutrient totals for a meal or recipe made of several products. "+
+			"Returns the totals, each item's contribution and which items lacked data. "+
+			"Use this instead of adding up nutrients yourself."),
+		mcp.WithArray("items",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf("Foods in the meal, 1 to %d items. The same barcode may appear more than once.", query.MaxBarcodesPerRequest)),
+			mcp.MinItems(1),
+			mcp.MaxItems(query.MaxBarcodesPerRequest),
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"barcode":  map[string]any{"type": "string", "description": "The barcode (UPC/EAN) of the product"},
+					"quantity": map[string]any{"type": "number", "exclusiveMinimum": 0, "description": "Amount in the given unit"},
+					"unit": map[string]any{
+						"type":        "string",
+						"enum":        []string{nutrition.UnitGram, nutrition.UnitMillilitre, nutrition.UnitServing},
+						"description": "Unit of quantity (default: g)",
+					},
+				},
+				"required": []string{"barcode", "quantity"},
+			}),
+		),
+		withDailyValues(),
+		mcp.WithOutputSchema[nutrition.MealNutrition](),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(true),
+	)
+
+	s.mcpServer.AddTool(mealTool, s.handleCalculateMeal)
+
+	labelTool := mcp.NewTool("nutrition_label",
+		mcp.WithDescription("Render a product's nutrition label: a US-style Nutrition Facts panel or an EU-style nutrition declaration table, "+
+			"as plain text, Markdown or SVG. Amounts follow the FDA or EU rounding rules. "+
+			"Show the rendered label to the user as-is instead of re-formatting the nutrients."),
+		mcp.WithString("barcode",
+			mcp.Required(),
+			mcp.Description("The barcode (UPC/EAN) of the product"),
+		),
+		mcp.WithString("style",
+			mcp.Description("Label style: us (Nutrition Facts with % Daily Value) or eu (nutrition declaration with %RI) (default: us)"),
+			mcp.Enum(label.Styles...),
+			mcp.DefaultString(label.StyleUS),
+		),
+		mcp.WithString("format",
+			mcp.Description("Output format (default: text)"),
+			mcp.Enum(label.Formats...),
+			mcp.DefaultString(label.FormatText),
+		),
+		mcp.WithString("basis",
+			mcp.Description("Describe one serving or 100 g/ml. EU labels always include per 100 g. "+
+				"Falls back to 100 g when the serving size is unknown (default: serving)"),
+			mcp.Enum(label.Bases...),
+			mcp.DefaultString(label.BasisServing),
+		),
+		mcp.WithOutputSchema[NutritionLabelResponse](),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(true),
+	)
+
+	s.mcpServer.AddTool(labelTool, s.handleNutritionLabel)
+
+	nutriScoreTool := mcp.NewTool("nutri_score",
+		mcp.WithDescription("Get a product's Nutri-Score (A to E). Returns the official grade from the dataset when there is one, "+
+			"and also computes the score locally with the 2023 algorithm (general foods, cheese, red meat, fats/oils/nuts/seeds, beverages, water), "+
+			"listing the points of every component. Computed results are flagged with computed: true and are not official grades."),
+		mcp.WithString("barcode",
+			mcp.Required(),
+			mcp.Description("The barcode (UPC/EAN) of the product"),
+		),
+		mcp.WithOutputSchema[NutriScoreResponse](),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(true),
+	)
+
+	s.mcpServer.AddTool(nutriScoreTool, s.handleNutriScore)
+}
+
+func (s *Server) handleNutritionForQuantity(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.log.Debug("handleNutritionForQuantity: Starting tool call",
+		"arguments", request.GetArguments())
+
+	barcode, err := request.RequireString("barcode")
+	if err != nil {
+		s.log.Warn("handleNutritionForQuantity: Missing 'barcode' parameter", "error", err)
+		return mcp.NewToolResultError(fmt.Sprintf("Missing required parameter 'barcode': %v", err)), nil
+	}
+
+	quantity, err := request.RequireFloat("quantity")
+	if err != nil {
+		s.log.Warn("handleNutritionForQuantity: Missing 'quantity' parameter", "error", err)
+		return mcp.NewToolResultError(fmt.Sprintf("Missing required parameter 'quantity': %v", err)), nil
+	}
+
+	unit := request.GetString("unit", nutrition.UnitGram)
+
+	product, err := s.queryEngine.SearchByBarcode(ctx, barcode)
+	if err != nil {
+		s.log.Error("Barcode search failed", "error", err)
+		return s.queryErrorResult("Barcode search failed", err), nil
+	}
+
+	response := NutritionForQuantityResponse{Found: product != nil, Quality: qualityFor(product)}
+	if product != nil {
+		response.Nutrition, err = nutrition.ForQuantity(product, quantity, unit)
+		if err != nil {
+			if errors.Is(err, nutrition.ErrInvalidQuantity) {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			s.log.Error("Nutrition calculation failed", "error", err)
+			return mcp.NewToolResultError(fmt.Sprintf("Nutrition calculation failed: %v", err)), nil
+		}
+	}
+
+	// Create fallback text for backwards compatibility
+	responseJSON, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		s.log.Error("handleNutritionForQuantity: Failed to marshal response", "error", err)
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal response: %v", err)), nil
+	}
+
+	s.log.Debug("handleNutritionForQuantity: Returning structured result",
+		"found", response.Found,
+		"response_size", len(responseJSON))
+
+	return mcp.NewToolResultStructured(response, string(responseJSON)), nil
+}
+
+func (s *Server) handleCalculateMeal(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.log.Debug("handleCalculateMeal: Starting tool call",
+		"arguments", request.GetArguments())
+
+	var mealRequest CalculateMealRequest
+	if err := request.BindArguments(&mealRequest); err != nil {
+		s.log.Warn("handleCalculateMeal: Invalid arguments", "error", err)
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid arguments: %v", err)), nil
+	}
+	if len(mealRequest.Items) == 0 {
+		return mcp.NewToolResultError("Parameter 'items' must contain at least one item"), nil
+	}
+	if len(mealRequest.Items) > query.MaxBarcodesPerRequest {
+		return mcp.NewToolResultError(fmt.Sprintf("Parameter 'items' accepts at most %d items", query.MaxBarcodesPerRequest)), nil
+	}
+	reference, errResult := dailyValuesReference(mealRequest.DailyValues)
+	if errResult != nil {
+		return errResult, nil
+	}
+
+	products, err := s.queryEngine.GetProductsByBarcodes(ctx, nutrition.MealBarcodes(mealRequest.Items))
+	if err != nil {
+		s.log.Error("Meal product lookup failed", "error", err)
+		return s.queryErrorResult("Meal product lookup failed", err), nil
+	}
+
+	byBarcode := make(map[string]*types.Product, len(products))
+	for i := range products {
+		byBarcode[products[i].Code] = &products[i]
+	}
+
+	response := nutrition.CalculateMeal(mealRequest.Items, byBarcode)
+	if reference != nil {
+		dailyValues := nutrition.DailyValuesForMeal(response, reference)
+		response.DailyValues = &dailyValues
+	}
+
+	// Create fallback text for backwards compatibility
+	responseJSON, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		s.log.Error("handleCalculateMeal: Failed to marshal response", "error", err)
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal response: %v", err)), nil
+	}
+
+	s.log.Debug("handleCalculateMeal: Returning structured result",
+		"items", response.ItemCount,
+		"counted", response.CountedItems,
+		"response_size", len(responseJSON))
+
+	return mcp.NewToolResultStructured(response, string(responseJSON)), nil
+}
+
+func (s *Server) handleNutritionLabel(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.log.Debug("handleNutritionLabel: Starting tool call",
+		"arguments", request.GetArguments())
+
+	barcode, err := request.RequireString("barcode")
+	if err != nil {
+		s.log.Warn("handleNutritionLabel: Missing 'barcode' parameter", "error", err)
+		return mcp.NewToolResultError(fmt.Sprintf("Missing required parameter 'barcode': %v", err)), nil
+	}
+
+	options := label.Options{
+		Style: request.GetString("style", label.StyleUS),
+		Basis: request.GetString("basis", label.BasisServing),
+	}
+	format := request.GetString("format", label.FormatText)
+
+	product, err := s.queryEngine.SearchByBarcode(ctx, barcode)
+	if err != nil {
+		s.log.Error("Barcode search failed", "error", err)
+		return s.queryErrorResult("Barcode search failed", err), nil
+	}
+
+	response := NutritionLabelResponse{Found: product != nil, Quality: qualityFor(product)}
+	if product != nil {
+		response.Label, err = label.Build(product, options)
+		if err == nil {
+			response.Format = format
+			response.Rendered, err = response.Label.Render(format)
+		}
+		if err != nil {
+			if errors.Is(err, label.ErrInvalidOption) {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			s.log.Error("Nutrition label rendering failed", "error", err)
+			return mcp.NewToolResultError(fmt.Sprintf("Nutrition label rendering failed: %v", err)), nil
+		}
+	}
+
+	responseJSON, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		s.log.Error("handleNutritionLabel: Failed to marshal response", "error", err)
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal response: %v", err)), nil
+	}
+
+	s.log.Debug("handleNutritionLabel: Returning structured result",
+		"found", response.Found,
+		"format", response.Format,
+		"response_size", len(responseJSON))
+
+	// The text content is the label itself so chat clients can display it directly
+	fallback := string(responseJSON)
+	if response.Found {
+		fallback = response.Rendered
+	}
+	return mcp.NewToolResultStructured(response, fallback), nil
+}
+
+func (s *Server) handleNutriScore(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.log.Debug("handleNutriScore: Starting tool call",
+		"arguments", request.GetArguments())
+
+	barcode, err := request.RequireString("barcode")
+	if err != nil {
+		s.log.Warn("handleNutriScore: Missing 'barcode' parameter", "error", err)
+		return mcp.NewToolResultError(fmt.Sprintf("Missing required parameter 'barcode': %v", err)), nil
+	}
+
+	product, err := s.queryEngine.SearchByBarcode(ctx, barcode)
+	if err != nil {
+		s.log.Error("Barcode search failed", "error", err)
+		return s.queryErrorResult("Barcode search failed", err), nil
+	}
+
+	response := NutriScoreResponse{Found: product != nil, Quality: qualityFor(product)}
+	if product != nil {
+		if grade := strings.ToLower(strings.TrimSpace(product.NutriscoreGrade)); len(grade) == 1 && grade >= "a" && grade <= "e" {
+			response.OfficialGrade = grade
+			response.OfficialScore = product.NutriscoreScore
+			response.Grade, response.Source = grade, NutriScoreSourceDataset
+		}
+
+		response.Computed, err = nutriscore.ForProduct(product)
+		switch {
+		case err == nil:
+			if response.Source == "" {
+				response.Grade, response.Source = response.Computed.Grade, NutriScoreSourceComputed
+			}
+		case errors.Is(err, nutriscore.ErrInsufficientData), errors.Is(err, nutriscore.ErrNotApplicable):
+			response.Reason = err.Error()
+		default:
+			s.log.Error("Nutri-Score computation failed", "error", err)
+			return mcp.NewToolResultError(fmt.Sprintf("Nutri-Score computation failed: %v", err)), nil
+		}
+	}
+
+	// Create fallback text for backwards compatibility
+	responseJSON, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		s.log.Error("handleNutriScore: Failed to marshal response", "error", err)
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal response: %v", err)), nil
+	}
+
+	s.log.Debug("handleNutriScore: Returning structured result",
+		"found", response.Found,
+		"grade", response.Grade,
+		"source", response.Source,
+		"response_size", len(responseJSON))
+
+	return mcp.NewToolResultStructured(response, string(responseJSON)), nil
+}
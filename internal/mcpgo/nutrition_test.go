@@ -0,0 +1,403 @@
+package mcpgo
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/auth"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/config"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/nutrition"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/query"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_NutritionForQuantityTool(t *testing.T) {
+	tests := []struct {
+		name             string
+		arguments        map[string]interface{}
+		expectError      bool
+		expectFound      bool
+		expectResolved   bool
+		expectedAmount   float64
+		expectedNutrient float64
+	}{
+		{
+			name:             "grams",
+			arguments:        map[string]interface{}{"barcode": "3017620422003", "quantity": 50.0, "unit": "g"},
+			expectFound:      true,
+			expectResolved:   true,
+			expectedAmount:   50,
+			expectedNutrient: 28.15,
+		},
+		{
+			name:             "servings parsed from serving size",
+			arguments:        map[string]interface{}{"barcode": "3017620422003", "quantity": 2.0, "unit": "serving"},
+			expectFound:      true,
+			expectResolved:   true,
+			expectedAmount:   30,
+			expectedNutrient: 16.89,
+		},
+		{
+			name:           "unit defaults to grams",
+			arguments:      map[string]interface{}{"barcode": "1234567890123", "quantity": 10.0},
+			expectFound:    true,
+			expectResolved: true,
+			expectedAmount: 10,
+		},
+		{
+			name:        "unresolved serving is flagged",
+			arguments:   map[string]interface{}{"barcode": "1234567890123", "quantity": 1.0, "unit": "serving"},
+			expectFound: true,
+		},
+		{
+			name:      "product not found",
+			arguments: map[string]interface{}{"barcode": "404", "quantity": 1.0},
+		},
+		{
+			name:        "invalid quantity",
+			arguments:   map[string]interface{}{"barcode": "3017620422003", "quantity": -1.0},
+			expectError: true,
+		},
+		{
+			name:        "missing quantity",
+			arguments:   map[string]interface{}{"barcode": "3017620422003"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := config.NewTestLogger(io.Discard, "debug")
+			server := NewServer(query.NewMockEngine(logger), auth.NewBearerTokenAuth("test-token"), &config.Config{}, logger)
+
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tt.arguments
+
+			result, err := server.handleNutritionForQuantity(context.Background(), request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectError, result.IsError)
+			if tt.expectError {
+				return
+			}
+
+			response, ok := result.StructuredContent.(NutritionForQuantityResponse)
+			require.True(t, ok)
+			assert.Equal(t, tt.expectFound, response.Found)
+			if !tt.expectFound {
+				assert.Nil(t, response.Nutrition)
+				return
+			}
+
+			require.NotNil(t, response.Nutrition)
+			assert.Equal(t, tt.expectResolved, response.Nutrition.Resolved)
+			assert.Equal(t, tt.expectedAmount, response.Nutrition.Amount)
+			if tt.expectedNutrient > 0 {
+				assert.Equal(t, tt.expectedNutrient, response.Nutrition.Nutrients["sugars"].Amount)
+			}
+		})
+	}
+}
+
+func TestServer_CalculateMealTool(t *testing.T) {
+	tests := []struct {
+		name               string
+		arguments          map[string]interface{}
+		expectError        bool
+		expectedCounted    int
+		expectedIncomplete int
+	}{
+		{
+			name: "mixed items",
+			arguments: map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"barcode": "3017620422003", "quantity": 1.0, "unit": "serving"},
+				map[string]interface{}{"barcode": "1234567890123", "quantity": 20.0},
+				map[string]interface{}{"barcode": "404", "quantity": 20.0},
+			}},
+			expectedCounted:    2,
+			expectedIncomplete: 2,
+		},
+		{
+			name:        "no items",
+			arguments:   map[string]interface{}{"items": []interface{}{}},
+			expectError: true,
+		},
+		{
+			name:        "malformed items",
+			arguments:   map[string]interface{}{"items": "nutella"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := config.NewTestLogger(io.Discard, "debug")
+			server := NewServer(query.NewMockEngine(logger), auth.NewBearerTokenAuth("test-token"), &config.Config{}, logger)
+
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tt.arguments
+
+			result, err := server.handleCalculateMeal(context.Background(), request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectError, result.IsError)
+			if tt.expectError {
+				return
+			}
+
+			response, ok := result.StructuredContent.(nutrition.MealNutrition)
+			require.True(t, ok)
+			assert.Equal(t, tt.expectedCounted, response.CountedItems)
+			assert.Len(t, response.Incomplete, tt.expectedIncomplete)
+			assert.Equal(t, 15.0*2255/100+20.0*2000/100, response.Totals["energy"].Amount)
+		})
+	}
+}
+
+func TestServer_DailyValues(t *testing.T) {
+	logger := config.NewTestLogger(io.Discard, "debug")
+	server := NewServer(query.NewMockEngine(logger), auth.NewBearerTokenAuth("test-token"), &config.Config{}, logger)
+
+	t.Run("barcode", func(t *testing.T) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"barcode": "3017620422003", "daily_values": "eu-ri"}
+
+		result, err := server.handleSearchByBarcode(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+
+		response, ok := result.StructuredContent.(SearchBarcodeResponse)
+		require.True(t, ok)
+		require.NotNil(t, response.DailyValues)
+		assert.Equal(t, 62.6, response.DailyValues.Per100.Nutrients["sugars"].Percent)
+		require.NotNil(t, response.DailyValues.PerServing)
+		assert.Equal(t, 9.4, response.DailyValues.PerServing.Nutrients["sugars"].Percent)
+	})
+
+	t.Run("barcode without daily values", func(t *testing.T) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"barcode": "3017620422003"}
+
+		result, err := server.handleSearchByBarcode(context.Background(), request)
+		require.NoError(t, err)
+
+		response, ok := result.StructuredContent.(SearchBarcodeResponse)
+		require.True(t, ok)
+		assert.Nil(t, response.DailyValues)
+	})
+
+	t.Run("simplified search", func(t *testing.T) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"name": "Nutella", "brand": "Ferrero", "daily_values": "us-fda-2016"}
+
+		result, err := server.handleSearchProductsSimplified(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+
+		response, ok := result.StructuredContent.(SearchProductsSimplifiedResponse)
+		require.True(t, ok)
+		require.Len(t, response.DailyValues, response.Count)
+		assert.Equal(t, "3017620422003", response.DailyValues[0].Code)
+		assert.Equal(t, nutrition.ReferenceUSFDA2016, response.DailyValues[0].Per100.Reference)
+	})
+
+	t.Run("meal", func(t *testing.T) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{
+			"items":        []interface{}{map[string]interface{}{"barcode": "3017620422003", "quantity": 100.0}},
+			"daily_values": "uk-child",
+		}
+
+		result, err := server.handleCalculateMeal(context.Background(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+
+		response, ok := result.StructuredContent.(nutrition.MealNutrition)
+		require.True(t, ok)
+		require.NotNil(t, response.DailyValues)
+		assert.Equal(t, nutrition.BasisMeal, response.DailyValues.Basis)
+		assert.Equal(t, 66.2, response.DailyValues.Nutrients["sugars"].Percent)
+	})
+
+	t.Run("unknown reference", func(t *testing.T) {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"barcode": "3017620422003", "daily_values": "mars"}
+
+		result, err := server.handleSearchByBarcode(context.Background(), request)
+		require.NoError(t, err)
+		assert.True(t, result.IsError)
+	})
+}
+
+func TestServer_NutritionLabelTool(t *testing.T) {
+	tests := []struct {
+		name           string
+		arguments      map[string]interface{}
+		expectError    bool
+		expectFound    bool
+		expectedPrefix string
+		expectedText   string
+	}{
+		{
+			name:           "defaults to a US text panel per serving",
+			arguments:      map[string]interface{}{"barcode": "3017620422003"},
+			expectFound:    true,
+			expectedPrefix: "Nutrition Facts\nNutella\n",
+			expectedText:   "Serving size 15 g",
+		},
+		{
+			name:           "EU markdown",
+			arguments:      map[string]interface{}{"barcode": "3017620422003", "style": "eu", "format": "markdown", "basis": "100g"},
+			expectFound:    true,
+			expectedPrefix: "### Nutrition declaration",
+			expectedText:   "of which sugars | 56 g | 63% |",
+		},
+		{
+			name:           "SVG",
+			arguments:      map[string]interface{}{"barcode": "3017620422003", "format": "svg"},
+			expectFound:    true,
+			expectedPrefix: "<svg",
+			expectedText:   "Total Fat",
+		},
+		{
+			name:      "product not found",
+			arguments: map[string]interface{}{"barcode": "404"},
+		},
+		{
+			name:        "invalid format",
+			arguments:   map[string]interface{}{"barcode": "3017620422003", "format": "pdf"},
+			expectError: true,
+		},
+		{
+			name:        "missing barcode",
+			arguments:   map[string]interface{}{},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := config.NewTestLogger(io.Discard, "debug")
+			server := NewServer(query.NewMockEngine(logger), auth.NewBearerTokenAuth("test-token"), &config.Config{}, logger)
+
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tt.arguments
+
+			result, err := server.handleNutritionLabel(context.Background(), request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectError, result.IsError)
+			if tt.expectError {
+				return
+			}
+
+			response, ok := result.StructuredContent.(NutritionLabelResponse)
+			require.True(t, ok)
+			assert.Equal(t, tt.expectFound, response.Found)
+			if !tt.expectFound {
+				assert.Nil(t, response.Label)
+				return
+			}
+
+			assert.True(t, strings.HasPrefix(response.Rendered, tt.expectedPrefix), response.Rendered)
+			assert.Contains(t, response.Rendered, tt.expectedText)
+
+			// The text content is the rendered label rather than JSON
+			require.Len(t, result.Content, 1)
+			text, ok := result.Content[0].(mcp.TextContent)
+			require.True(t, ok)
+			assert.Equal(t, response.Rendered, text.Text)
+		})
+	}
+}
+
+func TestServer_NutriScoreTool(t *testing.T) {
+	official := 26
+
+	tests := []struct {
+		name           string
+		products       []types.Product
+		barcode        string
+		expectFound    bool
+		expectedGrade  string
+		expectedSource string
+		expectComputed bool
+		expectReason   bool
+	}{
+		{
+			name:           "computed when the dataset has no grade",
+			barcode:        "3017620422003",
+			expectFound:    true,
+			expectedGrade:  "e",
+			expectedSource: NutriScoreSourceComputed,
+			expectComputed: true,
+		},
+		{
+			name: "official grade preferred",
+			products: []types.Product{{
+				Code:            "3017620422003",
+				NutriscoreGrade: "E",
+				NutriscoreScore: &official,
+				Nutriments:      map[string]interface{}{"energy": 2255.0, "sugars": 56.3, "saturated-fat": 10.6, "salt": 0.107, "proteins": 6.3},
+			}},
+			barcode:        "3017620422003",
+			expectFound:    true,
+			expectedGrade:  "e",
+			expectedSource: NutriScoreSourceDataset,
+			expectComputed: true,
+		},
+		{
+			name:         "insufficient data",
+			barcode:      "1234567890123",
+			expectFound:  true,
+			expectReason: true,
+		},
+		{
+			name: "unknown official grade is ignored",
+			products: []types.Product{{
+				Code:            "42",
+				NutriscoreGrade: "unknown",
+				CategoriesTags:  []string{"en:beverages", "en:beers", "en:alcoholic-beverages"},
+			}},
+			barcode:      "42",
+			expectFound:  true,
+			expectReason: true,
+		},
+		{
+			name:    "product not found",
+			barcode: "404",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := config.NewTestLogger(io.Discard, "debug")
+			engine := query.NewMockEngine(logger)
+			if tt.products != nil {
+				engine.SetProducts(tt.products)
+			}
+			server := NewServer(engine, auth.NewBearerTokenAuth("test-token"), &config.Config{}, logger)
+
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = map[string]interface{}{"barcode": tt.barcode}
+
+			result, err := server.handleNutriScore(context.Background(), request)
+			require.NoError(t, err)
+			require.False(t, result.IsError)
+
+			response, ok := result.StructuredContent.(NutriScoreResponse)
+			require.True(t, ok)
+			assert.Equal(t, tt.expectFound, response.Found)
+			assert.Equal(t, tt.expectedGrade, response.Grade)
+			assert.Equal(t, tt.expectedSource, response.Source)
+			assert.Equal(t, tt.expectComputed, response.Computed != nil)
+			assert.Equal(t, tt.expectReason, response.Reason != "")
+			if response.Computed != nil {
+				assert.True(t, response.Computed.Computed)
+				assert.Equal(t, 31, response.Computed.Score)
+			}
+		})
+	}
+}
@@ -3,15 +3,23 @@ package mcpgo
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/noot-app/openfoodfacts-mcp-server/internal/auth"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/config"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/diet"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/nutrition"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/packaging"
 	"github.com/noot-app/openfoodfacts-mcp-server/internal/query"
 	"github.com/noot-app/openfoodfacts-mcp-server/internal/types"
 )
@@ -22,14 +30,25 @@ type responseRecorder struct {
 	statusCode    int
 	bytesWritten  int
 	headerWritten bool
+	busy          *busySignal // Set when a tool call of this request was shed
+	discard       bool        // The response was replaced by a 503
 }
 
 func (r *responseRecorder) WriteHeader(code int) {
 	if r.headerWritten {
 		return // Prevent duplicate WriteHeader calls
 	}
-	r.statusCode = code
 	r.headerWritten = true
+
+	// A shed tool call replaces the JSON-RPC response with the same 503 as an up-front shed
+	if retryAfterMs, busy := r.busy.retryAfter(); busy && code == http.StatusOK {
+		r.statusCode = http.StatusServiceUnavailable
+		r.discard = true
+		writeBusy(r.ResponseWriter, retryAfterMs)
+		return
+	}
+
+	r.statusCode = code
 	r.ResponseWriter.WriteHeader(code)
 }
 
@@ -37,17 +56,66 @@ func (r *responseRecorder) Write(data []byte) (int, error) {
 	if !r.headerWritten {
 		r.WriteHeader(http.StatusOK)
 	}
+	if r.discard {
+		return len(data), nil
+	}
 	n, err := r.ResponseWriter.Write(data)
 	r.bytesWritten += n
 	return n, err
 }
 
+// busySignalKey is the context key of the busySignal of an HTTP request
+type busySignalKey struct{}
+
+// busySignal records that a tool call made during an HTTP request was shed by admission control
+type busySignal struct {
+	mu           sync.Mutex
+	busy         bool
+	retryAfterMs int64
+}
+
+// set marks the request as shed, keeping the longest retry hint
+func (b *busySignal) set(retryAfterMs int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.busy = true
+	if retryAfterMs > b.retryAfterMs {
+		b.retryAfterMs = retryAfterMs
+	}
+}
+
+// retryAfter returns the retry hint and whether a tool call was shed; a nil signal was never shed
+func (b *busySignal) retryAfter() (int64, bool) {
+	if b == nil {
+		return 0, false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.retryAfterMs, b.busy
+}
+
+// signalBusy is a tool middleware that reports shed tool calls to the HTTP transport
+// Over stdio there is no signal in the context and the busy tool result is returned as is
+func signalBusy(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result, err := next(ctx, request)
+		if signal, ok := ctx.Value(busySignalKey{}).(*busySignal); ok && result != nil && result.IsError {
+			if busy, ok := result.StructuredContent.(BusyResponse); ok {
+				signal.set(busy.RetryAfterMs)
+			}
+		}
+		return result, err
+	}
+}
+
 // Server wraps the mark3labs MCP server with authentication
 type Server struct {
 	mcpServer   *server.MCPServer
 	queryEngine query.QueryEngine
 	auth        *auth.BearerTokenAuth
+	config      *config.Config
 	log         *slog.Logger
+	diets       *diet.Registry // Diet profiles for check_diet, nil if they failed to load
 
 	// Health check caching to prevent DOS attacks
 	healthMu        sync.RWMutex
@@ -57,26 +125,61 @@ type Server struct {
 
 // SearchProductsResponse represents the response from search_products_by_brand_and_name
 type SearchProductsResponse struct {
-	Found    bool            `json:"found"`
-	Count    int             `json:"count"`
-	Products []types.Product `json:"products"`
+	Found    bool                      `json:"found"`
+	Count    int                       `json:"count"`
+	Products []types.Product           `json:"products"`
+	Quality  []nutrition.QualityReport `json:"quality"` // Data quality of each product, in the same order
+}
+
+// QueryProductsResponse represents the response from query_products
+type QueryProductsResponse struct {
+	Found    bool                      `json:"found"`
+	Count    int                       `json:"count"`
+	Products []types.Product           `json:"products"`
+	Quality  []nutrition.QualityReport `json:"quality"` // Data quality of each product, in the same order
 }
 
 // SearchBarcodeResponse represents the response from search_by_barcode
 type SearchBarcodeResponse struct {
-	Found   bool           `json:"found"`
-	Product *types.Product `json:"product,omitempty"`
+	Found       bool                          `json:"found"`
+	Product     *types.Product                `json:"product,omitempty"`
+	DailyValues *nutrition.ProductDailyValues `json:"daily_values,omitempty"`
+	Quality     *nutrition.QualityReport      `json:"quality,omitempty"`
 }
 
 // SearchProductsSimplifiedResponse represents the simplified response from search_products_by_brand_and_name_simplified
 type SearchProductsSimplifiedResponse struct {
-	Found    bool                      `json:"found"`
-	Count    int                       `json:"count"`
-	Products []types.SimplifiedProduct `json:"products"`
+	Found       bool                           `json:"found"`
+	Count       int                            `json:"count"`
+	Products    []types.SimplifiedProduct      `json:"products"`
+	DailyValues []nutrition.ProductDailyValues `json:"daily_values,omitempty"`
+	Quality     []nutrition.QualityReport      `json:"quality"` // Data quality of each product, in the same order
+}
+
+// PackagingSummaryResponse represents the response from packaging_summary
+type PackagingSummaryResponse struct {
+	packaging.Summary
+	Quality []nutrition.QualityReport `json:"quality"` // Data quality of each product found
+}
+
+// BusyResponse is returned when a request is shed by query admission control
+type BusyResponse struct {
+	Error        string `json:"error"`
+	Message      string `json:"message"`
+	RetryAfterMs int64  `json:"retry_after_ms"`
+}
+
+// newBusyResponse builds the busy payload shared by MCP tool results and HTTP 503 responses
+func newBusyResponse(retryAfterMs int64) BusyResponse {
+	return BusyResponse{
+		Error:        "server_busy",
+		Message:      fmt.Sprintf("Server busy, retry after %d ms", retryAfterMs),
+		RetryAfterMs: retryAfterMs,
+	}
 }
 
 // NewServer creates a new MCP server with the mark3labs SDK
-func NewServer(queryEngine query.QueryEngine, authenticator *auth.BearerTokenAuth, logger *slog.Logger) *Server {
+func NewServer(queryEngine query.QueryEngine, authenticator *auth.BearerTokenAuth, cfg *config.Config, logger *slog.Logger) *Server {
 	// Create MCP server
 	mcpServer := server.NewMCPServer(
 		"OpenFoodFacts MCP Server",
@@ -84,15 +187,20 @@ func NewServer(queryEngine query.QueryEngine, authenticator *auth.BearerTokenAut
 		server.WithToolCapabilities(false), // Tools don't change dynamically
 		server.WithRecovery(),              // Recover from panics
 		server.WithLogging(),               // Enable logging
+		server.WithToolHandlerMiddleware(signalBusy),
 	)
 
 	s := &Server{
 		mcpServer:   mcpServer,
 		queryEngine: queryEngine,
 		auth:        authenticator,
+		config:      cfg,
 		log:         logger,
 	}
 
+	// Load diet profiles before registering check_diet, which lists their IDs
+	s.diets = loadDietProfiles(cfg.DietProfilesDir, logger)
+
 	// Add tools
 	s.addTools()
 
@@ -135,6 +243,33 @@ func (s *Server) checkHealthWithCache(ctx context.Context) error {
 	return err
 }
 
+// queryErrorResult converts a query engine error into a tool result
+// Over-capacity errors become a structured "server busy" result so clients can back off and retry
+func (s *Server) queryErrorResult(prefix string, err error) *mcp.CallToolResult {
+	var busyErr *query.BusyError
+	if !errors.As(err, &busyErr) {
+		return mcp.NewToolResultError(fmt.Sprintf("%s: %v", prefix, err))
+	}
+
+	response := newBusyResponse(busyErr.RetryAfter.Milliseconds())
+	result := mcp.NewToolResultStructured(response, response.Message)
+	result.IsError = true
+	return result
+}
+
+// writeBusy writes a 503 response with a Retry-After header (in whole seconds, rounded up)
+func writeBusy(w http.ResponseWriter, retryAfterMs int64) {
+	retryAfterSeconds := int64(math.Ceil(float64(retryAfterMs) / 1000))
+	if retryAfterSeconds < 1 {
+		retryAfterSeconds = 1
+	}
+
+	w.Header().Set("Retry-After", strconv.FormatInt(retryAfterSeconds, 10))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	json.NewEncoder(w).Encode(newBusyResponse(retryAfterMs))
+}
+
 func (s *Server) addTools() {
 	// Search products by brand and name tool
 	searchTool := mcp.NewTool("search_products_by_brand_and_name",
@@ -168,6 +303,7 @@ func (s *Server) addTools() {
 			mcp.Required(),
 			mcp.Description("The barcode (UPC/EAN) to search for"),
 		),
+		withDailyValues(),
 		mcp.WithOutputSchema[SearchBarcodeResponse](),
 		mcp.WithIdempotentHintAnnotation(true),
 	)
@@ -193,11 +329,103 @@ func (s *Server) addTools() {
 			mcp.Min(1),
 			mcp.Max(10),
 		),
+		withDailyValues(),
 		mcp.WithOutputSchema[SearchProductsSimplifiedResponse](),
 		mcp.WithIdempotentHintAnnotation(true),
 	)
 
 	s.mcpServer.AddTool(searchSimplifiedTool, s.handleSearchProductsSimplified)
+
+	// Structured filter query tool
+	queryTool := mcp.NewTool("query_products",
+		mcp.WithDescription("Find products with a structured filter, e.g. products in en:breakfast-cereals sold in en:france "+
+			"with nutriments.sugars below 5 and nutriscore_grade a or b. Filters combine with and/or/not; "+
+			"each comparison names a field, an operator and a value."),
+		mcp.WithObject("where",
+			mcp.Description("Filter to apply. Omit to match all products."),
+			filterRef,
+		),
+		mcp.WithArray("sort",
+			mcp.Description("Sort order, applied in sequence. Tag fields cannot be sorted on. Defaults to code."),
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"field": map[string]any{"type": "string", "description": "Text, numeric or nutriments.<nutrient> field"},
+					"desc":  map[string]any{"type": "boolean", "description": "Sort descending"},
+				},
+				"required": []string{"field"},
+			}),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("Maximum number of results (default: %d, max: %d)", query.DefaultProductQueryLimit, query.MaxProductQueryLimit)),
+			mcp.DefaultNumber(query.DefaultProductQueryLimit),
+			mcp.Min(1),
+			mcp.Max(query.MaxProductQueryLimit),
+		),
+		withSchemaDefs(query.FilterSchemaDefs()),
+		mcp.WithOutputSchema[QueryProductsResponse](),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(true),
+	)
+
+	s.mcpServer.AddTool(queryTool, s.handleQueryProducts)
+
+	// Packaging and recyclability summary tool
+	packagingTool := mcp.NewTool("packaging_summary",
+		mcp.WithDescription("Summarize packaging components (material, shape, recycling instruction, weight) for one or more products. "+
+			"Aggregates units, weight and recyclability by material and by material family (plastic, glass, metal, paper-or-cardboard, wood)."),
+		mcp.WithArray("barcodes",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf("Barcodes (UPC/EAN) to summarize, 1 to %d", query.MaxBarcodesPerRequest)),
+			mcp.WithStringItems(mcp.MinLength(1)),
+			mcp.MinItems(1),
+			mcp.MaxItems(query.MaxBarcodesPerRequest),
+		),
+		mcp.WithOutputSchema[PackagingSummaryResponse](),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(true),
+	)
+
+	s.mcpServer.AddTool(packagingTool, s.handlePackagingSummary)
+
+	s.addNutritionTools()
+	s.addDietTools()
+	s.addHistoryTools()
+
+	// Read-only SQL tool for power users, only registered when explicitly enabled
+	if s.config.EnableSQLTool {
+		sqlTool := mcp.NewTool("run_sql",
+			mcp.WithDescription(fmt.Sprintf("Run a single read-only DuckDB SELECT statement against the `%s` view. "+
+				"Columns: code, product_name, brands, categories_tags, countries_tags, labels_tags, allergens_tags, "+
+				"nutriscore_grade, nutriscore_score, nova_group, nutriments (list of {name, value, 100g, serving, unit}), "+
+				"serving_size, serving_quantity, quantity, link, last_modified_t. "+
+				"Only SELECT/WITH statements are accepted; results are limited to %d rows and %d seconds.",
+				query.SQLToolViewName, s.config.SQLToolRowLimit, s.config.SQLToolTimeoutSeconds)),
+			mcp.WithString("sql",
+				mcp.Required(),
+				mcp.MinLength(1),
+				mcp.Description("A single SELECT statement, e.g. SELECT nutriscore_grade, count(*) FROM products GROUP BY 1"),
+			),
+			mcp.WithOutputSchema[query.SQLResult](),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithIdempotentHintAnnotation(true),
+		)
+
+		s.mcpServer.AddTool(sqlTool, s.handleRunSQL)
+	}
+}
+
+// filterRef points an object parameter at the shared recursive filter definition
+func filterRef(schema map[string]any) {
+	delete(schema, "properties")
+	schema["$ref"] = "#/$defs/filter"
+}
+
+// withSchemaDefs adds shared $defs to a tool's input schema
+func withSchemaDefs(defs map[string]any) mcp.ToolOption {
+	return func(t *mcp.Tool) {
+		t.InputSchema.Defs = defs
+	}
 }
 
 func (s *Server) handleSearchProducts(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -245,7 +473,7 @@ func (s *Server) handleSearchProducts(ctx context.Context, request mcp.CallToolR
 	products, err := s.queryEngine.SearchProductsByBrandAndName(ctx, name, brand, limit)
 	if err != nil {
 		s.log.Error("Product search failed", "error", err)
-		return mcp.NewToolResultError(fmt.Sprintf("Search failed: %v", err)), nil
+		return s.queryErrorResult("Search failed", err), nil
 	}
 
 	// Prepare structured response
@@ -253,6 +481,7 @@ func (s *Server) handleSearchProducts(ctx context.Context, request mcp.CallToolR
 		Found:    len(products) > 0,
 		Count:    len(products),
 		Products: products,
+		Quality:  nutrition.AssessQualityAll(products),
 	}
 
 	// Create fallback text for backwards compatibility
@@ -307,6 +536,11 @@ func (s *Server) handleSearchProductsSimplified(ctx context.Context, request mcp
 		limit = 10
 	}
 
+	reference, errResult := dailyValuesReference(request.GetString("daily_values", ""))
+	if errResult != nil {
+		return errResult, nil
+	}
+
 	s.log.Debug("MCP SearchProductsByBrandAndNameSimplified called",
 		"name", name,
 		"brand", brand,
@@ -316,7 +550,7 @@ func (s *Server) handleSearchProductsSimplified(ctx context.Context, request mcp
 	products, err := s.queryEngine.SearchProductsByBrandAndName(ctx, name, brand, limit)
 	if err != nil {
 		s.log.Error("Product search failed", "error", err)
-		return mcp.NewToolResultError(fmt.Sprintf("Search failed: %v", err)), nil
+		return s.queryErrorResult("Search failed", err), nil
 	}
 
 	// Convert to simplified products
@@ -330,6 +564,13 @@ func (s *Server) handleSearchProductsSimplified(ctx context.Context, request mcp
 		Found:    len(simplifiedProducts) > 0,
 		Count:    len(simplifiedProducts),
 		Products: simplifiedProducts,
+		Quality:  nutrition.AssessQualityAll(products),
+	}
+	if reference != nil {
+		response.DailyValues = make([]nutrition.ProductDailyValues, 0, len(products))
+		for i := range products {
+			response.DailyValues = append(response.DailyValues, nutrition.DailyValuesForProduct(&products[i], reference))
+		}
 	}
 
 	// Create fallback text for backwards compatibility
@@ -359,19 +600,29 @@ func (s *Server) handleSearchByBarcode(ctx context.Context, request mcp.CallTool
 		return mcp.NewToolResultError(fmt.Sprintf("Missing required parameter 'barcode': %v", err)), nil
 	}
 
+	reference, errResult := dailyValuesReference(request.GetString("daily_values", ""))
+	if errResult != nil {
+		return errResult, nil
+	}
+
 	s.log.Debug("MCP SearchByBarcode called", "barcode", barcode)
 
 	// Execute search
 	product, err := s.queryEngine.SearchByBarcode(ctx, barcode)
 	if err != nil {
 		s.log.Error("Barcode search failed", "error", err)
-		return mcp.NewToolResultError(fmt.Sprintf("Barcode search failed: %v", err)), nil
+		return s.queryErrorResult("Barcode search failed", err), nil
 	}
 
 	// Prepare structured response
 	response := SearchBarcodeResponse{
 		Found:   product != nil,
 		Product: product,
+		Quality: qualityFor(product),
+	}
+	if product != nil && reference != nil {
+		dailyValues := nutrition.DailyValuesForProduct(product, reference)
+		response.DailyValues = &dailyValues
 	}
 
 	// Create fallback text for backwards compatibility
@@ -390,8 +641,150 @@ func (s *Server) handleSearchByBarcode(ctx context.Context, request mcp.CallTool
 	return mcp.NewToolResultStructured(response, string(responseJSON)), nil
 }
 
+func (s *Server) handleQueryProducts(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.log.Debug("handleQueryProducts: Starting tool call",
+		"arguments", request.GetArguments())
+
+	var productQuery query.ProductQuery
+	if err := request.BindArguments(&productQuery); err != nil {
+		s.log.Warn("handleQueryProducts: Invalid arguments", "error", err)
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid arguments: %v", err)), nil
+	}
+
+	products, err := s.queryEngine.QueryProducts(ctx, productQuery)
+	if err != nil {
+		if errors.Is(err, query.ErrInvalidFilter) {
+			s.log.Warn("handleQueryProducts: Filter rejected", "error", err)
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		s.log.Error("Product query failed", "error", err)
+		return s.queryErrorResult("Query failed", err), nil
+	}
+
+	response := QueryProductsResponse{
+		Found:    len(products) > 0,
+		Count:    len(products),
+		Products: products,
+		Quality:  nutrition.AssessQualityAll(products),
+	}
+
+	// Create fallback text for backwards compatibility
+	responseJSON, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		s.log.Error("handleQueryProducts: Failed to marshal response", "error", err)
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal response: %v", err)), nil
+	}
+
+	s.log.Debug("handleQueryProducts: Returning structured result",
+		"found", response.Found,
+		"count", response.Count,
+		"response_size", len(responseJSON))
+
+	return mcp.NewToolResultStructured(response, string(responseJSON)), nil
+}
+
+func (s *Server) handlePackagingSummary(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.log.Debug("handlePackagingSummary: Starting tool call",
+		"arguments", request.GetArguments())
+
+	barcodes, err := request.RequireStringSlice("barcodes")
+	if err != nil {
+		s.log.Warn("handlePackagingSummary: Missing 'barcodes' parameter", "error", err)
+		return mcp.NewToolResultError(fmt.Sprintf("Missing required parameter 'barcodes': %v", err)), nil
+	}
+
+	barcodes = uniqueNonEmpty(barcodes)
+	if len(barcodes) == 0 {
+		return mcp.NewToolResultError("Parameter 'barcodes' must contain at least one barcode"), nil
+	}
+	if len(barcodes) > query.MaxBarcodesPerRequest {
+		return mcp.NewToolResultError(fmt.Sprintf("Parameter 'barcodes' accepts at most %d barcodes", query.MaxBarcodesPerRequest)), nil
+	}
+
+	products, err := s.queryEngine.GetProductsByBarcodes(ctx, barcodes)
+	if err != nil {
+		s.log.Error("Packaging lookup failed", "error", err)
+		return s.queryErrorResult("Packaging lookup failed", err), nil
+	}
+
+	response := PackagingSummaryResponse{
+		Summary: packaging.Summarize(barcodes, products),
+		Quality: nutrition.AssessQualityAll(products),
+	}
+
+	// Create fallback text for backwards compatibility
+	responseJSON, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		s.log.Error("handlePackagingSummary: Failed to marshal response", "error", err)
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal response: %v", err)), nil
+	}
+
+	s.log.Debug("handlePackagingSummary: Returning structured result",
+		"requested", response.Requested,
+		"found", response.Found,
+		"response_size", len(responseJSON))
+
+	return mcp.NewToolResultStructured(response, string(responseJSON)), nil
+}
+
+// uniqueNonEmpty trims barcodes and drops blanks and duplicates, keeping the first occurrence
+func uniqueNonEmpty(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		result = append(result, value)
+	}
+	return result
+}
+
+func (s *Server) handleRunSQL(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.log.Debug("handleRunSQL: Starting tool call",
+		"arguments", request.GetArguments())
+
+	statement, err := request.RequireString("sql")
+	if err != nil {
+		s.log.Warn("handleRunSQL: Missing 'sql' parameter", "error", err)
+		return mcp.NewToolResultError(fmt.Sprintf("Missing required parameter 'sql': %v", err)), nil
+	}
+
+	result, err := s.queryEngine.RunSQL(ctx, statement)
+	if err != nil {
+		if errors.Is(err, query.ErrSQLNotAllowed) || errors.Is(err, query.ErrSQLToolDisabled) {
+			s.log.Warn("handleRunSQL: Statement rejected", "error", err)
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		s.log.Error("SQL statement failed", "error", err)
+		return s.queryErrorResult("Query failed", err), nil
+	}
+
+	// Create fallback text for backwards compatibility
+	responseJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		s.log.Error("handleRunSQL: Failed to marshal response", "error", err)
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal response: %v", err)), nil
+	}
+
+	s.log.Debug("handleRunSQL: Returning structured result",
+		"row_count", result.RowCount,
+		"truncated", result.Truncated,
+		"response_size", len(responseJSON))
+
+	return mcp.NewToolResultStructured(result, string(responseJSON)), nil
+}
+
 // ServeHTTP serves the MCP server over HTTP with authentication
 func (s *Server) ServeHTTP(addr string) error {
+	s.log.Info("Starting MCP server", "addr", addr)
+	return http.ListenAndServe(addr, s.httpHandler())
+}
+
+// httpHandler builds the HTTP handler with the health and authenticated MCP endpoints
+func (s *Server) httpHandler() http.Handler {
 	// Create a custom HTTP handler that includes authentication
 	mux := http.NewServeMux()
 
@@ -411,6 +804,7 @@ func (s *Server) ServeHTTP(addr string) error {
 			json.NewEncoder(w).Encode(map[string]interface{}{
 				"status": "unhealthy",
 				"error":  err.Error(),
+				"queue":  s.queryEngine.QueueStats(),
 			})
 			return
 		}
@@ -419,6 +813,7 @@ func (s *Server) ServeHTTP(addr string) error {
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"status": "healthy",
+			"queue":  s.queryEngine.QueueStats(),
 		})
 	})
 
@@ -460,11 +855,23 @@ func (s *Server) ServeHTTP(addr string) error {
 			return
 		}
 
-		// Create a custom ResponseWriter to capture response details
-		recorder := &responseRecorder{ResponseWriter: w}
+		// Shed load up front when the query queue is already full
+		if stats := s.queryEngine.QueueStats(); stats.Saturated() {
+			writeBusy(w, stats.RetryAfterMs)
+			s.log.Warn("MCP request shed, query queue full",
+				"in_flight", stats.InFlight,
+				"queued", stats.Queued,
+				"retry_after_ms", stats.RetryAfterMs,
+				"remote_addr", r.RemoteAddr)
+			return
+		}
+
+		// Create a custom ResponseWriter to capture response details and turn shed tool calls into 503s
+		signal := &busySignal{}
+		recorder := &responseRecorder{ResponseWriter: w, busy: signal}
 
 		// Forward to the streamable HTTP server
-		streamableServer.ServeHTTP(recorder, r)
+		streamableServer.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), busySignalKey{}, signal)))
 
 		s.log.Debug("MCP response sent",
 			"status_code", recorder.statusCode,
@@ -472,8 +879,7 @@ func (s *Server) ServeHTTP(addr string) error {
 			"content_type", recorder.Header().Get("Content-Type"))
 	})
 
-	s.log.Info("Starting MCP server", "addr", addr)
-	return http.ListenAndServe(addr, mux)
+	return mux
 }
 
 // ServeStdio serves the MCP server over stdio (no auth required for local use)
@@ -2,11 +2,16 @@ package mcpgo
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/noot-app/openfoodfacts-mcp-server/internal/auth"
 	"github.com/noot-app/openfoodfacts-mcp-server/internal/config"
 	"github.com/noot-app/openfoodfacts-mcp-server/internal/query"
@@ -20,7 +25,7 @@ func TestServer_checkHealthWithCache(t *testing.T) {
 		mockEngine := query.NewMockEngine(logger)
 		auth := auth.NewBearerTokenAuth("test-token")
 
-		server := NewServer(mockEngine, auth, logger)
+		server := NewServer(mockEngine, auth, &config.Config{}, logger)
 		ctx := context.Background()
 
 		// First call should perform actual health check
@@ -37,7 +42,7 @@ func TestServer_checkHealthWithCache(t *testing.T) {
 		mockEngine := query.NewMockEngine(logger)
 		auth := auth.NewBearerTokenAuth("test-token")
 
-		server := NewServer(mockEngine, auth, logger)
+		server := NewServer(mockEngine, auth, &config.Config{}, logger)
 		ctx := context.Background()
 
 		// First call
@@ -61,7 +66,7 @@ func TestServer_checkHealthWithCache(t *testing.T) {
 
 		auth := auth.NewBearerTokenAuth("test-token")
 
-		server := NewServer(mockEngine, auth, logger)
+		server := NewServer(mockEngine, auth, &config.Config{}, logger)
 		ctx := context.Background()
 
 		// First call should get error and cache it
@@ -84,7 +89,7 @@ func TestServer_checkHealthWithCache(t *testing.T) {
 		mockEngine := query.NewMockEngine(logger)
 		auth := auth.NewBearerTokenAuth("test-token")
 
-		server := NewServer(mockEngine, auth, logger)
+		server := NewServer(mockEngine, auth, &config.Config{}, logger)
 		ctx := context.Background()
 
 		// First call
@@ -107,7 +112,7 @@ func TestServer_checkHealthWithCache(t *testing.T) {
 		mockEngine := query.NewMockEngine(logger)
 		auth := auth.NewBearerTokenAuth("test-token")
 
-		server := NewServer(mockEngine, auth, logger)
+		server := NewServer(mockEngine, auth, &config.Config{}, logger)
 		ctx := context.Background()
 
 		// Set cache as expired
@@ -144,7 +149,7 @@ func TestServer_HealthCacheIntegration(t *testing.T) {
 		mockEngine := query.NewMockEngine(logger)
 		auth := auth.NewBearerTokenAuth("test-token")
 
-		server := NewServer(mockEngine, auth, logger)
+		server := NewServer(mockEngine, auth, &config.Config{}, logger)
 
 		// Pre-populate cache with successful result
 		ctx := context.Background()
@@ -159,3 +164,447 @@ func TestServer_HealthCacheIntegration(t *testing.T) {
 		assert.NoError(t, err, "Should use cached success result even though mock engine is now broken")
 	})
 }
+
+func TestServer_QueryErrorResult(t *testing.T) {
+	logger := config.NewTestLogger(io.Discard, "debug")
+	server := NewServer(query.NewMockEngine(logger), auth.NewBearerTokenAuth("test-token"), &config.Config{}, logger)
+
+	t.Run("busy error returns structured retry hint", func(t *testing.T) {
+		result := server.queryErrorResult("Search failed", &query.BusyError{Reason: "queue_full", RetryAfter: 1500 * time.Millisecond})
+		assert.True(t, result.IsError)
+
+		response, ok := result.StructuredContent.(BusyResponse)
+		require.True(t, ok)
+		assert.Equal(t, "server_busy", response.Error)
+		assert.Equal(t, int64(1500), response.RetryAfterMs)
+	})
+
+	t.Run("other errors return plain error result", func(t *testing.T) {
+		result := server.queryErrorResult("Search failed", errors.New("boom"))
+		assert.True(t, result.IsError)
+		assert.Nil(t, result.StructuredContent)
+
+		text, ok := result.Content[0].(mcp.TextContent)
+		require.True(t, ok)
+		assert.Equal(t, "Search failed: boom", text.Text)
+	})
+}
+
+func TestServer_HandleSearchByBarcode_Busy(t *testing.T) {
+	logger := config.NewTestLogger(io.Discard, "debug")
+	mockEngine := query.NewMockEngine(logger)
+	mockEngine.SetError(&query.BusyError{Reason: "queue_timeout", RetryAfter: 2 * time.Second})
+	server := NewServer(mockEngine, auth.NewBearerTokenAuth("test-token"), &config.Config{}, logger)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{"barcode": "3017620422003"}
+
+	result, err := server.handleSearchByBarcode(context.Background(), request)
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+
+	response, ok := result.StructuredContent.(BusyResponse)
+	require.True(t, ok)
+	assert.Equal(t, int64(2000), response.RetryAfterMs)
+}
+
+func TestServer_HandleSearchByBarcode_Quality(t *testing.T) {
+	tests := []struct {
+		name             string
+		barcode          string
+		expectedFound    bool
+		expectedReliable bool
+		expectedMissing  []string
+	}{
+		{
+			name:             "complete product",
+			barcode:          "3017620422003",
+			expectedFound:    true,
+			expectedReliable: true,
+			expectedMissing:  []string{"fiber"},
+		},
+		{
+			name:             "sparse product",
+			barcode:          "1234567890123",
+			expectedFound:    true,
+			expectedReliable: true,
+			expectedMissing:  []string{"saturated-fat", "carbohydrates", "sugars", "fiber", "proteins", "salt", "serving_size"},
+		},
+		{
+			name:    "unknown barcode",
+			barcode: "404",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := config.NewTestLogger(io.Discard, "debug")
+			server := NewServer(query.NewMockEngine(logger), auth.NewBearerTokenAuth("test-token"), &config.Config{}, logger)
+
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = map[string]interface{}{"barcode": tt.barcode}
+
+			result, err := server.handleSearchByBarcode(context.Background(), request)
+			require.NoError(t, err)
+			require.False(t, result.IsError)
+
+			response, ok := result.StructuredContent.(SearchBarcodeResponse)
+			require.True(t, ok)
+			assert.Equal(t, tt.expectedFound, response.Found)
+			if !tt.expectedFound {
+				assert.Nil(t, response.Quality)
+				return
+			}
+			require.NotNil(t, response.Quality)
+			assert.Equal(t, tt.barcode, response.Quality.Code)
+			assert.Equal(t, tt.expectedReliable, response.Quality.Reliable)
+			assert.Equal(t, tt.expectedMissing, response.Quality.Missing)
+		})
+	}
+}
+
+func TestServer_HTTPLoadShedding(t *testing.T) {
+	tests := []struct {
+		name               string
+		stats              query.QueueStats
+		expectedStatus     int
+		expectedRetryAfter string
+	}{
+		{
+			name:               "saturated queue returns 503",
+			stats:              query.QueueStats{Capacity: 2, InFlight: 2, Queued: 4, MaxQueueDepth: 4, RetryAfterMs: 1500},
+			expectedStatus:     http.StatusServiceUnavailable,
+			expectedRetryAfter: "2",
+		},
+		{
+			name:               "sub-second retry hint rounds up to one second",
+			stats:              query.QueueStats{Capacity: 1, InFlight: 1, Queued: 0, MaxQueueDepth: 0, RetryAfterMs: 200},
+			expectedStatus:     http.StatusServiceUnavailable,
+			expectedRetryAfter: "1",
+		},
+		{
+			name:           "queue with room is forwarded",
+			stats:          query.QueueStats{Capacity: 2, InFlight: 2, Queued: 1, MaxQueueDepth: 4, RetryAfterMs: 1500},
+			expectedStatus: http.StatusOK, // forwarded to the streamable HTTP server
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := config.NewTestLogger(io.Discard, "debug")
+			mockEngine := query.NewMockEngine(logger)
+			mockEngine.SetQueueStats(tt.stats)
+			server := NewServer(mockEngine, auth.NewBearerTokenAuth("test-token"), &config.Config{}, logger)
+
+			req := httptest.NewRequest(http.MethodGet, "/mcp", nil)
+			req.Header.Set("Authorization", "Bearer test-token")
+			rec := httptest.NewRecorder()
+
+			server.httpHandler().ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedRetryAfter != "" {
+				assert.Equal(t, tt.expectedRetryAfter, rec.Header().Get("Retry-After"))
+
+				var response BusyResponse
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+				assert.Equal(t, "server_busy", response.Error)
+				assert.Equal(t, tt.stats.RetryAfterMs, response.RetryAfterMs)
+			}
+		})
+	}
+}
+
+func TestServer_HTTPToolCallShed(t *testing.T) {
+	tests := []struct {
+		name               string
+		engineErr          error
+		expectedStatus     int
+		expectedRetryAfter string
+	}{
+		{
+			name:               "queue timeout during the call returns 503",
+			engineErr:          &query.BusyError{Reason: "queue_timeout", RetryAfter: 2500 * time.Millisecond},
+			expectedStatus:     http.StatusServiceUnavailable,
+			expectedRetryAfter: "3",
+		},
+		{
+			name:           "other errors stay tool errors",
+			engineErr:      errors.New("boom"),
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := config.NewTestLogger(io.Discard, "debug")
+			mockEngine := query.NewMockEngine(logger)
+			mockEngine.SetError(tt.engineErr)
+			server := NewServer(mockEngine, auth.NewBearerTokenAuth("test-token"), &config.Config{}, logger)
+
+			body := `{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": {"name": "search_by_barcode", "arguments": {"barcode": "3017620422003"}}}`
+			req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+			req.Header.Set("Authorization", "Bearer test-token")
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			server.httpHandler().ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedRetryAfter == "" {
+				assert.Contains(t, rec.Body.String(), "boom")
+				return
+			}
+			assert.Equal(t, tt.expectedRetryAfter, rec.Header().Get("Retry-After"))
+
+			var response BusyResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+			assert.Equal(t, "server_busy", response.Error)
+			assert.Equal(t, int64(2500), response.RetryAfterMs)
+		})
+	}
+}
+
+func TestServer_HealthExposesQueueStats(t *testing.T) {
+	logger := config.NewTestLogger(io.Discard, "debug")
+	mockEngine := query.NewMockEngine(logger)
+	mockEngine.SetQueueStats(query.QueueStats{Capacity: 4, InFlight: 1, Queued: 2, MaxQueueDepth: 16})
+	server := NewServer(mockEngine, auth.NewBearerTokenAuth("test-token"), &config.Config{}, logger)
+
+	rec := httptest.NewRecorder()
+	server.httpHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var body struct {
+		Status string           `json:"status"`
+		Queue  query.QueueStats `json:"queue"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, "healthy", body.Status)
+	assert.Equal(t, 2, body.Queue.Queued)
+	assert.Equal(t, 4, body.Queue.Capacity)
+}
+
+// listTools returns the tools registered on the server
+func listTools(t *testing.T, server *Server) []mcp.Tool {
+	t.Helper()
+
+	message := []byte(`{"jsonrpc": "2.0", "id": 1, "method": "tools/list"}`)
+	response := server.mcpServer.HandleMessage(context.Background(), message)
+
+	rpcResponse, ok := response.(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	result, ok := rpcResponse.Result.(mcp.ListToolsResult)
+	require.True(t, ok)
+	return result.Tools
+}
+
+// listToolNames returns the names of the tools registered on the server
+func listToolNames(t *testing.T, server *Server) []string {
+	t.Helper()
+
+	tools := listTools(t, server)
+	names := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+	return names
+}
+
+func TestServer_RunSQLTool(t *testing.T) {
+	t.Run("not registered unless enabled", func(t *testing.T) {
+		logger := config.NewTestLogger(io.Discard, "debug")
+		server := NewServer(query.NewMockEngine(logger), auth.NewBearerTokenAuth("test-token"), &config.Config{}, logger)
+
+		assert.NotContains(t, listToolNames(t, server), "run_sql")
+	})
+
+	t.Run("registered when enabled", func(t *testing.T) {
+		logger := config.NewTestLogger(io.Discard, "debug")
+		cfg := &config.Config{EnableSQLTool: true, SQLToolRowLimit: 100, SQLToolTimeoutSeconds: 5}
+		server := NewServer(query.NewMockEngine(logger), auth.NewBearerTokenAuth("test-token"), cfg, logger)
+
+		assert.Contains(t, listToolNames(t, server), "run_sql")
+	})
+
+	tests := []struct {
+		name          string
+		sql           string
+		expectError   bool
+		expectedCount int
+	}{
+		{
+			name:          "select returns rows",
+			sql:           "SELECT code, product_name FROM products",
+			expectedCount: 2,
+		},
+		{
+			name:        "non-select is rejected",
+			sql:         "DELETE FROM products",
+			expectError: true,
+		},
+		{
+			name:        "missing sql",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := config.NewTestLogger(io.Discard, "debug")
+			server := NewServer(query.NewMockEngine(logger), auth.NewBearerTokenAuth("test-token"), &config.Config{EnableSQLTool: true}, logger)
+
+			request := mcp.CallToolRequest{}
+			arguments := map[string]interface{}{}
+			if tt.sql != "" {
+				arguments["sql"] = tt.sql
+			}
+			request.Params.Arguments = arguments
+
+			result, err := server.handleRunSQL(context.Background(), request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectError, result.IsError)
+
+			if !tt.expectError {
+				response, ok := result.StructuredContent.(*query.SQLResult)
+				require.True(t, ok)
+				assert.Equal(t, tt.expectedCount, response.RowCount)
+			}
+		})
+	}
+}
+
+func TestServer_QueryProductsTool(t *testing.T) {
+	t.Run("schema exposes filter definitions", func(t *testing.T) {
+		logger := config.NewTestLogger(io.Discard, "debug")
+		server := NewServer(query.NewMockEngine(logger), auth.NewBearerTokenAuth("test-token"), &config.Config{}, logger)
+
+		var schemaJSON []byte
+		for _, tool := range listTools(t, server) {
+			if tool.Name == "query_products" {
+				var err error
+				schemaJSON, err = json.Marshal(tool.InputSchema)
+				require.NoError(t, err)
+			}
+		}
+		require.NotEmpty(t, schemaJSON)
+		assert.Contains(t, string(schemaJSON), `"$defs"`)
+		assert.Contains(t, string(schemaJSON), `"$ref":"#/$defs/filter"`)
+		assert.Contains(t, string(schemaJSON), "nutriments.")
+	})
+
+	tests := []struct {
+		name          string
+		arguments     map[string]interface{}
+		expectError   bool
+		expectedCount int
+	}{
+		{
+			name:          "no filter",
+			arguments:     map[string]interface{}{},
+			expectedCount: 2,
+		},
+		{
+			name: "valid filter and limit",
+			arguments: map[string]interface{}{
+				"where": map[string]interface{}{
+					"and": []interface{}{
+						map[string]interface{}{"field": "categories_tags", "op": "contains", "value": "en:spreads"},
+						map[string]interface{}{"field": "nutriments.sugars", "op": "lt", "value": 60},
+					},
+				},
+				"sort":  []interface{}{map[string]interface{}{"field": "nutriscore_score", "desc": true}},
+				"limit": 1,
+			},
+			expectedCount: 1,
+		},
+		{
+			name: "unknown field is rejected",
+			arguments: map[string]interface{}{
+				"where": map[string]interface{}{"field": "password", "op": "eq", "value": "x"},
+			},
+			expectError: true,
+		},
+		{
+			name: "malformed arguments are rejected",
+			arguments: map[string]interface{}{
+				"where": "brands = 'Ferrero'",
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := config.NewTestLogger(io.Discard, "debug")
+			server := NewServer(query.NewMockEngine(logger), auth.NewBearerTokenAuth("test-token"), &config.Config{}, logger)
+
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tt.arguments
+
+			result, err := server.handleQueryProducts(context.Background(), request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectError, result.IsError)
+
+			if !tt.expectError {
+				response, ok := result.StructuredContent.(QueryProductsResponse)
+				require.True(t, ok)
+				assert.Equal(t, tt.expectedCount, response.Count)
+				require.Len(t, response.Quality, tt.expectedCount)
+				for i, report := range response.Quality {
+					assert.Equal(t, response.Products[i].Code, report.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestServer_PackagingSummaryTool(t *testing.T) {
+	tests := []struct {
+		name             string
+		arguments        map[string]interface{}
+		expectError      bool
+		expectedFound    int
+		expectedNotFound []string
+	}{
+		{
+			name:             "found and missing barcodes",
+			arguments:        map[string]interface{}{"barcodes": []interface{}{"3017620422003", " 3017620422003 ", "404"}},
+			expectedFound:    1,
+			expectedNotFound: []string{"404"},
+		},
+		{
+			name:        "missing barcodes",
+			arguments:   map[string]interface{}{},
+			expectError: true,
+		},
+		{
+			name:        "only blank barcodes",
+			arguments:   map[string]interface{}{"barcodes": []interface{}{" "}},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := config.NewTestLogger(io.Discard, "debug")
+			server := NewServer(query.NewMockEngine(logger), auth.NewBearerTokenAuth("test-token"), &config.Config{}, logger)
+
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tt.arguments
+
+			result, err := server.handlePackagingSummary(context.Background(), request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectError, result.IsError)
+
+			if !tt.expectError {
+				response, ok := result.StructuredContent.(PackagingSummaryResponse)
+				require.True(t, ok)
+				assert.Equal(t, tt.expectedFound, response.Found)
+				assert.Len(t, response.Quality, tt.expectedFound)
+				assert.Equal(t, tt.expectedNotFound, response.NotFound)
+				assert.Equal(t, 2, response.Units)
+			}
+		})
+	}
+}
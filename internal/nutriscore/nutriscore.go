@@ -0,0 +1,260 @@
+package nutriscore
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Version is the revision of the Nutri-Score algorithm implemented here
+const Version = "2023"
+
+// ErrInsufficientData is returned when a nutrient the algorithm needs is missing
+var ErrInsufficientData = errors.New("insufficient data for Nutri-Score")
+
+// ErrUnknownCategory is returned for categories other than the ones below
+var ErrUnknownCategory = errors.New("unknown Nutri-Score category")
+
+// Categories with their own Nutri-Score rules
+const (
+	CategoryGeneral  = "general"
+	CategoryCheese   = "cheese"
+	CategoryRedMeat  = "red_meat"
+	CategoryFat      = "fats_oils_nuts_seeds"
+	CategoryBeverage = "beverage"
+	CategoryWater    = "water"
+)
+
+// Component names
+const (
+	ComponentEnergy                  = "energy"
+	ComponentEnergyFromSaturatedFat  = "energy_from_saturated_fat"
+	ComponentSugars                  = "sugars"
+	ComponentSaturatedFat            = "saturated_fat"
+	ComponentSaturatedFatRatio       = "saturated_fat_ratio"
+	ComponentSalt                    = "salt"
+	ComponentNonNutritiveSweeteners  = "non_nutritive_sweeteners"
+	ComponentProteins                = "proteins"
+	ComponentFiber                   = "fiber"
+	ComponentFruitsVegetablesLegumes = "fruits_vegetables_legumes"
+)
+
+// Input holds the per 100 g (or 100 ml for beverages) values the algorithm uses
+type Input struct {
+	Category                string
+	Energy                  *float64 // kJ
+	Sugars                  *float64 // g
+	Fat                     *float64 // g, used by the fats category
+	SaturatedFat            *float64 // g
+	Salt                    *float64 // g
+	Proteins                *float64 // g
+	Fiber                   *float64 // g
+	FruitsVegetablesLegumes *float64 // %
+	NonNutritiveSweeteners  bool     // Beverages only
+}
+
+// Component is the points earned by one nutrient
+type Component struct {
+	Name      string   `json:"name"`
+	Value     *float64 `json:"value"` // Per 100 g/ml in Unit, null when missing
+	Unit      string   `json:"unit"`
+	Points    int      `json:"points"`
+	MaxPoints int      `json:"max_points"`
+	Counted   bool     `json:"counted"` // False for proteins excluded by the protein rule
+}
+
+// Result is a locally computed Nutri-Score with its component points
+type Result struct {
+	Version        string      `json:"version"`
+	Category       string      `json:"category"`
+	Score          int         `json:"score"`    // Negative minus positive points
+	Grade          string      `json:"grade"`    // a to e
+	Computed       bool        `json:"computed"` // Always true: computed by this server, not the official grade
+	NegativePoints int         `json:"negative_points"`
+	PositivePoints int         `json:"positive_points"`
+	Negative       []Component `json:"negative"`
+	Positive       []Component `json:"positive"`
+	Missing        []string    `json:"missing,omitempty"` // Optional components without data, scored 0
+}
+
+// Compute runs the 2023 Nutri-Score algorithm for the input's category
+func Compute(in Input) (*Result, error) {
+	category := strings.TrimSpace(in.Category)
+	if category == "" {
+		category = CategoryGeneral
+	}
+	in.Category = category
+
+	result := &Result{Version: Version, Category: category, Computed: true, Negative: []Component{}, Positive: []Component{}}
+
+	switch category {
+	case CategoryWater:
+		result.Grade = "a"
+		return result, nil
+	case CategoryGeneral, CategoryCheese, CategoryRedMeat, CategoryFat, CategoryBeverage:
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownCategory, category)
+	}
+
+	if err := checkRequired(in, category); err != nil {
+		return nil, err
+	}
+
+	switch category {
+	case CategoryBeverage:
+		result.Negative = []Component{
+			thresholdComponent(ComponentEnergy, in.Energy, "kJ", beverageEnergyThresholds),
+			thresholdComponent(ComponentSugars, in.Sugars, "g", beverageSugarsThresholds),
+			thresholdComponent(ComponentSaturatedFat, in.SaturatedFat, "g", foodSaturatedFatThresholds),
+			thresholdComponent(ComponentSalt, in.Salt, "g", foodSaltThresholds),
+			sweetenerComponent(in.NonNutritiveSweeteners),
+		}
+		result.Positive = []Component{
+			thresholdComponent(ComponentProteins, in.Proteins, "g", beverageProteinsThresholds),
+			thresholdComponent(ComponentFiber, in.Fiber, "g", foodFiberThresholds),
+			steppedComponent(ComponentFruitsVegetablesLegumes, in.FruitsVegetablesLegumes, "%", beverageFruitsVegetablesPoints),
+		}
+	case CategoryFat:
+		saturatedEnergy := *in.SaturatedFat * kilojoulesPerGramSaturatedFat
+		ratio := 0.0
+		if *in.Fat > 0 {
+			ratio = math.Round(*in.SaturatedFat / *in.Fat * 1000) / 10
+		}
+		result.Negative = []Component{
+			thresholdComponent(ComponentEnergyFromSaturatedFat, &saturatedEnergy, "kJ", fatSaturatedEnergyThresholds),
+			ratioComponent(ratio),
+			thresholdComponent(ComponentSugars, in.Sugars, "g", foodSugarsThresholds),
+			thresholdComponent(ComponentSalt, in.Salt, "g", foodSaltThresholds),
+		}
+		result.Positive = foodPositive(in)
+	default:
+		result.Negative = []Component{
+			thresholdComponent(ComponentEnergy, in.Energy, "kJ", foodEnergyThresholds),
+			thresholdComponent(ComponentSugars, in.Sugars, "g", foodSugarsThresholds),
+			thresholdComponent(ComponentSaturatedFat, in.SaturatedFat, "g", foodSaturatedFatThresholds),
+			thresholdComponent(ComponentSalt, in.Salt, "g", foodSaltThresholds),
+		}
+		result.Positive = foodPositive(in)
+	}
+
+	for _, component := range result.Negative {
+		result.NegativePoints += component.Points
+	}
+	applyProteinRule(result)
+	for _, component := range result.Positive {
+		if component.Counted {
+			result.PositivePoints += component.Points
+		}
+		if component.Value == nil {
+			result.Missing = append(result.Missing, component.Name)
+		}
+	}
+
+	result.Score = result.NegativePoints - result.PositivePoints
+	switch category {
+	case CategoryBeverage:
+		result.Grade = gradeFor(result.Score, beverageGrades)
+	case CategoryFat:
+		result.Grade = gradeFor(result.Score, fatGrades)
+	default:
+		result.Grade = gradeFor(result.Score, foodGrades)
+	}
+	return result, nil
+}
+
+// checkRequired reports the nutrients the category needs that the input lacks
+func checkRequired(in Input, category string) error {
+	required := map[string]*float64{
+		"energy":        in.Energy,
+		"sugars":        in.Sugars,
+		"saturated-fat": in.SaturatedFat,
+		"salt":          in.Salt,
+		"proteins":      in.Proteins,
+	}
+	if category == CategoryFat {
+		delete(required, "energy")
+		required["fat"] = in.Fat
+	}
+
+	var missing []string
+	for _, name := range []string{"energy", "fat", "sugars", "saturated-fat", "salt", "proteins"} {
+		if value, ok := required[name]; ok && value == nil {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: missing %s", ErrInsufficientData, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// foodPositive scores the positive components shared by foods and fats
+func foodPositive(in Input) []Component {
+	proteins := thresholdComponent(ComponentProteins, in.Proteins, "g", foodProteinsThresholds)
+	if in.Category == CategoryRedMeat && proteins.Points > redMeatMaxProtein {
+		proteins.Points = redMeatMaxProtein
+	}
+	return []Component{
+		proteins,
+		thresholdComponent(ComponentFiber, in.Fiber, "g", foodFiberThresholds),
+		steppedComponent(ComponentFruitsVegetablesLegumes, in.FruitsVegetablesLegumes, "%", foodFruitsVegetablesPoints),
+	}
+}
+
+// applyProteinRule stops counting proteins for foods with many negative points, except cheese
+func applyProteinRule(result *Result) {
+	cutoff := foodProteinCutoff
+	switch result.Category {
+	case CategoryBeverage, CategoryCheese:
+		return
+	case CategoryFat:
+		cutoff = fatProteinCutoff
+	}
+	if result.NegativePoints < cutoff {
+		return
+	}
+	for i := range result.Positive {
+		if result.Positive[i].Name == ComponentProteins {
+			result.Positive[i].Counted = false
+		}
+	}
+}
+
+func thresholdComponent(name string, value *float64, unit string, thresholds []float64) Component {
+	component := Component{Name: name, Value: value, Unit: unit, MaxPoints: len(thresholds), Counted: true}
+	if value != nil {
+		component.Points = pointsAbove(*value, thresholds)
+	}
+	return component
+}
+
+func steppedComponent(name string, value *float64, unit string, steps []steppedPoints) Component {
+	component := Component{Name: name, Value: value, Unit: unit, MaxPoints: steps[0].points, Counted: true}
+	if value != nil {
+		component.Points = steppedPointsFor(*value, steps)
+	}
+	return component
+}
+
+func ratioComponent(ratio float64) Component {
+	return Component{
+		Name:      ComponentSaturatedFatRatio,
+		Value:     &ratio,
+		Unit:      "%",
+		Points:    pointsAtOrAbove(ratio, fatSaturatedRatioThresholds),
+		MaxPoints: len(fatSaturatedRatioThresholds),
+		Counted:   true,
+	}
+}
+
+func sweetenerComponent(present bool) Component {
+	component := Component{Name: ComponentNonNutritiveSweeteners, MaxPoints: beverageSweetenerPoints, Counted: true}
+	value := 0.0
+	if present {
+		value = 1
+		component.Points = beverageSweetenerPoints
+	}
+	component.Value = &value
+	return component
+}
@@ -0,0 +1,178 @@
+package nutriscore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ptr(value float64) *float64 {
+	return &value
+}
+
+// Reference products use the per-100 values from their packaging and the grades
+// published for the 2023 algorithm. Beverage points follow the tables in "Update of
+// the Nutri-Score algorithm for beverages" (Scientific Committee of the Nutri-Score,
+// Santé publique France, 2023); foods follow the 2022 update report for foods
+func TestCompute_References(t *testing.T) {
+	tests := []struct {
+		name             string
+		input            Input
+		expectedNegative int
+		expectedPositive int
+		expectedScore    int
+		expectedGrade    string
+	}{
+		{
+			name: "Nutella hazelnut spread",
+			input: Input{Category: CategoryGeneral, Energy: ptr(2252), Sugars: ptr(56.3), SaturatedFat: ptr(10.6),
+				Salt: ptr(0.107), Proteins: ptr(6.3), Fiber: ptr(0), FruitsVegetablesLegumes: ptr(13)},
+			expectedNegative: 31, expectedPositive: 0, expectedScore: 31, expectedGrade: "e",
+		},
+		{
+			name: "rolled oats",
+			input: Input{Category: CategoryGeneral, Energy: ptr(1560), Sugars: ptr(1), SaturatedFat: ptr(1.3),
+				Salt: ptr(0.01), Proteins: ptr(13.5), Fiber: ptr(10), FruitsVegetablesLegumes: ptr(0)},
+			expectedNegative: 5, expectedPositive: 10, expectedScore: -5, expectedGrade: "a",
+		},
+		{
+			name: "Camembert cheese keeps its protein points",
+			input: Input{Category: CategoryCheese, Energy: ptr(1170), Sugars: ptr(0.5), SaturatedFat: ptr(15),
+				Salt: ptr(1.4), Proteins: ptr(20), Fiber: ptr(0), FruitsVegetablesLegumes: ptr(0)},
+			expectedNegative: 19, expectedPositive: 7, expectedScore: 12, expectedGrade: "d",
+		},
+		{
+			name: "beef steak protein points are capped",
+			input: Input{Category: CategoryRedMeat, Energy: ptr(600), Sugars: ptr(0), SaturatedFat: ptr(3),
+				Salt: ptr(0.1), Proteins: ptr(26)},
+			expectedNegative: 3, expectedPositive: 2, expectedScore: 1, expectedGrade: "b",
+		},
+		{
+			name: "extra virgin olive oil",
+			input: Input{Category: CategoryFat, Energy: ptr(3378), Fat: ptr(100), SaturatedFat: ptr(14), Sugars: ptr(0),
+				Salt: ptr(0), Proteins: ptr(0), Fiber: ptr(0), FruitsVegetablesLegumes: ptr(100)},
+			expectedNegative: 5, expectedPositive: 5, expectedScore: 0, expectedGrade: "b",
+		},
+		{
+			name: "butter",
+			input: Input{Category: CategoryFat, Energy: ptr(3061), Fat: ptr(82), SaturatedFat: ptr(54), Sugars: ptr(0.6),
+				Salt: ptr(0.02), Proteins: ptr(0.7), Fiber: ptr(0), FruitsVegetablesLegumes: ptr(0)},
+			expectedNegative: 20, expectedPositive: 0, expectedScore: 20, expectedGrade: "e",
+		},
+		{
+			name: "Coca-Cola",
+			input: Input{Category: CategoryBeverage, Energy: ptr(180), Sugars: ptr(10.6), SaturatedFat: ptr(0),
+				Salt: ptr(0), Proteins: ptr(0), Fiber: ptr(0), FruitsVegetablesLegumes: ptr(0)},
+			expectedNegative: 12, expectedPositive: 0, expectedScore: 12, expectedGrade: "e",
+		},
+		{
+			name: "Coca-Cola Zero with sweeteners",
+			input: Input{Category: CategoryBeverage, Energy: ptr(1.3), Sugars: ptr(0), SaturatedFat: ptr(0),
+				Salt: ptr(0.02), Proteins: ptr(0), NonNutritiveSweeteners: true},
+			expectedNegative: 4, expectedPositive: 0, expectedScore: 4, expectedGrade: "c",
+		},
+		{
+			name: "orange juice",
+			input: Input{Category: CategoryBeverage, Energy: ptr(190), Sugars: ptr(8.9), SaturatedFat: ptr(0),
+				Salt: ptr(0), Proteins: ptr(0.7), Fiber: ptr(0.5), FruitsVegetablesLegumes: ptr(100)},
+			expectedNegative: 10, expectedPositive: 6, expectedScore: 4, expectedGrade: "c",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Compute(tt.input)
+			require.NoError(t, err)
+
+			assert.True(t, result.Computed)
+			assert.Equal(t, Version, result.Version)
+			assert.Equal(t, tt.expectedNegative, result.NegativePoints)
+			assert.Equal(t, tt.expectedPositive, result.PositivePoints)
+			assert.Equal(t, tt.expectedScore, result.Score)
+			assert.Equal(t, tt.expectedGrade, result.Grade)
+
+			total := 0
+			for _, component := range result.Negative {
+				assert.LessOrEqual(t, component.Points, component.MaxPoints, component.Name)
+				total += component.Points
+			}
+			assert.Equal(t, result.NegativePoints, total)
+		})
+	}
+}
+
+func TestCompute_Components(t *testing.T) {
+	result, err := Compute(Input{Energy: ptr(2252), Sugars: ptr(56.3), SaturatedFat: ptr(10.6), Salt: ptr(0.107), Proteins: ptr(6.3)})
+	require.NoError(t, err)
+
+	assert.Equal(t, CategoryGeneral, result.Category)
+	assert.Equal(t, []Component{
+		{Name: ComponentEnergy, Value: ptr(2252), Unit: "kJ", Points: 6, MaxPoints: 10, Counted: true},
+		{Name: ComponentSugars, Value: ptr(56.3), Unit: "g", Points: 15, MaxPoints: 15, Counted: true},
+		{Name: ComponentSaturatedFat, Value: ptr(10.6), Unit: "g", Points: 10, MaxPoints: 10, Counted: true},
+		{Name: ComponentSalt, Value: ptr(0.107), Unit: "g", Points: 0, MaxPoints: 20, Counted: true},
+	}, result.Negative)
+
+	// Proteins earn points but are not counted above 11 negative points
+	assert.Equal(t, Component{Name: ComponentProteins, Value: ptr(6.3), Unit: "g", Points: 2, MaxPoints: 7, Counted: false}, result.Positive[0])
+	assert.Equal(t, []string{ComponentFiber, ComponentFruitsVegetablesLegumes}, result.Missing)
+}
+
+func TestCompute_FatRatioAndProteinCutoff(t *testing.T) {
+	// Walnuts: 7 negative points is already enough to drop proteins in the fats category
+	result, err := Compute(Input{Category: CategoryFat, Fat: ptr(65), SaturatedFat: ptr(6.1), Sugars: ptr(2.6),
+		Salt: ptr(0.005), Proteins: ptr(15), Fiber: ptr(6.7), FruitsVegetablesLegumes: ptr(0)})
+	require.NoError(t, err)
+
+	assert.Equal(t, Component{Name: ComponentEnergyFromSaturatedFat, Value: ptr(225.7), Unit: "kJ", Points: 1, MaxPoints: 10, Counted: true}, roundValue(result.Negative[0]))
+	assert.Equal(t, Component{Name: ComponentSaturatedFatRatio, Value: ptr(9.4), Unit: "%", Points: 0, MaxPoints: 10, Counted: true}, result.Negative[1])
+	assert.Equal(t, 1, result.NegativePoints)
+	assert.True(t, result.Positive[0].Counted)
+	assert.Equal(t, -9, result.Score)
+	assert.Equal(t, "a", result.Grade)
+}
+
+func TestCompute_Water(t *testing.T) {
+	result, err := Compute(Input{Category: CategoryWater})
+	require.NoError(t, err)
+	assert.Equal(t, "a", result.Grade)
+	assert.True(t, result.Computed)
+	assert.Empty(t, result.Negative)
+}
+
+func TestCompute_Errors(t *testing.T) {
+	_, err := Compute(Input{Energy: ptr(100), Sugars: ptr(1)})
+	assert.ErrorIs(t, err, ErrInsufficientData)
+	assert.EqualError(t, err, "insufficient data for Nutri-Score: missing saturated-fat, salt, proteins")
+
+	_, err = Compute(Input{Category: CategoryFat, Energy: ptr(3000), Sugars: ptr(0), SaturatedFat: ptr(10), Salt: ptr(0), Proteins: ptr(0)})
+	assert.EqualError(t, err, "insufficient data for Nutri-Score: missing fat")
+
+	_, err = Compute(Input{Category: "pet_food"})
+	assert.ErrorIs(t, err, ErrUnknownCategory)
+}
+
+func TestGradeFor(t *testing.T) {
+	tests := []struct {
+		score    int
+		bounds   []gradeBound
+		expected string
+	}{
+		{0, foodGrades, "a"}, {1, foodGrades, "b"}, {2, foodGrades, "b"}, {3, foodGrades, "c"},
+		{10, foodGrades, "c"}, {11, foodGrades, "d"}, {18, foodGrades, "d"}, {19, foodGrades, "e"},
+		{-6, fatGrades, "a"}, {-5, fatGrades, "b"}, {2, fatGrades, "b"}, {3, fatGrades, "c"},
+		{-3, beverageGrades, "b"}, {2, beverageGrades, "b"}, {6, beverageGrades, "c"}, {9, beverageGrades, "d"}, {10, beverageGrades, "e"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, gradeFor(tt.score, tt.bounds), "score %d", tt.score)
+	}
+}
+
+// roundValue trims floating point noise from a computed component value
+func roundValue(component Component) Component {
+	value := float64(int(*component.Value*10+0.5)) / 10
+	component.Value = &value
+	return component
+}
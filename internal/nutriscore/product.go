@@ -0,0 +1,166 @@
+package nutriscore
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/types"
+)
+
+// ErrNotApplicable is returned for products the Nutri-Score does not cover, such as alcoholic beverages
+var ErrNotApplicable = errors.New("Nutri-Score does not apply to this product")
+
+// Unit conversions for nutrients reported in other forms
+const (
+	kilojoulesPerKilocalorie = 4.184
+	saltPerSodium            = 2.5
+)
+
+// maxNonAlcoholic is the alcohol content (% vol) above which a beverage is not graded
+const maxNonAlcoholic = 1.2
+
+// Category tags from the Open Food Facts taxonomy, checked in this order
+var (
+	alcoholicTags = []string{"en:alcoholic-beverages"}
+	flavoredWater = []string{"en:flavoured-waters", "en:flavored-waters"}
+	waterTags     = []string{"en:waters", "en:spring-waters", "en:mineral-waters", "en:natural-mineral-waters"}
+	beverageTags  = []string{"en:beverages", "en:milks", "en:dairy-drinks", "en:plant-based-milk-alternatives"}
+	cheeseTags    = []string{"en:cheeses"}
+	fatTags       = []string{"en:fats", "en:vegetable-oils", "en:nuts", "en:seeds", "en:nut-butters"}
+	redMeatTags   = []string{"en:red-meats", "en:beef", "en:veal", "en:pork", "en:lamb", "en:mutton"}
+)
+
+// fruitsVegetablesNutrients are the dataset entries for the fruit, vegetable and legume share, most specific first
+var fruitsVegetablesNutrients = []string{
+	"fruits-vegetables-legumes",
+	"fruits-vegetables-legumes-estimate-from-ingredients",
+	"fruits-vegetables-nuts",
+	"fruits-vegetables-nuts-estimate",
+	"fruits-vegetables-nuts-estimate-from-ingredients",
+}
+
+// nonNutritiveSweeteners are ingredient IDs that trigger the beverage sweetener points
+var nonNutritiveSweeteners = map[string]bool{
+	"en:e950": true, "en:acesulfame-k": true,
+	"en:e951": true, "en:aspartame": true,
+	"en:e952": true, "en:cyclamate": true, "en:sodium-cyclamate": true,
+	"en:e954": true, "en:saccharin": true, "en:sodium-saccharin": true,
+	"en:e955": true, "en:sucralose": true,
+	"en:e957": true, "en:thaumatin": true,
+	"en:e959": true, "en:neohesperidine-dc": true,
+	"en:e960": true, "en:steviol-glycosides": true, "en:stevia": true,
+	"en:e961": true, "en:neotame": true,
+	"en:e962": true, "en:aspartame-acesulfame-salt": true,
+	"en:e969": true, "en:advantame": true,
+}
+
+// CategoryFor picks the Nutri-Score category from a product's categories_tags
+func CategoryFor(p *types.Product) (string, error) {
+	tags := make(map[string]bool, len(p.CategoriesTags))
+	for _, tag := range p.CategoriesTags {
+		tags[strings.ToLower(tag)] = true
+	}
+
+	if hasAny(tags, alcoholicTags) {
+		return "", ErrNotApplicable
+	}
+	if alcohol := per100g(p.ParsedNutriments(), "alcohol"); alcohol != nil && *alcohol > maxNonAlcoholic {
+		return "", ErrNotApplicable
+	}
+
+	switch {
+	case hasAny(tags, waterTags) && !hasAny(tags, flavoredWater):
+		return CategoryWater, nil
+	case hasAny(tags, beverageTags):
+		return CategoryBeverage, nil
+	case hasAny(tags, cheeseTags):
+		return CategoryCheese, nil
+	case hasAny(tags, fatTags):
+		return CategoryFat, nil
+	case hasAny(tags, redMeatTags):
+		return CategoryRedMeat, nil
+	default:
+		return CategoryGeneral, nil
+	}
+}
+
+// InputFromProduct reads the algorithm inputs from a product's nutriments and ingredients
+func InputFromProduct(p *types.Product, category string) Input {
+	nutriments := p.ParsedNutriments()
+
+	in := Input{
+		Category:                category,
+		Energy:                  per100g(nutriments, "energy-kj", "energy"),
+		Sugars:                  per100g(nutriments, "sugars"),
+		Fat:                     per100g(nutriments, "fat"),
+		SaturatedFat:            per100g(nutriments, "saturated-fat"),
+		Salt:                    per100g(nutriments, "salt"),
+		Proteins:                per100g(nutriments, "proteins"),
+		Fiber:                   per100g(nutriments, "fiber"),
+		FruitsVegetablesLegumes: per100g(nutriments, fruitsVegetablesNutrients...),
+		NonNutritiveSweeteners:  hasSweetener(p.Ingredients),
+	}
+
+	if in.Energy == nil {
+		if kcal := per100g(nutriments, "energy-kcal"); kcal != nil {
+			kj := *kcal * kilojoulesPerKilocalorie
+			in.Energy = &kj
+		}
+	}
+	if in.Salt == nil {
+		if sodium := per100g(nutriments, "sodium"); sodium != nil {
+			salt := *sodium * saltPerSodium
+			in.Salt = &salt
+		}
+	}
+	return in
+}
+
+// ForProduct computes the Nutri-Score of a product from its categories and nutriments
+func ForProduct(p *types.Product) (*Result, error) {
+	category, err := CategoryFor(p)
+	if err != nil {
+		return nil, err
+	}
+	return Compute(InputFromProduct(p, category))
+}
+
+// per100g returns the first per-100 value found among the given nutrient names
+func per100g(nutriments map[string]types.Nutriment, names ...string) *float64 {
+	for _, name := range names {
+		if nutriment, ok := nutriments[name]; ok && nutriment.Per100g != nil {
+			return nutriment.Per100g
+		}
+	}
+	return nil
+}
+
+// hasSweetener walks the nested ingredient list looking for non-nutritive sweeteners
+func hasSweetener(ingredients interface{}) bool {
+	list, ok := ingredients.([]interface{})
+	if !ok {
+		return false
+	}
+	for _, item := range list {
+		ingredient, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if id, ok := ingredient["id"].(string); ok && nonNutritiveSweeteners[strings.ToLower(id)] {
+			return true
+		}
+		if hasSweetener(ingredient["ingredients"]) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAny(tags map[string]bool, candidates []string) bool {
+	for _, candidate := range candidates {
+		if tags[candidate] {
+			return true
+		}
+	}
+	return false
+}
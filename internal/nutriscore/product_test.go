@@ -0,0 +1,97 @@
+package nutriscore
+
+import (
+	"testing"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCategoryFor(t *testing.T) {
+	tests := []struct {
+		name        string
+		product     types.Product
+		expected    string
+		expectedErr error
+	}{
+		{name: "no categories", expected: CategoryGeneral},
+		{name: "spread", product: types.Product{CategoriesTags: []string{"en:spreads", "en:sweet-spreads"}}, expected: CategoryGeneral},
+		{name: "mineral water", product: types.Product{CategoriesTags: []string{"en:beverages", "en:waters", "en:mineral-waters"}}, expected: CategoryWater},
+		{name: "flavoured water", product: types.Product{CategoriesTags: []string{"en:beverages", "en:waters", "en:flavoured-waters"}}, expected: CategoryBeverage},
+		{name: "soda", product: types.Product{CategoriesTags: []string{"en:beverages", "en:sodas"}}, expected: CategoryBeverage},
+		{name: "milk", product: types.Product{CategoriesTags: []string{"en:dairies", "en:milks"}}, expected: CategoryBeverage},
+		{name: "cheese", product: types.Product{CategoriesTags: []string{"en:dairies", "en:cheeses"}}, expected: CategoryCheese},
+		{name: "olive oil", product: types.Product{CategoriesTags: []string{"en:fats", "en:vegetable-oils", "en:olive-oils"}}, expected: CategoryFat},
+		{name: "beef", product: types.Product{CategoriesTags: []string{"en:meats", "en:beef"}}, expected: CategoryRedMeat},
+		{name: "beer", product: types.Product{CategoriesTags: []string{"en:beverages", "en:alcoholic-beverages", "en:beers"}}, expectedErr: ErrNotApplicable},
+		{name: "alcohol content", product: types.Product{Nutriments: map[string]interface{}{"alcohol": 5.0}}, expectedErr: ErrNotApplicable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			category, err := CategoryFor(&tt.product)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, category)
+		})
+	}
+}
+
+func TestInputFromProduct(t *testing.T) {
+	product := &types.Product{
+		Nutriments: map[string]interface{}{
+			"energy-kcal":   map[string]interface{}{"100g": 42.0},
+			"sugars":        10.6,
+			"saturated-fat": 0.0,
+			"sodium":        0.004,
+			"proteins":      0.0,
+			"fruits-vegetables-nuts-estimate-from-ingredients": 12.5,
+		},
+		Ingredients: []interface{}{
+			map[string]interface{}{"id": "en:carbonated-water"},
+			map[string]interface{}{"id": "en:sweeteners", "ingredients": []interface{}{
+				map[string]interface{}{"id": "en:e955"},
+			}},
+		},
+	}
+
+	in := InputFromProduct(product, CategoryBeverage)
+	assert.Equal(t, CategoryBeverage, in.Category)
+	require.NotNil(t, in.Energy)
+	assert.InDelta(t, 175.728, *in.Energy, 0.001)
+	require.NotNil(t, in.Salt)
+	assert.InDelta(t, 0.01, *in.Salt, 0.0001)
+	assert.Equal(t, 12.5, *in.FruitsVegetablesLegumes)
+	assert.Nil(t, in.Fiber)
+	assert.True(t, in.NonNutritiveSweeteners)
+}
+
+func TestForProduct(t *testing.T) {
+	product := &types.Product{
+		Code:           "5449000000996",
+		CategoriesTags: []string{"en:beverages", "en:sodas"},
+		Nutriments: map[string]interface{}{
+			"energy":        180.0,
+			"sugars":        10.6,
+			"fat":           0.0,
+			"saturated-fat": 0.0,
+			"salt":          0.0,
+			"proteins":      0.0,
+		},
+	}
+
+	result, err := ForProduct(product)
+	require.NoError(t, err)
+	assert.Equal(t, CategoryBeverage, result.Category)
+	assert.Equal(t, 12, result.Score)
+	assert.Equal(t, "e", result.Grade)
+	assert.True(t, result.Computed)
+
+	product.Nutriments = map[string]interface{}{"sugars": 10.6}
+	_, err = ForProduct(product)
+	assert.ErrorIs(t, err, ErrInsufficientData)
+}
@@ -0,0 +1,107 @@
+package nutriscore
+
+// Point thresholds of the 2023 Nutri-Score revision. A component scores one point
+// for every threshold its value exceeds, unless noted otherwise.
+
+// Foods (general, cheese and red meat)
+var (
+	foodEnergyThresholds       = []float64{335, 670, 1005, 1340, 1675, 2010, 2345, 2680, 3015, 3350}
+	foodSugarsThresholds       = []float64{3.4, 6.8, 10, 14, 17, 20, 24, 27, 31, 34, 37, 41, 44, 48, 51}
+	foodSaturatedFatThresholds = []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	foodSaltThresholds         = []float64{0.2, 0.4, 0.6, 0.8, 1, 1.2, 1.4, 1.6, 1.8, 2, 2.2, 2.4, 2.6, 2.8, 3, 3.2, 3.4, 3.6, 3.8, 4}
+	foodProteinsThresholds     = []float64{2.4, 4.8, 7.2, 9.6, 12, 14, 17}
+	foodFiberThresholds        = []float64{3.0, 4.1, 5.2, 6.3, 7.4}
+)
+
+// foodFruitsVegetablesPoints maps the fruit, vegetable and legume share to points for foods
+var foodFruitsVegetablesPoints = []steppedPoints{{80, 5}, {60, 2}, {40, 1}}
+
+// Fats, oils, nuts and seeds replace energy and saturated fat with these components
+var (
+	fatSaturatedEnergyThresholds = []float64{120, 240, 360, 480, 600, 720, 840, 960, 1080, 1200} // kJ from saturated fat
+	fatSaturatedRatioThresholds  = []float64{10, 16, 22, 28, 34, 40, 46, 52, 58, 64}             // % of fat, scored at or above
+)
+
+// Beverages
+var (
+	beverageEnergyThresholds   = []float64{30, 90, 150, 210, 240, 270, 300, 330, 360, 390}
+	beverageSugarsThresholds   = []float64{0.5, 2, 3.5, 5, 6, 7, 8, 9, 10, 11}
+	beverageProteinsThresholds = []float64{1.2, 1.5, 1.8, 2.1, 2.4, 2.7, 3.0}
+)
+
+// beverageFruitsVegetablesPoints maps the fruit, vegetable and legume share to points for beverages
+var beverageFruitsVegetablesPoints = []steppedPoints{{80, 6}, {60, 4}, {40, 2}}
+
+// beverageSweetenerPoints are added when a beverage contains non-nutritive sweeteners
+const beverageSweetenerPoints = 4
+
+// kilojoulesPerGramSaturatedFat converts saturated fat to energy for the fats category
+const kilojoulesPerGramSaturatedFat = 37
+
+// Protein rules
+const (
+	foodProteinCutoff = 11 // Proteins are not counted at or above these negative points, except for cheese
+	fatProteinCutoff  = 7
+	redMeatMaxProtein = 2 // Red meat products score at most this many protein points
+)
+
+// gradeBound is the highest score that still earns a grade
+type gradeBound struct {
+	maxScore int
+	grade    string
+}
+
+// Grade boundaries per category; scores above the last bound are graded E
+var (
+	foodGrades     = []gradeBound{{0, "a"}, {2, "b"}, {10, "c"}, {18, "d"}}
+	fatGrades      = []gradeBound{{-6, "a"}, {2, "b"}, {10, "c"}, {18, "d"}}
+	beverageGrades = []gradeBound{{2, "b"}, {6, "c"}, {9, "d"}} // Only water is graded A
+)
+
+// steppedPoints awards points when a value exceeds min
+type steppedPoints struct {
+	min    float64
+	points int
+}
+
+// pointsAbove counts the thresholds a value exceeds
+func pointsAbove(value float64, thresholds []float64) int {
+	points := 0
+	for _, threshold := range thresholds {
+		if value > threshold {
+			points++
+		}
+	}
+	return points
+}
+
+// pointsAtOrAbove counts the thresholds a value reaches
+func pointsAtOrAbove(value float64, thresholds []float64) int {
+	points := 0
+	for _, threshold := range thresholds {
+		if value >= threshold {
+			points++
+		}
+	}
+	return points
+}
+
+// steppedPointsFor returns the points of the first step a value exceeds
+func steppedPointsFor(value float64, steps []steppedPoints) int {
+	for _, step := range steps {
+		if value > step.min {
+			return step.points
+		}
+	}
+	return 0
+}
+
+// gradeFor converts a score to a letter grade
+func gradeFor(score int, bounds []gradeBound) string {
+	for _, bound := range bounds {
+		if score <= bound.maxScore {
+			return bound.grade
+		}
+	}
+	return "e"
+}
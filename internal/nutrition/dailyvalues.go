@@ -0,0 +1,175 @@
+package nutrition
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/types"
+)
+
+// ErrUnknownReference is returned for reference table IDs that are not shipped with the server
+var ErrUnknownReference = errors.New("unknown daily value reference")
+
+// Daily value bases
+const (
+	BasisPer100  = "per_100"
+	BasisServing = "serving"
+	BasisMeal    = "meal"
+)
+
+// saltPerSodium is the EU conversion factor between sodium and salt
+const saltPerSodium = 2.5
+
+// derivations let a reference nutrient be computed from an equivalent one the product reports
+var derivations = map[string]struct {
+	from   string
+	factor float64
+}{
+	"sodium":      {"salt", 1 / saltPerSodium},
+	"salt":        {"sodium", saltPerSodium},
+	"energy-kcal": {"energy", 1 / kilojoulesPerKilocalorie},
+	"energy":      {"energy-kcal", kilojoulesPerKilocalorie},
+}
+
+// DailyValue is a nutrient amount as a percentage of its daily reference intake
+type DailyValue struct {
+	Amount    float64 `json:"amount"`            // Nutrient amount in Unit
+	Unit      string  `json:"unit"`              // Unit of the reference intake
+	Reference float64 `json:"reference"`         // Daily reference intake in Unit
+	Percent   float64 `json:"percent"`           // Amount / Reference * 100, one decimal
+	Derived   bool    `json:"derived,omitempty"` // Computed from salt/sodium or kJ/kcal
+}
+
+// DailyValues are the percentages of one reference table for an amount of food
+type DailyValues struct {
+	Reference     string                `json:"reference"`
+	ReferenceName string                `json:"reference_name"`
+	Basis         string                `json:"basis"`            // per_100, serving or meal
+	Amount        float64               `json:"amount,omitempty"` // Quantity of food the percentages are for
+	Unit          string                `json:"unit,omitempty"`   // g or ml
+	Nutrients     map[string]DailyValue `json:"nutrients"`
+	Missing       []string              `json:"missing,omitempty"` // Reference nutrients without data
+}
+
+// ProductDailyValues are a product's daily value percentages per 100 g/ml and per serving
+type ProductDailyValues struct {
+	Code       string       `json:"code"`
+	Per100     DailyValues  `json:"per_100"`
+	PerServing *DailyValues `json:"per_serving,omitempty"` // Omitted when the serving size is unknown
+}
+
+// ReferenceIDs returns the IDs of the reference tables shipped with the server, sorted
+func ReferenceIDs() []string {
+	ids := make([]string, 0, len(referenceTables))
+	for id := range referenceTables {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// LookupReference returns the reference table with the given ID
+func LookupReference(id string) (*ReferenceTable, error) {
+	table, ok := referenceTables[strings.ToLower(strings.TrimSpace(id))]
+	if !ok {
+		return nil, fmt.Errorf("%w %q: must be one of %s", ErrUnknownReference, id, strings.Join(ReferenceIDs(), ", "))
+	}
+	return table, nil
+}
+
+// Percentages compares nutrient amounts against the table's reference intakes
+func (t *ReferenceTable) Percentages(nutrients map[string]Amount, basis string, amount float64, unit string) DailyValues {
+	values := DailyValues{
+		Reference:     t.ID,
+		ReferenceName: t.Name,
+		Basis:         basis,
+		Amount:        amount,
+		Unit:          unit,
+		Nutrients:     map[string]DailyValue{},
+	}
+
+	for name, intake := range t.Intakes {
+		nutrient, derived, ok := lookupNutrient(nutrients, name)
+		if !ok {
+			values.Missing = append(values.Missing, name)
+			continue
+		}
+		converted, ok := convertAmount(nutrient, intake.Unit)
+		if !ok {
+			values.Missing = append(values.Missing, name)
+			continue
+		}
+		values.Nutrients[name] = DailyValue{
+			Amount:    round(converted),
+			Unit:      intake.Unit,
+			Reference: intake.Amount,
+			Percent:   math.Round(converted/intake.Amount*1000) / 10,
+			Derived:   derived,
+		}
+	}
+
+	sort.Strings(values.Missing)
+	return values
+}
+
+// DailyValuesForProduct computes a product's percentages per 100 g/ml and, when known, per serving
+func DailyValuesForProduct(p *types.Product, table *ReferenceTable) ProductDailyValues {
+	nutriments := p.ParsedNutriments()
+	basisUnit := BasisUnit(p)
+
+	result := ProductDailyValues{
+		Code:   p.Code,
+		Per100: table.Percentages(scaleExact(nutriments, 100), BasisPer100, 100, basisUnit),
+	}
+
+	if serving := ResolveServing(p); serving.Resolved {
+		perServing := table.Percentages(scaleExact(nutriments, serving.Amount), BasisServing, round(serving.Amount), serving.Unit)
+		result.PerServing = &perServing
+	}
+	return result
+}
+
+// DailyValuesForMeal computes the percentages of a meal's nutrient totals
+// Percentages use the totals before rounding when the meal was built by CalculateMeal
+func DailyValuesForMeal(meal MealNutrition, table *ReferenceTable) DailyValues {
+	if meal.exactTotals != nil {
+		return table.Percentages(meal.exactTotals, BasisMeal, 0, "")
+	}
+	totals := make(map[string]Amount, len(meal.Totals))
+	for name, total := range meal.Totals {
+		totals[name] = Amount{Amount: total.Amount, Unit: total.Unit}
+	}
+	return table.Percentages(totals, BasisMeal, 0, "")
+}
+
+// lookupNutrient finds a nutrient, deriving it from an equivalent one when it is not reported
+func lookupNutrient(nutrients map[string]Amount, name string) (Amount, bool, bool) {
+	if nutrient, ok := nutrients[name]; ok {
+		return nutrient, false, true
+	}
+	derivation, ok := derivations[name]
+	if !ok {
+		return Amount{}, false, false
+	}
+	source, ok := nutrients[derivation.from]
+	if !ok {
+		return Amount{}, false, false
+	}
+	return Amount{Amount: source.Amount * derivation.factor, Unit: NutrientUnit(name)}, true, true
+}
+
+// convertAmount expresses a nutrient amount in the unit of a reference intake
+func convertAmount(nutrient Amount, unit string) (float64, bool) {
+	if nutrient.Unit == unit {
+		return nutrient.Amount, true
+	}
+	from, fromOK := unitsPerGram[nutrient.Unit]
+	to, toOK := unitsPerGram[unit]
+	if !fromOK || !toOK {
+		return 0, false
+	}
+	return nutrient.Amount / from * to, true
+}
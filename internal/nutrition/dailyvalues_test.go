@@ -0,0 +1,161 @@
+package nutrition
+
+import (
+	"testing"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupReference(t *testing.T) {
+	assert.Equal(t, []string{ReferenceEURI, ReferenceUKChild, ReferenceUSFDA2016}, ReferenceIDs())
+
+	table, err := LookupReference(" US-FDA-2016 ")
+	require.NoError(t, err)
+	assert.Equal(t, ReferenceUSFDA2016, table.ID)
+	assert.Equal(t, ReferenceIntake{Amount: 2300, Unit: UnitMilligram}, table.Intakes["sodium"])
+
+	_, err = LookupReference("fr-anses")
+	assert.ErrorIs(t, err, ErrUnknownReference)
+}
+
+func TestReferenceTables(t *testing.T) {
+	for _, id := range ReferenceIDs() {
+		table, err := LookupReference(id)
+		require.NoError(t, err)
+		assert.NotEmpty(t, table.Name)
+		assert.NotEmpty(t, table.Source)
+		for name, intake := range table.Intakes {
+			assert.Positive(t, intake.Amount, "%s %s", id, name)
+			if name == "energy" || name == "energy-kcal" {
+				assert.Equal(t, NutrientUnit(name), intake.Unit, "%s %s", id, name)
+				continue
+			}
+			assert.Contains(t, unitsPerGram, intake.Unit, "%s %s", id, name)
+		}
+	}
+}
+
+func TestDailyValuesForProduct(t *testing.T) {
+	tests := []struct {
+		name              string
+		reference         string
+		nutrient          string
+		expectedPer100    DailyValue
+		expectedServing   float64
+		expectedInMissing string
+	}{
+		{
+			name:              "US sodium in milligrams",
+			reference:         ReferenceUSFDA2016,
+			nutrient:          "sodium",
+			expectedPer100:    DailyValue{Amount: 42.8, Unit: UnitMilligram, Reference: 2300, Percent: 1.9},
+			expectedServing:   0.3,
+			expectedInMissing: "fat",
+		},
+		{
+			name:              "EU salt derived from sodium",
+			reference:         ReferenceEURI,
+			nutrient:          "salt",
+			expectedPer100:    DailyValue{Amount: 0.107, Unit: UnitGram, Reference: 6, Percent: 1.8, Derived: true},
+			expectedServing:   0.3,
+			expectedInMissing: "proteins",
+		},
+		{
+			name:              "EU sugars",
+			reference:         ReferenceEURI,
+			nutrient:          "sugars",
+			expectedPer100:    DailyValue{Amount: 56.3, Unit: UnitGram, Reference: 90, Percent: 62.6},
+			expectedServing:   9.4,
+			expectedInMissing: "fat",
+		},
+		{
+			name:              "UK child energy",
+			reference:         ReferenceUKChild,
+			nutrient:          "energy-kcal",
+			expectedPer100:    DailyValue{Amount: 539, Unit: UnitKilocalorie, Reference: 1800, Percent: 29.9},
+			expectedServing:   4.5,
+			expectedInMissing: "fiber",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := LookupReference(tt.reference)
+			require.NoError(t, err)
+
+			result := DailyValuesForProduct(testProduct(), table)
+			assert.Equal(t, "3017620422003", result.Code)
+			assert.Equal(t, BasisPer100, result.Per100.Basis)
+			assert.Equal(t, 100.0, result.Per100.Amount)
+			assert.Equal(t, UnitGram, result.Per100.Unit)
+			assert.Equal(t, tt.expectedPer100, result.Per100.Nutrients[tt.nutrient])
+			assert.Contains(t, result.Per100.Missing, tt.expectedInMissing)
+
+			require.NotNil(t, result.PerServing)
+			assert.Equal(t, BasisServing, result.PerServing.Basis)
+			assert.Equal(t, 15.0, result.PerServing.Amount)
+			assert.Equal(t, tt.expectedServing, result.PerServing.Nutrients[tt.nutrient].Percent)
+		})
+	}
+}
+
+func TestDailyValuesForProduct_MicronutrientsAndUnknownServing(t *testing.T) {
+	product := &types.Product{
+		Code: "123",
+		Nutriments: map[string]interface{}{
+			"vitamin-d": 0.0000025,
+			"energy":    1000.0,
+		},
+	}
+
+	table, err := LookupReference(ReferenceEURI)
+	require.NoError(t, err)
+
+	result := DailyValuesForProduct(product, table)
+	assert.Nil(t, result.PerServing)
+	assert.Equal(t, DailyValue{Amount: 2.5, Unit: UnitMicrogram, Reference: 5, Percent: 50}, result.Per100.Nutrients["vitamin-d"])
+	assert.Equal(t, DailyValue{Amount: 1000, Unit: UnitKilojoule, Reference: 8400, Percent: 11.9}, result.Per100.Nutrients["energy"])
+	assert.Equal(t, DailyValue{Amount: 239.006, Unit: UnitKilocalorie, Reference: 2000, Percent: 12, Derived: true}, result.Per100.Nutrients["energy-kcal"])
+}
+
+func TestDailyValuesForMeal(t *testing.T) {
+	meal := CalculateMeal(
+		[]MealItem{{Barcode: "3017620422003", Quantity: 2, Unit: "serving"}},
+		map[string]*types.Product{"3017620422003": testProduct()},
+	)
+
+	table, err := LookupReference(ReferenceUSFDA2016)
+	require.NoError(t, err)
+
+	values := DailyValuesForMeal(meal, table)
+	assert.Equal(t, BasisMeal, values.Basis)
+	assert.Equal(t, DailyValue{Amount: 161.7, Unit: UnitKilocalorie, Reference: 2000, Percent: 8.1}, values.Nutrients["energy-kcal"])
+	assert.Equal(t, 0.6, values.Nutrients["sodium"].Percent)
+	assert.NotContains(t, values.Nutrients, "sugars")
+}
+
+func TestDailyValuesForMeal_Micronutrients(t *testing.T) {
+	fortified := &types.Product{
+		Code: "3250390000000",
+		Nutriments: map[string]interface{}{
+			"vitamin-d":   nutrient("vitamin-d", 0.000005, "µg"),
+			"vitamin-b12": nutrient("vitamin-b12", 0.000001, "µg"),
+		},
+	}
+	// 200 g in two portions, so the percentages come from summed microgram amounts
+	meal := CalculateMeal(
+		[]MealItem{{Barcode: "3250390000000", Quantity: 120}, {Barcode: "3250390000000", Quantity: 80}},
+		map[string]*types.Product{"3250390000000": fortified},
+	)
+	assert.Equal(t, 0.00001, meal.Totals["vitamin-d"].Amount)
+	assert.Equal(t, 0.000002, meal.Totals["vitamin-b12"].Amount)
+
+	table, err := LookupReference(ReferenceUSFDA2016)
+	require.NoError(t, err)
+
+	values := DailyValuesForMeal(meal, table)
+	assert.Equal(t, DailyValue{Amount: 10, Unit: UnitMicrogram, Reference: 20, Percent: 50}, values.Nutrients["vitamin-d"])
+	assert.Equal(t, DailyValue{Amount: 2, Unit: UnitMicrogram, Reference: 2.4, Percent: 83.3}, values.Nutrients["vitamin-b12"])
+}
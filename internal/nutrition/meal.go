@@ -0,0 +1,210 @@
+package nutrition
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/types"
+)
+
+// kilojoulesPerKilocalorie converts between the two energy units
+const kilojoulesPerKilocalorie = 4.184
+
+// MealItem is one food in a meal, e.g. {"barcode": "3017620422003", "quantity": 2, "unit": "serving"}
+type MealItem struct {
+	Barcode  string  `json:"barcode"`
+	Quantity float64 `json:"quantity"`
+	Unit     string  `json:"unit,omitempty"` // g, ml or serving; defaults to g
+}
+
+// ItemContribution is the nutrient contribution of one meal item
+type ItemContribution struct {
+	Barcode     string            `json:"barcode"`
+	ProductName string            `json:"product_name,omitempty"`
+	Quantity    float64           `json:"quantity"`
+	Unit        string            `json:"unit"`
+	Amount      float64           `json:"amount,omitempty"`      // Resolved quantity in AmountUnit
+	AmountUnit  string            `json:"amount_unit,omitempty"` // g or ml
+	Counted     bool              `json:"counted"`               // Whether the item is included in the totals
+	Reason      string            `json:"reason,omitempty"`      // Why the item was not counted
+	Nutrients   map[string]Amount `json:"nutrients"`
+	Warnings    []string          `json:"warnings,omitempty"`
+	Quality     *QualityReport    `json:"quality,omitempty"` // Data quality of the product, omitted when it was not found
+
+	exact map[string]Amount // Nutrients before rounding, summed for the totals
+}
+
+// MealTotal is the combined amount of one nutrient across the counted items
+type MealTotal struct {
+	Amount      float64  `json:"amount"`
+	Unit        string   `json:"unit"`
+	MissingFrom []string `json:"missing_from,omitempty"` // Counted items without this nutrient; the total is a lower bound
+}
+
+// IncompleteItem is a meal item left out of the totals or missing nutrients reported by other items
+type IncompleteItem struct {
+	Index            int      `json:"index"` // Position in the request
+	Barcode          string   `json:"barcode"`
+	Reason           string   `json:"reason,omitempty"`
+	MissingNutrients []string `json:"missing_nutrients,omitempty"`
+}
+
+// MealNutrition is the result of a meal calculation
+type MealNutrition struct {
+	ItemCount    int                  `json:"item_count"`
+	CountedItems int                  `json:"counted_items"`
+	Totals       map[string]MealTotal `json:"totals"`
+	Items        []ItemContribution   `json:"items"`
+	Incomplete   []IncompleteItem     `json:"incomplete_items"`
+	DailyValues  *DailyValues         `json:"daily_values,omitempty"` // Percentages of the totals, when requested
+
+	exactTotals map[string]Amount // Totals before rounding, for daily value percentages
+}
+
+// CalculateMeal sums the nutrients of the meal items using the products keyed by barcode
+// Items whose product is missing or whose quantity cannot be resolved are reported but not counted
+func CalculateMeal(items []MealItem, products map[string]*types.Product) MealNutrition {
+	meal := MealNutrition{
+		ItemCount:   len(items),
+		Totals:      map[string]MealTotal{},
+		Items:       make([]ItemContribution, 0, len(items)),
+		Incomplete:  []IncompleteItem{},
+		exactTotals: map[string]Amount{},
+	}
+
+	for _, item := range items {
+		contribution := contributionFor(item, products[strings.TrimSpace(item.Barcode)])
+		meal.Items = append(meal.Items, contribution)
+		if !contribution.Counted {
+			continue
+		}
+
+		meal.CountedItems++
+		for name, amount := range contribution.exact {
+			exact := meal.exactTotals[name]
+			exact.Amount += amount.Amount
+			exact.Unit = amount.Unit
+			meal.exactTotals[name] = exact
+		}
+	}
+
+	// Only the displayed totals are rounded, so µg-level nutrients still add up
+	for name, exact := range meal.exactTotals {
+		meal.Totals[name] = MealTotal{Amount: roundAmount(exact.Amount), Unit: exact.Unit}
+	}
+
+	// A nutrient is incomplete when any counted item does not report it
+	for name, total := range meal.Totals {
+		for _, contribution := range meal.Items {
+			if contribution.Counted {
+				if _, ok := contribution.Nutrients[name]; !ok {
+					total.MissingFrom = append(total.MissingFrom, contribution.Barcode)
+				}
+			}
+		}
+		meal.Totals[name] = total
+	}
+
+	for i, contribution := range meal.Items {
+		if !contribution.Counted {
+			meal.Incomplete = append(meal.Incomplete, IncompleteItem{Index: i, Barcode: contribution.Barcode, Reason: contribution.Reason})
+			continue
+		}
+		if missing := missingNutrients(contribution, meal.Totals); len(missing) > 0 {
+			meal.Incomplete = append(meal.Incomplete, IncompleteItem{Index: i, Barcode: contribution.Barcode, MissingNutrients: missing})
+		}
+	}
+
+	return meal
+}
+
+// contributionFor scales one meal item, recording why it could not be counted
+func contributionFor(item MealItem, product *types.Product) ItemContribution {
+	unit := strings.ToLower(strings.TrimSpace(item.Unit))
+	if unit == "" {
+		unit = UnitGram
+	}
+
+	contribution := ItemContribution{
+		Barcode:   strings.TrimSpace(item.Barcode),
+		Quantity:  item.Quantity,
+		Unit:      unit,
+		Nutrients: map[string]Amount{},
+	}
+
+	if product == nil {
+		contribution.Reason = "product not found"
+		return contribution
+	}
+	contribution.ProductName = product.ProductName
+	quality := AssessQuality(product)
+	contribution.Quality = &quality
+
+	result, err := ForQuantity(product, item.Quantity, unit)
+	if err != nil {
+		contribution.Reason = err.Error()
+		return contribution
+	}
+
+	contribution.Warnings = result.Warnings
+	if !result.Resolved {
+		contribution.Reason = "serving size could not be resolved: " + result.Serving.Reason
+		return contribution
+	}
+	if len(result.Nutrients) == 0 {
+		contribution.Reason = "product has no per-100 nutrient values"
+		return contribution
+	}
+
+	contribution.Unit = result.RequestedUnit
+	contribution.Amount = result.Amount
+	contribution.AmountUnit = result.Unit
+	contribution.exact = scaleExact(product.ParsedNutriments(), result.Amount)
+	fillEnergy(contribution.exact)
+	for name, nutrient := range contribution.exact {
+		nutrient.Amount = roundAmount(nutrient.Amount)
+		contribution.Nutrients[name] = nutrient
+	}
+	contribution.Counted = true
+	return contribution
+}
+
+// missingNutrients returns the nutrients in the totals that a counted item does not report, sorted
+func missingNutrients(contribution ItemContribution, totals map[string]MealTotal) []string {
+	var missing []string
+	for name := range totals {
+		if _, ok := contribution.Nutrients[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// fillEnergy derives energy-kcal from energy (kJ) or the reverse, so items that
+// report energy in different units still add up; the amount is rounded by the caller
+func fillEnergy(nutrients map[string]Amount) {
+	kcal, hasKcal := nutrients["energy-kcal"]
+	kj, hasKj := nutrients["energy"]
+	switch {
+	case hasKj && !hasKcal:
+		nutrients["energy-kcal"] = Amount{Amount: kj.Amount / kilojoulesPerKilocalorie, Unit: "kcal", Per100: round(kj.Per100 / kilojoulesPerKilocalorie)}
+	case hasKcal && !hasKj:
+		nutrients["energy"] = Amount{Amount: kcal.Amount * kilojoulesPerKilocalorie, Unit: "kJ", Per100: round(kcal.Per100 * kilojoulesPerKilocalorie)}
+	}
+}
+
+// MealBarcodes returns the distinct barcodes of the meal items in order
+func MealBarcodes(items []MealItem) []string {
+	seen := make(map[string]bool, len(items))
+	barcodes := make([]string, 0, len(items))
+	for _, item := range items {
+		barcode := strings.TrimSpace(item.Barcode)
+		if barcode == "" || seen[barcode] {
+			continue
+		}
+		seen[barcode] = true
+		barcodes = append(barcodes, barcode)
+	}
+	return barcodes
+}
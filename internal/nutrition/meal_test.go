@@ -0,0 +1,75 @@
+package nutrition
+
+import (
+	"testing"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateMeal(t *testing.T) {
+	cola := &types.Product{
+		Code:                "5449000000996",
+		ProductName:         "Coca-Cola",
+		ServingSize:         "1 can (330 ml)",
+		ServingQuantityUnit: "ml",
+		Nutriments: map[string]interface{}{
+			"energy": nutrient("energy", 180, "kJ"),
+			"sugars": nutrient("sugars", 10.6, "g"),
+		},
+	}
+	noServing := &types.Product{
+		Code:        "1111111111111",
+		ProductName: "Loose bread",
+		Nutriments:  map[string]interface{}{"sugars": nutrient("sugars", 3, "g")},
+	}
+	products := map[string]*types.Product{
+		"3017620422003": testProduct(),
+		cola.Code:       cola,
+		noServing.Code:  noServing,
+	}
+
+	items := []MealItem{
+		{Barcode: "3017620422003", Quantity: 2, Unit: "serving"},
+		{Barcode: " 5449000000996 ", Quantity: 1, Unit: "serving"},
+		{Barcode: "3017620422003", Quantity: 10},
+		{Barcode: "1111111111111", Quantity: 1, Unit: "serving"},
+		{Barcode: "404", Quantity: 100, Unit: "g"},
+		{Barcode: "5449000000996", Quantity: 0, Unit: "ml"},
+	}
+
+	meal := CalculateMeal(items, products)
+
+	assert.Equal(t, 6, meal.ItemCount)
+	assert.Equal(t, 3, meal.CountedItems)
+	require.Len(t, meal.Items, 6)
+
+	// Nutella 30 g + 10 g and one can of cola
+	assert.Equal(t, 16.89, meal.Items[0].Nutrients["sugars"].Amount)
+	assert.Equal(t, 34.98, meal.Items[1].Nutrients["sugars"].Amount)
+	assert.Equal(t, 5.63, meal.Items[2].Nutrients["sugars"].Amount)
+	assert.Equal(t, 57.5, meal.Totals["sugars"].Amount)
+	assert.Equal(t, "g", meal.Totals["sugars"].Unit)
+	assert.Empty(t, meal.Totals["sugars"].MissingFrom)
+
+	// Energy in kJ only is converted so kcal totals include every item
+	assert.Equal(t, 141.969, meal.Items[1].Nutrients["energy-kcal"].Amount)
+	assert.Equal(t, 357.569, meal.Totals["energy-kcal"].Amount)
+
+	// Sodium is only reported by Nutella, so the cola is listed as missing it
+	assert.Equal(t, []string{"5449000000996"}, meal.Totals["sodium"].MissingFrom)
+
+	require.Len(t, meal.Incomplete, 4)
+	assert.Equal(t, IncompleteItem{Index: 1, Barcode: "5449000000996", MissingNutrients: []string{"sodium"}}, meal.Incomplete[0])
+	assert.Equal(t, 3, meal.Incomplete[1].Index)
+	assert.Contains(t, meal.Incomplete[1].Reason, "serving size could not be resolved")
+	assert.Equal(t, IncompleteItem{Index: 4, Barcode: "404", Reason: "product not found"}, meal.Incomplete[2])
+	assert.Equal(t, 5, meal.Incomplete[3].Index)
+	assert.Contains(t, meal.Incomplete[3].Reason, "quantity must be greater than 0")
+}
+
+func TestMealBarcodes(t *testing.T) {
+	items := []MealItem{{Barcode: "2"}, {Barcode: " 1 "}, {Barcode: "2"}, {Barcode: ""}}
+	assert.Equal(t, []string{"2", "1"}, MealBarcodes(items))
+}
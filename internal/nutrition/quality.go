@@ -0,0 +1,218 @@
+package nutrition
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/types"
+)
+
+// Quality issue severities
+const (
+	SeverityError   = "error"   // The values contradict each other; don't quote them without a caveat
+	SeverityWarning = "warning" // The values are incomplete or suspicious
+)
+
+// Quality issue codes
+const (
+	IssueNoNutritionData          = "no_nutrition_data"
+	IssueMissingNutrients         = "missing_nutrients"
+	IssueNegativeNutrient         = "negative_nutrient"
+	IssueEnergyMismatch           = "energy_mismatch"
+	IssueEnergyUnitsMismatch      = "energy_units_mismatch"
+	IssueSugarsExceedCarbohydrate = "sugars_exceed_carbohydrates"
+	IssueSaturatedFatExceedsFat   = "saturated_fat_exceeds_fat"
+	IssueMacrosExceed100          = "macros_exceed_100"
+	IssueServingSizeUnparseable   = "serving_size_unparseable"
+)
+
+// Atwater factors used to estimate energy from the macronutrients, kcal per gram
+const (
+	kcalPerGramCarbohydrate = 4
+	kcalPerGramProtein      = 4
+	kcalPerGramFat          = 9
+	kcalPerGramAlcohol      = 7
+	gramsPerMlAlcohol       = 0.789 // Converts alcohol % vol to grams per 100 ml
+)
+
+// Tolerances for the consistency checks, which must absorb label rounding and fiber/polyols
+const (
+	energyMismatchRatio   = 0.2  // Relative difference between declared and estimated energy
+	energyMismatchMinKcal = 20.0 // Differences below this many kcal per 100 are ignored
+	energyUnitsRatio      = 0.05 // Allowed difference between the declared kJ and kcal
+	componentTolerance    = 0.1  // Grams a part may exceed its whole, e.g. sugars over carbohydrates
+	macrosTolerance       = 1.0  // Grams the macros may exceed 100 g per 100 g
+)
+
+// mandatoryNutrients are the nutrients of the EU nutrition declaration; energy is checked separately
+var mandatoryNutrients = []string{"fat", "saturated-fat", "carbohydrates", "sugars", "proteins", "salt"}
+
+// completenessFields are the fields the completeness percentage is computed over
+var completenessFields = []string{
+	"energy", "fat", "saturated-fat", "carbohydrates", "sugars", "fiber", "proteins", "salt",
+	"product_name", "ingredients", "serving_size",
+}
+
+// QualityIssue is one problem found in a product's data
+type QualityIssue struct {
+	Code     string `json:"code"`
+	Severity string `json:"severity"` // error or warning
+	Message  string `json:"message"`
+}
+
+// QualityReport describes how complete and self-consistent a product's data is
+type QualityReport struct {
+	Code         string         `json:"code"`
+	Completeness float64        `json:"completeness"`      // Percentage of the checked fields that are present
+	Missing      []string       `json:"missing,omitempty"` // Checked fields without data
+	Reliable     bool           `json:"reliable"`          // False when any issue is an error
+	Issues       []QualityIssue `json:"issues"`
+}
+
+// AssessQuality checks a product's nutrition data for gaps and contradictions
+func AssessQuality(p *types.Product) QualityReport {
+	report := QualityReport{Code: p.Code, Issues: []QualityIssue{}}
+	nutriments := p.ParsedNutriments()
+	per100 := func(name string) (float64, bool) {
+		if nutriment, ok := nutriments[name]; ok && nutriment.Per100g != nil {
+			return *nutriment.Per100g, true
+		}
+		return 0, false
+	}
+	issue := func(code, severity, format string, args ...interface{}) {
+		report.Issues = append(report.Issues, QualityIssue{Code: code, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	kj, hasKJ := per100("energy")
+	if !hasKJ {
+		kj, hasKJ = per100("energy-kj")
+	}
+	kcal, hasKcal := per100("energy-kcal")
+	_, hasSalt := per100("salt")
+	_, hasSodium := per100("sodium")
+
+	present := map[string]bool{
+		"energy":       hasKJ || hasKcal,
+		"salt":         hasSalt || hasSodium,
+		"product_name": strings.TrimSpace(p.ProductName) != "",
+		"ingredients":  hasIngredients(p.Ingredients),
+		"serving_size": strings.TrimSpace(p.ServingSize) != "" || p.ServingQuantity != nil,
+	}
+	for _, name := range []string{"fat", "saturated-fat", "carbohydrates", "sugars", "fiber", "proteins"} {
+		_, present[name] = per100(name)
+	}
+	for _, field := range completenessFields {
+		if !present[field] {
+			report.Missing = append(report.Missing, field)
+		}
+	}
+	report.Completeness = math.Round(float64(len(completenessFields)-len(report.Missing))/float64(len(completenessFields))*1000) / 10
+
+	// Presence of the nutrition declaration
+	var missingNutrients []string
+	if !present["energy"] {
+		missingNutrients = append(missingNutrients, "energy")
+	}
+	for _, name := range mandatoryNutrients {
+		if !present[name] {
+			missingNutrients = append(missingNutrients, name)
+		}
+	}
+	switch {
+	case len(missingNutrients) == len(mandatoryNutrients)+1:
+		issue(IssueNoNutritionData, SeverityError, "product has no nutrition facts per 100 g/ml")
+	case len(missingNutrients) > 0:
+		issue(IssueMissingNutrients, SeverityWarning, "missing %s; totals and scores using them are incomplete", strings.Join(missingNutrients, ", "))
+	}
+
+	var negative []string
+	for name, nutriment := range nutriments {
+		if nutriment.Per100g != nil && *nutriment.Per100g < 0 {
+			negative = append(negative, name)
+		}
+	}
+	if len(negative) > 0 {
+		sort.Strings(negative)
+		issue(IssueNegativeNutrient, SeverityError, "negative values for %s", strings.Join(negative, ", "))
+	}
+
+	// Energy against the macronutrients
+	if hasKJ && hasKcal && math.Abs(kj/kilojoulesPerKilocalorie-kcal) > energyUnitsRatio*math.Max(kcal, kj/kilojoulesPerKilocalorie) {
+		issue(IssueEnergyUnitsMismatch, SeverityWarning, "declared energy of %s kJ does not match %s kcal (expected about %s kJ)",
+			formatValue(kj), formatValue(kcal), formatValue(kcal*kilojoulesPerKilocalorie))
+	}
+	if !hasKcal && hasKJ {
+		kcal, hasKcal = kj/kilojoulesPerKilocalorie, true
+	}
+	fat, hasFat := per100("fat")
+	carbohydrates, hasCarbohydrates := per100("carbohydrates")
+	proteins, hasProteins := per100("proteins")
+	if hasKcal && hasFat && hasCarbohydrates && hasProteins {
+		estimated := carbohydrates*kcalPerGramCarbohydrate + proteins*kcalPerGramProtein + fat*kcalPerGramFat
+		if alcohol, ok := per100("alcohol"); ok {
+			estimated += alcohol * gramsPerMlAlcohol * kcalPerGramAlcohol
+		}
+		difference := math.Abs(kcal - estimated)
+		if difference > energyMismatchMinKcal && difference > energyMismatchRatio*math.Max(kcal, estimated) {
+			issue(IssueEnergyMismatch, SeverityWarning, "declared energy of %s kcal per 100 differs from the %s kcal computed from fat, carbohydrates and proteins (9/4/4 kcal/g)",
+				formatValue(kcal), formatValue(estimated))
+		}
+	}
+
+	// Parts against their whole
+	if sugars, ok := per100("sugars"); ok && hasCarbohydrates && sugars > carbohydrates+componentTolerance {
+		issue(IssueSugarsExceedCarbohydrate, SeverityError, "sugars (%s g) exceed carbohydrates (%s g)", formatValue(sugars), formatValue(carbohydrates))
+	}
+	if saturated, ok := per100("saturated-fat"); ok && hasFat && saturated > fat+componentTolerance {
+		issue(IssueSaturatedFatExceedsFat, SeverityError, "saturated fat (%s g) exceeds fat (%s g)", formatValue(saturated), formatValue(fat))
+	}
+	if hasFat || hasCarbohydrates || hasProteins {
+		salt, _ := per100("salt")
+		if total := fat + carbohydrates + proteins + salt; total > 100+macrosTolerance {
+			issue(IssueMacrosExceed100, SeverityError, "fat, carbohydrates, proteins and salt add up to %s g per 100 g", formatValue(total))
+		}
+	}
+
+	if serving := ResolveServing(p); !serving.Resolved && serving.Text != "" {
+		issue(IssueServingSizeUnparseable, SeverityWarning, "serving size %q has no gram or millilitre amount; per-serving values are unavailable", serving.Text)
+	}
+
+	report.Reliable = true
+	for _, found := range report.Issues {
+		if found.Severity == SeverityError {
+			report.Reliable = false
+		}
+	}
+	return report
+}
+
+// AssessQualityAll reports on each product in order
+func AssessQualityAll(products []types.Product) []QualityReport {
+	reports := make([]QualityReport, 0, len(products))
+	for i := range products {
+		reports = append(reports, AssessQuality(&products[i]))
+	}
+	return reports
+}
+
+// hasIngredients reports whether a product has a parsed ingredient list or an ingredients text
+func hasIngredients(ingredients interface{}) bool {
+	switch value := ingredients.(type) {
+	case []interface{}:
+		return len(value) > 0
+	case map[string]interface{}:
+		text, _ := value["text"].(string)
+		return strings.TrimSpace(text) != ""
+	case string:
+		return strings.TrimSpace(value) != ""
+	default:
+		return false
+	}
+}
+
+// formatValue prints a value with at most one decimal
+func formatValue(value float64) string {
+	return fmt.Sprintf("%g", math.Round(value*10)/10)
+}
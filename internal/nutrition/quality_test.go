@@ -0,0 +1,156 @@
+package nutrition
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/types"
+)
+
+func TestAssessQuality(t *testing.T) {
+	complete := map[string]interface{}{
+		"energy-kcal":   539.0,
+		"energy":        2255.0,
+		"fat":           30.9,
+		"saturated-fat": 10.6,
+		"carbohydrates": 57.5,
+		"sugars":        56.3,
+		"fiber":         0.0,
+		"proteins":      6.3,
+		"salt":          0.107,
+	}
+	with := func(changes map[string]interface{}) map[string]interface{} {
+		nutriments := map[string]interface{}{}
+		for name, value := range complete {
+			nutriments[name] = value
+		}
+		for name, value := range changes {
+			if value == nil {
+				delete(nutriments, name)
+				continue
+			}
+			nutriments[name] = value
+		}
+		return nutriments
+	}
+
+	tests := []struct {
+		name                 string
+		product              types.Product
+		expectedIssues       []string
+		expectedReliable     bool
+		expectedCompleteness float64
+		expectedMissing      []string
+	}{
+		{
+			name: "complete and consistent",
+			product: types.Product{
+				ProductName: "Nutella", Nutriments: complete, ServingSize: "15 g",
+				Ingredients: map[string]interface{}{"text": "sugar, palm oil"},
+			},
+			expectedIssues:       []string{},
+			expectedReliable:     true,
+			expectedCompleteness: 100,
+		},
+		{
+			name:                 "no nutrition data",
+			product:              types.Product{ProductName: "Mystery"},
+			expectedIssues:       []string{IssueNoNutritionData},
+			expectedReliable:     false,
+			expectedCompleteness: 9.1,
+			expectedMissing: []string{
+				"energy", "fat", "saturated-fat", "carbohydrates", "sugars", "fiber", "proteins", "salt",
+				"ingredients", "serving_size",
+			},
+		},
+		{
+			name:                 "missing nutrients and unparseable serving",
+			product:              types.Product{ProductName: "Biscuits", Nutriments: with(map[string]interface{}{"saturated-fat": nil, "salt": nil}), ServingSize: "2 biscuits"},
+			expectedIssues:       []string{IssueMissingNutrients, IssueServingSizeUnparseable},
+			expectedReliable:     true,
+			expectedCompleteness: 72.7,
+			expectedMissing:      []string{"saturated-fat", "salt", "ingredients"},
+		},
+		{
+			name:                 "sodium counts as salt",
+			product:              types.Product{ProductName: "Soup", Nutriments: with(map[string]interface{}{"salt": nil, "sodium": 0.04}), ServingSize: "250 ml"},
+			expectedIssues:       []string{},
+			expectedReliable:     true,
+			expectedCompleteness: 90.9,
+			expectedMissing:      []string{"ingredients"},
+		},
+		{
+			name:                 "energy does not match macros",
+			product:              types.Product{Nutriments: with(map[string]interface{}{"energy-kcal": 239.0, "energy": 1000.0})},
+			expectedIssues:       []string{IssueEnergyMismatch},
+			expectedReliable:     true,
+			expectedCompleteness: 72.7,
+			expectedMissing:      []string{"product_name", "ingredients", "serving_size"},
+		},
+		{
+			name:                 "kJ entered as kcal",
+			product:              types.Product{Nutriments: with(map[string]interface{}{"energy-kcal": 2255.0})},
+			expectedIssues:       []string{IssueEnergyUnitsMismatch, IssueEnergyMismatch},
+			expectedReliable:     true,
+			expectedCompleteness: 72.7,
+			expectedMissing:      []string{"product_name", "ingredients", "serving_size"},
+		},
+		{
+			name: "parts exceed their whole",
+			product: types.Product{Nutriments: with(map[string]interface{}{
+				"energy-kcal": nil, "energy": nil, "sugars": 60.0, "saturated-fat": 35.0,
+			})},
+			expectedIssues:       []string{IssueMissingNutrients, IssueSugarsExceedCarbohydrate, IssueSaturatedFatExceedsFat},
+			expectedReliable:     false,
+			expectedCompleteness: 63.6,
+			expectedMissing:      []string{"energy", "product_name", "ingredients", "serving_size"},
+		},
+		{
+			name: "macros over 100 g and negative values",
+			product: types.Product{Nutriments: with(map[string]interface{}{
+				"energy-kcal": nil, "energy": nil, "fat": 60.0, "carbohydrates": 70.0, "fiber": -1.0,
+			})},
+			expectedIssues:       []string{IssueMissingNutrients, IssueNegativeNutrient, IssueMacrosExceed100},
+			expectedReliable:     false,
+			expectedCompleteness: 63.6,
+			expectedMissing:      []string{"energy", "product_name", "ingredients", "serving_size"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := AssessQuality(&tt.product)
+
+			codes := []string{}
+			for _, issue := range report.Issues {
+				codes = append(codes, issue.Code)
+				assert.NotEmpty(t, issue.Message)
+			}
+			assert.Equal(t, tt.expectedIssues, codes)
+			assert.Equal(t, tt.expectedReliable, report.Reliable)
+			assert.Equal(t, tt.expectedCompleteness, report.Completeness)
+			assert.Equal(t, tt.expectedMissing, report.Missing)
+		})
+	}
+}
+
+func TestAssessQuality_Messages(t *testing.T) {
+	report := AssessQuality(&types.Product{Nutriments: map[string]interface{}{
+		"energy-kcal": 100.0, "fat": 20.0, "carbohydrates": 10.0, "sugars": 12.0, "saturated-fat": 2.0, "proteins": 5.0, "salt": 0.5,
+	}})
+
+	require.Len(t, report.Issues, 2)
+	assert.Equal(t, "declared energy of 100 kcal per 100 differs from the 240 kcal computed from fat, carbohydrates and proteins (9/4/4 kcal/g)", report.Issues[0].Message)
+	assert.Equal(t, SeverityWarning, report.Issues[0].Severity)
+	assert.Equal(t, "sugars (12 g) exceed carbohydrates (10 g)", report.Issues[1].Message)
+	assert.Equal(t, SeverityError, report.Issues[1].Severity)
+}
+
+func TestAssessQualityAll(t *testing.T) {
+	reports := AssessQualityAll([]types.Product{{Code: "1"}, {Code: "2"}})
+	require.Len(t, reports, 2)
+	assert.Equal(t, "1", reports[0].Code)
+	assert.Equal(t, "2", reports[1].Code)
+}
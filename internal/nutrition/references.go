@@ -0,0 +1,145 @@
+package nutrition
+
+// Reference table IDs accepted by the daily_values tool parameter
+const (
+	ReferenceUSFDA2016 = "us-fda-2016"
+	ReferenceEURI      = "eu-ri"
+	ReferenceUKChild   = "uk-child"
+)
+
+// Units of reference intakes
+const (
+	UnitMilligram   = "mg"
+	UnitMicrogram   = "µg"
+	UnitKilocalorie = "kcal"
+	UnitKilojoule   = "kJ"
+)
+
+// ReferenceIntake is the daily reference amount of one nutrient
+type ReferenceIntake struct {
+	Amount float64 `json:"amount"`
+	Unit   string  `json:"unit"` // g, mg, µg, kcal or kJ
+}
+
+// ReferenceTable is a set of daily reference intakes keyed by Open Food Facts nutrient name
+type ReferenceTable struct {
+	ID         string                     `json:"id"`
+	Name       string                     `json:"name"`
+	Source     string                     `json:"source"`
+	Population string                     `json:"population"`
+	Intakes    map[string]ReferenceIntake `json:"intakes"`
+}
+
+// referenceTables are the reference intake tables shipped with the server
+var referenceTables = map[string]*ReferenceTable{
+	ReferenceUSFDA2016: {
+		ID:         ReferenceUSFDA2016,
+		Name:       "US FDA Daily Values (2016)",
+		Source:     "21 CFR 101.9, as revised by the 2016 Nutrition Facts label final rule",
+		Population: "adults and children 4 years and older, 2000 kcal diet",
+		Intakes: map[string]ReferenceIntake{
+			"energy-kcal":      {2000, UnitKilocalorie},
+			"fat":              {78, UnitGram},
+			"saturated-fat":    {20, UnitGram},
+			"cholesterol":      {300, UnitMilligram},
+			"sodium":           {2300, UnitMilligram},
+			"carbohydrates":    {275, UnitGram},
+			"fiber":            {28, UnitGram},
+			"added-sugars":     {50, UnitGram},
+			"proteins":         {50, UnitGram},
+			"vitamin-a":        {900, UnitMicrogram},
+			"vitamin-c":        {90, UnitMilligram},
+			"vitamin-d":        {20, UnitMicrogram},
+			"vitamin-e":        {15, UnitMilligram},
+			"vitamin-k":        {120, UnitMicrogram},
+			"vitamin-b1":       {1.2, UnitMilligram},
+			"vitamin-b2":       {1.3, UnitMilligram},
+			"vitamin-pp":       {16, UnitMilligram},
+			"vitamin-b6":       {1.7, UnitMilligram},
+			"vitamin-b9":       {400, UnitMicrogram},
+			"vitamin-b12":      {2.4, UnitMicrogram},
+			"biotin":           {30, UnitMicrogram},
+			"pantothenic-acid": {5, UnitMilligram},
+			"choline":          {550, UnitMilligram},
+			"calcium":          {1300, UnitMilligram},
+			"iron":             {18, UnitMilligram},
+			"potassium":        {4700, UnitMilligram},
+			"phosphorus":       {1250, UnitMilligram},
+			"iodine":           {150, UnitMicrogram},
+			"magnesium":        {420, UnitMilligram},
+			"zinc":             {11, UnitMilligram},
+			"selenium":         {55, UnitMicrogram},
+			"copper":           {0.9, UnitMilligram},
+			"manganese":        {2.3, UnitMilligram},
+			"chromium":         {35, UnitMicrogram},
+			"molybdenum":       {45, UnitMicrogram},
+			"chloride":         {2300, UnitMilligram},
+		},
+	},
+	ReferenceEURI: {
+		ID:         ReferenceEURI,
+		Name:       "EU Reference Intakes",
+		Source:     "Regulation (EU) No 1169/2011, Annex XIII",
+		Population: "average adult, 8400 kJ / 2000 kcal",
+		Intakes: map[string]ReferenceIntake{
+			"energy":           {8400, UnitKilojoule},
+			"energy-kcal":      {2000, UnitKilocalorie},
+			"fat":              {70, UnitGram},
+			"saturated-fat":    {20, UnitGram},
+			"carbohydrates":    {260, UnitGram},
+			"sugars":           {90, UnitGram},
+			"proteins":         {50, UnitGram},
+			"salt":             {6, UnitGram},
+			"vitamin-a":        {800, UnitMicrogram},
+			"vitamin-d":        {5, UnitMicrogram},
+			"vitamin-e":        {12, UnitMilligram},
+			"vitamin-k":        {75, UnitMicrogram},
+			"vitamin-c":        {80, UnitMilligram},
+			"vitamin-b1":       {1.1, UnitMilligram},
+			"vitamin-b2":       {1.4, UnitMilligram},
+			"vitamin-pp":       {16, UnitMilligram},
+			"vitamin-b6":       {1.4, UnitMilligram},
+			"vitamin-b9":       {200, UnitMicrogram},
+			"vitamin-b12":      {2.5, UnitMicrogram},
+			"biotin":           {50, UnitMicrogram},
+			"pantothenic-acid": {6, UnitMilligram},
+			"potassium":        {2000, UnitMilligram},
+			"chloride":         {800, UnitMilligram},
+			"calcium":          {800, UnitMilligram},
+			"phosphorus":       {700, UnitMilligram},
+			"magnesium":        {375, UnitMilligram},
+			"iron":             {14, UnitMilligram},
+			"zinc":             {10, UnitMilligram},
+			"copper":           {1, UnitMilligram},
+			"manganese":        {2, UnitMilligram},
+			"fluoride":         {3.5, UnitMilligram},
+			"selenium":         {55, UnitMicrogram},
+			"chromium":         {40, UnitMicrogram},
+			"molybdenum":       {50, UnitMicrogram},
+			"iodine":           {150, UnitMicrogram},
+		},
+	},
+	ReferenceUKChild: {
+		ID:         ReferenceUKChild,
+		Name:       "UK Guideline Daily Amounts for children",
+		Source:     "IGD Guideline Daily Amounts for children aged 5-10",
+		Population: "children aged 5 to 10 years",
+		Intakes: map[string]ReferenceIntake{
+			"energy-kcal":   {1800, UnitKilocalorie},
+			"fat":           {70, UnitGram},
+			"saturated-fat": {20, UnitGram},
+			"carbohydrates": {220, UnitGram},
+			"sugars":        {85, UnitGram},
+			"fiber":         {15, UnitGram},
+			"proteins":      {24, UnitGram},
+			"salt":          {4, UnitGram},
+		},
+	},
+}
+
+// unitsPerGram converts gram amounts to the mass units used by reference intakes
+var unitsPerGram = map[string]float64{
+	UnitGram:      1,
+	UnitMilligram: 1000,
+	UnitMicrogram: 1000000,
+}
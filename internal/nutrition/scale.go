@@ -0,0 +1,174 @@
+package nutrition
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/types"
+)
+
+// ErrInvalidQuantity is returned for non-positive quantities or unknown units
+var ErrInvalidQuantity = errors.New("invalid quantity")
+
+// nonScalableNutrients are per-100g entries that describe the food rather than an amount in it
+var nonScalableNutrients = map[string]bool{
+	"alcohol":            true, // % vol
+	"nova-group":         true,
+	"nutrition-score-fr": true,
+	"nutrition-score-uk": true,
+	"ph":                 true,
+	"carbon-footprint-from-known-ingredients": true,
+	"fruits-vegetables-nuts":                  true,
+	"fruits-vegetables-legumes":               true,
+}
+
+// nonScalableSuffixes catch the estimate variants of the entries above
+var nonScalableSuffixes = []string{"-estimate", "-estimate-from-ingredients", "-score"}
+
+// Amount is a nutrient amount for a quantity of food
+type Amount struct {
+	Amount float64 `json:"amount"`
+	Unit   string  `json:"unit"`    // g, kcal or kJ
+	Per100 float64 `json:"per_100"` // Value per 100 g or 100 ml
+}
+
+// QuantityNutrition is the nutrient content of a given quantity of a product
+type QuantityNutrition struct {
+	Code              string            `json:"code"`
+	ProductName       string            `json:"product_name"`
+	RequestedQuantity float64           `json:"requested_quantity"`
+	RequestedUnit     string            `json:"requested_unit"`
+	Amount            float64           `json:"amount"`     // Resolved quantity in Unit, 0 if unresolved
+	Unit              string            `json:"unit"`       // g or ml
+	BasisUnit         string            `json:"basis_unit"` // Nutrients in the dataset are per 100 of this unit
+	Serving           Serving           `json:"serving"`    // The product's serving size
+	Resolved          bool              `json:"resolved"`   // False when servings were requested but the serving is unknown
+	Nutrients         map[string]Amount `json:"nutrients"`  // Scaled nutrients keyed by name
+	Warnings          []string          `json:"warnings"`   // Assumptions made, e.g. 1 ml = 1 g
+}
+
+// ForQuantity scales a product's per-100 nutrients to a quantity in g, ml or servings
+func ForQuantity(p *types.Product, quantity float64, unit string) (*QuantityNutrition, error) {
+	unit = strings.ToLower(strings.TrimSpace(unit))
+	if quantity <= 0 || math.IsNaN(quantity) || math.IsInf(quantity, 0) {
+		return nil, fmt.Errorf("%w: quantity must be greater than 0", ErrInvalidQuantity)
+	}
+
+	result := &QuantityNutrition{
+		Code:              p.Code,
+		ProductName:       p.ProductName,
+		RequestedQuantity: quantity,
+		RequestedUnit:     unit,
+		BasisUnit:         BasisUnit(p),
+		Serving:           ResolveServing(p),
+		Nutrients:         map[string]Amount{},
+		Warnings:          []string{},
+	}
+
+	switch unit {
+	case UnitGram, UnitMillilitre:
+		result.Amount, result.Unit = quantity, unit
+	case UnitServing, "servings":
+		result.RequestedUnit = UnitServing
+		if !result.Serving.Resolved {
+			result.Warnings = append(result.Warnings, "serving size could not be resolved: "+result.Serving.Reason)
+			return result, nil
+		}
+		result.Amount, result.Unit = quantity*result.Serving.Amount, result.Serving.Unit
+	default:
+		return nil, fmt.Errorf("%w: unit must be one of g, ml or serving", ErrInvalidQuantity)
+	}
+
+	if result.Unit != result.BasisUnit {
+		result.Warnings = append(result.Warnings, fmt.Sprintf(
+			"nutrients are per 100 %s; assuming 1 ml weighs 1 g", result.BasisUnit))
+	}
+
+	result.Resolved = true
+	result.Amount = round(result.Amount)
+	result.Nutrients = Scale(p.ParsedNutriments(), result.Amount)
+	if len(result.Nutrients) == 0 {
+		result.Warnings = append(result.Warnings, "product has no per-100 nutrient values")
+	}
+	return result, nil
+}
+
+// Scale multiplies per-100 nutrient values by amount/100
+// Nutrients without a per-100 value and descriptive entries such as scores are skipped
+func Scale(nutriments map[string]types.Nutriment, amount float64) map[string]Amount {
+	scaled := scaleExact(nutriments, amount)
+	for name, nutrient := range scaled {
+		nutrient.Amount = roundAmount(nutrient.Amount)
+		scaled[name] = nutrient
+	}
+	return scaled
+}
+
+// scaleExact is Scale without rounding, for callers that convert to smaller units such as µg
+func scaleExact(nutriments map[string]types.Nutriment, amount float64) map[string]Amount {
+	scaled := make(map[string]Amount, len(nutriments))
+	for name, nutriment := range nutriments {
+		if nutriment.Per100g == nil || !Scalable(name) {
+			continue
+		}
+		per100 := *nutriment.Per100g
+		scaled[name] = Amount{
+			Amount: per100 * amount / 100,
+			Unit:   NutrientUnit(name),
+			Per100: per100,
+		}
+	}
+	return scaled
+}
+
+// Scalable reports whether a nutrient is an amount that grows with the quantity eaten
+func Scalable(name string) bool {
+	if nonScalableNutrients[name] {
+		return false
+	}
+	for _, suffix := range nonScalableSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return false
+		}
+	}
+	return true
+}
+
+// NutrientUnit returns the unit of a nutrient's per-100 value
+// Open Food Facts normalizes per-100 values to grams, except energy
+func NutrientUnit(name string) string {
+	switch name {
+	case "energy", "energy-kj", "energy-from-fat":
+		return "kJ"
+	case "energy-kcal":
+		return "kcal"
+	default:
+		return UnitGram
+	}
+}
+
+// BasisUnit returns whether a product's nutrients are per 100 g or per 100 ml
+func BasisUnit(p *types.Product) string {
+	if normalizeBaseUnit(p.ServingQuantityUnit) == UnitMillilitre {
+		return UnitMillilitre
+	}
+	return UnitGram
+}
+
+// round rounds to three decimals, for quantities and gram-level amounts
+func round(value float64) float64 {
+	return math.Round(value*1000) / 1000
+}
+
+// roundAmount rounds a nutrient amount in grams to three decimals, or to three significant
+// figures below 0.1 g so that milligram and microgram vitamins and minerals are not zeroed
+func roundAmount(value float64) float64 {
+	if value == 0 || math.Abs(value) >= 0.1 || math.IsNaN(value) || math.IsInf(value, 0) {
+		return round(value)
+	}
+	digits := 2 - int(math.Floor(math.Log10(math.Abs(value))))
+	scale := math.Pow10(digits)
+	return math.Round(value*scale) / scale
+}
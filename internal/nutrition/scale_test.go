@@ -0,0 +1,135 @@
+package nutrition
+
+import (
+	"testing"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nutrient builds a dataset-style nutrient object
+func nutrient(name string, per100g float64, unit string) map[string]interface{} {
+	return map[string]interface{}{"name": name, "100g": per100g, "value": per100g, "unit": unit}
+}
+
+func testProduct() *types.Product {
+	return &types.Product{
+		Code:                "3017620422003",
+		ProductName:         "Nutella",
+		ServingSize:         "15 g",
+		ServingQuantity:     15.0,
+		ServingQuantityUnit: "g",
+		Nutriments: map[string]interface{}{
+			"energy-kcal":        nutrient("energy-kcal", 539, "kcal"),
+			"energy":             nutrient("energy", 2255, "kJ"),
+			"sugars":             nutrient("sugars", 56.3, "g"),
+			"sodium":             nutrient("sodium", 0.0428, "mg"),
+			"nutrition-score-fr": nutrient("nutrition-score-fr", 26, ""),
+			"fruits-vegetables-nuts-estimate-from-ingredients": nutrient("fruits-vegetables-nuts-estimate-from-ingredients", 13, ""),
+			"fiber": map[string]interface{}{"name": "fiber", "value": 0, "unit": "g"},
+		},
+	}
+}
+
+func TestForQuantity(t *testing.T) {
+	tests := []struct {
+		name             string
+		quantity         float64
+		unit             string
+		expectedAmount   float64
+		expectedUnit     string
+		expectedSugars   float64
+		expectedWarnings int
+	}{
+		{name: "grams", quantity: 50, unit: "g", expectedAmount: 50, expectedUnit: UnitGram, expectedSugars: 28.15},
+		{name: "servings", quantity: 2, unit: "serving", expectedAmount: 30, expectedUnit: UnitGram, expectedSugars: 16.89},
+		{name: "plural servings", quantity: 1, unit: "Servings", expectedAmount: 15, expectedUnit: UnitGram, expectedSugars: 8.445},
+		{name: "millilitres on a per 100 g product", quantity: 100, unit: "ml", expectedAmount: 100, expectedUnit: UnitMillilitre, expectedSugars: 56.3, expectedWarnings: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ForQuantity(testProduct(), tt.quantity, tt.unit)
+			require.NoError(t, err)
+			assert.True(t, result.Resolved)
+			assert.Equal(t, tt.expectedAmount, result.Amount)
+			assert.Equal(t, tt.expectedUnit, result.Unit)
+			assert.Len(t, result.Warnings, tt.expectedWarnings)
+
+			assert.Equal(t, tt.expectedSugars, result.Nutrients["sugars"].Amount)
+			assert.Equal(t, 56.3, result.Nutrients["sugars"].Per100)
+			assert.Equal(t, "kcal", result.Nutrients["energy-kcal"].Unit)
+			assert.Equal(t, "kJ", result.Nutrients["energy"].Unit)
+
+			// Scores, estimates and entries without a per-100 value are not scaled
+			assert.NotContains(t, result.Nutrients, "nutrition-score-fr")
+			assert.NotContains(t, result.Nutrients, "fruits-vegetables-nuts-estimate-from-ingredients")
+			assert.NotContains(t, result.Nutrients, "fiber")
+		})
+	}
+}
+
+func TestForQuantity_UnresolvedServing(t *testing.T) {
+	product := testProduct()
+	product.ServingSize = "1 portion"
+	product.ServingQuantity = nil
+
+	result, err := ForQuantity(product, 1, UnitServing)
+	require.NoError(t, err)
+	assert.False(t, result.Resolved)
+	assert.False(t, result.Serving.Resolved)
+	assert.Empty(t, result.Nutrients)
+	require.Len(t, result.Warnings, 1)
+	assert.Contains(t, result.Warnings[0], "serving size could not be resolved")
+}
+
+func TestForQuantity_Invalid(t *testing.T) {
+	_, err := ForQuantity(testProduct(), 0, UnitGram)
+	assert.ErrorIs(t, err, ErrInvalidQuantity)
+
+	_, err = ForQuantity(testProduct(), 10, "cups")
+	assert.ErrorIs(t, err, ErrInvalidQuantity)
+}
+
+func TestForQuantity_Micronutrients(t *testing.T) {
+	product := &types.Product{
+		Code: "3250390000000",
+		Nutriments: map[string]interface{}{
+			"vitamin-d":   nutrient("vitamin-d", 0.000005, "µg"),
+			"vitamin-b12": nutrient("vitamin-b12", 0.000001, "µg"),
+			"iron":        nutrient("iron", 0.0021, "mg"),
+		},
+	}
+
+	result, err := ForQuantity(product, 30, "g")
+	require.NoError(t, err)
+	assert.Equal(t, 0.0000015, result.Nutrients["vitamin-d"].Amount)
+	assert.Equal(t, 0.0000003, result.Nutrients["vitamin-b12"].Amount)
+	assert.Equal(t, 0.00063, result.Nutrients["iron"].Amount)
+}
+
+func TestRoundAmount(t *testing.T) {
+	tests := []struct {
+		value    float64
+		expected float64
+	}{
+		{value: 0, expected: 0},
+		{value: 28.15, expected: 28.15},
+		{value: 16.8912, expected: 16.891},
+		{value: 0.123456, expected: 0.123},
+		{value: 0.0123456, expected: 0.0123},
+		{value: 0.0000015004, expected: 0.0000015},
+		{value: -0.0004567, expected: -0.000457},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, roundAmount(tt.value), "roundAmount(%v)", tt.value)
+	}
+}
+
+func TestBasisUnit(t *testing.T) {
+	assert.Equal(t, UnitMillilitre, BasisUnit(&types.Product{ServingQuantityUnit: "ml"}))
+	assert.Equal(t, UnitGram, BasisUnit(&types.Product{ServingQuantityUnit: "g"}))
+	assert.Equal(t, UnitGram, BasisUnit(&types.Product{}))
+}
@@ -0,0 +1,165 @@
+package nutrition
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/types"
+)
+
+// Quantity units
+const (
+	UnitGram       = "g"
+	UnitMillilitre = "ml"
+	UnitServing    = "serving"
+)
+
+// Serving sources
+const (
+	SourceServingQuantity = "serving_quantity"
+	SourceServingSize     = "serving_size"
+)
+
+// Imperial conversions used when a serving size has no metric amount
+const (
+	gramsPerOunce      = 28.349523125
+	millilitresPerFlOz = 29.5735295625
+)
+
+// maxServingAmount bounds plausible servings; larger values are treated as data errors
+const maxServingAmount = 5000.0
+
+// quantityPattern matches an amount followed by a mass or volume unit, e.g. "25 g", "355ml", "8 fl oz"
+var quantityPattern = regexp.MustCompile(
+	`(\d+(?:\.\d+)?)\s*(kg|mg|grammes|gramme|grams|gram|gr|g|ml|cl|dl|litres|litre|liters|liter|l|fl\.?\s*oz|oz)\b`)
+
+// decimalCommaPattern matches a decimal comma between digits, e.g. "2,5 g"
+var decimalCommaPattern = regexp.MustCompile(`(\d),(\d)`)
+
+// unitConversions maps a parsed unit to its base unit and multiplier
+var unitConversions = map[string]struct {
+	base   string
+	factor float64
+}{
+	"kg": {UnitGram, 1000}, "g": {UnitGram, 1}, "gr": {UnitGram, 1}, "gram": {UnitGram, 1}, "grams": {UnitGram, 1},
+	"gramme": {UnitGram, 1}, "grammes": {UnitGram, 1}, "mg": {UnitGram, 0.001},
+	"ml": {UnitMillilitre, 1}, "cl": {UnitMillilitre, 10}, "dl": {UnitMillilitre, 100},
+	"l": {UnitMillilitre, 1000}, "litre": {UnitMillilitre, 1000}, "litres": {UnitMillilitre, 1000},
+	"liter": {UnitMillilitre, 1000}, "liters": {UnitMillilitre, 1000},
+	"oz": {UnitGram, gramsPerOunce}, "fl oz": {UnitMillilitre, millilitresPerFlOz},
+}
+
+// Serving is a product's serving size resolved to grams or millilitres
+type Serving struct {
+	Text     string  `json:"text,omitempty"`   // Free-text serving size from the dataset
+	Amount   float64 `json:"amount,omitempty"` // Resolved amount in Unit
+	Unit     string  `json:"unit,omitempty"`   // g or ml
+	Source   string  `json:"source,omitempty"` // serving_quantity or serving_size
+	Resolved bool    `json:"resolved"`
+	Reason   string  `json:"reason,omitempty"` // Why the serving could not be resolved
+}
+
+// ResolveServing determines the serving size of a product in grams or millilitres
+// The numeric serving_quantity is preferred; otherwise the free-text serving_size is parsed
+func ResolveServing(p *types.Product) Serving {
+	serving := Serving{Text: strings.TrimSpace(p.ServingSize)}
+
+	textAmount, textUnit, textOK := ParseQuantity(serving.Text)
+
+	if amount, ok := servingQuantity(p.ServingQuantity); ok {
+		unit := normalizeBaseUnit(p.ServingQuantityUnit)
+		if unit == "" && textOK {
+			unit = textUnit
+		}
+		if unit == "" {
+			unit = UnitGram
+		}
+		serving.Amount, serving.Unit, serving.Source, serving.Resolved = amount, unit, SourceServingQuantity, true
+		return serving
+	}
+
+	if textOK {
+		serving.Amount, serving.Unit, serving.Source, serving.Resolved = textAmount, textUnit, SourceServingSize, true
+		return serving
+	}
+
+	if serving.Text == "" {
+		serving.Reason = "product has no serving size"
+	} else {
+		serving.Reason = "serving size has no gram or millilitre amount"
+	}
+	return serving
+}
+
+// ParseQuantity extracts an amount in grams or millilitres from free text such as "2 biscuits (25 g)"
+// Metric amounts are preferred over imperial ones when both are present
+func ParseQuantity(text string) (float64, string, bool) {
+	normalized := decimalCommaPattern.ReplaceAllString(strings.ToLower(text), "$1.$2")
+
+	var imperialAmount float64
+	var imperialUnit string
+	for _, match := range quantityPattern.FindAllStringSubmatch(normalized, -1) {
+		amount, err := strconv.ParseFloat(match[1], 64)
+		if err != nil || amount <= 0 {
+			continue
+		}
+
+		unit := match[2]
+		if strings.HasPrefix(unit, "fl") {
+			unit = "fl oz"
+		}
+		conversion, ok := unitConversions[unit]
+		if !ok {
+			continue
+		}
+
+		value := amount * conversion.factor
+		if value > maxServingAmount {
+			continue
+		}
+		if unit == "oz" || unit == "fl oz" {
+			if imperialUnit == "" {
+				imperialAmount, imperialUnit = value, conversion.base
+			}
+			continue
+		}
+		return value, conversion.base, true
+	}
+
+	if imperialUnit != "" {
+		return imperialAmount, imperialUnit, true
+	}
+	return 0, "", false
+}
+
+// servingQuantity reads the loosely typed serving_quantity as a positive number
+func servingQuantity(value interface{}) (float64, bool) {
+	var amount float64
+	switch v := value.(type) {
+	case float64:
+		amount = v
+	case int:
+		amount = float64(v)
+	case int64:
+		amount = float64(v)
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(strings.ReplaceAll(v, ",", ".")), 64)
+		if err != nil {
+			return 0, false
+		}
+		amount = parsed
+	default:
+		return 0, false
+	}
+	return amount, amount > 0 && amount <= maxServingAmount
+}
+
+// normalizeBaseUnit maps g/ml spellings to UnitGram or UnitMillilitre, or "" if unknown
+func normalizeBaseUnit(unit string) string {
+	conversion, ok := unitConversions[strings.ToLower(strings.TrimSpace(unit))]
+	if !ok || conversion.factor != 1 {
+		return ""
+	}
+	return conversion.base
+}
@@ -0,0 +1,81 @@
+package nutrition
+
+import (
+	"testing"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		text           string
+		expectedAmount float64
+		expectedUnit   string
+		expectedOK     bool
+	}{
+		{text: "15 g", expectedAmount: 15, expectedUnit: UnitGram, expectedOK: true},
+		{text: "2 biscuits (25 g)", expectedAmount: 25, expectedUnit: UnitGram, expectedOK: true},
+		{text: "1 can (355 mL)", expectedAmount: 355, expectedUnit: UnitMillilitre, expectedOK: true},
+		{text: "30g", expectedAmount: 30, expectedUnit: UnitGram, expectedOK: true},
+		{text: "2,5 g", expectedAmount: 2.5, expectedUnit: UnitGram, expectedOK: true},
+		{text: "33 cl", expectedAmount: 330, expectedUnit: UnitMillilitre, expectedOK: true},
+		{text: "1 l", expectedAmount: 1000, expectedUnit: UnitMillilitre, expectedOK: true},
+		{text: "0.5 kg", expectedAmount: 500, expectedUnit: UnitGram, expectedOK: true},
+		{text: "1 oz (28 g)", expectedAmount: 28, expectedUnit: UnitGram, expectedOK: true},
+		{text: "8 fl oz", expectedAmount: 236.588, expectedUnit: UnitMillilitre, expectedOK: true},
+		{text: "12 galettes", expectedOK: false},
+		{text: "1 glass", expectedOK: false},
+		{text: "1 portion", expectedOK: false},
+		{text: "", expectedOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			amount, unit, ok := ParseQuantity(tt.text)
+			assert.Equal(t, tt.expectedOK, ok)
+			assert.InDelta(t, tt.expectedAmount, amount, 0.001)
+			assert.Equal(t, tt.expectedUnit, unit)
+		})
+	}
+}
+
+func TestResolveServing(t *testing.T) {
+	tests := []struct {
+		name     string
+		product  types.Product
+		expected Serving
+	}{
+		{
+			name:     "numeric serving quantity wins",
+			product:  types.Product{ServingQuantity: 30.0, ServingQuantityUnit: "g", ServingSize: "1 bowl (40 g)"},
+			expected: Serving{Text: "1 bowl (40 g)", Amount: 30, Unit: UnitGram, Source: SourceServingQuantity, Resolved: true},
+		},
+		{
+			name:     "string serving quantity takes unit from text",
+			product:  types.Product{ServingQuantity: "330", ServingSize: "1 can (330 ml)"},
+			expected: Serving{Text: "1 can (330 ml)", Amount: 330, Unit: UnitMillilitre, Source: SourceServingQuantity, Resolved: true},
+		},
+		{
+			name:     "free text only",
+			product:  types.Product{ServingSize: "2 biscuits (25 g)"},
+			expected: Serving{Text: "2 biscuits (25 g)", Amount: 25, Unit: UnitGram, Source: SourceServingSize, Resolved: true},
+		},
+		{
+			name:     "unparseable text",
+			product:  types.Product{ServingSize: "1 portion", ServingQuantity: "0"},
+			expected: Serving{Text: "1 portion", Reason: "serving size has no gram or millilitre amount"},
+		},
+		{
+			name:     "no serving",
+			product:  types.Product{},
+			expected: Serving{Reason: "product has no serving size"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ResolveServing(&tt.product))
+		})
+	}
+}
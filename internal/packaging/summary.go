@@ -0,0 +1,252 @@
+package packaging
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/types"
+)
+
+// Recycling instruction tags
+const (
+	RecyclingRecycle = "en:recycle"
+	RecyclingReuse   = "en:reuse"
+	RecyclingReturn  = "en:return"
+	RecyclingDiscard = "en:discard"
+)
+
+// Material families used to group detailed material tags
+const (
+	FamilyPlastic = "plastic"
+	FamilyGlass   = "glass"
+	FamilyMetal   = "metal"
+	FamilyPaper   = "paper-or-cardboard"
+	FamilyWood    = "wood"
+	FamilyOther   = "other"
+	FamilyUnknown = "unknown"
+)
+
+// familyKeywords maps material tag fragments to a family, checked in order
+var familyKeywords = []struct {
+	family   string
+	keywords []string
+}{
+	{FamilyGlass, []string{"glass"}},
+	{FamilyMetal, []string{"metal", "aluminium", "aluminum", "steel", "tin"}},
+	{FamilyPaper, []string{"paper", "cardboard", "carton", "paperboard", "kraft"}},
+	{FamilyWood, []string{"wood", "cork"}},
+	{FamilyPlastic, []string{"plastic", "pet", "hdpe", "ldpe", "pp", "ps", "pvc", "polystyrene", "polypropylene", "polyethylene"}},
+}
+
+// RecyclingCounts counts packaging units by recycling instruction
+type RecyclingCounts struct {
+	Recycle int `json:"recycle"`
+	Reuse   int `json:"reuse"` // Includes en:return (deposit schemes)
+	Discard int `json:"discard"`
+	Unknown int `json:"unknown"`
+}
+
+// add counts units under the bucket for a recycling tag
+func (c *RecyclingCounts) add(recycling string, units int) {
+	switch recycling {
+	case RecyclingRecycle:
+		c.Recycle += units
+	case RecyclingReuse, RecyclingReturn:
+		c.Reuse += units
+	case RecyclingDiscard:
+		c.Discard += units
+	default:
+		c.Unknown += units
+	}
+}
+
+// recyclableRate is the share of units with a known instruction that can be recycled or reused
+func (c RecyclingCounts) recyclableRate() *float64 {
+	known := c.Recycle + c.Reuse + c.Discard
+	if known == 0 {
+		return nil
+	}
+	rate := math.Round(float64(c.Recycle+c.Reuse)/float64(known)*1000) / 1000
+	return &rate
+}
+
+// MaterialTotal aggregates packaging units of one material
+type MaterialTotal struct {
+	Material  string          `json:"material"`
+	Family    string          `json:"family"`
+	Units     int             `json:"units"`
+	WeightG   float64         `json:"weight_g"`      // Sum of measured weights, components without a weight count as 0
+	Weighed   int             `json:"weighed_units"` // Units that had a measured weight
+	Recycling RecyclingCounts `json:"recycling"`
+}
+
+// ProductSummary is the packaging breakdown of a single product
+type ProductSummary struct {
+	Code           string                     `json:"code"`
+	ProductName    string                     `json:"product_name"`
+	HasData        bool                       `json:"has_packaging_data"`
+	Components     []types.PackagingComponent `json:"components"`
+	Units          int                        `json:"units"`
+	WeightG        float64                    `json:"weight_g"`
+	Recycling      RecyclingCounts            `json:"recycling"`
+	RecyclableRate *float64                   `json:"recyclable_rate,omitempty"` // Recycle or reuse share of units with an instruction
+}
+
+// Summary aggregates packaging across one or more products
+type Summary struct {
+	Requested      int              `json:"requested"`
+	Found          int              `json:"found"`
+	NotFound       []string         `json:"not_found"`
+	WithoutData    []string         `json:"without_packaging_data"`
+	Products       []ProductSummary `json:"products"`
+	Materials      []MaterialTotal  `json:"materials"`
+	Families       []MaterialTotal  `json:"families"`
+	Units          int              `json:"units"`
+	WeightG        float64          `json:"weight_g"`
+	Recycling      RecyclingCounts  `json:"recycling"`
+	RecyclableRate *float64         `json:"recyclable_rate,omitempty"`
+}
+
+// Summarize aggregates the packaging of products found for the requested barcodes
+// Requested barcodes with no matching product are listed in NotFound
+func Summarize(requested []string, products []types.Product) Summary {
+	summary := Summary{
+		Requested:   len(requested),
+		Found:       len(products),
+		NotFound:    []string{},
+		WithoutData: []string{},
+		Products:    make([]ProductSummary, 0, len(products)),
+	}
+
+	found := make(map[string]bool, len(products))
+	materials := map[string]*MaterialTotal{}
+	families := map[string]*MaterialTotal{}
+
+	for _, product := range products {
+		found[product.Code] = true
+
+		ps := ProductSummary{
+			Code:        product.Code,
+			ProductName: product.ProductName,
+			HasData:     len(product.Packagings) > 0,
+			Components:  product.Packagings,
+		}
+		if ps.Components == nil {
+			ps.Components = []types.PackagingComponent{}
+		}
+		if !ps.HasData {
+			summary.WithoutData = append(summary.WithoutData, product.Code)
+		}
+
+		for _, component := range product.Packagings {
+			units := component.Units()
+			weight := 0.0
+			if component.WeightMeasured != nil {
+				weight = *component.WeightMeasured * float64(units)
+			}
+
+			ps.Units += units
+			ps.WeightG += weight
+			ps.Recycling.add(component.Recycling, units)
+
+			material := component.Material
+			if material == "" {
+				material = FamilyUnknown
+			}
+			family := MaterialFamily(component.Material)
+			for _, total := range []*MaterialTotal{
+				totalFor(materials, material, family),
+				totalFor(families, family, family),
+			} {
+				total.Units += units
+				total.WeightG += weight
+				if component.WeightMeasured != nil {
+					total.Weighed += units
+				}
+				total.Recycling.add(component.Recycling, units)
+			}
+		}
+
+		ps.WeightG = round(ps.WeightG)
+		ps.RecyclableRate = ps.Recycling.recyclableRate()
+
+		summary.Units += ps.Units
+		summary.WeightG += ps.WeightG
+		summary.Recycling.Recycle += ps.Recycling.Recycle
+		summary.Recycling.Reuse += ps.Recycling.Reuse
+		summary.Recycling.Discard += ps.Recycling.Discard
+		summary.Recycling.Unknown += ps.Recycling.Unknown
+		summary.Products = append(summary.Products, ps)
+	}
+
+	for _, barcode := range requested {
+		if !found[barcode] {
+			summary.NotFound = append(summary.NotFound, barcode)
+		}
+	}
+
+	summary.WeightG = round(summary.WeightG)
+	summary.RecyclableRate = summary.Recycling.recyclableRate()
+	summary.Materials = sortedTotals(materials)
+	summary.Families = sortedTotals(families)
+	return summary
+}
+
+// MaterialFamily groups a material tag such as en:pet-1-polyethylene-terephthalate into a family
+func MaterialFamily(material string) string {
+	if material == "" {
+		return FamilyUnknown
+	}
+
+	name := material
+	if _, after, ok := strings.Cut(material, ":"); ok {
+		name = after
+	}
+	words := strings.FieldsFunc(name, func(r rune) bool { return r == '-' || r == '_' || r == ' ' })
+
+	for _, group := range familyKeywords {
+		for _, word := range words {
+			for _, keyword := range group.keywords {
+				if word == keyword {
+					return group.family
+				}
+			}
+		}
+	}
+	return FamilyOther
+}
+
+// totalFor returns the running total for key, creating it on first use
+func totalFor(totals map[string]*MaterialTotal, key, family string) *MaterialTotal {
+	total, ok := totals[key]
+	if !ok {
+		total = &MaterialTotal{Material: key, Family: family}
+		totals[key] = total
+	}
+	return total
+}
+
+// sortedTotals returns totals ordered by units, then weight, then name
+func sortedTotals(totals map[string]*MaterialTotal) []MaterialTotal {
+	result := make([]MaterialTotal, 0, len(totals))
+	for _, total := range totals {
+		total.WeightG = round(total.WeightG)
+		result = append(result, *total)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Units != result[j].Units {
+			return result[i].Units > result[j].Units
+		}
+		if result[i].WeightG != result[j].WeightG {
+			return result[i].WeightG > result[j].WeightG
+		}
+		return result[i].Material < result[j].Material
+	})
+	return result
+}
+
+// round rounds grams to two decimals
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,110 @@
+package packaging
+
+import (
+	"testing"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(v int) *int           { return &v }
+func floatPtr(v float64) *float64 { return &v }
+
+func TestMaterialFamily(t *testing.T) {
+	tests := []struct {
+		material string
+		expected string
+	}{
+		{material: "en:glass", expected: FamilyGlass},
+		{material: "en:pet-1-polyethylene-terephthalate", expected: FamilyPlastic},
+		{material: "en:pp-5-polypropylene", expected: FamilyPlastic},
+		{material: "en:aluminium", expected: FamilyMetal},
+		{material: "en:steel", expected: FamilyMetal},
+		{material: "en:paper-or-cardboard", expected: FamilyPaper},
+		{material: "en:non-corrugated-cardboard", expected: FamilyPaper},
+		{material: "en:wood", expected: FamilyWood},
+		{material: "en:ceramic", expected: FamilyOther},
+		{material: "", expected: FamilyUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.material, func(t *testing.T) {
+			assert.Equal(t, tt.expected, MaterialFamily(tt.material))
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	products := []types.Product{
+		{
+			Code:        "3017620422003",
+			ProductName: "Nutella",
+			Packagings: []types.PackagingComponent{
+				{Material: "en:glass", Shape: "en:jar", Recycling: "en:recycle", NumberOfUnits: intPtr(1), WeightMeasured: floatPtr(180.5)},
+				{Material: "en:pp-5-polypropylene", Shape: "en:lid", Recycling: "en:discard", WeightMeasured: floatPtr(4)},
+			},
+		},
+		{
+			Code:        "5449000000996",
+			ProductName: "Coca-Cola",
+			Packagings: []types.PackagingComponent{
+				{Material: "en:aluminium", Shape: "en:can", Recycling: "en:recycle", NumberOfUnits: intPtr(6), WeightMeasured: floatPtr(13)},
+				{Material: "en:cardboard", Shape: "en:box", Recycling: "en:return"},
+			},
+		},
+		{Code: "0000000000001", ProductName: "Eau"},
+	}
+
+	summary := Summarize([]string{"3017620422003", "5449000000996", "0000000000001", "404"}, products)
+
+	assert.Equal(t, 4, summary.Requested)
+	assert.Equal(t, 3, summary.Found)
+	assert.Equal(t, []string{"404"}, summary.NotFound)
+	assert.Equal(t, []string{"0000000000001"}, summary.WithoutData)
+
+	require.Len(t, summary.Products, 3)
+	nutella := summary.Products[0]
+	assert.True(t, nutella.HasData)
+	assert.Equal(t, 2, nutella.Units)
+	assert.Equal(t, 184.5, nutella.WeightG)
+	assert.Equal(t, RecyclingCounts{Recycle: 1, Discard: 1}, nutella.Recycling)
+	require.NotNil(t, nutella.RecyclableRate)
+	assert.Equal(t, 0.5, *nutella.RecyclableRate)
+
+	water := summary.Products[2]
+	assert.False(t, water.HasData)
+	assert.Empty(t, water.Components)
+	assert.Nil(t, water.RecyclableRate)
+
+	assert.Equal(t, 9, summary.Units)
+	assert.Equal(t, 262.5, summary.WeightG)
+	assert.Equal(t, RecyclingCounts{Recycle: 7, Reuse: 1, Discard: 1}, summary.Recycling)
+	require.NotNil(t, summary.RecyclableRate)
+	assert.Equal(t, 0.889, *summary.RecyclableRate)
+
+	// Materials are ordered by units
+	require.Len(t, summary.Materials, 4)
+	assert.Equal(t, "en:aluminium", summary.Materials[0].Material)
+	assert.Equal(t, FamilyMetal, summary.Materials[0].Family)
+	assert.Equal(t, 6, summary.Materials[0].Units)
+	assert.Equal(t, 78.0, summary.Materials[0].WeightG)
+	assert.Equal(t, 6, summary.Materials[0].Weighed)
+
+	families := map[string]MaterialTotal{}
+	for _, f := range summary.Families {
+		families[f.Material] = f
+	}
+	assert.Equal(t, 1, families[FamilyPaper].Units)
+	assert.Equal(t, 0, families[FamilyPaper].Weighed)
+	assert.Equal(t, 1, families[FamilyPaper].Recycling.Reuse)
+	assert.Equal(t, 180.5, families[FamilyGlass].WeightG)
+}
+
+func TestSummarize_Empty(t *testing.T) {
+	summary := Summarize([]string{"123"}, nil)
+	assert.Equal(t, []string{"123"}, summary.NotFound)
+	assert.Empty(t, summary.Products)
+	assert.Empty(t, summary.Materials)
+	assert.Nil(t, summary.RecyclableRate)
+}
@@ -0,0 +1,137 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultRetryAfter is the retry hint used when no queue timeout is configured
+const DefaultRetryAfter = 1 * time.Second
+
+// ErrServerBusy is matched by errors.Is for any query rejected by admission control
+var ErrServerBusy = errors.New("server busy")
+
+// BusyError is returned when a query is shed because the engine is over capacity
+type BusyError struct {
+	Reason     string        // "queue_full" or "queue_timeout"
+	RetryAfter time.Duration // Suggested delay before retrying
+}
+
+func (e *BusyError) Error() string {
+	return fmt.Sprintf("server busy (%s), retry after %d ms", e.Reason, e.RetryAfter.Milliseconds())
+}
+
+// Is allows errors.Is(err, ErrServerBusy) to match any BusyError
+func (e *BusyError) Is(target error) bool {
+	return target == ErrServerBusy
+}
+
+// QueueStats is a point-in-time snapshot of the admission queue for monitoring
+type QueueStats struct {
+	Capacity      int    `json:"capacity"`        // Concurrent queries allowed (0 = unlimited)
+	InFlight      int    `json:"in_flight"`       // Queries currently holding a slot
+	Queued        int    `json:"queued"`          // Queries waiting for a slot
+	MaxQueueDepth int    `json:"max_queue_depth"` // Queries allowed to wait before shedding
+	Rejected      uint64 `json:"rejected_total"`  // Queries shed since startup
+	RetryAfterMs  int64  `json:"retry_after_ms"`  // Retry hint given to shed callers
+}
+
+// Saturated reports whether new queries would be rejected immediately
+func (s QueueStats) Saturated() bool {
+	return s.Capacity > 0 && s.InFlight >= s.Capacity && s.Queued >= s.MaxQueueDepth
+}
+
+// admissionController bounds concurrent queries and the number of callers waiting for a slot
+// A nil controller admits everything, which is used when the pool size is unlimited
+type admissionController struct {
+	slots    chan struct{}
+	maxQueue int
+	maxWait  time.Duration
+	queued   atomic.Int64
+	rejected atomic.Uint64
+}
+
+// newAdmissionController creates a controller, returning nil if capacity is unlimited
+func newAdmissionController(capacity, maxQueue int, maxWait time.Duration) *admissionController {
+	if capacity <= 0 {
+		return nil
+	}
+	if maxQueue < 0 {
+		maxQueue = 0
+	}
+	return &admissionController{
+		slots:    make(chan struct{}, capacity),
+		maxQueue: maxQueue,
+		maxWait:  maxWait,
+	}
+}
+
+// acquire waits for a query slot and returns a release func that must be called when done
+func (a *admissionController) acquire(ctx context.Context) (func(), error) {
+	if a == nil {
+		return func() {}, nil
+	}
+
+	// Fast path: a slot is free, no need to queue
+	select {
+	case a.slots <- struct{}{}:
+		return a.release, nil
+	default:
+	}
+
+	if int(a.queued.Add(1)) > a.maxQueue {
+		a.queued.Add(-1)
+		a.rejected.Add(1)
+		return nil, &BusyError{Reason: "queue_full", RetryAfter: a.retryAfter()}
+	}
+	defer a.queued.Add(-1)
+
+	// A zero max wait means callers wait until their own context is done
+	var timeout <-chan time.Time
+	if a.maxWait > 0 {
+		timer := time.NewTimer(a.maxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case a.slots <- struct{}{}:
+		return a.release, nil
+	case <-timeout:
+		a.rejected.Add(1)
+		return nil, &BusyError{Reason: "queue_timeout", RetryAfter: a.retryAfter()}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// release frees a query slot
+func (a *admissionController) release() {
+	<-a.slots
+}
+
+// retryAfter returns the retry hint for shed callers
+func (a *admissionController) retryAfter() time.Duration {
+	if a.maxWait > 0 {
+		return a.maxWait
+	}
+	return DefaultRetryAfter
+}
+
+// stats returns a snapshot of the queue state
+func (a *admissionController) stats() QueueStats {
+	if a == nil {
+		return QueueStats{RetryAfterMs: DefaultRetryAfter.Milliseconds()}
+	}
+	return QueueStats{
+		Capacity:      cap(a.slots),
+		InFlight:      len(a.slots),
+		Queued:        int(a.queued.Load()),
+		MaxQueueDepth: a.maxQueue,
+		Rejected:      a.rejected.Load(),
+		RetryAfterMs:  a.retryAfter().Milliseconds(),
+	}
+}
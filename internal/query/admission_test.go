@@ -0,0 +1,157 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdmissionController_Unlimited(t *testing.T) {
+	a := newAdmissionController(0, 10, time.Second)
+	assert.Nil(t, a)
+
+	// A nil controller admits everything
+	release, err := a.acquire(context.Background())
+	require.NoError(t, err)
+	release()
+
+	stats := a.stats()
+	assert.Equal(t, 0, stats.Capacity)
+	assert.False(t, stats.Saturated())
+	assert.Equal(t, DefaultRetryAfter.Milliseconds(), stats.RetryAfterMs)
+}
+
+func TestAdmissionController_AcquireRelease(t *testing.T) {
+	a := newAdmissionController(2, 1, time.Second)
+	ctx := context.Background()
+
+	release1, err := a.acquire(ctx)
+	require.NoError(t, err)
+	release2, err := a.acquire(ctx)
+	require.NoError(t, err)
+
+	stats := a.stats()
+	assert.Equal(t, 2, stats.Capacity)
+	assert.Equal(t, 2, stats.InFlight)
+	assert.Equal(t, 0, stats.Queued)
+
+	release1()
+	release2()
+	assert.Equal(t, 0, a.stats().InFlight)
+}
+
+func TestAdmissionController_Shedding(t *testing.T) {
+	tests := []struct {
+		name           string
+		maxQueue       int
+		maxWait        time.Duration
+		expectedReason string
+		expectedRetry  time.Duration
+	}{
+		{
+			name:           "no queue rejects immediately",
+			maxQueue:       0,
+			maxWait:        50 * time.Millisecond,
+			expectedReason: "queue_full",
+			expectedRetry:  50 * time.Millisecond,
+		},
+		{
+			name:           "queued caller times out",
+			maxQueue:       1,
+			maxWait:        20 * time.Millisecond,
+			expectedReason: "queue_timeout",
+			expectedRetry:  20 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newAdmissionController(1, tt.maxQueue, tt.maxWait)
+			ctx := context.Background()
+
+			release, err := a.acquire(ctx)
+			require.NoError(t, err)
+			defer release()
+
+			_, err = a.acquire(ctx)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, ErrServerBusy))
+
+			var busyErr *BusyError
+			require.True(t, errors.As(err, &busyErr))
+			assert.Equal(t, tt.expectedReason, busyErr.Reason)
+			assert.Equal(t, tt.expectedRetry, busyErr.RetryAfter)
+
+			stats := a.stats()
+			assert.Equal(t, uint64(1), stats.Rejected)
+			assert.Equal(t, 0, stats.Queued)
+		})
+	}
+}
+
+func TestAdmissionController_QueuedCallerAdmittedOnRelease(t *testing.T) {
+	a := newAdmissionController(1, 1, time.Second)
+	ctx := context.Background()
+
+	release, err := a.acquire(ctx)
+	require.NoError(t, err)
+
+	admitted := make(chan error, 1)
+	go func() {
+		r, err := a.acquire(ctx)
+		if err == nil {
+			r()
+		}
+		admitted <- err
+	}()
+
+	// Wait for the second caller to be queued
+	require.Eventually(t, func() bool { return a.stats().Queued == 1 }, time.Second, time.Millisecond)
+	assert.True(t, a.stats().Saturated())
+
+	release()
+	assert.NoError(t, <-admitted)
+	assert.Equal(t, 0, a.stats().Queued)
+}
+
+func TestAdmissionController_ContextCancelled(t *testing.T) {
+	a := newAdmissionController(1, 1, 0)
+
+	release, err := a.acquire(context.Background())
+	require.NoError(t, err)
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = a.acquire(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, errors.Is(err, ErrServerBusy))
+	assert.Equal(t, uint64(0), a.stats().Rejected)
+	assert.Equal(t, DefaultRetryAfter.Milliseconds(), a.stats().RetryAfterMs)
+}
+
+func TestEngine_QueueStats(t *testing.T) {
+	logger := config.NewTestLogger(os.Stdout, "ERROR")
+	cfg := &config.Config{
+		DuckDBMaxOpenConns:   3,
+		DuckDBMaxQueueDepth:  5,
+		DuckDBQueueTimeoutMs: 250,
+	}
+
+	engine, err := NewEngine("test.parquet", cfg, logger)
+	require.NoError(t, err)
+	defer engine.Close()
+
+	stats := engine.QueueStats()
+	assert.Equal(t, 3, stats.Capacity)
+	assert.Equal(t, 5, stats.MaxQueueDepth)
+	assert.Equal(t, int64(250), stats.RetryAfterMs)
+	assert.False(t, stats.Saturated())
+}
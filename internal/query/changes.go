@@ -0,0 +1,284 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Changed products feed limits
+const (
+	DefaultChangesLimit = 100
+	MaxChangesLimit     = 1000
+)
+
+// ErrInvalidChangesQuery is wrapped by every changes query validation failure
+var ErrInvalidChangesQuery = errors.New("invalid changes query")
+
+// ErrNoPreviousSnapshot is returned when changes since the previous snapshot are requested but fewer than two are retained
+var ErrNoPreviousSnapshot = errors.New("no previous snapshot to compare with")
+
+// ChangesQuery selects products changed since a time or since the previous dataset snapshot
+// Exactly one of Since and SincePreviousSnapshot must be set
+type ChangesQuery struct {
+	Since                 *time.Time // Products whose last_modified_t is after this time
+	SincePreviousSnapshot bool       // Products whose fingerprint differs between the two latest snapshots
+	Cursor                string     // NextCursor of the previous page
+	Limit                 int        // Page size, DefaultChangesLimit when 0
+}
+
+// ChangedProduct is one entry of the changed products feed
+type ChangedProduct struct {
+	Code         string     `json:"code"`
+	ProductName  string     `json:"product_name,omitempty"`
+	Event        string     `json:"event,omitempty"`  // added, changed or removed; only when comparing snapshots
+	Fields       []string   `json:"fields,omitempty"` // Fingerprinted fields that changed
+	LastModified *time.Time `json:"last_modified,omitempty"`
+}
+
+// ChangesPage is one page of the changed products feed
+type ChangesPage struct {
+	Products     []ChangedProduct `json:"products"`
+	Count        int              `json:"count"`
+	NextCursor   string           `json:"next_cursor,omitempty"` // Pass as cursor to fetch the next page; empty on the last page
+	Since        *time.Time       `json:"since,omitempty"`
+	FromSnapshot string           `json:"from_snapshot,omitempty"`
+	ToSnapshot   string           `json:"to_snapshot,omitempty"`
+}
+
+// validateChangesQuery checks the query and returns its page size
+func validateChangesQuery(q ChangesQuery) (int, error) {
+	if (q.Since == nil) == !q.SincePreviousSnapshot {
+		return 0, fmt.Errorf("%w: set exactly one of since and since_previous_snapshot", ErrInvalidChangesQuery)
+	}
+
+	limit := q.Limit
+	if limit == 0 {
+		limit = DefaultChangesLimit
+	}
+	if limit < 1 || limit > MaxChangesLimit {
+		return 0, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidChangesQuery, MaxChangesLimit)
+	}
+	return limit, nil
+}
+
+// Cursor kinds, so a cursor from one kind of changes query is rejected by the other
+const (
+	cursorSince    = "since"
+	cursorSnapshot = "snapshot"
+)
+
+// encodeCursor makes an opaque paging cursor from the sort key of the last returned product
+func encodeCursor(kind string, key ...string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strings.Join(append([]string{kind}, key...), "|")))
+}
+
+// decodeCursor returns the sort key stored in a cursor; an empty cursor starts from the beginning
+func decodeCursor(cursor, kind string, parts int) ([]string, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidChangesQuery)
+	}
+	key := strings.Split(string(decoded), "|")
+	if len(key) != parts+1 || key[0] != kind {
+		return nil, fmt.Errorf("%w: cursor belongs to a different kind of query", ErrInvalidChangesQuery)
+	}
+	return key[1:], nil
+}
+
+// ChangedProducts lists products changed since a time or since the previous snapshot, one page at a time
+func (e *Engine) ChangedProducts(ctx context.Context, q ChangesQuery) (*ChangesPage, error) {
+	start := time.Now()
+	e.log.Debug("ChangedProducts starting", "since", q.Since, "since_previous_snapshot", q.SincePreviousSnapshot, "limit", q.Limit)
+
+	limit, err := validateChangesQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	var page *ChangesPage
+	if q.Since != nil {
+		page, err = e.changedSince(ctx, *q.Since, q.Cursor, limit)
+	} else {
+		page, err = e.changedSincePreviousSnapshot(ctx, q.Cursor, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	e.log.Info("ChangedProducts completed", "count", page.Count, "more", page.NextCursor != "", "duration", time.Since(start))
+	return page, nil
+}
+
+// changedSince pages through the products edited after since, ordered by last_modified_t then code
+func (e *Engine) changedSince(ctx context.Context, since time.Time, cursor string, limit int) (*ChangesPage, error) {
+	key, err := decodeCursor(cursor, cursorSince, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	release, err := e.admit(ctx, "ChangedProducts")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	query := `
+		SELECT code, ` + productNameExpr + ` AS product_name_text, CAST(last_modified_t AS BIGINT) AS last_modified
+		FROM read_parquet(?)
+		WHERE code IS NOT NULL AND last_modified_t > ?`
+	args := []interface{}{e.parquetPath, since.Unix()}
+	if key != nil {
+		lastModified, err := strconv.ParseInt(key[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidChangesQuery)
+		}
+		query += ` AND (last_modified_t > ? OR (last_modified_t = ? AND code > ?))`
+		args = append(args, lastModified, lastModified, key[1])
+	}
+	query += `
+		ORDER BY last_modified_t, code
+		LIMIT ?`
+	args = append(args, limit+1) // One extra row tells whether there is a next page
+
+	rows, err := e.queryWithRetry(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("changes query failed: %w", err)
+	}
+	defer rows.Close()
+
+	sinceUTC := since.UTC()
+	page := &ChangesPage{Products: []ChangedProduct{}, Since: &sinceUTC}
+	var lastModified []int64
+	for rows.Next() {
+		var code string
+		var name sql.NullString
+		var modified int64
+		if err := rows.Scan(&code, &name, &modified); err != nil {
+			return nil, fmt.Errorf("changes scan failed: %w", err)
+		}
+		modifiedAt := time.Unix(modified, 0).UTC()
+		page.Products = append(page.Products, ChangedProduct{Code: code, ProductName: name.String, LastModified: &modifiedAt})
+		lastModified = append(lastModified, modified)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	if len(page.Products) > limit {
+		page.Products = page.Products[:limit]
+		last := page.Products[limit-1]
+		page.NextCursor = encodeCursor(cursorSince, strconv.FormatInt(lastModified[limit-1], 10), last.Code)
+	}
+	page.Count = len(page.Products)
+	return page, nil
+}
+
+// changedSincePreviousSnapshot pages through the products whose fingerprint differs between the two latest snapshots
+func (e *Engine) changedSincePreviousSnapshot(ctx context.Context, cursor string, limit int) (*ChangesPage, error) {
+	if !e.historyEnabled() {
+		return nil, ErrHistoryDisabled
+	}
+	// The cursor records the compared snapshots as well as the last code
+	key, err := decodeCursor(cursor, cursorSnapshot, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := listSnapshots(e.historyDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) < 2 {
+		return nil, ErrNoPreviousSnapshot
+	}
+	from, to := snapshots[len(snapshots)-2], snapshots[len(snapshots)-1]
+	if key != nil && (key[0] != from.ID || key[1] != to.ID) {
+		return nil, fmt.Errorf("%w: cursor compares snapshots %s and %s, which are no longer the latest; start again without a cursor",
+			ErrInvalidChangesQuery, key[0], key[1])
+	}
+
+	release, err := e.admit(ctx, "ChangedProducts")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	columns := []string{
+		"COALESCE(n.code, o.code) AS code",
+		"CASE WHEN o.code IS NULL THEN '" + EventAdded + "' WHEN n.code IS NULL THEN '" + EventRemoved + "' ELSE '" + EventChanged + "' END AS event",
+		"n.last_modified_t",
+	}
+	differs := []string{"o.code IS NULL", "n.code IS NULL"}
+	for _, field := range historyFields {
+		diff := fmt.Sprintf("o.%s IS DISTINCT FROM n.%s", field.name, field.name)
+		columns = append(columns, diff)
+		differs = append(differs, diff)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM read_parquet('%s') o
+		FULL OUTER JOIN read_parquet('%s') n ON o.code = n.code
+		WHERE (%s)`,
+		strings.Join(columns, ", "), escapeSQLString(from.path), escapeSQLString(to.path), strings.Join(differs, " OR "))
+	args := []interface{}{}
+	if key != nil {
+		query += ` AND COALESCE(n.code, o.code) > ?`
+		args = append(args, key[2])
+	}
+	query += `
+		ORDER BY 1
+		LIMIT ?`
+	args = append(args, limit+1) // One extra row tells whether there is a next page
+
+	rows, err := e.queryWithRetry(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("changes query failed: %w", err)
+	}
+	defer rows.Close()
+
+	page := &ChangesPage{Products: []ChangedProduct{}, FromSnapshot: from.ID, ToSnapshot: to.ID}
+	for rows.Next() {
+		var product ChangedProduct
+		var modified sql.NullInt64
+		changed := make([]bool, len(historyFields))
+		pointers := []interface{}{&product.Code, &product.Event, &modified}
+		for i := range changed {
+			pointers = append(pointers, &changed[i])
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("changes scan failed: %w", err)
+		}
+		if product.Event == EventChanged {
+			for i, field := range historyFields {
+				if changed[i] {
+					product.Fields = append(product.Fields, field.name)
+				}
+			}
+		}
+		if modified.Valid {
+			modifiedAt := time.Unix(modified.Int64, 0).UTC()
+			product.LastModified = &modifiedAt
+		}
+		page.Products = append(page.Products, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	if len(page.Products) > limit {
+		page.Products = page.Products[:limit]
+		page.NextCursor = encodeCursor(cursorSnapshot, from.ID, to.ID, page.Products[limit-1].Code)
+	}
+	page.Count = len(page.Products)
+	return page, nil
+}
@@ -0,0 +1,127 @@
+package query
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateChangesQuery(t *testing.T) {
+	since := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name          string
+		query         ChangesQuery
+		expectError   bool
+		expectedLimit int
+	}{
+		{name: "since with default limit", query: ChangesQuery{Since: &since}, expectedLimit: DefaultChangesLimit},
+		{name: "previous snapshot with limit", query: ChangesQuery{SincePreviousSnapshot: true, Limit: 10}, expectedLimit: 10},
+		{name: "neither", query: ChangesQuery{}, expectError: true},
+		{name: "both", query: ChangesQuery{Since: &since, SincePreviousSnapshot: true}, expectError: true},
+		{name: "limit too large", query: ChangesQuery{Since: &since, Limit: MaxChangesLimit + 1}, expectError: true},
+		{name: "negative limit", query: ChangesQuery{Since: &since, Limit: -1}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, err := validateChangesQuery(tt.query)
+			if tt.expectError {
+				assert.ErrorIs(t, err, ErrInvalidChangesQuery)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedLimit, limit)
+		})
+	}
+}
+
+func TestEngine_ChangedProducts_Since_Integration(t *testing.T) {
+	engine := newFixtureEngine(t)
+	ctx := context.Background()
+	since := time.Unix(1700000000, 0)
+
+	first, err := engine.ChangedProducts(ctx, ChangesQuery{Since: &since, Limit: 1})
+	require.NoError(t, err)
+	require.Equal(t, 1, first.Count)
+	assert.Equal(t, "5449000000996", first.Products[0].Code)
+	assert.Equal(t, "Coca-Cola", first.Products[0].ProductName)
+	require.NotNil(t, first.Products[0].LastModified)
+	assert.Equal(t, time.Unix(1710000000, 0).UTC(), *first.Products[0].LastModified)
+	require.NotEmpty(t, first.NextCursor)
+
+	second, err := engine.ChangedProducts(ctx, ChangesQuery{Since: &since, Limit: 1, Cursor: first.NextCursor})
+	require.NoError(t, err)
+	require.Equal(t, 1, second.Count)
+	assert.Equal(t, "0000000000001", second.Products[0].Code)
+	assert.Empty(t, second.NextCursor)
+
+	_, err = engine.ChangedProducts(ctx, ChangesQuery{Since: &since, Cursor: "not a cursor"})
+	assert.ErrorIs(t, err, ErrInvalidChangesQuery)
+
+	_, err = engine.ChangedProducts(ctx, ChangesQuery{SincePreviousSnapshot: true})
+	assert.ErrorIs(t, err, ErrHistoryDisabled)
+}
+
+func TestEngine_ChangedProducts_SincePreviousSnapshot_Integration(t *testing.T) {
+	dir := t.TempDir()
+	historyDir := filepath.Join(dir, "history")
+	original := filepath.Join(dir, "original.parquet")
+	updated := filepath.Join(dir, "updated.parquet")
+	writeTestParquet(t, original)
+	writeUpdatedParquet(t, original, updated)
+
+	ctx := context.Background()
+	taken := time.Date(2025, 1, 1, 6, 0, 0, 0, time.UTC)
+
+	engine := newHistoryEngine(t, updated, historyDir, 5)
+	_, err := newHistoryEngine(t, original, historyDir, 5).RecordSnapshot(ctx, "aaaaaaaaaaaa", taken)
+	require.NoError(t, err)
+
+	_, err = engine.ChangedProducts(ctx, ChangesQuery{SincePreviousSnapshot: true})
+	assert.ErrorIs(t, err, ErrNoPreviousSnapshot)
+
+	_, err = engine.RecordSnapshot(ctx, "bbbbbbbbbbbb", taken.Add(24*time.Hour))
+	require.NoError(t, err)
+
+	first, err := engine.ChangedProducts(ctx, ChangesQuery{SincePreviousSnapshot: true, Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "20250101T060000Z-aaaaaaaaaaaa", first.FromSnapshot)
+	assert.Equal(t, "20250102T060000Z-bbbbbbbbbbbb", first.ToSnapshot)
+	require.Equal(t, 1, first.Count)
+	assert.Equal(t, ChangedProduct{
+		Code:         "3017620422003",
+		Event:        EventChanged,
+		Fields:       []string{"nutriments", "nutriscore_grade", "nutriscore_score"},
+		LastModified: first.Products[0].LastModified,
+	}, first.Products[0])
+	require.NotEmpty(t, first.NextCursor)
+
+	second, err := engine.ChangedProducts(ctx, ChangesQuery{SincePreviousSnapshot: true, Cursor: first.NextCursor})
+	require.NoError(t, err)
+	require.Equal(t, 1, second.Count)
+	assert.Equal(t, "5449000000996", second.Products[0].Code)
+	assert.Equal(t, EventRemoved, second.Products[0].Event)
+	assert.Empty(t, second.Products[0].Fields)
+	assert.Empty(t, second.NextCursor)
+
+	// A cursor from the other kind of query is rejected
+	since := time.Unix(0, 0)
+	_, err = engine.ChangedProducts(ctx, ChangesQuery{Since: &since, Cursor: first.NextCursor})
+	assert.ErrorIs(t, err, ErrInvalidChangesQuery)
+
+	// A cursor is rejected once a newer snapshot changes which two snapshots are compared
+	_, err = newHistoryEngine(t, original, historyDir, 5).RecordSnapshot(ctx, "cccccccccccc", taken.Add(48*time.Hour))
+	require.NoError(t, err)
+	_, err = engine.ChangedProducts(ctx, ChangesQuery{SincePreviousSnapshot: true, Cursor: first.NextCursor})
+	assert.ErrorIs(t, err, ErrInvalidChangesQuery)
+	assert.Contains(t, err.Error(), "20250101T060000Z-aaaaaaaaaaaa")
+
+	restarted, err := engine.ChangedProducts(ctx, ChangesQuery{SincePreviousSnapshot: true})
+	require.NoError(t, err)
+	assert.Equal(t, "20250103T060000Z-cccccccccccc", restarted.ToSnapshot)
+}
@@ -0,0 +1,198 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnknownDumpFormat is returned for an import file that is neither a JSON Lines nor a CSV export
+var ErrUnknownDumpFormat = errors.New("unknown dump format")
+
+// productColumn is a dataset column with its parquet type and how it is read from an Open Food Facts JSON product
+type productColumn struct {
+	name string
+	typ  string
+	// fromJSON reads the column from the product object in the json column
+	fromJSON string
+}
+
+// imageSizeType is the width and height of one image resolution
+const imageSizeType = `STRUCT(h INTEGER, w INTEGER)`
+
+// productSchema is the layout of the Hugging Face export for the columns the engine reads
+// Nested fields are built as JSON shaped like the export and converted with TRY_CAST, which leaves
+// missing or malformed values NULL instead of failing the row
+var productSchema = []productColumn{
+	{name: "code", typ: "VARCHAR", fromJSON: `json->>'code'`},
+	{
+		name: "product_name",
+		typ:  "STRUCT(lang VARCHAR, text VARCHAR)[]",
+		// The main name under "main", then one entry per product_name_<lang> field
+		fromJSON: `list_prepend(json_object('lang', 'main', 'text', json->>'product_name'),
+			[json_object('lang', substr(k, 14), 'text', json->>k)
+				for k in json_keys(json) if k LIKE 'product_name\_%' ESCAPE '\' AND (json->>k) <> ''])`,
+	},
+	{name: "brands", typ: "VARCHAR", fromJSON: `json->>'brands'`},
+	{name: "link", typ: "VARCHAR", fromJSON: `json->>'link'`},
+	{
+		name: "nutriments",
+		typ: `STRUCT(name VARCHAR, value FLOAT, "100g" FLOAT, serving FLOAT, unit VARCHAR,
+			prepared_value FLOAT, prepared_100g FLOAT, prepared_serving FLOAT, prepared_unit VARCHAR)[]`,
+		// The JSON object has flat <name>, <name>_100g, <name>_serving and <name>_unit keys
+		fromJSON: `[json_object(
+				'name', k[:-6],
+				'value', json->'nutriments'->(k[:-6]),
+				'100g', json->'nutriments'->k,
+				'serving', json->'nutriments'->(k[:-6] || '_serving'),
+				'unit', json->'nutriments'->>(k[:-6] || '_unit'),
+				'prepared_value', json->'nutriments'->(k[:-6] || '_prepared'),
+				'prepared_100g', json->'nutriments'->(k[:-6] || '_prepared_100g'),
+				'prepared_serving', json->'nutriments'->(k[:-6] || '_prepared_serving'),
+				'prepared_unit', json->'nutriments'->>(k[:-6] || '_prepared_unit'))
+			for k in json_keys(json->'nutriments') if k LIKE '%\_100g' ESCAPE '\' AND k NOT LIKE '%\_prepared\_100g' ESCAPE '\']`,
+	},
+	// Ingredients nest arbitrarily deep, so the export keeps them as JSON text
+	{name: "ingredients", typ: "JSON", fromJSON: `json->'ingredients'`},
+	{name: "serving_quantity", typ: "VARCHAR", fromJSON: `json->>'serving_quantity'`},
+	{name: "serving_size", typ: "VARCHAR", fromJSON: `json->>'serving_size'`},
+	{name: "quantity", typ: "VARCHAR", fromJSON: `json->>'quantity'`},
+	{name: "product_quantity_unit", typ: "VARCHAR", fromJSON: `json->>'product_quantity_unit'`},
+	{
+		name: "images",
+		typ: `STRUCT(key VARCHAR, imgid INTEGER, rev INTEGER,
+			sizes STRUCT("100" ` + imageSizeType + `, "200" ` + imageSizeType + `, "400" ` + imageSizeType + `, "full" ` + imageSizeType + `),
+			uploaded_t BIGINT, uploader VARCHAR)[]`,
+		// The JSON object is keyed by image id
+		fromJSON: `[json_object(
+				'key', k,
+				'imgid', json->'images'->k->'imgid',
+				'rev', json->'images'->k->'rev',
+				'sizes', json->'images'->k->'sizes',
+				'uploaded_t', json->'images'->k->'uploaded_t',
+				'uploader', json->'images'->k->>'uploader')
+			for k in json_keys(json->'images')]`,
+	},
+	{
+		name: "packagings",
+		typ: `STRUCT(material VARCHAR, number_of_units BIGINT, quantity_per_unit VARCHAR,
+			recycling VARCHAR, shape VARCHAR, weight_measured FLOAT)[]`,
+		fromJSON: `json->'packagings'`,
+	},
+	{name: "categories_tags", typ: "VARCHAR[]", fromJSON: `json->'categories_tags'`},
+	{name: "countries_tags", typ: "VARCHAR[]", fromJSON: `json->'countries_tags'`},
+	{name: "labels_tags", typ: "VARCHAR[]", fromJSON: `json->'labels_tags'`},
+	{name: "allergens_tags", typ: "VARCHAR[]", fromJSON: `json->'allergens_tags'`},
+	{name: "nutriscore_grade", typ: "VARCHAR", fromJSON: `json->>'nutriscore_grade'`},
+	{name: "nutriscore_score", typ: "INTEGER", fromJSON: `json->'nutriscore_score'`},
+	{name: "nova_group", typ: "INTEGER", fromJSON: `json->'nova_group'`},
+	{name: "last_modified_t", typ: "BIGINT", fromJSON: `json->'last_modified_t'`},
+}
+
+// productsQuery selects the productSchema columns from a relation with one JSON product per row in a json column,
+// skipping products without a code
+func productsQuery(from string) string {
+	columns := make([]string, len(productSchema))
+	for i, column := range productSchema {
+		columns[i] = fmt.Sprintf("TRY_CAST(%s AS %s) AS %s", column.fromJSON, column.typ, column.name)
+	}
+
+	return fmt.Sprintf(`SELECT %s
+		FROM %s
+		WHERE (json->>'code') IS NOT NULL AND (json->>'code') <> ''`,
+		strings.Join(columns, ",\n\t\t\t"), from)
+}
+
+// jsonProductsQuery selects the productSchema columns from JSON Lines product files, optionally gzipped
+func jsonProductsQuery(paths []string) string {
+	return productsQuery(fmt.Sprintf("read_ndjson_objects(%s, maximum_object_size = 67108864)", sqlStringList(paths)))
+}
+
+// csvProductsQuery selects the productSchema columns from the tab-separated CSV export, optionally gzipped
+// Each row is turned into an object shaped like a JSON product: the comma-separated tag columns become lists
+// and the <nutrient>_100g columns move into nutriments. The export has no ingredient, image or packaging details
+func csvProductsQuery(paths []string) string {
+	return productsQuery(fmt.Sprintf(`(
+		SELECT json_merge_patch(row, json_object(
+			'categories_tags', %s,
+			'countries_tags', %s,
+			'labels_tags', %s,
+			'allergens_tags', %s,
+			'nutriments', to_json(map_from_entries(
+				[(k, row->>k) for k in json_keys(row) if k LIKE '%%\_100g' ESCAPE '\' AND (row->>k) <> '']))
+		)) AS json
+		FROM (
+			SELECT to_json(t) AS row
+			FROM read_csv(%s, delim = '\t', quote = '', header = true, all_varchar = true,
+				null_padding = true, strict_mode = false, union_by_name = true) t
+		)
+	)`,
+		csvTagList("categories_tags"), csvTagList("countries_tags"), csvTagList("labels_tags"),
+		// The export lists allergen tags in the allergens column
+		csvTagList("allergens"), sqlStringList(paths)))
+}
+
+// csvTagList splits a comma-separated CSV tag column into a JSON list
+func csvTagList(column string) string {
+	return fmt.Sprintf(`to_json(list_filter(string_split(row->>'%s', ','), x -> x <> ''))`, column)
+}
+
+// sqlStringList formats paths as a DuckDB list literal
+func sqlStringList(paths []string) string {
+	quoted := make([]string, len(paths))
+	for i, path := range paths {
+		quoted[i] = "'" + escapeSQLString(path) + "'"
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// ImportProducts converts Open Food Facts JSON Lines or CSV exports into a parquet file with the layout the engine reads
+// All files must have the same format. DuckDB streams the conversion, so the dumps never have to fit in memory
+func ImportProducts(ctx context.Context, paths []string, outPath string) (int64, error) {
+	if len(paths) == 0 {
+		return 0, fmt.Errorf("no files to import")
+	}
+
+	format := dumpFormat(paths[0])
+	for _, path := range paths {
+		if dumpFormat(path) != format || format == "" {
+			return 0, fmt.Errorf("%w: %s, expected .jsonl or .csv, optionally gzipped, all of the same format", ErrUnknownDumpFormat, path)
+		}
+	}
+
+	query := jsonProductsQuery(paths)
+	if format == "csv" {
+		query = csvProductsQuery(paths)
+	}
+
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		return 0, fmt.Errorf("failed to open duckdb: %w", err)
+	}
+	defer db.Close()
+
+	result, err := db.ExecContext(ctx, fmt.Sprintf(`COPY (%s) TO '%s' (FORMAT parquet, COMPRESSION zstd)`, query, escapeSQLString(outPath)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to convert %s export: %w", format, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return rows, nil
+}
+
+// dumpFormat returns "json" or "csv" from a dump's file extension, ignoring a .gz suffix
+func dumpFormat(path string) string {
+	switch filepath.Ext(strings.TrimSuffix(strings.ToLower(path), ".gz")) {
+	case ".jsonl", ".ndjson", ".json":
+		return "json"
+	case ".csv", ".tsv":
+		return "csv"
+	default:
+		return ""
+	}
+}
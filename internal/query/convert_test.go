@@ -0,0 +1,116 @@
+package query
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const csvFixture = "testdata/en.openfoodfacts.org.products.csv"
+
+func TestImportProducts(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	gzippedJSON := filepath.Join(dir, "openfoodfacts-products.jsonl.gz")
+	gzipFixture(t, deltaFixture, gzippedJSON)
+	gzippedCSV := filepath.Join(dir, "en.openfoodfacts.org.products.csv.gz")
+	gzipFixture(t, csvFixture, gzippedCSV)
+
+	tests := []struct {
+		name          string
+		paths         []string
+		expectedRows  int64
+		barcode       string
+		expectedName  string
+		expectedBrand string
+		expectedSugar float64
+		expectedTags  []string
+	}{
+		{
+			name:          "JSON Lines export",
+			paths:         []string{gzippedJSON},
+			expectedRows:  4, // Both edits of the oat drink are kept, the product without a code is not
+			barcode:       "3017620422003",
+			expectedName:  "Nutella Plus",
+			expectedBrand: "Ferrero",
+			expectedSugar: 48,
+			expectedTags:  []string{"en:spreads", "en:hazelnut-spreads"},
+		},
+		{
+			name:          "CSV export",
+			paths:         []string{gzippedCSV},
+			expectedRows:  2,
+			barcode:       "3017620422003",
+			expectedName:  "[{'lang': main, 'text': Nutella}]",
+			expectedBrand: "Ferrero",
+			expectedSugar: 56.3,
+			expectedTags:  []string{"en:spreads", "en:sweet-spreads"},
+		},
+		{
+			name:          "uncompressed CSV export",
+			paths:         []string{csvFixture},
+			expectedRows:  2,
+			barcode:       "5449000000996",
+			expectedName:  `[{'lang': main, 'text': 'Coca-Cola "Original"'}]`,
+			expectedBrand: "Coca-Cola",
+			expectedSugar: 10.6,
+			expectedTags:  []string{"en:sodas"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "imported.parquet")
+			rows, err := ImportProducts(ctx, tt.paths, out)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedRows, rows)
+			require.NoError(t, ValidateDataset(ctx, out))
+
+			engine, err := NewEngine(out, &config.Config{}, config.NewTestLogger(os.Stdout, "ERROR"))
+			require.NoError(t, err)
+			defer engine.Close()
+
+			product, err := engine.SearchByBarcode(ctx, tt.barcode)
+			require.NoError(t, err)
+			require.NotNil(t, product)
+			assert.Equal(t, tt.expectedName, product.ProductName)
+			assert.Equal(t, tt.expectedBrand, product.Brands)
+			assert.Equal(t, tt.expectedTags, product.CategoriesTags)
+			sugars, ok := product.Nutriments["sugars"].(map[string]interface{})
+			require.True(t, ok, "sugars nutriment: %v", product.Nutriments)
+			assert.InDelta(t, tt.expectedSugar, sugars["100g"], 0.001)
+		})
+	}
+
+	t.Run("CSV allergens become allergen tags", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "imported.parquet")
+		_, err := ImportProducts(ctx, []string{csvFixture}, out)
+		require.NoError(t, err)
+
+		engine, err := NewEngine(out, &config.Config{}, config.NewTestLogger(os.Stdout, "ERROR"))
+		require.NoError(t, err)
+		defer engine.Close()
+
+		product, err := engine.SearchByBarcode(ctx, "3017620422003")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"en:milk", "en:nuts"}, product.AllergensTags)
+		require.NotNil(t, product.NutriscoreScore)
+		assert.Equal(t, 26, *product.NutriscoreScore)
+	})
+
+	t.Run("rejects unknown or mixed formats", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "imported.parquet")
+		_, err := ImportProducts(ctx, []string{filepath.Join(dir, "products.xml")}, out)
+		assert.ErrorIs(t, err, ErrUnknownDumpFormat)
+		_, err = ImportProducts(ctx, []string{gzippedJSON, gzippedCSV}, out)
+		assert.ErrorIs(t, err, ErrUnknownDumpFormat)
+		_, err = ImportProducts(ctx, nil, out)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,95 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// MergeDeltas writes a copy of the dataset at basePath to outPath with the products from Open Food Facts
+// delta exports (JSON Lines, optionally gzipped) applied on top
+// A delta product replaces the dataset row with the same code unless that row was modified more recently,
+// so applying an old delta again is harmless. Columns the deltas do not provide are left NULL for changed products
+func MergeDeltas(ctx context.Context, basePath string, deltaPaths []string, outPath string) error {
+	if len(deltaPaths) == 0 {
+		return fmt.Errorf("no delta files to merge")
+	}
+
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		return fmt.Errorf("failed to open duckdb: %w", err)
+	}
+	defer db.Close()
+
+	baseTypes, err := parquetColumnTypes(ctx, db, basePath)
+	if err != nil {
+		return err
+	}
+
+	// Delta rows are cast to the dataset's own column types so both sides of the union agree
+	var deltaColumns []string
+	for _, column := range productSchema {
+		if typ, ok := baseTypes[column.name]; ok {
+			deltaColumns = append(deltaColumns, fmt.Sprintf("TRY_CAST(n.%s AS %s) AS %s", column.name, typ, column.name))
+		}
+	}
+	if _, ok := baseTypes["code"]; !ok {
+		return fmt.Errorf("dataset has no code column")
+	}
+
+	base := fmt.Sprintf("read_parquet('%s')", escapeSQLString(basePath))
+	statement := fmt.Sprintf(`COPY (
+		WITH delta AS (
+			%s
+			QUALIFY row_number() OVER (PARTITION BY code ORDER BY last_modified_t DESC NULLS LAST) = 1
+		),
+		newer AS (
+			SELECT d.*
+			FROM delta d
+			LEFT JOIN (SELECT code, max(last_modified_t) AS last_modified_t FROM %s GROUP BY code) b ON b.code = d.code
+			WHERE b.code IS NULL OR b.last_modified_t IS NULL OR d.last_modified_t >= b.last_modified_t
+		)
+		SELECT b.* FROM %s b ANTI JOIN newer n ON b.code = n.code
+		UNION ALL BY NAME
+		SELECT %s FROM newer n
+	) TO '%s' (FORMAT parquet, COMPRESSION zstd)`,
+		jsonProductsQuery(deltaPaths), base, base, strings.Join(deltaColumns, ", "), escapeSQLString(outPath))
+
+	if _, err := db.ExecContext(ctx, statement); err != nil {
+		return fmt.Errorf("failed to merge delta files: %w", err)
+	}
+	return nil
+}
+
+// parquetColumnTypes returns the DuckDB type of every top-level column in a parquet file
+func parquetColumnTypes(ctx context.Context, db *sql.DB, path string) (map[string]string, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`DESCRIBE SELECT * FROM read_parquet('%s')`, escapeSQLString(path)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dataset schema: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	types := make(map[string]string)
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to read dataset schema: %w", err)
+		}
+		// column_name and column_type come first
+		types[values[0].String] = values[1].String
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dataset schema: %w", err)
+	}
+	return types, nil
+}
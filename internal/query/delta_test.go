@@ -0,0 +1,98 @@
+package query
+
+import (
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const deltaFixture = "testdata/openfoodfacts_products_1800000000_1800086400.json"
+
+// gzipFixture writes a gzipped copy of a fixture, as Open Food Facts publishes deltas
+func gzipFixture(t *testing.T, src, dst string) {
+	t.Helper()
+
+	data, err := os.ReadFile(src)
+	require.NoError(t, err)
+	f, err := os.Create(dst)
+	require.NoError(t, err)
+	defer f.Close()
+	w := gzip.NewWriter(f)
+	_, err = w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+}
+
+func TestMergeDeltas(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.parquet")
+	writeTestParquet(t, base)
+
+	delta := filepath.Join(dir, "openfoodfacts_products_1800000000_1800086400.json.gz")
+	gzipFixture(t, deltaFixture, delta)
+
+	merged := filepath.Join(dir, "merged.parquet")
+	ctx := context.Background()
+	require.NoError(t, MergeDeltas(ctx, base, []string{delta}, merged))
+	require.NoError(t, ValidateDataset(ctx, merged))
+
+	engine, err := NewEngine(merged, &config.Config{}, config.NewTestLogger(os.Stdout, "ERROR"))
+	require.NoError(t, err)
+	defer engine.Close()
+
+	tests := []struct {
+		name          string
+		barcode       string
+		expectedName  string
+		expectedGrade string
+		expectedSugar float64
+	}{
+		{name: "newer delta product replaces the dataset row", barcode: "3017620422003", expectedName: "Nutella Plus", expectedGrade: "d", expectedSugar: 48},
+		{name: "older delta product is ignored", barcode: "5449000000996", expectedName: "Coca-Cola", expectedGrade: "e", expectedSugar: 10.6},
+		{name: "new product is added from its latest edit", barcode: "4000000000001", expectedName: "Oat Drink Barista", expectedGrade: "c", expectedSugar: 3.4},
+		{name: "untouched product is kept", barcode: "0000000000001", expectedName: "[{'lang': fr, 'text': Eau}]", expectedGrade: "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product, err := engine.SearchByBarcode(ctx, tt.barcode)
+			require.NoError(t, err)
+			require.NotNil(t, product)
+			assert.Equal(t, tt.expectedName, product.ProductName)
+			assert.Equal(t, tt.expectedGrade, product.NutriscoreGrade)
+			if tt.expectedSugar != 0 {
+				sugars, ok := product.Nutriments["sugars"].(map[string]interface{})
+				require.True(t, ok, "sugars nutriment: %v", product.Nutriments)
+				assert.InDelta(t, tt.expectedSugar, sugars["100g"], 0.001)
+			}
+		})
+	}
+
+	t.Run("nested fields are converted", func(t *testing.T) {
+		product, err := engine.SearchByBarcode(ctx, "3017620422003")
+		require.NoError(t, err)
+		var ingredient string
+		require.NoError(t, engine.db.QueryRow(`SELECT ingredients[1].id FROM read_parquet(?) WHERE code = ?`, merged, product.Code).Scan(&ingredient))
+		assert.Equal(t, "en:sugar", ingredient)
+		require.Len(t, product.Packagings, 1)
+		assert.Equal(t, "en:glass", product.Packagings[0].Material)
+		assert.NotEmpty(t, product.Images)
+		assert.Equal(t, []string{"en:spreads", "en:hazelnut-spreads"}, product.CategoriesTags)
+	})
+
+	t.Run("products without a barcode are skipped", func(t *testing.T) {
+		var rows int
+		require.NoError(t, engine.db.QueryRow(`SELECT count(*) FROM read_parquet(?)`, merged).Scan(&rows))
+		assert.Equal(t, 4, rows)
+	})
+
+	t.Run("requires delta files", func(t *testing.T) {
+		assert.Error(t, MergeDeltas(ctx, base, nil, filepath.Join(dir, "empty.parquet")))
+	})
+}
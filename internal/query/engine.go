@@ -9,6 +9,7 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	_ "github.com/marcboeker/go-duckdb/v2"
@@ -18,7 +19,8 @@ import (
 
 // Query engine constants
 const (
-	MaxJSONDebugLength = 100
+	MaxJSONDebugLength    = 100
+	MaxBarcodesPerRequest = 50
 )
 
 // Engine handles DuckDB queries against the parquet dataset
@@ -26,6 +28,18 @@ type Engine struct {
 	db          *sql.DB
 	parquetPath string
 	log         *slog.Logger
+	admission   *admissionController
+
+	// Optional locked-down instance backing the run_sql tool (nil when disabled)
+	sqlDB        *sql.DB
+	sqlRowLimit  int
+	sqlTimeout   time.Duration
+	sqlViewMu    sync.Mutex
+	sqlViewReady bool
+
+	// Per-snapshot product fingerprints backing the product history
+	historyDir    string
+	historyRetain int
 }
 
 // Ensure Engine implements QueryEngine interface
@@ -88,10 +102,37 @@ func NewEngine(parquetPath string, cfg *config.Config, logger *slog.Logger) (*En
 		}
 	}
 
+	// Bound the number of queries waiting on the pool so overload is shed instead of queued silently
+	admission := newAdmissionController(cfg.DuckDBMaxOpenConns, cfg.DuckDBMaxQueueDepth, cfg.QueueTimeout())
+
+	logger.Info("DuckDB admission control configured",
+		"enabled", admission != nil,
+		"max_queue_depth", cfg.DuckDBMaxQueueDepth,
+		"queue_timeout_ms", cfg.DuckDBQueueTimeoutMs)
+
 	engine := &Engine{
-		db:          db,
-		parquetPath: parquetPath,
-		log:         logger,
+		db:            db,
+		parquetPath:   parquetPath,
+		log:           logger,
+		admission:     admission,
+		historyDir:    cfg.HistoryDir,
+		historyRetain: cfg.HistorySnapshots,
+	}
+
+	if cfg.EnableSQLTool {
+		sqlDB, err := openSQLToolDB(parquetPath, cfg.SQLToolMemoryLimit, cfg.DuckDBThreads, cfg.DuckDBMaxOpenConns)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		engine.sqlDB = sqlDB
+		engine.sqlRowLimit = cfg.SQLToolRowLimit
+		engine.sqlTimeout = cfg.SQLToolTimeout()
+
+		logger.Info("run_sql tool enabled",
+			"row_limit", cfg.SQLToolRowLimit,
+			"timeout_seconds", cfg.SQLToolTimeoutSeconds,
+			"memory_limit", cfg.SQLToolMemoryLimit)
 	}
 
 	return engine, nil
@@ -99,9 +140,27 @@ func NewEngine(parquetPath string, cfg *config.Config, logger *slog.Logger) (*En
 
 // Close closes the database connection
 func (e *Engine) Close() error {
+	if e.sqlDB != nil {
+		e.sqlDB.Close()
+	}
 	return e.db.Close()
 }
 
+// QueueStats returns a snapshot of the admission queue for monitoring
+func (e *Engine) QueueStats() QueueStats {
+	return e.admission.stats()
+}
+
+// admit waits for a query slot, logging when the query is shed
+func (e *Engine) admit(ctx context.Context, operation string) (func(), error) {
+	release, err := e.admission.acquire(ctx)
+	if err != nil {
+		e.log.Warn("Query rejected by admission control", "operation", operation, "error", err)
+		return nil, err
+	}
+	return release, nil
+}
+
 // queryWithRetry executes a query with retry logic to handle brief file unavailability
 func (e *Engine) queryWithRetry(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
 	maxRetries := 3
@@ -281,240 +340,193 @@ func (e *Engine) parseNutrimentsJSON(nutrimentsStr sql.NullString) map[string]in
 	return nutrientsMap
 }
 
-// SearchProductsByBrandAndName searches for products by name and brand
-func (e *Engine) SearchProductsByBrandAndName(ctx context.Context, name, brand string, limit int) ([]types.Product, error) {
-	totalStart := time.Now()
-	e.log.Debug("SearchProductsByBrandAndName starting", "name", name, "brand", brand, "limit", limit)
-
-	// Build optimized query with pre-computed text extraction
-	// Performance guide optimization: avoid repeated complex expressions in WHERE clause
-	var query string
-	args := []interface{}{e.parquetPath}
-
-	queryBuildStart := time.Now()
-
-	if name != "" && brand != "" {
-		// Most specific case - use CTE to pre-compute text fields once
-		// Performance optimization: compute complex expressions only once
-		query = `
-		WITH extracted AS (
-			SELECT 
-				code,
-				COALESCE(
-					(SELECT list_extract(list_filter(product_name, x -> x.lang = 'en'), 1).text),
-					CAST(product_name AS VARCHAR)
-				) as product_name_text,
-				CAST(brands AS VARCHAR) as brands_text,
-				CAST(nutriments AS VARCHAR) as nutriments_json,
-				link,
-				CAST(ingredients AS VARCHAR) as ingredients_json,
-				serving_quantity,
-				product_quantity_unit,
-				serving_size
-			FROM read_parquet(?)
-			-- Performance: filter on simpler field first (brands is typically smaller)
-			WHERE brands IS NOT NULL 
-			  AND CAST(brands AS VARCHAR) ILIKE ?
-		)
-		SELECT * FROM extracted 
-		WHERE product_name_text IS NOT NULL 
-		  AND product_name_text ILIKE ?
-		ORDER BY length(product_name_text)  -- Performance: shorter names first for relevance
-		LIMIT ?`
-
-		brandPattern := fmt.Sprintf("%%%s%%", brand)
-		namePattern := fmt.Sprintf("%%%s%%", name)
-		args = append(args, brandPattern, namePattern, limit)
-
-	} else if brand != "" {
-		// Brand only - optimized for single filter
-		query = `
-		SELECT 
-			code, 
-			COALESCE(
-				(SELECT list_extract(list_filter(product_name, x -> x.lang = 'en'), 1).text),
-				CAST(product_name AS VARCHAR)
-			) as product_name_text,
+// productColumns is the select list read by scanProduct, in scan order
+const productColumns = `
+			code,
+			` + productNameExpr + ` as product_name_text,
 			CAST(brands AS VARCHAR) as brands_text,
 			CAST(nutriments AS VARCHAR) as nutriments_json,
 			link,
 			CAST(ingredients AS VARCHAR) as ingredients_json,
 			serving_quantity,
 			product_quantity_unit,
-			serving_size
-		FROM read_parquet(?)
-		WHERE brands IS NOT NULL 
-		  AND CAST(brands AS VARCHAR) ILIKE ?
-		ORDER BY code  -- Performance: leverage potential ordering on code
-		LIMIT ?`
-
-		brandPattern := fmt.Sprintf("%%%s%%", brand)
-		args = append(args, brandPattern, limit)
-
-	} else if name != "" {
-		// Name only - avoid duplicate complex expression evaluation
-		query = `
-		WITH product_names AS (
-			SELECT 
-				code,
-				COALESCE(
-					(SELECT list_extract(list_filter(product_name, x -> x.lang = 'en'), 1).text),
-					CAST(product_name AS VARCHAR)
-				) as product_name_text,
-				CAST(brands AS VARCHAR) as brands_text,
-				CAST(nutriments AS VARCHAR) as nutriments_json,
-				link,
-				CAST(ingredients AS VARCHAR) as ingredients_json,
-				serving_quantity,
-				product_quantity_unit,
-				serving_size
-			FROM read_parquet(?)
-			WHERE product_name IS NOT NULL
-		)
-		SELECT * FROM product_names
-		WHERE product_name_text ILIKE ?
-		ORDER BY length(product_name_text)  -- Performance: relevance ordering
-		LIMIT ?`
-
-		namePattern := fmt.Sprintf("%%%s%%", name)
-		args = append(args, namePattern, limit)
-
-	} else {
-		// No filters - simple select with basic optimization
-		query = `
-		SELECT 
-			code, 
-			COALESCE(
-				(SELECT list_extract(list_filter(product_name, x -> x.lang = 'en'), 1).text),
-				CAST(product_name AS VARCHAR)
-			) as product_name_text,
-			CAST(brands AS VARCHAR) as brands_text,
-			CAST(nutriments AS VARCHAR) as nutriments_json,
-			link,
-			CAST(ingredients AS VARCHAR) as ingredients_json,
-			serving_quantity,
-			product_quantity_unit,
-			serving_size
-		FROM read_parquet(?)
-		WHERE product_name IS NOT NULL  -- Performance: filter out nulls early
-		ORDER BY code  -- Performance: leverage potential ordering
-		LIMIT ?`
-
-		args = append(args, limit)
+			serving_size,
+			CAST(to_json(images) AS VARCHAR) as images_json,
+			CAST(to_json(packagings) AS VARCHAR) as packagings_json,
+			CAST(to_json(categories_tags) AS VARCHAR) as categories_tags_json,
+			CAST(nutriscore_grade AS VARCHAR) as nutriscore_grade_text,
+			CAST(nutriscore_score AS BIGINT) as nutriscore_score_value,
+			CAST(to_json(labels_tags) AS VARCHAR) as labels_tags_json,
+			CAST(to_json(allergens_tags) AS VARCHAR) as allergens_tags_json`
+
+// scanProduct scans the current row, selected with productColumns, into a Product
+func (e *Engine) scanProduct(rows *sql.Rows) (*types.Product, error) {
+	var p types.Product
+	var nutrimentsStr sql.NullString
+	var ingredientsStr sql.NullString
+	var linkStr sql.NullString
+	var codeStr sql.NullString
+	var productNameStr sql.NullString
+	var brandsStr sql.NullString
+	var servingQuantity sql.NullString
+	var productQuantityUnit sql.NullString
+	var servingSize sql.NullString
+	var imagesStr sql.NullString
+	var packagingsStr sql.NullString
+	var categoriesStr sql.NullString
+	var nutriscoreGrade sql.NullString
+	var nutriscoreScore sql.NullInt64
+	var labelsStr sql.NullString
+	var allergensStr sql.NullString
+
+	if err := rows.Scan(&codeStr, &productNameStr, &brandsStr, &nutrimentsStr, &linkStr, &ingredientsStr, &servingQuantity, &productQuantityUnit, &servingSize, &imagesStr, &packagingsStr,
+		&categoriesStr, &nutriscoreGrade, &nutriscoreScore, &labelsStr, &allergensStr); err != nil {
+		return nil, err
 	}
 
-	e.log.Debug("Query built", "duration", time.Since(queryBuildStart), "sql_length", len(query))
-
-	queryStart := time.Now()
-	rows, err := e.queryWithRetry(ctx, query, args...)
-	if err != nil {
-		e.log.Error("DuckDB query failed", "error", err, "duration", time.Since(queryStart))
-		return nil, fmt.Errorf("query failed: %w", err)
+	// Handle nullable fields
+	if codeStr.Valid {
+		p.Code = codeStr.String
+	}
+	if productNameStr.Valid {
+		p.ProductName = productNameStr.String
+	}
+	if brandsStr.Valid {
+		p.Brands = brandsStr.String
+	}
+	if linkStr.Valid {
+		p.Link = linkStr.String
+	}
+	if productQuantityUnit.Valid {
+		p.ServingQuantityUnit = productQuantityUnit.String
+	}
+	if servingSize.Valid {
+		p.ServingSize = servingSize.String
+	}
+	if nutriscoreGrade.Valid {
+		p.NutriscoreGrade = nutriscoreGrade.String
+	}
+	if nutriscoreScore.Valid {
+		score := int(nutriscoreScore.Int64)
+		p.NutriscoreScore = &score
 	}
-	defer rows.Close()
-
-	e.log.Debug("Query executed", "duration", time.Since(queryStart))
-
-	scanStart := time.Now()
-	rowCount := 0
 
-	var results []types.Product
-	for rows.Next() {
-		rowCount++
-		var p types.Product
-		var nutrimentsStr sql.NullString
-		var ingredientsStr sql.NullString
-		var linkStr sql.NullString
-		var codeStr sql.NullString
-		var productNameStr sql.NullString
-		var brandsStr sql.NullString
-		var servingQuantity sql.NullString
-		var productQuantityUnit sql.NullString
-		var servingSize sql.NullString
-
-		if err := rows.Scan(&codeStr, &productNameStr, &brandsStr, &nutrimentsStr, &linkStr, &ingredientsStr, &servingQuantity, &productQuantityUnit, &servingSize); err != nil {
-			continue // Skip malformed rows
+	// Handle serving_quantity which can be string, int, float, or null
+	if servingQuantity.Valid && servingQuantity.String != "" {
+		// Try to parse as JSON to handle various types
+		var qty interface{}
+		if err := json.Unmarshal([]byte(servingQuantity.String), &qty); err != nil {
+			// If JSON parsing fails, use the raw string
+			p.ServingQuantity = servingQuantity.String
+		} else {
+			p.ServingQuantity = qty
 		}
+	}
 
-		// Handle nullable fields
-		if codeStr.Valid {
-			p.Code = codeStr.String
+	// Parse JSON fields
+	p.Nutriments = e.parseNutrimentsJSON(nutrimentsStr)
+	if ingredientsStr.Valid && ingredientsStr.String != "" {
+		var ingredients interface{}
+		if err := json.Unmarshal([]byte(ingredientsStr.String), &ingredients); err != nil {
+			p.Ingredients = ingredientsStr.String // Use raw string on parse error
+		} else {
+			p.Ingredients = ingredients
 		}
-		if productNameStr.Valid {
-			p.ProductName = productNameStr.String
+	}
+	if imagesStr.Valid {
+		images, err := types.ParseImages(p.Code, imagesStr.String)
+		if err != nil {
+			e.log.Debug("Failed to parse images", "code", p.Code, "error", err)
 		}
-		if brandsStr.Valid {
-			p.Brands = brandsStr.String
+		p.Images = images
+	}
+	if packagingsStr.Valid {
+		packagings, err := types.ParsePackagings(packagingsStr.String)
+		if err != nil {
+			e.log.Debug("Failed to parse packagings", "code", p.Code, "error", err)
 		}
-		if linkStr.Valid {
-			p.Link = linkStr.String
+		p.Packagings = packagings
+	}
+	if categoriesStr.Valid {
+		if err := json.Unmarshal([]byte(categoriesStr.String), &p.CategoriesTags); err != nil {
+			e.log.Debug("Failed to parse categories", "code", p.Code, "error", err)
 		}
-		if productQuantityUnit.Valid {
-			p.ServingQuantityUnit = productQuantityUnit.String
+	}
+	if labelsStr.Valid {
+		if err := json.Unmarshal([]byte(labelsStr.String), &p.LabelsTags); err != nil {
+			e.log.Debug("Failed to parse labels", "code", p.Code, "error", err)
 		}
-		if servingSize.Valid {
-			p.ServingSize = servingSize.String
+	}
+	if allergensStr.Valid {
+		if err := json.Unmarshal([]byte(allergensStr.String), &p.AllergensTags); err != nil {
+			e.log.Debug("Failed to parse allergens", "code", p.Code, "error", err)
 		}
+	}
 
-		// Handle serving_quantity which can be string, int, float, or null
-		if servingQuantity.Valid && servingQuantity.String != "" {
-			// Try to parse as JSON to handle various types
-			var qty interface{}
-			if err := json.Unmarshal([]byte(servingQuantity.String), &qty); err != nil {
-				// If JSON parsing fails, use the raw string
-				p.ServingQuantity = servingQuantity.String
-			} else {
-				p.ServingQuantity = qty
-			}
-		}
+	return &p, nil
+}
 
-		// Parse JSON fields
-		p.Nutriments = e.parseNutrimentsJSON(nutrimentsStr)
-		if ingredientsStr.Valid && ingredientsStr.String != "" {
-			var ingredients interface{}
-			if err := json.Unmarshal([]byte(ingredientsStr.String), &ingredients); err != nil {
-				p.Ingredients = ingredientsStr.String // Use raw string on parse error
-			} else {
-				p.Ingredients = ingredients
-			}
-		}
+// SearchProductsByBrandAndName searches for products by name and brand
+// The search is compiled like a structured query, with shorter names ranked first when a name is given
+func (e *Engine) SearchProductsByBrandAndName(ctx context.Context, name, brand string, limit int) ([]types.Product, error) {
+	start := time.Now()
+	e.log.Debug("SearchProductsByBrandAndName starting", "name", name, "brand", brand, "limit", limit)
 
-		results = append(results, p)
+	where, orderBy, filterArgs, limit, err := compileProductQuery(brandAndNameQuery(name, brand, limit))
+	if err != nil {
+		return nil, err
+	}
+	if name != "" {
+		// Shorter names match the search more closely
+		orderBy = "length(" + productNameExpr + "), " + orderBy
 	}
 
-	if err := rows.Err(); err != nil {
-		e.log.Error("Rows iteration failed", "error", err)
-		return nil, fmt.Errorf("rows error: %w", err)
+	release, err := e.admit(ctx, "SearchProductsByBrandAndName")
+	if err != nil {
+		return nil, err
 	}
+	defer release()
 
-	e.log.Debug("Row scanning completed", "rows_scanned", rowCount, "scan_duration", time.Since(scanStart))
+	results, err := e.queryProducts(ctx, where, orderBy, filterArgs, limit)
+	if err != nil {
+		return nil, err
+	}
 
-	totalDuration := time.Since(totalStart)
-	e.log.Info("SearchProductsByBrandAndName completed", "count", len(results), "total_duration_ms", totalDuration.Milliseconds())
+	e.log.Info("SearchProductsByBrandAndName completed", "count", len(results), "total_duration_ms", time.Since(start).Milliseconds())
 	return results, nil
 }
 
+// brandAndNameQuery matches the brand and product name as case-insensitive substrings
+// Without either, it lists products that have a name
+func brandAndNameQuery(name, brand string, limit int) ProductQuery {
+	var filters []Filter
+	if brand != "" {
+		filters = append(filters, Filter{Field: "brands", Op: "match", Value: brand})
+	}
+	if name != "" {
+		filters = append(filters, Filter{Field: "product_name", Op: "match", Value: name})
+	}
+	if len(filters) == 0 {
+		filters = append(filters, Filter{Field: "product_name", Op: "exists"})
+	}
+	return ProductQuery{Where: &Filter{And: filters}, Limit: limit}
+}
+
 // SearchByBarcode searches for a product by barcode (exact match)
 func (e *Engine) SearchByBarcode(ctx context.Context, barcode string) (*types.Product, error) {
 	start := time.Now()
 	e.log.Debug("SearchByBarcode starting", "barcode", barcode)
 
+	release, err := e.admit(ctx, "SearchByBarcode")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	// Performance optimization: exact match on code should be very fast
 	// Use simple query structure for best performance on parquet
 	query := `
-		SELECT 
-			code, 
-			COALESCE(
-				(SELECT list_extract(list_filter(product_name, x -> x.lang = 'en'), 1).text),
-				CAST(product_name AS VARCHAR)
-			) as product_name_text,
-			CAST(brands AS VARCHAR) as brands_text,
-			CAST(nutriments AS VARCHAR) as nutriments_json,
-			link,
-			CAST(ingredients AS VARCHAR) as ingredients_json,
-			serving_quantity,
-			product_quantity_unit,
-			serving_size
+		SELECT ` + productColumns + `
 		FROM read_parquet(?)
 		WHERE code = ?
 		LIMIT 1`
@@ -530,69 +542,81 @@ func (e *Engine) SearchByBarcode(ctx context.Context, barcode string) (*types.Pr
 		return nil, nil
 	}
 
-	var p types.Product
-	var nutrimentsStr sql.NullString
-	var ingredientsStr sql.NullString
-	var linkStr sql.NullString
-	var codeStr sql.NullString
-	var productNameStr sql.NullString
-	var brandsStr sql.NullString
-	var servingQuantity sql.NullString
-	var productQuantityUnit sql.NullString
-	var servingSize sql.NullString
-
-	if err := rows.Scan(&codeStr, &productNameStr, &brandsStr, &nutrimentsStr, &linkStr, &ingredientsStr, &servingQuantity, &productQuantityUnit, &servingSize); err != nil {
+	p, err := e.scanProduct(rows)
+	if err != nil {
 		e.log.Error("Row scan failed", "error", err)
 		return nil, fmt.Errorf("scan failed: %w", err)
 	}
 
-	// Handle nullable fields
-	if codeStr.Valid {
-		p.Code = codeStr.String
+	duration := time.Since(start)
+	e.log.Info("SearchByBarcode completed", "found", true, "duration", duration)
+	return p, nil
+}
+
+// GetProductsByBarcodes looks up several products by barcode in a single scan
+// Results are returned in request order; barcodes that are not found are omitted
+func (e *Engine) GetProductsByBarcodes(ctx context.Context, barcodes []string) ([]types.Product, error) {
+	start := time.Now()
+	e.log.Debug("GetProductsByBarcodes starting", "count", len(barcodes))
+
+	if len(barcodes) == 0 {
+		return []types.Product{}, nil
 	}
-	if productNameStr.Valid {
-		p.ProductName = productNameStr.String
+	if len(barcodes) > MaxBarcodesPerRequest {
+		return nil, fmt.Errorf("too many barcodes: %d (max %d)", len(barcodes), MaxBarcodesPerRequest)
 	}
-	if brandsStr.Valid {
-		p.Brands = brandsStr.String
-	}
-	if linkStr.Valid {
-		p.Link = linkStr.String
+
+	release, err := e.admit(ctx, "GetProductsByBarcodes")
+	if err != nil {
+		return nil, err
 	}
-	if productQuantityUnit.Valid {
-		p.ServingQuantityUnit = productQuantityUnit.String
+	defer release()
+
+	placeholders := make([]string, len(barcodes))
+	args := []interface{}{e.parquetPath}
+	for i, barcode := range barcodes {
+		placeholders[i] = "?"
+		args = append(args, barcode)
 	}
-	if servingSize.Valid {
-		p.ServingSize = servingSize.String
+
+	query := `
+		SELECT ` + productColumns + `
+		FROM read_parquet(?)
+		WHERE code IN (` + strings.Join(placeholders, ", ") + `)`
+
+	rows, err := e.queryWithRetry(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("barcodes query failed: %w", err)
 	}
+	defer rows.Close()
 
-	// Handle serving_quantity which can be string, int, float, or null
-	if servingQuantity.Valid && servingQuantity.String != "" {
-		// Try to parse as JSON to handle various types
-		var qty interface{}
-		if err := json.Unmarshal([]byte(servingQuantity.String), &qty); err != nil {
-			// If JSON parsing fails, use the raw string
-			p.ServingQuantity = servingQuantity.String
-		} else {
-			p.ServingQuantity = qty
+	found := make(map[string]types.Product, len(barcodes))
+	for rows.Next() {
+		p, err := e.scanProduct(rows)
+		if err != nil {
+			continue // Skip malformed rows
+		}
+		if _, seen := found[p.Code]; !seen {
+			found[p.Code] = *p
 		}
 	}
 
-	// Parse JSON fields
-	p.Nutriments = e.parseNutrimentsJSON(nutrimentsStr)
+	if err := rows.Err(); err != nil {
+		e.log.Error("Rows iteration failed", "error", err)
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
 
-	if ingredientsStr.Valid && ingredientsStr.String != "" {
-		var ingredients interface{}
-		if err := json.Unmarshal([]byte(ingredientsStr.String), &ingredients); err != nil {
-			p.Ingredients = ingredientsStr.String // Use raw string on parse error
-		} else {
-			p.Ingredients = ingredients
+	results := make([]types.Product, 0, len(found))
+	added := make(map[string]bool, len(found))
+	for _, barcode := range barcodes {
+		if p, ok := found[barcode]; ok && !added[barcode] {
+			results = append(results, p)
+			added[barcode] = true
 		}
 	}
 
-	duration := time.Since(start)
-	e.log.Info("SearchByBarcode completed", "found", true, "duration", duration)
-	return &p, nil
+	e.log.Info("GetProductsByBarcodes completed", "requested", len(barcodes), "found", len(results), "duration", time.Since(start))
+	return results, nil
 }
 
 // TestConnection tests the database connection and parquet file access
@@ -600,11 +624,17 @@ func (e *Engine) TestConnection(ctx context.Context) error {
 	start := time.Now()
 	e.log.Debug("Testing DuckDB connection and parquet file")
 
+	release, err := e.admit(ctx, "TestConnection")
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// For health checks, use a much more efficient query that only reads metadata
 	// instead of scanning the entire file with COUNT(*)
 	query := `SELECT 1 FROM read_parquet(?) LIMIT 1`
 	var dummy int
-	err := e.queryRowWithRetry(ctx, query, e.parquetPath).Scan(&dummy)
+	err = e.queryRowWithRetry(ctx, query, e.parquetPath).Scan(&dummy)
 	if err != nil {
 		return fmt.Errorf("failed to test parquet file access: %w", err)
 	}
@@ -627,6 +657,7 @@ func (e *Engine) TestConnection(ctx context.Context) error {
 
 // HealthCheck performs a lightweight health check suitable for production monitoring
 // This avoids expensive operations like COUNT(*) queries and is optimized for speed
+// It bypasses admission control so an overloaded server is not reported as unhealthy
 func (e *Engine) HealthCheck(ctx context.Context) error {
 	start := time.Now()
 	e.log.Debug("Performing lightweight health check")
@@ -4,11 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/noot-app/openfoodfacts-mcp-server/internal/config"
 	"github.com/noot-app/openfoodfacts-mcp-server/internal/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewMockEngine(t *testing.T) {
@@ -424,26 +426,111 @@ func TestProduct_JSONSerialization(t *testing.T) {
 	assert.Equal(t, 100, energy)
 }
 
-// Integration test would require a real parquet file
-// This is a placeholder for when we have test data
+// newFixtureEngine creates an engine over the test parquet fixture
+func newFixtureEngine(t *testing.T) *Engine {
+	t.Helper()
+
+	parquetPath := filepath.Join(t.TempDir(), "products.parquet")
+	writeTestParquet(t, parquetPath)
+
+	engine, err := NewEngine(parquetPath, &config.Config{}, config.NewTestLogger(os.Stdout, "ERROR"))
+	require.NoError(t, err)
+	t.Cleanup(func() { engine.Close() })
+	return engine
+}
+
 func TestEngine_SearchProductsByBrandAndName_Integration(t *testing.T) {
-	t.Skip("Integration test - requires actual parquet file")
+	engine := newFixtureEngine(t)
+
+	tests := []struct {
+		name          string
+		productName   string
+		brand         string
+		expectedCodes []string
+	}{
+		{name: "name and brand", productName: "nutella", brand: "ferrero", expectedCodes: []string{"3017620422003"}},
+		{name: "brand only", brand: "cola", expectedCodes: []string{"5449000000996"}},
+		{name: "name only", productName: "eau", expectedCodes: []string{"0000000000001"}},
+		{name: "no match", productName: "nutella", brand: "source", expectedCodes: []string{}},
+		{name: "wildcards match literally", productName: "%", expectedCodes: []string{}},
+		{name: "no filters lists named products", expectedCodes: []string{"0000000000001", "3017620422003", "5449000000996"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products, err := engine.SearchProductsByBrandAndName(context.Background(), tt.productName, tt.brand, 10)
+			require.NoError(t, err)
 
-	// This test would:
-	// 1. Create a small test parquet file with known data
-	// 2. Initialize the engine with that file
-	// 3. Test various search scenarios
-	// 4. Verify results match expected data
+			codes := []string{}
+			for _, p := range products {
+				codes = append(codes, p.Code)
+			}
+			assert.Equal(t, tt.expectedCodes, codes)
+		})
+	}
 }
 
 func TestEngine_SearchByBarcode_Integration(t *testing.T) {
-	t.Skip("Integration test - requires actual parquet file")
+	engine := newFixtureEngine(t)
+	ctx := context.Background()
+
+	product, err := engine.SearchByBarcode(ctx, "3017620422003")
+	require.NoError(t, err)
+	require.NotNil(t, product)
+	assert.Equal(t, "Nutella", product.ProductName)
+	assert.Equal(t, "Ferrero", product.Brands)
+	assert.Equal(t, "15 g", product.ServingSize)
+	assert.Equal(t, []string{"en:spreads"}, product.CategoriesTags)
+	assert.Equal(t, []string{"en:no-gluten"}, product.LabelsTags)
+	assert.Equal(t, []string{"en:milk", "en:nuts"}, product.AllergensTags)
+	assert.Equal(t, "e", product.NutriscoreGrade)
+	require.NotNil(t, product.NutriscoreScore)
+	assert.Equal(t, 26, *product.NutriscoreScore)
+
+	// Only selected images are returned, with URLs for the sizes listed in the metadata
+	require.Len(t, product.Images, 1)
+	assert.Equal(t, "front", product.Images[0].Type)
+	assert.Equal(t, "en", product.Images[0].Language)
+	assert.Equal(t, map[string]string{
+		"100":  "https://images.openfoodfacts.org/images/products/301/762/042/2003/front_en.12.100.jpg",
+		"400":  "https://images.openfoodfacts.org/images/products/301/762/042/2003/front_en.12.400.jpg",
+		"full": "https://images.openfoodfacts.org/images/products/301/762/042/2003/front_en.12.full.jpg",
+	}, product.Images[0].URLs)
+
+	product, err = engine.SearchByBarcode(ctx, "0000000000001")
+	require.NoError(t, err)
+	require.NotNil(t, product)
+	assert.Empty(t, product.Images)
 
-	// This test would:
-	// 1. Create a small test parquet file with known barcodes
-	// 2. Initialize the engine with that file
-	// 3. Test barcode searches
-	// 4. Verify exact match behavior
+	product, err = engine.SearchByBarcode(ctx, "9999999999999")
+	require.NoError(t, err)
+	assert.Nil(t, product)
+}
+
+func TestEngine_GetProductsByBarcodes_Integration(t *testing.T) {
+	engine := newFixtureEngine(t)
+
+	products, err := engine.GetProductsByBarcodes(context.Background(), []string{"5449000000996", "404", "3017620422003"})
+	require.NoError(t, err)
+	require.Len(t, products, 2)
+
+	// Request order is preserved
+	assert.Equal(t, "5449000000996", products[0].Code)
+	assert.Equal(t, "3017620422003", products[1].Code)
+
+	// Packaging components are parsed from the packagings column
+	require.Len(t, products[1].Packagings, 2)
+	jar := products[1].Packagings[0]
+	assert.Equal(t, "en:glass", jar.Material)
+	assert.Equal(t, "en:jar", jar.Shape)
+	assert.Equal(t, "en:recycle", jar.Recycling)
+	require.NotNil(t, jar.WeightMeasured)
+	assert.Equal(t, 180.5, *jar.WeightMeasured)
+	assert.Equal(t, 6, products[0].Packagings[0].Units())
+
+	tooMany := make([]string, MaxBarcodesPerRequest+1)
+	_, err = engine.GetProductsByBarcodes(context.Background(), tooMany)
+	assert.Error(t, err)
 }
 
 func TestEngine_TestConnection_WithInvalidFile(t *testing.T) {
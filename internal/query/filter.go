@@ -0,0 +1,464 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/types"
+)
+
+// Filter language limits
+const (
+	DefaultProductQueryLimit = 10
+	MaxProductQueryLimit     = 50
+	MaxFilterDepth           = 8
+	MaxFilterNodes           = 64
+)
+
+// ErrInvalidFilter is wrapped by every filter compilation error
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// Filter is a node in the product filter language
+// Exactly one of And, Or, Not or Field (with Op and Value) must be set
+type Filter struct {
+	And   []Filter    `json:"and,omitempty"`
+	Or    []Filter    `json:"or,omitempty"`
+	Not   *Filter     `json:"not,omitempty"`
+	Field string      `json:"field,omitempty"`
+	Op    string      `json:"op,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// SortSpec orders query results by a whitelisted scalar field
+type SortSpec struct {
+	Field string `json:"field"`
+	Desc  bool   `json:"desc,omitempty"`
+}
+
+// ProductQuery is a structured product query compiled to parameterized SQL
+type ProductQuery struct {
+	Where *Filter    `json:"where,omitempty"`
+	Sort  []SortSpec `json:"sort,omitempty"`
+	Limit int        `json:"limit,omitempty"`
+}
+
+// fieldKind describes which operators a field supports
+type fieldKind int
+
+const (
+	fieldText fieldKind = iota
+	fieldNumber
+	fieldTags
+)
+
+// filterField maps a public field name to the SQL expression it compiles to
+type filterField struct {
+	kind fieldKind
+	expr string
+}
+
+// productNameExpr extracts the English product name, falling back to the raw value
+const productNameExpr = `COALESCE(
+				(SELECT list_extract(list_filter(product_name, x -> x.lang = 'en'), 1).text),
+				CAST(product_name AS VARCHAR)
+			)`
+
+// nutrimentExpr reads the per-100g value of the nutriment named by a bound parameter
+const nutrimentExpr = `list_extract(list_filter(nutriments, x -> x.name = ?), 1)."100g"`
+
+// nutrimentFieldPrefix selects a nutriment per 100g, e.g. nutriments.sugars
+const nutrimentFieldPrefix = "nutriments."
+
+// filterFields is the whitelist of fields that can be filtered and sorted on
+// Field expressions are fixed strings; user input only ever reaches the query as bound parameters
+var filterFields = map[string]filterField{
+	"code":             {kind: fieldText, expr: "code"},
+	"product_name":     {kind: fieldText, expr: productNameExpr},
+	"brands":           {kind: fieldText, expr: "CAST(brands AS VARCHAR)"},
+	"nutriscore_grade": {kind: fieldText, expr: "nutriscore_grade"},
+	"nutriscore_score": {kind: fieldNumber, expr: "nutriscore_score"},
+	"nova_group":       {kind: fieldNumber, expr: "nova_group"},
+	"last_modified_t":  {kind: fieldNumber, expr: "last_modified_t"},
+	"categories_tags":  {kind: fieldTags, expr: "categories_tags"},
+	"countries_tags":   {kind: fieldTags, expr: "countries_tags"},
+	"labels_tags":      {kind: fieldTags, expr: "labels_tags"},
+	"allergens_tags":   {kind: fieldTags, expr: "allergens_tags"},
+}
+
+// comparisonOps maps comparison operators to SQL
+var comparisonOps = map[string]string{
+	"eq":  "=",
+	"ne":  "<>",
+	"lt":  "<",
+	"lte": "<=",
+	"gt":  ">",
+	"gte": ">=",
+}
+
+// filterOps lists the operators supported by each field kind
+var filterOps = map[fieldKind][]string{
+	fieldText:   {"eq", "ne", "in", "match", "exists"},
+	fieldNumber: {"eq", "ne", "lt", "lte", "gt", "gte", "in", "exists"},
+	fieldTags:   {"contains", "contains_any", "contains_all", "exists"},
+}
+
+// nutrimentNamePattern restricts nutriment names to the Open Food Facts naming scheme
+var nutrimentNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// queryCompiler accumulates SQL fragments and bound parameters
+type queryCompiler struct {
+	args  []interface{}
+	nodes int
+}
+
+// compileProductQuery compiles a ProductQuery to a WHERE clause, ORDER BY clause and limit
+// Returned args line up with the placeholders in where followed by those in orderBy
+func compileProductQuery(q ProductQuery) (where string, orderBy string, args []interface{}, limit int, err error) {
+	c := &queryCompiler{}
+
+	where = "TRUE"
+	if q.Where != nil {
+		where, err = c.compileFilter(q.Where, 0)
+		if err != nil {
+			return "", "", nil, 0, err
+		}
+	}
+
+	orderBy, err = c.compileSort(q.Sort)
+	if err != nil {
+		return "", "", nil, 0, err
+	}
+
+	limit = q.Limit
+	if limit <= 0 {
+		limit = DefaultProductQueryLimit
+	}
+	if limit > MaxProductQueryLimit {
+		limit = MaxProductQueryLimit
+	}
+
+	return where, orderBy, c.args, limit, nil
+}
+
+// compileFilter compiles a filter node, recursing into boolean combinators
+func (c *queryCompiler) compileFilter(f *Filter, depth int) (string, error) {
+	if depth > MaxFilterDepth {
+		return "", fmt.Errorf("%w: nesting deeper than %d levels", ErrInvalidFilter, MaxFilterDepth)
+	}
+	c.nodes++
+	if c.nodes > MaxFilterNodes {
+		return "", fmt.Errorf("%w: more than %d filter nodes", ErrInvalidFilter, MaxFilterNodes)
+	}
+
+	set := 0
+	for _, present := range []bool{len(f.And) > 0, len(f.Or) > 0, f.Not != nil, f.Field != ""} {
+		if present {
+			set++
+		}
+	}
+	if set != 1 {
+		return "", fmt.Errorf("%w: each node needs exactly one of and, or, not or field", ErrInvalidFilter)
+	}
+
+	switch {
+	case len(f.And) > 0:
+		return c.compileGroup(f.And, " AND ", depth)
+	case len(f.Or) > 0:
+		return c.compileGroup(f.Or, " OR ", depth)
+	case f.Not != nil:
+		inner, err := c.compileFilter(f.Not, depth+1)
+		if err != nil {
+			return "", err
+		}
+		return "NOT (" + inner + ")", nil
+	default:
+		return c.compileComparison(f)
+	}
+}
+
+// compileGroup joins sub-filters with AND or OR
+func (c *queryCompiler) compileGroup(filters []Filter, joiner string, depth int) (string, error) {
+	parts := make([]string, 0, len(filters))
+	for i := range filters {
+		part, err := c.compileFilter(&filters[i], depth+1)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, part)
+	}
+	return "(" + strings.Join(parts, joiner) + ")", nil
+}
+
+// resolveField looks up a field in the whitelist, binding the nutriment name for nutriment fields
+func (c *queryCompiler) resolveField(name string) (filterField, error) {
+	if field, ok := filterFields[name]; ok {
+		return field, nil
+	}
+
+	if nutriment, ok := strings.CutPrefix(name, nutrimentFieldPrefix); ok {
+		if !nutrimentNamePattern.MatchString(nutriment) {
+			return filterField{}, fmt.Errorf("%w: invalid nutriment name %q", ErrInvalidFilter, nutriment)
+		}
+		c.args = append(c.args, nutriment)
+		return filterField{kind: fieldNumber, expr: nutrimentExpr}, nil
+	}
+
+	return filterField{}, fmt.Errorf("%w: unknown field %q", ErrInvalidFilter, name)
+}
+
+// compileComparison compiles a field comparison leaf
+func (c *queryCompiler) compileComparison(f *Filter) (string, error) {
+	field, err := c.resolveField(f.Field)
+	if err != nil {
+		return "", err
+	}
+
+	op := strings.ToLower(f.Op)
+	if !containsString(filterOps[field.kind], op) {
+		return "", fmt.Errorf("%w: operator %q not supported for field %q (supported: %s)",
+			ErrInvalidFilter, f.Op, f.Field, strings.Join(filterOps[field.kind], ", "))
+	}
+
+	switch op {
+	case "exists":
+		if field.kind == fieldTags {
+			return fmt.Sprintf("(%s IS NOT NULL AND len(%s) > 0)", field.expr, field.expr), nil
+		}
+		return fmt.Sprintf("(%s IS NOT NULL)", field.expr), nil
+
+	case "match":
+		text, ok := f.Value.(string)
+		if !ok || text == "" {
+			return "", fmt.Errorf("%w: %q with match needs a non-empty string value", ErrInvalidFilter, f.Field)
+		}
+		c.args = append(c.args, "%"+escapeLikePattern(text)+"%")
+		return fmt.Sprintf("(%s ILIKE ? ESCAPE '\\')", field.expr), nil
+
+	case "in":
+		values, ok := f.Value.([]interface{})
+		if !ok || len(values) == 0 {
+			return "", fmt.Errorf("%w: %q with in needs a non-empty array value", ErrInvalidFilter, f.Field)
+		}
+		placeholders := make([]string, 0, len(values))
+		for _, v := range values {
+			bound, err := bindScalar(field.kind, f.Field, v)
+			if err != nil {
+				return "", err
+			}
+			c.args = append(c.args, bound)
+			placeholders = append(placeholders, "?")
+		}
+		return fmt.Sprintf("(%s IN (%s))", field.expr, strings.Join(placeholders, ", ")), nil
+
+	case "contains":
+		tag, ok := f.Value.(string)
+		if !ok || tag == "" {
+			return "", fmt.Errorf("%w: %q with contains needs a non-empty string tag", ErrInvalidFilter, f.Field)
+		}
+		c.args = append(c.args, tag)
+		return fmt.Sprintf("list_contains(%s, ?)", field.expr), nil
+
+	case "contains_any", "contains_all":
+		tags, ok := f.Value.([]interface{})
+		if !ok || len(tags) == 0 {
+			return "", fmt.Errorf("%w: %q with %s needs a non-empty array of tags", ErrInvalidFilter, f.Field, op)
+		}
+		parts := make([]string, 0, len(tags))
+		for _, v := range tags {
+			tag, ok := v.(string)
+			if !ok || tag == "" {
+				return "", fmt.Errorf("%w: %q tags must be non-empty strings", ErrInvalidFilter, f.Field)
+			}
+			c.args = append(c.args, tag)
+			parts = append(parts, fmt.Sprintf("list_contains(%s, ?)", field.expr))
+		}
+		joiner := " OR "
+		if op == "contains_all" {
+			joiner = " AND "
+		}
+		return "(" + strings.Join(parts, joiner) + ")", nil
+
+	default:
+		bound, err := bindScalar(field.kind, f.Field, f.Value)
+		if err != nil {
+			return "", err
+		}
+		c.args = append(c.args, bound)
+		return fmt.Sprintf("(%s %s ?)", field.expr, comparisonOps[op]), nil
+	}
+}
+
+// compileSort compiles sort specs into an ORDER BY clause
+func (c *queryCompiler) compileSort(specs []SortSpec) (string, error) {
+	if len(specs) == 0 {
+		return "code", nil
+	}
+
+	parts := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		field, err := c.resolveField(spec.Field)
+		if err != nil {
+			return "", err
+		}
+		if field.kind == fieldTags {
+			return "", fmt.Errorf("%w: cannot sort by tag field %q", ErrInvalidFilter, spec.Field)
+		}
+		direction := "ASC"
+		if spec.Desc {
+			direction = "DESC"
+		}
+		parts = append(parts, fmt.Sprintf("%s %s NULLS LAST", field.expr, direction))
+	}
+
+	// Tie-break on code so paging through equal values is stable
+	parts = append(parts, "code")
+	return strings.Join(parts, ", "), nil
+}
+
+// QueryProducts runs a structured product query
+// Invalid filters are rejected with ErrInvalidFilter before any SQL is executed
+func (e *Engine) QueryProducts(ctx context.Context, q ProductQuery) ([]types.Product, error) {
+	start := time.Now()
+	e.log.Debug("QueryProducts starting", "sort", len(q.Sort), "limit", q.Limit)
+
+	where, orderBy, filterArgs, limit, err := compileProductQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	release, err := e.admit(ctx, "QueryProducts")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	results, err := e.queryProducts(ctx, where, orderBy, filterArgs, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	e.log.Info("QueryProducts completed", "count", len(results), "duration", time.Since(start))
+	return results, nil
+}
+
+// queryProducts runs a compiled product query; the caller must hold an admission slot
+func (e *Engine) queryProducts(ctx context.Context, where, orderBy string, filterArgs []interface{}, limit int) ([]types.Product, error) {
+	start := time.Now()
+	query := `
+		SELECT ` + productColumns + `
+		FROM read_parquet(?)
+		WHERE ` + where + `
+		ORDER BY ` + orderBy + `
+		LIMIT ?`
+
+	args := append([]interface{}{e.parquetPath}, filterArgs...)
+	args = append(args, limit)
+
+	rows, err := e.queryWithRetry(ctx, query, args...)
+	if err != nil {
+		e.log.Error("DuckDB query failed", "error", err, "duration", time.Since(start))
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	results := []types.Product{}
+	for rows.Next() {
+		p, err := e.scanProduct(rows)
+		if err != nil {
+			continue // Skip malformed rows
+		}
+		results = append(results, *p)
+	}
+
+	if err := rows.Err(); err != nil {
+		e.log.Error("Rows iteration failed", "error", err)
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return results, nil
+}
+
+// bindScalar validates a comparison value against the field kind
+func bindScalar(kind fieldKind, name string, value interface{}) (interface{}, error) {
+	switch kind {
+	case fieldNumber:
+		if number, ok := value.(float64); ok {
+			return number, nil
+		}
+		if number, ok := value.(int); ok {
+			return float64(number), nil
+		}
+		return nil, fmt.Errorf("%w: %q needs a numeric value", ErrInvalidFilter, name)
+	default:
+		if text, ok := value.(string); ok {
+			return text, nil
+		}
+		return nil, fmt.Errorf("%w: %q needs a string value", ErrInvalidFilter, name)
+	}
+}
+
+// escapeLikePattern escapes LIKE wildcards so text match is literal
+func escapeLikePattern(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(value)
+}
+
+// containsString reports whether values contains target
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterFieldNames returns the whitelisted field names in sorted order
+func FilterFieldNames() []string {
+	names := make([]string, 0, len(filterFields))
+	for name := range filterFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FilterSchemaDefs returns the JSON schema definitions for the filter language
+// The filter definition is recursive, so it is referenced as #/$defs/filter
+func FilterSchemaDefs() map[string]any {
+	ops := []string{"eq", "ne", "lt", "lte", "gt", "gte", "in", "match", "contains", "contains_any", "contains_all", "exists"}
+	filterRef := map[string]any{"$ref": "#/$defs/filter"}
+
+	return map[string]any{
+		"filter": map[string]any{
+			"type": "object",
+			"description": "A filter node. Use exactly one of: 'and' (all must match), 'or' (any must match), " +
+				"'not' (negation), or a comparison with 'field', 'op' and 'value'.",
+			"properties": map[string]any{
+				"and": map[string]any{"type": "array", "items": filterRef, "minItems": 1},
+				"or":  map[string]any{"type": "array", "items": filterRef, "minItems": 1},
+				"not": filterRef,
+				"field": map[string]any{
+					"type": "string",
+					"description": fmt.Sprintf("Field to compare. One of: %s, or %s<nutrient> for a value per 100 g (e.g. nutriments.sugars, nutriments.energy-kcal). "+
+						"Tag fields hold Open Food Facts tags such as en:vegan or en:france.",
+						strings.Join(FilterFieldNames(), ", "), nutrimentFieldPrefix),
+				},
+				"op": map[string]any{
+					"type": "string",
+					"enum": ops,
+					"description": "Text fields: eq, ne, in, match (case-insensitive substring), exists. " +
+						"Numeric fields and nutriments: eq, ne, lt, lte, gt, gte, in, exists. " +
+						"Tag fields: contains, contains_any, contains_all, exists.",
+				},
+				"value": map[string]any{
+					"description": "Comparison value: a number, a string, or an array for in/contains_any/contains_all. Omit for exists.",
+				},
+			},
+		},
+	}
+}
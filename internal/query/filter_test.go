@@ -0,0 +1,229 @@
+package query
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileProductQuery(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         ProductQuery
+		expectedWhere string
+		expectedOrder string
+		expectedArgs  []interface{}
+		expectedLimit int
+	}{
+		{
+			name:          "empty query",
+			query:         ProductQuery{},
+			expectedWhere: "TRUE",
+			expectedOrder: "code",
+			expectedLimit: DefaultProductQueryLimit,
+		},
+		{
+			name:          "numeric comparison",
+			query:         ProductQuery{Where: &Filter{Field: "nova_group", Op: "lte", Value: 2.0}, Limit: 5},
+			expectedWhere: "(nova_group <= ?)",
+			expectedOrder: "code",
+			expectedArgs:  []interface{}{2.0},
+			expectedLimit: 5,
+		},
+		{
+			name:          "nutriment binds name before value",
+			query:         ProductQuery{Where: &Filter{Field: "nutriments.sugars", Op: "lt", Value: 5.0}},
+			expectedWhere: "(" + nutrimentExpr + " < ?)",
+			expectedOrder: "code",
+			expectedArgs:  []interface{}{"sugars", 5.0},
+			expectedLimit: DefaultProductQueryLimit,
+		},
+		{
+			name: "boolean combinators",
+			query: ProductQuery{Where: &Filter{
+				And: []Filter{
+					{Field: "countries_tags", Op: "contains", Value: "en:france"},
+					{Or: []Filter{
+						{Field: "nutriscore_grade", Op: "eq", Value: "a"},
+						{Not: &Filter{Field: "labels_tags", Op: "exists"}},
+					}},
+				},
+			}},
+			expectedWhere: "(list_contains(countries_tags, ?) AND ((nutriscore_grade = ?) OR NOT ((labels_tags IS NOT NULL AND len(labels_tags) > 0))))",
+			expectedOrder: "code",
+			expectedArgs:  []interface{}{"en:france", "a"},
+			expectedLimit: DefaultProductQueryLimit,
+		},
+		{
+			name:          "in list",
+			query:         ProductQuery{Where: &Filter{Field: "nutriscore_grade", Op: "in", Value: []interface{}{"a", "b"}}},
+			expectedWhere: "(nutriscore_grade IN (?, ?))",
+			expectedOrder: "code",
+			expectedArgs:  []interface{}{"a", "b"},
+			expectedLimit: DefaultProductQueryLimit,
+		},
+		{
+			name:          "contains_any",
+			query:         ProductQuery{Where: &Filter{Field: "labels_tags", Op: "contains_any", Value: []interface{}{"en:vegan", "en:vegetarian"}}},
+			expectedWhere: "(list_contains(labels_tags, ?) OR list_contains(labels_tags, ?))",
+			expectedOrder: "code",
+			expectedArgs:  []interface{}{"en:vegan", "en:vegetarian"},
+			expectedLimit: DefaultProductQueryLimit,
+		},
+		{
+			name:          "match escapes wildcards",
+			query:         ProductQuery{Where: &Filter{Field: "brands", Op: "match", Value: "100%_pure"}},
+			expectedWhere: "(CAST(brands AS VARCHAR) ILIKE ? ESCAPE '\\')",
+			expectedOrder: "code",
+			expectedArgs:  []interface{}{`%100\%\_pure%`},
+			expectedLimit: DefaultProductQueryLimit,
+		},
+		{
+			name:          "sort args follow filter args and limit is capped",
+			query:         ProductQuery{Where: &Filter{Field: "code", Op: "ne", Value: "1"}, Sort: []SortSpec{{Field: "nutriments.fat", Desc: true}}, Limit: 500},
+			expectedWhere: "(code <> ?)",
+			expectedOrder: nutrimentExpr + " DESC NULLS LAST, code",
+			expectedArgs:  []interface{}{"1", "fat"},
+			expectedLimit: MaxProductQueryLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, orderBy, args, limit, err := compileProductQuery(tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedWhere, where)
+			assert.Equal(t, tt.expectedOrder, orderBy)
+			assert.Equal(t, tt.expectedArgs, args)
+			assert.Equal(t, tt.expectedLimit, limit)
+		})
+	}
+}
+
+func TestCompileProductQuery_Invalid(t *testing.T) {
+	deep := &Filter{Field: "code", Op: "eq", Value: "1"}
+	for i := 0; i <= MaxFilterDepth; i++ {
+		deep = &Filter{Not: deep}
+	}
+
+	tests := []struct {
+		name  string
+		query ProductQuery
+	}{
+		{name: "unknown field", query: ProductQuery{Where: &Filter{Field: "password", Op: "eq", Value: "x"}}},
+		{name: "injection in field name", query: ProductQuery{Where: &Filter{Field: "code; DROP TABLE x", Op: "eq", Value: "x"}}},
+		{name: "injection in nutriment name", query: ProductQuery{Where: &Filter{Field: "nutriments.sugars' OR 1=1 --", Op: "lt", Value: 1.0}}},
+		{name: "unsupported operator for kind", query: ProductQuery{Where: &Filter{Field: "nova_group", Op: "match", Value: "1"}}},
+		{name: "unknown operator", query: ProductQuery{Where: &Filter{Field: "code", Op: "like", Value: "1"}}},
+		{name: "wrong value type", query: ProductQuery{Where: &Filter{Field: "nova_group", Op: "eq", Value: "one"}}},
+		{name: "empty in list", query: ProductQuery{Where: &Filter{Field: "code", Op: "in", Value: []interface{}{}}}},
+		{name: "empty node", query: ProductQuery{Where: &Filter{}}},
+		{name: "ambiguous node", query: ProductQuery{Where: &Filter{Field: "code", Op: "eq", Value: "1", Not: &Filter{Field: "code", Op: "exists"}}}},
+		{name: "too deep", query: ProductQuery{Where: deep}},
+		{name: "sort by tags", query: ProductQuery{Sort: []SortSpec{{Field: "labels_tags"}}}},
+		{name: "sort by unknown field", query: ProductQuery{Sort: []SortSpec{{Field: "random()"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, _, err := compileProductQuery(tt.query)
+			assert.ErrorIs(t, err, ErrInvalidFilter)
+		})
+	}
+}
+
+func TestCompileProductQuery_TooManyNodes(t *testing.T) {
+	leaves := make([]Filter, MaxFilterNodes)
+	for i := range leaves {
+		leaves[i] = Filter{Field: "code", Op: "exists"}
+	}
+
+	_, _, _, _, err := compileProductQuery(ProductQuery{Where: &Filter{Or: leaves}})
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+}
+
+func TestFilterSchemaDefs(t *testing.T) {
+	defs := FilterSchemaDefs()
+	filter, ok := defs["filter"].(map[string]any)
+	require.True(t, ok)
+
+	properties := filter["properties"].(map[string]any)
+	field := properties["field"].(map[string]any)
+	for _, name := range FilterFieldNames() {
+		assert.True(t, strings.Contains(field["description"].(string), name), "schema should document %s", name)
+	}
+}
+
+func TestEngine_QueryProducts(t *testing.T) {
+	parquetPath := filepath.Join(t.TempDir(), "products.parquet")
+	writeTestParquet(t, parquetPath)
+
+	logger := config.NewTestLogger(os.Stdout, "ERROR")
+	engine, err := NewEngine(parquetPath, &config.Config{}, logger)
+	require.NoError(t, err)
+	defer engine.Close()
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		query         ProductQuery
+		expectedCodes []string
+	}{
+		{
+			name:          "no filter orders by code",
+			query:         ProductQuery{},
+			expectedCodes: []string{"0000000000001", "3017620422003", "5449000000996"},
+		},
+		{
+			name: "tags and nutriments",
+			query: ProductQuery{Where: &Filter{And: []Filter{
+				{Field: "countries_tags", Op: "contains", Value: "en:france"},
+				{Field: "nutriments.sugars", Op: "gt", Value: 10.0},
+			}}},
+			expectedCodes: []string{"3017620422003"},
+		},
+		{
+			name:          "english product name match",
+			query:         ProductQuery{Where: &Filter{Field: "product_name", Op: "match", Value: "cola"}},
+			expectedCodes: []string{"5449000000996"},
+		},
+		{
+			name: "negated allergen with sort",
+			query: ProductQuery{
+				Where: &Filter{Not: &Filter{Field: "allergens_tags", Op: "contains", Value: "en:milk"}},
+				Sort:  []SortSpec{{Field: "nutriscore_score", Desc: true}},
+			},
+			expectedCodes: []string{"5449000000996", "0000000000001"},
+		},
+		{
+			name:          "limit",
+			query:         ProductQuery{Where: &Filter{Field: "nova_group", Op: "in", Value: []interface{}{1.0, 4.0}}, Limit: 1},
+			expectedCodes: []string{"0000000000001"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products, err := engine.QueryProducts(ctx, tt.query)
+			require.NoError(t, err)
+
+			codes := make([]string, 0, len(products))
+			for _, p := range products {
+				codes = append(codes, p.Code)
+			}
+			assert.Equal(t, tt.expectedCodes, codes)
+		})
+	}
+
+	t.Run("invalid filter", func(t *testing.T) {
+		_, err := engine.QueryProducts(ctx, ProductQuery{Where: &Filter{Field: "nope", Op: "eq", Value: "x"}})
+		assert.ErrorIs(t, err, ErrInvalidFilter)
+	})
+}
@@ -0,0 +1,43 @@
+package query
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestParquet writes a small parquet file with the columns the engine reads
+func writeTestParquet(t testing.TB, path string) {
+	t.Helper()
+
+	db, err := sql.Open("duckdb", "")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(fmt.Sprintf(`COPY (
+		SELECT * FROM (VALUES
+			('3017620422003', [{'lang': 'en', 'text': 'Nutella'}], 'Ferrero', ['en:spreads'], ['en:france'], ['en:no-gluten'], ['en:milk', 'en:nuts'], 'e', 26, 4,
+				[{'name': 'sugars', 'value': 56.3, '100g': 56.3, 'serving': 8.4, 'unit': 'g'}], '15 g', '15', '400 g', 'https://world.openfoodfacts.org/product/3017620422003', 1700000000,
+				[{'id': 'en:sugar', 'text': 'sugar', 'percent_estimate': 56.3}, {'id': 'en:hazelnut', 'text': 'hazelnuts', 'percent_estimate': 13.0}], 'g',
+				[{'key': 'front_en', 'imgid': 3, 'rev': 12, 'sizes': {'100': {'h': 100, 'w': 75}, '400': {'h': 400, 'w': 300}, 'full': {'h': 1200, 'w': 900}}},
+				 {'key': '3', 'imgid': 3, 'rev': NULL, 'sizes': {'100': {'h': 100, 'w': 75}, '400': {'h': 400, 'w': 300}, 'full': {'h': 1200, 'w': 900}}}],
+				[{'material': 'en:glass', 'shape': 'en:jar', 'recycling': 'en:recycle', 'number_of_units': 1, 'quantity_per_unit': '400 g', 'weight_measured': 180.5},
+				 {'material': 'en:pp-5-polypropylene', 'shape': 'en:lid', 'recycling': 'en:discard', 'number_of_units': 1, 'quantity_per_unit': NULL, 'weight_measured': 4.0}]),
+			('5449000000996', [{'lang': 'en', 'text': 'Coca-Cola'}], 'Coca-Cola', ['en:sodas'], ['en:united-states'], [], [], 'e', 13, 4,
+				[{'name': 'sugars', 'value': 10.6, '100g': 10.6, 'serving': 35.0, 'unit': 'g'}], '330 ml', '330', '330 ml', 'https://world.openfoodfacts.org/product/5449000000996', 1710000000,
+				[{'id': 'en:carbonated-water', 'text': 'carbonated water', 'percent_estimate': 89.0}], 'ml',
+				[],
+				[{'material': 'en:aluminium', 'shape': 'en:can', 'recycling': 'en:recycle', 'number_of_units': 6, 'quantity_per_unit': '330 ml', 'weight_measured': 13.0}]),
+			('0000000000001', [{'lang': 'fr', 'text': 'Eau'}], 'Source', ['en:waters'], ['en:france'], [], [], 'a', -2, 1,
+				[], NULL, NULL, '1 l', NULL, 1720000000,
+				[], NULL,
+				NULL,
+				NULL)
+		) AS t(code, product_name, brands, categories_tags, countries_tags, labels_tags, allergens_tags, nutriscore_grade, nutriscore_score, nova_group,
+			nutriments, serving_size, serving_quantity, quantity, link, last_modified_t, ingredients, product_quantity_unit,
+			images, packagings)
+	) TO '%s' (FORMAT parquet)`, escapeSQLString(path)))
+	require.NoError(t, err)
+}
@@ -0,0 +1,316 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrHistoryDisabled is returned by the product history methods when no snapshots are retained
+var ErrHistoryDisabled = errors.New("product history is disabled")
+
+// Product history events
+const (
+	EventFirstSeen = "first_seen" // Present in the oldest retained snapshot
+	EventAdded     = "added"      // Absent from the previous snapshot
+	EventChanged   = "changed"    // One or more fingerprinted fields differ from the previous snapshot
+	EventRemoved   = "removed"    // Present in the previous snapshot but not in this one
+)
+
+// snapshotTimeLayout is the timestamp prefix of fingerprint file names, which keeps them sorted by time
+const snapshotTimeLayout = "20060102T150405Z"
+
+// snapshotFilePattern matches fingerprint files: <download time>-<dataset SHA256 prefix>.parquet
+var snapshotFilePattern = regexp.MustCompile(`^(\d{8}T\d{6}Z)-([0-9a-f]+)\.parquet$`)
+
+// historyField is one product field tracked across snapshots
+// Large fields are stored as a 64-bit MD5 prefix; small ones verbatim so their old and new values can be shown
+type historyField struct {
+	name   string
+	expr   string
+	hashed bool
+}
+
+// historyFields are the fields fingerprinted for every product, in fingerprint column order
+var historyFields = []historyField{
+	{name: "product_name", expr: productNameExpr, hashed: true},
+	{name: "brands", expr: "CAST(brands AS VARCHAR)", hashed: true},
+	{name: "nutriments", expr: "CAST(nutriments AS VARCHAR)", hashed: true},
+	{name: "ingredients", expr: "CAST(ingredients AS VARCHAR)", hashed: true},
+	{name: "nutriscore_grade", expr: "CAST(nutriscore_grade AS VARCHAR)"},
+	{name: "nutriscore_score", expr: "CAST(nutriscore_score AS VARCHAR)"},
+	{name: "nova_group", expr: "CAST(nova_group AS VARCHAR)"},
+}
+
+// Snapshot is one dataset version whose product fingerprints are retained
+type Snapshot struct {
+	ID      string    `json:"id"`
+	TakenAt time.Time `json:"taken_at"` // When the dataset version was downloaded
+	Dataset string    `json:"dataset"`  // SHA256 prefix of the dataset file
+	path    string
+}
+
+// FieldChange is one field that differs between two snapshots
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before,omitempty"` // Only for fields stored verbatim, such as scores
+	After  string `json:"after,omitempty"`
+}
+
+// ProductChange is one event in a product's history
+type ProductChange struct {
+	Snapshot     string        `json:"snapshot"`
+	SnapshotAt   time.Time     `json:"snapshot_at"`
+	Event        string        `json:"event"` // first_seen, added, changed or removed
+	Fields       []FieldChange `json:"fields,omitempty"`
+	LastModified *time.Time    `json:"last_modified,omitempty"` // Last edit on Open Food Facts according to this snapshot
+}
+
+// ProductHistory lists the changes to one product across the retained snapshots
+type ProductHistory struct {
+	Barcode   string          `json:"barcode"`
+	Snapshots []Snapshot      `json:"snapshots"` // Retained snapshots, oldest first
+	Changes   []ProductChange `json:"changes"`   // Oldest first; snapshots without changes are omitted
+}
+
+// fingerprint is one product's row in a snapshot
+type fingerprint struct {
+	lastModified sql.NullInt64
+	values       []sql.NullString
+}
+
+// historyEnabled reports whether snapshots are recorded
+func (e *Engine) historyEnabled() bool {
+	return e.historyDir != "" && e.historyRetain > 0
+}
+
+// RecordSnapshot writes the fingerprints of every product in the dataset to the history directory
+// A dataset version that is already recorded is not written again; the oldest snapshots beyond
+// the retention limit are removed
+func (e *Engine) RecordSnapshot(ctx context.Context, sha256 string, takenAt time.Time) (*Snapshot, error) {
+	start := time.Now()
+
+	if !e.historyEnabled() {
+		return nil, ErrHistoryDisabled
+	}
+	if sha256 == "" {
+		return nil, fmt.Errorf("dataset SHA256 is required to record a snapshot")
+	}
+
+	snapshots, err := listSnapshots(e.historyDir)
+	if err != nil {
+		return nil, err
+	}
+	dataset := sha256[:min(12, len(sha256))]
+	for _, snapshot := range snapshots {
+		if snapshot.Dataset == dataset {
+			e.log.Debug("Dataset snapshot already recorded", "snapshot", snapshot.ID)
+			return &snapshot, nil
+		}
+	}
+
+	if err := os.MkdirAll(e.historyDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	id := takenAt.UTC().Format(snapshotTimeLayout) + "-" + dataset
+	path := filepath.Join(e.historyDir, id+".parquet")
+	tmpPath := path + ".tmp"
+
+	columns := []string{"code", "CAST(last_modified_t AS BIGINT) AS last_modified_t"}
+	for _, field := range historyFields {
+		expr := field.expr
+		if field.hashed {
+			expr = "left(md5(" + expr + "), 16)"
+		}
+		columns = append(columns, expr+" AS "+field.name)
+	}
+
+	// Runs outside admission control: it is a one-off background job, not a user query
+	statement := fmt.Sprintf(`COPY (
+		SELECT %s
+		FROM read_parquet('%s')
+		WHERE code IS NOT NULL
+	) TO '%s' (FORMAT parquet, COMPRESSION zstd)`,
+		strings.Join(columns, ", "), escapeSQLString(e.parquetPath), escapeSQLString(tmpPath))
+	if _, err := e.db.ExecContext(ctx, statement); err != nil {
+		os.Remove(tmpPath)
+		return nil, fmt.Errorf("failed to write snapshot fingerprints: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return nil, fmt.Errorf("failed to store snapshot fingerprints: %w", err)
+	}
+
+	snapshot := Snapshot{ID: id, TakenAt: takenAt.UTC().Truncate(time.Second), Dataset: dataset, path: path}
+	snapshots = append(snapshots, snapshot)
+	sortSnapshots(snapshots)
+	for len(snapshots) > e.historyRetain {
+		if err := os.Remove(snapshots[0].path); err != nil {
+			e.log.Warn("Failed to remove expired snapshot", "snapshot", snapshots[0].ID, "error", err)
+		}
+		snapshots = snapshots[1:]
+	}
+
+	e.log.Info("Dataset snapshot recorded", "snapshot", id, "retained", len(snapshots), "duration", time.Since(start))
+	return &snapshot, nil
+}
+
+// ProductHistory compares a product's fingerprints across the retained snapshots
+func (e *Engine) ProductHistory(ctx context.Context, barcode string) (*ProductHistory, error) {
+	start := time.Now()
+	e.log.Debug("ProductHistory starting", "barcode", barcode)
+
+	if !e.historyEnabled() {
+		return nil, ErrHistoryDisabled
+	}
+
+	snapshots, err := listSnapshots(e.historyDir)
+	if err != nil {
+		return nil, err
+	}
+	history := &ProductHistory{Barcode: barcode, Snapshots: snapshots, Changes: []ProductChange{}}
+	if len(snapshots) == 0 {
+		return history, nil
+	}
+
+	release, err := e.admit(ctx, "ProductHistory")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	files := make([]string, len(snapshots))
+	for i, snapshot := range snapshots {
+		files[i] = "'" + escapeSQLString(snapshot.path) + "'"
+	}
+	columns := []string{"filename", "last_modified_t"}
+	for _, field := range historyFields {
+		columns = append(columns, field.name)
+	}
+	query := fmt.Sprintf(`SELECT %s FROM read_parquet([%s], filename = true, union_by_name = true) WHERE code = ?`,
+		strings.Join(columns, ", "), strings.Join(files, ", "))
+
+	rows, err := e.queryWithRetry(ctx, query, barcode)
+	if err != nil {
+		return nil, fmt.Errorf("history query failed: %w", err)
+	}
+	defer rows.Close()
+
+	found := make(map[string]fingerprint, len(snapshots))
+	for rows.Next() {
+		var filename string
+		current := fingerprint{values: make([]sql.NullString, len(historyFields))}
+		pointers := []interface{}{&filename, &current.lastModified}
+		for i := range current.values {
+			pointers = append(pointers, &current.values[i])
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("history scan failed: %w", err)
+		}
+		if _, seen := found[filename]; !seen {
+			found[filename] = current
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	var previous *fingerprint
+	for i, snapshot := range snapshots {
+		current, ok := found[snapshot.path]
+		change := ProductChange{Snapshot: snapshot.ID, SnapshotAt: snapshot.TakenAt}
+		switch {
+		case ok && previous == nil && i == 0:
+			change.Event = EventFirstSeen
+		case ok && previous == nil:
+			change.Event = EventAdded
+		case ok:
+			change.Event = EventChanged
+			change.Fields = previous.diff(current)
+		case previous != nil:
+			change.Event = EventRemoved
+		}
+
+		if ok {
+			if current.lastModified.Valid {
+				lastModified := time.Unix(current.lastModified.Int64, 0).UTC()
+				change.LastModified = &lastModified
+			}
+			previous = &current
+		} else {
+			previous = nil
+		}
+		if change.Event != "" && (change.Event != EventChanged || len(change.Fields) > 0) {
+			history.Changes = append(history.Changes, change)
+		}
+	}
+
+	e.log.Info("ProductHistory completed", "barcode", barcode, "snapshots", len(snapshots), "changes", len(history.Changes), "duration", time.Since(start))
+	return history, nil
+}
+
+// diff lists the fields that differ from another fingerprint of the same product
+func (f *fingerprint) diff(other fingerprint) []FieldChange {
+	var changes []FieldChange
+	for i, field := range historyFields {
+		before, after := f.values[i], other.values[i]
+		if before == after {
+			continue
+		}
+		change := FieldChange{Field: field.name}
+		if !field.hashed {
+			change.Before, change.After = before.String, after.String
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// listSnapshots returns the fingerprint files in dir, oldest first; a missing directory has none
+func listSnapshots(dir string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Snapshot{}, nil
+		}
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	snapshots := []Snapshot{}
+	for _, entry := range entries {
+		match := snapshotFilePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		takenAt, err := time.Parse(snapshotTimeLayout, match[1])
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, Snapshot{
+			ID:      strings.TrimSuffix(entry.Name(), ".parquet"),
+			TakenAt: takenAt,
+			Dataset: match[2],
+			path:    filepath.Join(dir, entry.Name()),
+		})
+	}
+	sortSnapshots(snapshots)
+	return snapshots, nil
+}
+
+// sortSnapshots orders snapshots oldest first
+func sortSnapshots(snapshots []Snapshot) {
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		if !snapshots[i].TakenAt.Equal(snapshots[j].TakenAt) {
+			return snapshots[i].TakenAt.Before(snapshots[j].TakenAt)
+		}
+		return snapshots[i].ID < snapshots[j].ID
+	})
+}
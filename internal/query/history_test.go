@@ -0,0 +1,151 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newHistoryEngine creates an engine over parquetPath that records snapshots in historyDir
+func newHistoryEngine(t *testing.T, parquetPath, historyDir string, retain int) *Engine {
+	t.Helper()
+
+	engine, err := NewEngine(parquetPath, &config.Config{HistoryDir: historyDir, HistorySnapshots: retain}, config.NewTestLogger(os.Stdout, "ERROR"))
+	require.NoError(t, err)
+	t.Cleanup(func() { engine.Close() })
+	return engine
+}
+
+// writeUpdatedParquet rewrites the fixture with a new Nutella recipe and without Coca-Cola
+func writeUpdatedParquet(t *testing.T, src, dst string) {
+	t.Helper()
+
+	db, err := sql.Open("duckdb", "")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(fmt.Sprintf(`COPY (
+		SELECT * REPLACE (
+			CASE WHEN code = '3017620422003' THEN 'd' ELSE nutriscore_grade END AS nutriscore_grade,
+			CASE WHEN code = '3017620422003' THEN 18 ELSE nutriscore_score END AS nutriscore_score,
+			CASE WHEN code = '3017620422003' THEN [{'name': 'sugars', 'value': 48.0, '100g': 48.0, 'serving': 7.2, 'unit': 'g'}] ELSE nutriments END AS nutriments,
+			CASE WHEN code = '3017620422003' THEN 1730000000 ELSE last_modified_t END AS last_modified_t
+		)
+		FROM read_parquet('%s')
+		WHERE code <> '5449000000996'
+	) TO '%s' (FORMAT parquet)`, escapeSQLString(src), escapeSQLString(dst)))
+	require.NoError(t, err)
+}
+
+func TestEngine_ProductHistory_Integration(t *testing.T) {
+	dir := t.TempDir()
+	historyDir := filepath.Join(dir, "history")
+	original := filepath.Join(dir, "original.parquet")
+	updated := filepath.Join(dir, "updated.parquet")
+	writeTestParquet(t, original)
+	writeUpdatedParquet(t, original, updated)
+
+	ctx := context.Background()
+	firstTaken := time.Date(2025, 1, 1, 6, 0, 0, 0, time.UTC)
+	secondTaken := firstTaken.Add(24 * time.Hour)
+
+	first, err := newHistoryEngine(t, original, historyDir, 5).RecordSnapshot(ctx, "aaaaaaaaaaaaaaaa", firstTaken)
+	require.NoError(t, err)
+	assert.Equal(t, "20250101T060000Z-aaaaaaaaaaaa", first.ID)
+
+	engine := newHistoryEngine(t, updated, historyDir, 5)
+	second, err := engine.RecordSnapshot(ctx, "bbbbbbbbbbbbbbbb", secondTaken)
+	require.NoError(t, err)
+
+	t.Run("changed fields", func(t *testing.T) {
+		history, err := engine.ProductHistory(ctx, "3017620422003")
+		require.NoError(t, err)
+		require.Len(t, history.Snapshots, 2)
+		require.Len(t, history.Changes, 2)
+
+		assert.Equal(t, EventFirstSeen, history.Changes[0].Event)
+		assert.Equal(t, first.ID, history.Changes[0].Snapshot)
+
+		changed := history.Changes[1]
+		assert.Equal(t, EventChanged, changed.Event)
+		assert.Equal(t, second.ID, changed.Snapshot)
+		assert.Equal(t, secondTaken, changed.SnapshotAt)
+		assert.Equal(t, []FieldChange{
+			{Field: "nutriments"},
+			{Field: "nutriscore_grade", Before: "e", After: "d"},
+			{Field: "nutriscore_score", Before: "26", After: "18"},
+		}, changed.Fields)
+		require.NotNil(t, changed.LastModified)
+		assert.Equal(t, time.Unix(1730000000, 0).UTC(), *changed.LastModified)
+	})
+
+	t.Run("removed product", func(t *testing.T) {
+		history, err := engine.ProductHistory(ctx, "5449000000996")
+		require.NoError(t, err)
+		require.Len(t, history.Changes, 2)
+		assert.Equal(t, EventFirstSeen, history.Changes[0].Event)
+		assert.Equal(t, EventRemoved, history.Changes[1].Event)
+		assert.Nil(t, history.Changes[1].LastModified)
+	})
+
+	t.Run("unchanged product", func(t *testing.T) {
+		history, err := engine.ProductHistory(ctx, "0000000000001")
+		require.NoError(t, err)
+		require.Len(t, history.Changes, 1)
+		assert.Equal(t, EventFirstSeen, history.Changes[0].Event)
+	})
+
+	t.Run("unknown product", func(t *testing.T) {
+		history, err := engine.ProductHistory(ctx, "404")
+		require.NoError(t, err)
+		assert.Len(t, history.Snapshots, 2)
+		assert.Empty(t, history.Changes)
+	})
+}
+
+func TestEngine_RecordSnapshot_Retention(t *testing.T) {
+	dir := t.TempDir()
+	historyDir := filepath.Join(dir, "history")
+	parquetPath := filepath.Join(dir, "products.parquet")
+	writeTestParquet(t, parquetPath)
+
+	ctx := context.Background()
+	engine := newHistoryEngine(t, parquetPath, historyDir, 2)
+	taken := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, sha := range []string{"111111111111", "222222222222", "333333333333"} {
+		_, err := engine.RecordSnapshot(ctx, sha, taken.Add(time.Duration(i)*time.Hour))
+		require.NoError(t, err)
+	}
+
+	// Recording the same dataset again returns the existing snapshot
+	again, err := engine.RecordSnapshot(ctx, "333333333333", taken.Add(10*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "20250101T020000Z-333333333333", again.ID)
+
+	snapshots, err := listSnapshots(historyDir)
+	require.NoError(t, err)
+	ids := []string{}
+	for _, snapshot := range snapshots {
+		ids = append(ids, snapshot.ID)
+	}
+	assert.Equal(t, []string{"20250101T010000Z-222222222222", "20250101T020000Z-333333333333"}, ids)
+}
+
+func TestEngine_ProductHistory_Disabled(t *testing.T) {
+	engine := newFixtureEngine(t)
+
+	_, err := engine.ProductHistory(context.Background(), "3017620422003")
+	assert.ErrorIs(t, err, ErrHistoryDisabled)
+
+	_, err = engine.RecordSnapshot(context.Background(), "aaaaaaaaaaaa", time.Now())
+	assert.ErrorIs(t, err, ErrHistoryDisabled)
+}
@@ -0,0 +1,101 @@
+package query
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/config"
+)
+
+// DatasetStats describes the contents of a dataset file
+type DatasetStats struct {
+	Rows        int64 `json:"rows"`
+	UniqueCodes int64 `json:"unique_codes"`
+	Columns     int   `json:"columns"`
+	// SchemaFingerprint is the SHA256 of the column names and types, independent of column order
+	SchemaFingerprint string `json:"schema_fingerprint"`
+}
+
+// InspectDataset counts the products of a dataset and fingerprints its schema
+func InspectDataset(ctx context.Context, path string) (*DatasetStats, error) {
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open duckdb: %w", err)
+	}
+	defer db.Close()
+
+	types, err := parquetColumnTypes(ctx, db, path)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	hash := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(hash, "%s %s\n", name, types[name])
+	}
+	stats := &DatasetStats{Columns: len(names), SchemaFingerprint: hex.EncodeToString(hash.Sum(nil))}
+
+	// The row count comes from the footer; unique codes need a scan of the code column
+	var rows sql.NullInt64
+	if err := db.QueryRowContext(ctx, `SELECT sum(num_rows) FROM parquet_file_metadata(?)`, path).Scan(&rows); err != nil {
+		return nil, fmt.Errorf("failed to read parquet metadata: %w", err)
+	}
+	stats.Rows = rows.Int64
+	if _, ok := types["code"]; ok {
+		if err := db.QueryRowContext(ctx, `SELECT count(DISTINCT code) FROM read_parquet(?)`, path).Scan(&stats.UniqueCodes); err != nil {
+			return nil, fmt.Errorf("failed to count unique codes: %w", err)
+		}
+	}
+	return stats, nil
+}
+
+// RunSampleQueries opens the dataset with the query engine and checks that a barcode lookup, a brand search
+// and a structured query find a sample product
+func RunSampleQueries(ctx context.Context, path string, cfg *config.Config, logger *slog.Logger) error {
+	engine, err := NewEngine(path, cfg, logger)
+	if err != nil {
+		return fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer engine.Close()
+
+	var code string
+	if err := engine.db.QueryRowContext(ctx,
+		`SELECT code FROM read_parquet(?) WHERE code IS NOT NULL AND code <> '' AND CAST(brands AS VARCHAR) <> '' LIMIT 1`,
+		path).Scan(&code); err != nil {
+		return fmt.Errorf("failed to pick a sample product: %w", err)
+	}
+
+	product, err := engine.SearchByBarcode(ctx, code)
+	if err != nil {
+		return fmt.Errorf("barcode lookup failed: %w", err)
+	}
+	if product == nil {
+		return fmt.Errorf("barcode lookup did not find sample product %s", code)
+	}
+
+	products, err := engine.SearchProductsByBrandAndName(ctx, "", product.Brands, 1)
+	if err != nil {
+		return fmt.Errorf("brand search failed: %w", err)
+	}
+	if len(products) == 0 {
+		return fmt.Errorf("brand search did not find products of %q", product.Brands)
+	}
+
+	products, err = engine.QueryProducts(ctx, ProductQuery{Limit: 1})
+	if err != nil {
+		return fmt.Errorf("structured query failed: %w", err)
+	}
+	if len(products) == 0 {
+		return fmt.Errorf("structured query returned no products")
+	}
+	return nil
+}
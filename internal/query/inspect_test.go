@@ -0,0 +1,85 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspectDataset(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.parquet")
+	writeTestParquet(t, base)
+
+	stats, err := InspectDataset(ctx, base)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), stats.Rows)
+	assert.Equal(t, int64(3), stats.UniqueCodes)
+	assert.Equal(t, 20, stats.Columns)
+	assert.Len(t, stats.SchemaFingerprint, 64)
+
+	db, err := sql.Open("duckdb", "")
+	require.NoError(t, err)
+	defer db.Close()
+
+	t.Run("fingerprint ignores column order and data", func(t *testing.T) {
+		reordered := filepath.Join(dir, "reordered.parquet")
+		_, err := db.Exec(fmt.Sprintf(`COPY (SELECT * EXCLUDE (code), code FROM read_parquet('%s') UNION ALL SELECT * EXCLUDE (code), code FROM read_parquet('%s') LIMIT 4) TO '%s' (FORMAT parquet)`,
+			escapeSQLString(base), escapeSQLString(base), escapeSQLString(reordered)))
+		require.NoError(t, err)
+
+		other, err := InspectDataset(ctx, reordered)
+		require.NoError(t, err)
+		assert.Equal(t, stats.SchemaFingerprint, other.SchemaFingerprint)
+		assert.Equal(t, int64(4), other.Rows)
+		assert.Equal(t, int64(3), other.UniqueCodes)
+	})
+
+	t.Run("fingerprint changes with column types", func(t *testing.T) {
+		retyped := filepath.Join(dir, "retyped.parquet")
+		_, err := db.Exec(fmt.Sprintf(`COPY (SELECT * REPLACE (CAST(nova_group AS VARCHAR) AS nova_group) FROM read_parquet('%s')) TO '%s' (FORMAT parquet)`,
+			escapeSQLString(base), escapeSQLString(retyped)))
+		require.NoError(t, err)
+
+		other, err := InspectDataset(ctx, retyped)
+		require.NoError(t, err)
+		assert.NotEqual(t, stats.SchemaFingerprint, other.SchemaFingerprint)
+	})
+
+	t.Run("unreadable file", func(t *testing.T) {
+		broken := filepath.Join(dir, "broken.parquet")
+		require.NoError(t, os.WriteFile(broken, []byte("not parquet"), 0644))
+		_, err := InspectDataset(ctx, broken)
+		assert.Error(t, err)
+	})
+}
+
+func TestRunSampleQueries(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	logger := config.NewTestLogger(os.Stdout, "ERROR")
+
+	base := filepath.Join(dir, "base.parquet")
+	writeTestParquet(t, base)
+	assert.NoError(t, RunSampleQueries(ctx, base, &config.Config{}, logger))
+
+	t.Run("dataset without usable products", func(t *testing.T) {
+		db, err := sql.Open("duckdb", "")
+		require.NoError(t, err)
+		defer db.Close()
+
+		empty := filepath.Join(dir, "no-brands.parquet")
+		_, err = db.Exec(fmt.Sprintf(`COPY (SELECT * REPLACE (NULL::VARCHAR AS brands) FROM read_parquet('%s')) TO '%s' (FORMAT parquet)`,
+			escapeSQLString(base), escapeSQLString(empty)))
+		require.NoError(t, err)
+		assert.Error(t, RunSampleQueries(ctx, empty, &config.Config{}, logger))
+	})
+}
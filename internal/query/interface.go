@@ -13,8 +13,14 @@ import (
 type QueryEngine interface {
 	SearchProductsByBrandAndName(ctx context.Context, name, brand string, limit int) ([]types.Product, error)
 	SearchByBarcode(ctx context.Context, barcode string) (*types.Product, error)
+	GetProductsByBarcodes(ctx context.Context, barcodes []string) ([]types.Product, error) // Found products in request order
+	QueryProducts(ctx context.Context, q ProductQuery) ([]types.Product, error)            // Structured filter query, ErrInvalidFilter on bad filters
+	RunSQL(ctx context.Context, statement string) (*SQLResult, error)                      // Read-only ad-hoc SQL, ErrSQLToolDisabled unless enabled
+	ProductHistory(ctx context.Context, barcode string) (*ProductHistory, error)           // Changes across retained snapshots, ErrHistoryDisabled unless enabled
+	ChangedProducts(ctx context.Context, q ChangesQuery) (*ChangesPage, error)             // Paged feed of changed products, ErrInvalidChangesQuery on bad queries
 	TestConnection(ctx context.Context) error
 	HealthCheck(ctx context.Context) error // Lightweight health check for production monitoring
+	QueueStats() QueueStats                // Admission queue snapshot for monitoring and load shedding
 	Close() error
 }
 
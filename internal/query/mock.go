@@ -10,9 +10,11 @@ import (
 
 // MockEngine is a mock implementation for testing
 type MockEngine struct {
-	products []types.Product
-	err      error
-	log      *slog.Logger
+	products   []types.Product
+	err        error
+	queueStats QueueStats
+	history    map[string]*ProductHistory
+	log        *slog.Logger
 }
 
 // NewMockEngine creates a new mock engine for testing
@@ -34,7 +36,13 @@ func NewMockEngine(logger *slog.Logger) *MockEngine {
 					"salt":          0.107,
 				},
 				Link:        "https://world.openfoodfacts.org/product/3017620422003/nutella-ferrero",
+				ServingSize: "15 g",
 				Ingredients: map[string]interface{}{"text": "sugar, hazelnuts, palm oil, cocoa, milk powder"},
+				Packagings: []types.PackagingComponent{
+					{Material: "en:glass", Shape: "en:jar", Recycling: "en:recycle"},
+					{Material: "en:pp-5-polypropylene", Shape: "en:lid", Recycling: "en:discard"},
+				},
+				CategoriesTags: []string{"en:spreads", "en:sweet-spreads"},
 			},
 			{
 				Code:        "1234567890123",
@@ -89,6 +97,105 @@ func (m *MockEngine) SearchByBarcode(ctx context.Context, barcode string) (*type
 	return nil, nil
 }
 
+// GetProductsByBarcodes returns the mock products matching the barcodes in request order
+func (m *MockEngine) GetProductsByBarcodes(ctx context.Context, barcodes []string) ([]types.Product, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	results := []types.Product{}
+	for _, barcode := range barcodes {
+		for _, product := range m.products {
+			if product.Code == barcode {
+				results = append(results, product)
+				break
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// QueryProducts validates the query and returns the mock products up to the limit
+// Filters are compiled to catch invalid queries but are not evaluated
+func (m *MockEngine) QueryProducts(ctx context.Context, q ProductQuery) ([]types.Product, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	_, _, _, limit, err := compileProductQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []types.Product{}
+	for _, product := range m.products {
+		if len(results) >= limit {
+			break
+		}
+		results = append(results, product)
+	}
+
+	return results, nil
+}
+
+// RunSQL validates the statement and returns the code and name of every mock product
+func (m *MockEngine) RunSQL(ctx context.Context, statement string) (*SQLResult, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if _, err := ValidateReadOnlySQL(statement); err != nil {
+		return nil, err
+	}
+
+	result := &SQLResult{
+		Columns: []string{"code", "product_name"},
+		Rows:    [][]interface{}{},
+	}
+	for _, product := range m.products {
+		result.Rows = append(result.Rows, []interface{}{product.Code, product.ProductName})
+	}
+	result.RowCount = len(result.Rows)
+	result.RowLimit = result.RowCount
+
+	return result, nil
+}
+
+// ProductHistory returns the history set with SetHistory, or one without snapshots
+func (m *MockEngine) ProductHistory(ctx context.Context, barcode string) (*ProductHistory, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	if history, ok := m.history[barcode]; ok {
+		return history, nil
+	}
+	return &ProductHistory{Barcode: barcode, Snapshots: []Snapshot{}, Changes: []ProductChange{}}, nil
+}
+
+// ChangedProducts validates the query and returns every mock product as changed, without paging
+func (m *MockEngine) ChangedProducts(ctx context.Context, q ChangesQuery) (*ChangesPage, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	limit, err := validateChangesQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &ChangesPage{Products: []ChangedProduct{}, Since: q.Since}
+	for _, product := range m.products {
+		if len(page.Products) >= limit {
+			break
+		}
+		page.Products = append(page.Products, ChangedProduct{Code: product.Code, ProductName: product.ProductName})
+	}
+	page.Count = len(page.Products)
+	return page, nil
+}
+
 // TestConnection tests the connection (respects SetError)
 func (m *MockEngine) TestConnection(ctx context.Context) error {
 	return m.err
@@ -99,6 +206,11 @@ func (m *MockEngine) HealthCheck(ctx context.Context) error {
 	return m.err
 }
 
+// QueueStats returns the queue stats set with SetQueueStats
+func (m *MockEngine) QueueStats() QueueStats {
+	return m.queueStats
+}
+
 // Close closes the mock engine (no-op)
 func (m *MockEngine) Close() error {
 	return nil
@@ -109,6 +221,19 @@ func (m *MockEngine) SetError(err error) {
 	m.err = err
 }
 
+// SetQueueStats sets the queue stats returned by the mock
+func (m *MockEngine) SetQueueStats(stats QueueStats) {
+	m.queueStats = stats
+}
+
+// SetHistory sets the history returned by ProductHistory for a barcode
+func (m *MockEngine) SetHistory(history *ProductHistory) {
+	if m.history == nil {
+		m.history = map[string]*ProductHistory{}
+	}
+	m.history[history.Barcode] = history
+}
+
 // SetProducts sets the products to be returned by the mock
 func (m *MockEngine) SetProducts(products []types.Product) {
 	m.products = products
@@ -0,0 +1,96 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// PruneOptions selects the products kept in a pruned dataset. Empty lists keep every product
+type PruneOptions struct {
+	Countries        []string // countries_tags such as "en:france"; a bare name gets the "en:" prefix
+	Languages        []string // Language codes of the product names, such as "fr"
+	RequireNutrition bool     // Keep only products with at least one nutriment per 100g
+}
+
+// PruneDataset writes the products of the dataset at basePath matching opts to outPath, keeping only the
+// columns the engine reads. It returns the number of products written
+func PruneDataset(ctx context.Context, basePath string, opts PruneOptions, outPath string) (int64, error) {
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		return 0, fmt.Errorf("failed to open duckdb: %w", err)
+	}
+	defer db.Close()
+
+	baseTypes, err := parquetColumnTypes(ctx, db, basePath)
+	if err != nil {
+		return 0, err
+	}
+	if _, ok := baseTypes["code"]; !ok {
+		return 0, fmt.Errorf("dataset has no code column")
+	}
+
+	var columns []string
+	for _, column := range productSchema {
+		if _, ok := baseTypes[column.name]; ok {
+			columns = append(columns, column.name)
+		}
+	}
+
+	var conditions []string
+	if len(opts.Countries) > 0 {
+		if _, ok := baseTypes["countries_tags"]; !ok {
+			return 0, fmt.Errorf("dataset has no countries_tags column to filter countries on")
+		}
+		countries := make([]string, len(opts.Countries))
+		for i, country := range opts.Countries {
+			countries[i] = countryTag(country)
+		}
+		conditions = append(conditions, fmt.Sprintf("list_has_any(countries_tags, %s)", sqlStringList(countries)))
+	}
+	if len(opts.Languages) > 0 {
+		if _, ok := baseTypes["product_name"]; !ok {
+			return 0, fmt.Errorf("dataset has no product_name column to filter languages on")
+		}
+		languages := make([]string, len(opts.Languages))
+		for i, language := range opts.Languages {
+			languages[i] = strings.ToLower(strings.TrimSpace(language))
+		}
+		conditions = append(conditions, fmt.Sprintf(
+			"len(list_filter(product_name, x -> list_contains(%s, x.lang) AND x.text <> '')) > 0", sqlStringList(languages)))
+	}
+	if opts.RequireNutrition {
+		if _, ok := baseTypes["nutriments"]; !ok {
+			return 0, fmt.Errorf("dataset has no nutriments column to filter nutrition facts on")
+		}
+		conditions = append(conditions, `len(list_filter(nutriments, x -> x."100g" IS NOT NULL)) > 0`)
+	}
+
+	where := "(code IS NOT NULL AND code <> '')"
+	for _, condition := range conditions {
+		where += " AND " + condition
+	}
+
+	result, err := db.ExecContext(ctx, fmt.Sprintf(`COPY (
+		SELECT %s FROM read_parquet('%s') WHERE %s
+	) TO '%s' (FORMAT parquet, COMPRESSION zstd)`,
+		strings.Join(columns, ", "), escapeSQLString(basePath), where, escapeSQLString(outPath)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune dataset: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return rows, nil
+}
+
+// countryTag normalises a country to its countries_tags form, so "France" matches "en:france"
+func countryTag(country string) string {
+	tag := strings.ToLower(strings.TrimSpace(country))
+	if !strings.Contains(tag, ":") {
+		tag = "en:" + strings.ReplaceAll(tag, " ", "-")
+	}
+	return tag
+}
@@ -0,0 +1,122 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneDataset(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.parquet")
+	writeTestParquet(t, base)
+
+	tests := []struct {
+		name          string
+		opts          PruneOptions
+		expectedCodes []string
+	}{
+		{
+			name:          "no filters keeps every product",
+			expectedCodes: []string{"0000000000001", "3017620422003", "5449000000996"},
+		},
+		{
+			name:          "country tags",
+			opts:          PruneOptions{Countries: []string{"en:united-states"}},
+			expectedCodes: []string{"5449000000996"},
+		},
+		{
+			name:          "bare country names",
+			opts:          PruneOptions{Countries: []string{"France"}},
+			expectedCodes: []string{"0000000000001", "3017620422003"},
+		},
+		{
+			name:          "product name languages",
+			opts:          PruneOptions{Languages: []string{"fr"}},
+			expectedCodes: []string{"0000000000001"},
+		},
+		{
+			name:          "nutrition facts",
+			opts:          PruneOptions{RequireNutrition: true},
+			expectedCodes: []string{"3017620422003", "5449000000996"},
+		},
+		{
+			name:          "filters combine",
+			opts:          PruneOptions{Countries: []string{"en:france"}, RequireNutrition: true},
+			expectedCodes: []string{"3017620422003"},
+		},
+		{
+			name: "nothing matches",
+			opts: PruneOptions{Countries: []string{"en:japan"}},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := filepath.Join(dir, fmt.Sprintf("pruned-%d.parquet", i))
+			rows, err := PruneDataset(ctx, base, tt.opts, out)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(tt.expectedCodes)), rows)
+
+			db, err := sql.Open("duckdb", "")
+			require.NoError(t, err)
+			defer db.Close()
+
+			var codes []string
+			result, err := db.Query(`SELECT code FROM read_parquet(?) ORDER BY code`, out)
+			require.NoError(t, err)
+			defer result.Close()
+			for result.Next() {
+				var code string
+				require.NoError(t, result.Scan(&code))
+				codes = append(codes, code)
+			}
+			require.NoError(t, result.Err())
+			assert.Equal(t, tt.expectedCodes, codes)
+		})
+	}
+
+	t.Run("keeps only the columns the engine reads", func(t *testing.T) {
+		wide := filepath.Join(dir, "wide.parquet")
+		db, err := sql.Open("duckdb", "")
+		require.NoError(t, err)
+		defer db.Close()
+		_, err = db.Exec(fmt.Sprintf(`COPY (SELECT *, 'unused' AS generic_name FROM read_parquet('%s')) TO '%s' (FORMAT parquet)`,
+			escapeSQLString(base), escapeSQLString(wide)))
+		require.NoError(t, err)
+
+		out := filepath.Join(dir, "slim.parquet")
+		_, err = PruneDataset(ctx, wide, PruneOptions{}, out)
+		require.NoError(t, err)
+		require.NoError(t, ValidateDataset(ctx, out))
+
+		types, err := parquetColumnTypes(ctx, db, out)
+		require.NoError(t, err)
+		assert.NotContains(t, types, "generic_name")
+		assert.Contains(t, types, "nutriments")
+	})
+}
+
+func TestCountryTag(t *testing.T) {
+	tests := []struct {
+		country  string
+		expected string
+	}{
+		{country: "en:france", expected: "en:france"},
+		{country: "France", expected: "en:france"},
+		{country: "United States", expected: "en:united-states"},
+		{country: "fr:allemagne", expected: "fr:allemagne"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.country, func(t *testing.T) {
+			assert.Equal(t, tt.expected, countryTag(tt.country))
+		})
+	}
+}
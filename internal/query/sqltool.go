@@ -0,0 +1,407 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/marcboeker/go-duckdb/v2"
+)
+
+// SQL tool constants
+const (
+	SQLToolViewName = "products"
+)
+
+// ErrSQLToolDisabled is returned when RunSQL is called but the tool is not enabled in config
+var ErrSQLToolDisabled = errors.New("run_sql is disabled")
+
+// ErrSQLNotAllowed is wrapped by every statement validation failure
+var ErrSQLNotAllowed = errors.New("statement not allowed")
+
+// sqlToolViewColumns is the curated column list exposed through the products view
+// Only well-known, read-only product columns are exposed to ad-hoc queries
+const sqlToolViewColumns = `
+	code,
+	COALESCE(
+		(SELECT list_extract(list_filter(product_name, x -> x.lang = 'en'), 1).text),
+		CAST(product_name AS VARCHAR)
+	) AS product_name,
+	brands,
+	categories_tags,
+	countries_tags,
+	labels_tags,
+	allergens_tags,
+	nutriscore_grade,
+	nutriscore_score,
+	nova_group,
+	nutriments,
+	serving_size,
+	serving_quantity,
+	quantity,
+	link,
+	last_modified_t`
+
+// blockedSQLKeywords are statement keywords that are never allowed anywhere in a statement
+var blockedSQLKeywords = map[string]bool{
+	"alter": true, "attach": true, "call": true, "checkpoint": true, "copy": true,
+	"create": true, "delete": true, "detach": true, "drop": true, "export": true,
+	"import": true, "insert": true, "install": true, "load": true, "pragma": true,
+	"reset": true, "set": true, "truncate": true, "update": true, "use": true,
+	"vacuum": true,
+}
+
+// blockedSQLFunctions are functions that read files, run nested SQL or expose server state
+var blockedSQLFunctions = map[string]bool{
+	"csv_scan": true, "glob": true, "getenv": true, "json_execute_serialized_sql": true,
+	"parquet_file_metadata": true, "parquet_kv_metadata": true, "parquet_metadata": true,
+	"parquet_scan": true, "parquet_schema": true, "query": true, "query_table": true,
+	"sniff_csv": true, "sqlite_scan": true,
+}
+
+// blockedSQLPrefixes catch whole families of file readers and system functions
+var blockedSQLPrefixes = []string{"read_", "pragma_", "duckdb_", "current_setting"}
+
+// SQLResult is the result of a run_sql statement
+type SQLResult struct {
+	Columns   []string        `json:"columns"`
+	Rows      [][]interface{} `json:"rows"`
+	RowCount  int             `json:"row_count"`
+	RowLimit  int             `json:"row_limit"`
+	Truncated bool            `json:"truncated"`
+}
+
+// ValidateReadOnlySQL checks that a statement is a single SELECT against the curated view
+// It returns the statement with surrounding whitespace and trailing semicolons removed
+func ValidateReadOnlySQL(statement string) (string, error) {
+	trimmed := strings.TrimSpace(statement)
+	for strings.HasSuffix(trimmed, ";") {
+		trimmed = strings.TrimSpace(strings.TrimSuffix(trimmed, ";"))
+	}
+	if trimmed == "" {
+		return "", fmt.Errorf("%w: empty statement", ErrSQLNotAllowed)
+	}
+
+	tokens, err := tokenizeSQL(trimmed)
+	if err != nil {
+		return "", err
+	}
+	if len(tokens) == 0 {
+		return "", fmt.Errorf("%w: empty statement", ErrSQLNotAllowed)
+	}
+
+	if first := tokens[0]; first != "select" && first != "with" {
+		return "", fmt.Errorf("%w: only SELECT statements are allowed", ErrSQLNotAllowed)
+	}
+
+	for _, token := range tokens {
+		if blockedSQLKeywords[token] {
+			return "", fmt.Errorf("%w: %s is not permitted", ErrSQLNotAllowed, strings.ToUpper(token))
+		}
+		if blockedSQLFunctions[token] {
+			return "", fmt.Errorf("%w: function %s is not permitted", ErrSQLNotAllowed, token)
+		}
+		for _, prefix := range blockedSQLPrefixes {
+			if strings.HasPrefix(token, prefix) {
+				return "", fmt.Errorf("%w: function %s is not permitted", ErrSQLNotAllowed, token)
+			}
+		}
+	}
+
+	return trimmed, nil
+}
+
+// tokenizeSQL returns the lower-cased identifiers and keywords in a statement
+// String literals and comments are skipped; quoted identifiers are returned unquoted
+// so that "read_csv"(...) is caught the same way as read_csv(...)
+// The literal forms follow DuckDB's lexer, including E'...' escape strings and $tag$...$tag$
+// dollar quotes, so a literal cannot hide the end of the statement from the checks
+func tokenizeSQL(statement string) ([]string, error) {
+	var tokens []string
+	runes := []rune(statement)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == ';':
+			return nil, fmt.Errorf("%w: multiple statements", ErrSQLNotAllowed)
+		case r == '\'':
+			end, err := skipQuoted(runes, i, '\'', false)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+		case (r == 'e' || r == 'E') && i+1 < len(runes) && runes[i+1] == '\'':
+			// Escape string, \' is an escaped quote as well as ''
+			end, err := skipQuoted(runes, i+1, '\'', true)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+		case r == '"':
+			end, err := skipQuoted(runes, i, '"', false)
+			if err != nil {
+				return nil, err
+			}
+			name := strings.ReplaceAll(string(runes[i+1:end]), `""`, `"`)
+			tokens = append(tokens, strings.ToLower(name))
+			i = end
+		case r == '$':
+			// $1 is a parameter; $$...$$ and $tag$...$tag$ are string literals
+			tag := i + 1
+			for tag < len(runes) && (unicode.IsLetter(runes[tag]) || runes[tag] == '_' || (tag > i+1 && unicode.IsDigit(runes[tag]))) {
+				tag++
+			}
+			if tag >= len(runes) || runes[tag] != '$' {
+				continue
+			}
+			delimiter := string(runes[i : tag+1])
+			rest := string(runes[tag+1:])
+			end := strings.Index(rest, delimiter)
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated dollar-quoted string", ErrSQLNotAllowed)
+			}
+			i = tag + len([]rune(rest[:end+len(delimiter)]))
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			end := i + 2
+			for end+1 < len(runes) && (runes[end] != '*' || runes[end+1] != '/') {
+				end++
+			}
+			if end+1 >= len(runes) {
+				return nil, fmt.Errorf("%w: unterminated comment", ErrSQLNotAllowed)
+			}
+			i = end + 1
+		case unicode.IsLetter(r) || r == '_':
+			end := i
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_' || runes[end] == '$') {
+				end++
+			}
+			tokens = append(tokens, strings.ToLower(string(runes[i:end])))
+			i = end - 1
+		}
+	}
+
+	return tokens, nil
+}
+
+// skipQuoted returns the index of the quote closing the literal or identifier opened at start
+// A doubled quote is an escaped quote; with backslashEscapes a backslash escapes the next character
+func skipQuoted(runes []rune, start int, quote rune, backslashEscapes bool) (int, error) {
+	for end := start + 1; end < len(runes); end++ {
+		switch {
+		case backslashEscapes && runes[end] == '\\':
+			end++
+		case runes[end] == quote:
+			if end+1 < len(runes) && runes[end+1] == quote {
+				end++
+				continue
+			}
+			return end, nil
+		}
+	}
+	if quote == '"' {
+		return 0, fmt.Errorf("%w: unterminated quoted identifier", ErrSQLNotAllowed)
+	}
+	return 0, fmt.Errorf("%w: unterminated string literal", ErrSQLNotAllowed)
+}
+
+// openSQLToolDB opens a separate, locked-down DuckDB instance for ad-hoc queries
+// It can only read the dataset file and has its own memory cap so a heavy ad-hoc query
+// cannot starve the main engine
+func openSQLToolDB(parquetPath, memoryLimit string, threads, maxConns int) (*sql.DB, error) {
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open run_sql duckdb: %w", err)
+	}
+
+	db.SetMaxOpenConns(maxConns)
+
+	settings := []string{
+		fmt.Sprintf("SET memory_limit='%s'", escapeSQLString(memoryLimit)),
+		fmt.Sprintf("SET allowed_paths=['%s']", escapeSQLString(parquetPath)),
+		"SET enable_external_access=false",
+		"SET autoinstall_known_extensions=false",
+		"SET autoload_known_extensions=false",
+	}
+	if threads > 0 {
+		settings = append(settings, fmt.Sprintf("SET threads=%d", threads))
+	}
+	// Must be last: prevents any later statement from loosening the settings above
+	settings = append(settings, "SET lock_configuration=true")
+
+	for _, setting := range settings {
+		if _, err := db.Exec(setting); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to configure run_sql duckdb (%s): %w", setting, err)
+		}
+	}
+
+	return db, nil
+}
+
+// ensureSQLToolView creates the curated products view on first use
+// The view is created lazily because the dataset may not exist when the engine is constructed
+func (e *Engine) ensureSQLToolView(ctx context.Context) error {
+	e.sqlViewMu.Lock()
+	defer e.sqlViewMu.Unlock()
+
+	if e.sqlViewReady {
+		return nil
+	}
+
+	createView := fmt.Sprintf("CREATE OR REPLACE VIEW %s AS SELECT %s\nFROM read_parquet('%s')",
+		SQLToolViewName, sqlToolViewColumns, escapeSQLString(e.parquetPath))
+	if _, err := e.sqlDB.ExecContext(ctx, createView); err != nil {
+		return fmt.Errorf("failed to create %s view: %w", SQLToolViewName, err)
+	}
+
+	e.sqlViewReady = true
+	return nil
+}
+
+// RunSQL runs a single read-only SELECT against the curated products view
+func (e *Engine) RunSQL(ctx context.Context, statement string) (*SQLResult, error) {
+	start := time.Now()
+
+	if e.sqlDB == nil {
+		return nil, ErrSQLToolDisabled
+	}
+
+	validated, err := ValidateReadOnlySQL(statement)
+	if err != nil {
+		e.log.Warn("run_sql statement rejected", "error", err)
+		return nil, err
+	}
+
+	release, err := e.admit(ctx, "RunSQL")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(ctx, e.sqlTimeout)
+	defer cancel()
+
+	if err := e.ensureSQLToolView(ctx); err != nil {
+		return nil, err
+	}
+
+	conn, err := e.sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("run_sql connection: %w", err)
+	}
+	defer conn.Close()
+
+	// Fetch one extra row to detect truncation without counting the full result
+	limited := fmt.Sprintf("SELECT * FROM (\n%s\n) AS run_sql LIMIT %d", validated, e.sqlRowLimit+1)
+
+	// The token checks are a first line of defence; DuckDB's own parser has the final say
+	for _, text := range []string{validated, limited} {
+		if err := requireSingleSelect(conn, text); err != nil {
+			e.log.Warn("run_sql statement rejected", "error", err)
+			return nil, err
+		}
+	}
+
+	rows, err := conn.QueryContext(ctx, limited)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("run_sql timed out after %s", e.sqlTimeout)
+		}
+		return nil, fmt.Errorf("run_sql failed: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("run_sql columns: %w", err)
+	}
+
+	result := &SQLResult{
+		Columns:  columns,
+		Rows:     [][]interface{}{},
+		RowLimit: e.sqlRowLimit,
+	}
+
+	for rows.Next() {
+		if len(result.Rows) == e.sqlRowLimit {
+			result.Truncated = true
+			break
+		}
+
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("run_sql scan: %w", err)
+		}
+		for i, value := range values {
+			// Blobs and strings come back as []byte; JSON would base64 them
+			if b, ok := value.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		result.Rows = append(result.Rows, values)
+	}
+
+	if err := rows.Err(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("run_sql timed out after %s", e.sqlTimeout)
+		}
+		return nil, fmt.Errorf("run_sql rows error: %w", err)
+	}
+
+	result.RowCount = len(result.Rows)
+	e.log.Info("RunSQL completed", "rows", result.RowCount, "truncated", result.Truncated, "duration", time.Since(start))
+	return result, nil
+}
+
+// requireSingleSelect asks DuckDB to parse a statement and rejects it unless it is exactly one SELECT
+// The driver's Prepare parses without running anything, unlike PrepareContext which executes all but
+// the last of several statements
+func requireSingleSelect(conn *sql.Conn, statement string) error {
+	return conn.Raw(func(driverConn any) error {
+		duckConn, ok := driverConn.(*duckdb.Conn)
+		if !ok {
+			return fmt.Errorf("run_sql: unexpected driver connection %T", driverConn)
+		}
+		stmt, err := duckConn.Prepare(statement)
+		if err != nil {
+			// Parse and bind errors come from DuckDB; anything else is the driver refusing several statements
+			var duckErr *duckdb.Error
+			if errors.As(err, &duckErr) {
+				return fmt.Errorf("run_sql failed: %w", err)
+			}
+			return fmt.Errorf("%w: multiple statements", ErrSQLNotAllowed)
+		}
+		defer stmt.Close()
+
+		duckStmt, ok := stmt.(*duckdb.Stmt)
+		if !ok {
+			return fmt.Errorf("run_sql: unexpected driver statement %T", stmt)
+		}
+		stmtType, err := duckStmt.StatementType()
+		if err != nil {
+			return fmt.Errorf("run_sql statement type: %w", err)
+		}
+		if stmtType != duckdb.STATEMENT_TYPE_SELECT {
+			return fmt.Errorf("%w: only SELECT statements are allowed", ErrSQLNotAllowed)
+		}
+		return nil
+	})
+}
+
+// escapeSQLString escapes a value for use inside a single-quoted SQL literal
+func escapeSQLString(value string) string {
+	return strings.ReplaceAll(value, "'", "''")
+}
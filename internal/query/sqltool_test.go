@@ -0,0 +1,226 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateReadOnlySQL(t *testing.T) {
+	tests := []struct {
+		name        string
+		statement   string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:      "simple select",
+			statement: "SELECT code FROM products",
+			expected:  "SELECT code FROM products",
+		},
+		{
+			name:      "trailing semicolons and whitespace are stripped",
+			statement: "  select count(*) from products;;  ",
+			expected:  "select count(*) from products",
+		},
+		{
+			name:      "CTE is allowed",
+			statement: "WITH x AS (SELECT code FROM products) SELECT * FROM x",
+			expected:  "WITH x AS (SELECT code FROM products) SELECT * FROM x",
+		},
+		{
+			name:      "blocked words inside string literals are ignored",
+			statement: "SELECT code FROM products WHERE brands = 'copy; drop read_csv'",
+			expected:  "SELECT code FROM products WHERE brands = 'copy; drop read_csv'",
+		},
+		{
+			name:      "escaped quotes in literals",
+			statement: "SELECT code FROM products WHERE brands = 'Ben ''n'' Jerry'",
+			expected:  "SELECT code FROM products WHERE brands = 'Ben ''n'' Jerry'",
+		},
+		{
+			name:      "comments are skipped",
+			statement: "SELECT code -- pragma in a comment\nFROM products /* attach */",
+			expected:  "SELECT code -- pragma in a comment\nFROM products /* attach */",
+		},
+		{name: "empty statement", statement: "  ; ", expectError: true},
+		{name: "insert", statement: "INSERT INTO products VALUES (1)", expectError: true},
+		{name: "pragma", statement: "PRAGMA database_list", expectError: true},
+		{name: "copy", statement: "COPY products TO '/tmp/out.csv'", expectError: true},
+		{name: "attach", statement: "ATTACH '/tmp/other.db'", expectError: true},
+		{name: "multiple statements", statement: "SELECT 1; DROP VIEW products", expectError: true},
+		{name: "read_csv function", statement: "SELECT * FROM read_csv('/etc/passwd')", expectError: true},
+		{name: "read_parquet function", statement: "SELECT * FROM read_parquet('/data/other.parquet')", expectError: true},
+		{name: "quoted function name", statement: `SELECT * FROM "read_csv_auto"('/etc/passwd')`, expectError: true},
+		{name: "pragma table function", statement: "SELECT * FROM pragma_table_info('products')", expectError: true},
+		{name: "duckdb system function", statement: "SELECT * FROM duckdb_settings()", expectError: true},
+		{name: "nested query function", statement: "SELECT * FROM query('SELECT 1')", expectError: true},
+		{name: "glob", statement: "SELECT * FROM glob('/*')", expectError: true},
+		{name: "set inside select", statement: "WITH x AS (SELECT 1) SET threads=1", expectError: true},
+		{name: "unterminated literal", statement: "SELECT 'oops FROM products", expectError: true},
+		{name: "unterminated comment", statement: "SELECT code /* FROM products", expectError: true},
+		{
+			name:      "escape string literal",
+			statement: `SELECT code FROM products WHERE brands = E'it\'s; drop'`,
+			expected:  `SELECT code FROM products WHERE brands = E'it\'s; drop'`,
+		},
+		{
+			name:      "dollar-quoted literal",
+			statement: "SELECT $tag$copy; drop$tag$, $$it's$$ AS x FROM products",
+			expected:  "SELECT $tag$copy; drop$tag$, $$it's$$ AS x FROM products",
+		},
+		{
+			name:      "doubled quote in quoted identifier",
+			statement: `SELECT code AS "a""b" FROM products`,
+			expected:  `SELECT code AS "a""b" FROM products`,
+		},
+		{
+			name:        "escape string hiding a second statement",
+			statement:   `SELECT E'\'' AS x) t; CREATE OR REPLACE VIEW products AS SELECT 1 AS code; SELECT * FROM (SELECT 1 --'`,
+			expectError: true,
+		},
+		{
+			name:        "dollar quote hiding a second statement",
+			statement:   "SELECT $$ ' $$ AS x; DROP VIEW products; SELECT ' --",
+			expectError: true,
+		},
+		{name: "unterminated escape string", statement: `SELECT E'oops\' FROM products`, expectError: true},
+		{name: "unterminated dollar quote", statement: "SELECT $q$ oops FROM products", expectError: true},
+		{name: "quoted identifier hiding a function", statement: `SELECT * FROM "a"""."read_csv"('/etc/passwd')`, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ValidateReadOnlySQL(tt.statement)
+			if tt.expectError {
+				assert.Error(t, err)
+				assert.True(t, errors.Is(err, ErrSQLNotAllowed))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestEngine_RunSQL(t *testing.T) {
+	parquetPath := filepath.Join(t.TempDir(), "products.parquet")
+	writeTestParquet(t, parquetPath)
+
+	logger := config.NewTestLogger(os.Stdout, "ERROR")
+	cfg := &config.Config{
+		DuckDBMaxOpenConns:    2,
+		DuckDBQueueTimeoutMs:  1000,
+		EnableSQLTool:         true,
+		SQLToolRowLimit:       2,
+		SQLToolTimeoutSeconds: 5,
+		SQLToolMemoryLimit:    "256MB",
+	}
+
+	engine, err := NewEngine(parquetPath, cfg, logger)
+	require.NoError(t, err)
+	defer engine.Close()
+
+	ctx := context.Background()
+
+	t.Run("aggregate query", func(t *testing.T) {
+		result, err := engine.RunSQL(ctx, "SELECT nutriscore_grade, count(*) AS n FROM products GROUP BY 1 ORDER BY 1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"nutriscore_grade", "n"}, result.Columns)
+		require.Len(t, result.Rows, 2)
+		assert.Equal(t, "a", result.Rows[0][0])
+		assert.False(t, result.Truncated)
+	})
+
+	t.Run("curated product name", func(t *testing.T) {
+		result, err := engine.RunSQL(ctx, "SELECT product_name FROM products WHERE code = '3017620422003'")
+		require.NoError(t, err)
+		require.Len(t, result.Rows, 1)
+		assert.Equal(t, "Nutella", result.Rows[0][0])
+	})
+
+	t.Run("row limit enforced", func(t *testing.T) {
+		result, err := engine.RunSQL(ctx, "SELECT code FROM products ORDER BY code")
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.RowCount)
+		assert.Equal(t, 2, result.RowLimit)
+		assert.True(t, result.Truncated)
+	})
+
+	t.Run("rejected statement", func(t *testing.T) {
+		_, err := engine.RunSQL(ctx, "COPY products TO '/tmp/leak.csv'")
+		assert.ErrorIs(t, err, ErrSQLNotAllowed)
+	})
+
+	t.Run("escape string cannot replace the products view", func(t *testing.T) {
+		_, err := engine.RunSQL(ctx, `SELECT E'\'' AS x) t; CREATE OR REPLACE VIEW products AS SELECT 'hijacked' AS code; SELECT * FROM (SELECT 1 --'`)
+		assert.ErrorIs(t, err, ErrSQLNotAllowed)
+
+		result, err := engine.RunSQL(ctx, "SELECT code FROM products WHERE code = '3017620422003'")
+		require.NoError(t, err)
+		assert.Len(t, result.Rows, 1)
+	})
+
+	t.Run("DuckDB parser requires a single SELECT", func(t *testing.T) {
+		conn, err := engine.sqlDB.Conn(ctx)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		assert.NoError(t, requireSingleSelect(conn, "SELECT code FROM products"))
+		assert.ErrorIs(t, requireSingleSelect(conn, "SELECT 1; CREATE OR REPLACE VIEW products AS SELECT 1"), ErrSQLNotAllowed)
+		assert.ErrorIs(t, requireSingleSelect(conn, "EXPLAIN SELECT code FROM products"), ErrSQLNotAllowed)
+		assert.Error(t, requireSingleSelect(conn, "SELECT FROM WHERE"))
+
+		// Nothing was executed while parsing
+		result, err := engine.RunSQL(ctx, "SELECT count(*) FROM products")
+		require.NoError(t, err)
+		assert.EqualValues(t, 3, result.Rows[0][0])
+	})
+
+	t.Run("file access blocked by sandbox", func(t *testing.T) {
+		// Even if a file reader slipped past validation, the sandbox cannot read other files
+		_, err := engine.sqlDB.ExecContext(ctx, "SELECT * FROM read_csv('/etc/hostname')")
+		assert.Error(t, err)
+
+		_, err = engine.sqlDB.ExecContext(ctx, "SET enable_external_access=true")
+		assert.Error(t, err)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		engine.sqlTimeout = 50 * time.Millisecond
+		defer func() { engine.sqlTimeout = cfg.SQLToolTimeout() }()
+
+		_, err := engine.RunSQL(ctx, "SELECT count(*) FROM range(10000000000) a")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "timed out")
+	})
+}
+
+func TestEngine_RunSQL_Disabled(t *testing.T) {
+	logger := config.NewTestLogger(os.Stdout, "ERROR")
+	engine, err := NewEngine("test.parquet", &config.Config{}, logger)
+	require.NoError(t, err)
+	defer engine.Close()
+
+	_, err = engine.RunSQL(context.Background(), "SELECT 1")
+	assert.ErrorIs(t, err, ErrSQLToolDisabled)
+}
+
+func TestMockEngine_RunSQL(t *testing.T) {
+	logger := config.NewTestLogger(os.Stdout, "ERROR")
+	engine := NewMockEngine(logger)
+
+	result, err := engine.RunSQL(context.Background(), "SELECT code, product_name FROM products")
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.RowCount)
+
+	_, err = engine.RunSQL(context.Background(), "DROP VIEW products")
+	assert.ErrorIs(t, err, ErrSQLNotAllowed)
+}
@@ -0,0 +1,171 @@
+package query
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/types"
+)
+
+// SwappableEngine forwards every call to the current engine, which can be replaced while queries run
+// A replaced engine is closed once the queries already running on it have finished
+type SwappableEngine struct {
+	mu      sync.RWMutex
+	current *engineRef
+	log     *slog.Logger
+}
+
+// engineRef counts the calls in flight on one engine
+type engineRef struct {
+	engine   QueryEngine
+	inflight sync.WaitGroup
+}
+
+// Ensure SwappableEngine implements QueryEngine interface
+var _ QueryEngine = (*SwappableEngine)(nil)
+
+// NewSwappableEngine wraps the initial engine
+func NewSwappableEngine(engine QueryEngine, logger *slog.Logger) *SwappableEngine {
+	return &SwappableEngine{current: &engineRef{engine: engine}, log: logger}
+}
+
+// acquire returns the current engine with its in-flight count incremented; call release when done
+func (s *SwappableEngine) acquire() *engineRef {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ref := s.current
+	ref.inflight.Add(1) // Under the read lock, so no call is added to an engine after it is swapped out
+	return ref
+}
+
+// release marks a call on the engine as finished
+func (r *engineRef) release() {
+	r.inflight.Done()
+}
+
+// Swap makes next the current engine, then closes the previous one after its in-flight queries finish
+// If they take longer than drainTimeout the previous engine is closed anyway, failing them
+func (s *SwappableEngine) Swap(next QueryEngine, drainTimeout time.Duration) {
+	start := time.Now()
+
+	s.mu.Lock()
+	previous := s.current
+	s.current = &engineRef{engine: next}
+	s.mu.Unlock()
+
+	s.log.Info("Query engine swapped, draining previous engine", "drain_timeout", drainTimeout)
+
+	drained := make(chan struct{})
+	go func() {
+		previous.inflight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		s.log.Info("Previous query engine drained", "duration", time.Since(start))
+	case <-time.After(drainTimeout):
+		s.log.Warn("Previous query engine still busy after drain timeout, closing it anyway", "drain_timeout", drainTimeout)
+	}
+
+	if err := previous.engine.Close(); err != nil {
+		s.log.Warn("Failed to close previous query engine", "error", err)
+	}
+}
+
+// SearchProductsByBrandAndName searches for products by name and brand on the current engine
+func (s *SwappableEngine) SearchProductsByBrandAndName(ctx context.Context, name, brand string, limit int) ([]types.Product, error) {
+	ref := s.acquire()
+	defer ref.release()
+	return ref.engine.SearchProductsByBrandAndName(ctx, name, brand, limit)
+}
+
+// SearchByBarcode searches for a product by barcode on the current engine
+func (s *SwappableEngine) SearchByBarcode(ctx context.Context, barcode string) (*types.Product, error) {
+	ref := s.acquire()
+	defer ref.release()
+	return ref.engine.SearchByBarcode(ctx, barcode)
+}
+
+// GetProductsByBarcodes looks up several products by barcode on the current engine
+func (s *SwappableEngine) GetProductsByBarcodes(ctx context.Context, barcodes []string) ([]types.Product, error) {
+	ref := s.acquire()
+	defer ref.release()
+	return ref.engine.GetProductsByBarcodes(ctx, barcodes)
+}
+
+// QueryProducts runs a structured filter query on the current engine
+func (s *SwappableEngine) QueryProducts(ctx context.Context, q ProductQuery) ([]types.Product, error) {
+	ref := s.acquire()
+	defer ref.release()
+	return ref.engine.QueryProducts(ctx, q)
+}
+
+// RunSQL runs a read-only statement on the current engine
+func (s *SwappableEngine) RunSQL(ctx context.Context, statement string) (*SQLResult, error) {
+	ref := s.acquire()
+	defer ref.release()
+	return ref.engine.RunSQL(ctx, statement)
+}
+
+// ProductHistory compares a product across snapshots on the current engine
+func (s *SwappableEngine) ProductHistory(ctx context.Context, barcode string) (*ProductHistory, error) {
+	ref := s.acquire()
+	defer ref.release()
+	return ref.engine.ProductHistory(ctx, barcode)
+}
+
+// ChangedProducts lists changed products on the current engine
+func (s *SwappableEngine) ChangedProducts(ctx context.Context, q ChangesQuery) (*ChangesPage, error) {
+	ref := s.acquire()
+	defer ref.release()
+	return ref.engine.ChangedProducts(ctx, q)
+}
+
+// snapshotRecorder is implemented by engines that fingerprint their dataset for product history
+type snapshotRecorder interface {
+	RecordSnapshot(ctx context.Context, sha256 string, takenAt time.Time) (*Snapshot, error)
+}
+
+// RecordSnapshot fingerprints the dataset of the current engine
+// The engine counts as in flight until the snapshot is written, so a swap does not close it mid-write
+func (s *SwappableEngine) RecordSnapshot(ctx context.Context, sha256 string, takenAt time.Time) (*Snapshot, error) {
+	ref := s.acquire()
+	defer ref.release()
+	recorder, ok := ref.engine.(snapshotRecorder)
+	if !ok {
+		return nil, ErrHistoryDisabled
+	}
+	return recorder.RecordSnapshot(ctx, sha256, takenAt)
+}
+
+// TestConnection tests the current engine
+func (s *SwappableEngine) TestConnection(ctx context.Context) error {
+	ref := s.acquire()
+	defer ref.release()
+	return ref.engine.TestConnection(ctx)
+}
+
+// HealthCheck checks the current engine
+func (s *SwappableEngine) HealthCheck(ctx context.Context) error {
+	ref := s.acquire()
+	defer ref.release()
+	return ref.engine.HealthCheck(ctx)
+}
+
+// QueueStats returns the admission queue snapshot of the current engine
+func (s *SwappableEngine) QueueStats() QueueStats {
+	ref := s.acquire()
+	defer ref.release()
+	return ref.engine.QueueStats()
+}
+
+// Close closes the current engine
+func (s *SwappableEngine) Close() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current.engine.Close()
+}
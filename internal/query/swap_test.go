@@ -0,0 +1,145 @@
+package query
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/config"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingEngine is a mock engine whose barcode lookups wait until unblocked
+type blockingEngine struct {
+	*MockEngine
+	started chan struct{}
+	unblock chan struct{}
+	closed  atomic.Bool
+}
+
+func newBlockingEngine(products []types.Product) *blockingEngine {
+	mock := NewMockEngine(config.NewTestLogger(io.Discard, "error"))
+	mock.SetProducts(products)
+	return &blockingEngine{MockEngine: mock, started: make(chan struct{}, 1), unblock: make(chan struct{})}
+}
+
+func (b *blockingEngine) SearchByBarcode(ctx context.Context, barcode string) (*types.Product, error) {
+	b.started <- struct{}{}
+	<-b.unblock
+	if b.closed.Load() {
+		return nil, assert.AnError
+	}
+	return b.MockEngine.SearchByBarcode(ctx, barcode)
+}
+
+func (b *blockingEngine) RecordSnapshot(ctx context.Context, sha256 string, takenAt time.Time) (*Snapshot, error) {
+	b.started <- struct{}{}
+	<-b.unblock
+	if b.closed.Load() {
+		return nil, assert.AnError
+	}
+	return &Snapshot{ID: sha256, TakenAt: takenAt}, nil
+}
+
+func (b *blockingEngine) Close() error {
+	b.closed.Store(true)
+	return nil
+}
+
+func TestSwappableEngine_Swap(t *testing.T) {
+	logger := config.NewTestLogger(io.Discard, "error")
+	ctx := context.Background()
+
+	old := newBlockingEngine([]types.Product{{Code: "1", ProductName: "Old"}})
+	next := NewMockEngine(logger)
+	next.SetProducts([]types.Product{{Code: "1", ProductName: "New"}})
+	swappable := NewSwappableEngine(old, logger)
+
+	// A query is running on the old engine when the swap starts
+	inflight := make(chan *types.Product)
+	go func() {
+		product, err := swappable.SearchByBarcode(ctx, "1")
+		assert.NoError(t, err)
+		inflight <- product
+	}()
+	<-old.started
+
+	swapped := make(chan struct{})
+	go func() {
+		swappable.Swap(next, time.Minute)
+		close(swapped)
+	}()
+
+	// New queries go to the new engine while the old one drains
+	require.Eventually(t, func() bool {
+		swappable.mu.RLock()
+		defer swappable.mu.RUnlock()
+		return swappable.current.engine == next
+	}, time.Second, time.Millisecond)
+	product, err := swappable.SearchByBarcode(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "New", product.ProductName)
+	assert.False(t, old.closed.Load(), "old engine must not be closed while a query runs on it")
+
+	close(old.unblock)
+	assert.Equal(t, "Old", (<-inflight).ProductName)
+	<-swapped
+	assert.True(t, old.closed.Load())
+}
+
+func TestSwappableEngine_SwapDrainTimeout(t *testing.T) {
+	logger := config.NewTestLogger(io.Discard, "error")
+
+	old := newBlockingEngine(nil)
+	swappable := NewSwappableEngine(old, logger)
+
+	go swappable.SearchByBarcode(context.Background(), "1")
+	<-old.started
+
+	// The stuck query does not keep the old engine open forever
+	swappable.Swap(NewMockEngine(logger), 20*time.Millisecond)
+	assert.True(t, old.closed.Load())
+	close(old.unblock)
+}
+
+func TestSwappableEngine_RecordSnapshot(t *testing.T) {
+	logger := config.NewTestLogger(io.Discard, "error")
+	ctx := context.Background()
+
+	old := newBlockingEngine(nil)
+	swappable := NewSwappableEngine(old, logger)
+
+	// A snapshot is being written when a refresh swaps the engine
+	recorded := make(chan error)
+	go func() {
+		_, err := swappable.RecordSnapshot(ctx, "aaaaaaaaaaaa", time.Now())
+		recorded <- err
+	}()
+	<-old.started
+
+	swapped := make(chan struct{})
+	go func() {
+		swappable.Swap(NewMockEngine(logger), time.Minute)
+		close(swapped)
+	}()
+
+	require.Eventually(t, func() bool {
+		swappable.mu.RLock()
+		defer swappable.mu.RUnlock()
+		return swappable.current.engine != old
+	}, time.Second, time.Millisecond)
+	assert.False(t, old.closed.Load(), "old engine must not be closed while a snapshot is written")
+
+	close(old.unblock)
+	assert.NoError(t, <-recorded)
+	<-swapped
+	assert.True(t, old.closed.Load())
+
+	// Engines without history cannot record snapshots
+	_, err := swappable.RecordSnapshot(ctx, "bbbbbbbbbbbb", time.Now())
+	assert.ErrorIs(t, err, ErrHistoryDisabled)
+}
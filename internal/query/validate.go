@@ -0,0 +1,109 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// parquetMagic starts and ends every parquet file
+const parquetMagic = "PAR1"
+
+// requiredColumns are the dataset columns the engine queries
+var requiredColumns = []string{
+	"code", "product_name", "brands", "link", "nutriments", "ingredients",
+	"serving_quantity", "serving_size", "product_quantity_unit", "images", "packagings",
+	"categories_tags", "countries_tags", "labels_tags", "allergens_tags",
+	"nutriscore_grade", "nutriscore_score", "nova_group", "last_modified_t",
+}
+
+// ValidateDataset checks that a parquet file has a readable footer, the columns the engine needs and at least one row
+func ValidateDataset(ctx context.Context, path string) error {
+	if err := checkParquetFooter(path); err != nil {
+		return err
+	}
+
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		return fmt.Errorf("failed to open duckdb: %w", err)
+	}
+	defer db.Close()
+
+	// parquet_schema and parquet_file_metadata only read the footer
+	rows, err := db.QueryContext(ctx, `SELECT name FROM parquet_schema(?)`, path)
+	if err != nil {
+		return fmt.Errorf("failed to read parquet schema: %w", err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return fmt.Errorf("failed to read parquet schema: %w", err)
+		}
+		columns[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read parquet schema: %w", err)
+	}
+
+	var missing []string
+	for _, column := range requiredColumns {
+		if !columns[column] {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("parquet file is missing columns: %s", strings.Join(missing, ", "))
+	}
+
+	var numRows sql.NullInt64
+	if err := db.QueryRowContext(ctx, `SELECT sum(num_rows) FROM parquet_file_metadata(?)`, path).Scan(&numRows); err != nil {
+		return fmt.Errorf("failed to read parquet metadata: %w", err)
+	}
+	if numRows.Int64 == 0 {
+		return fmt.Errorf("parquet file has no rows")
+	}
+	return nil
+}
+
+// checkParquetFooter checks the magic bytes at both ends of the file and that the footer length fits in it
+func checkParquetFooter(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	// Header magic, footer length and footer magic
+	if stat.Size() < 12 {
+		return fmt.Errorf("file too small to be parquet: %d bytes", stat.Size())
+	}
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return err
+	}
+	trailer := make([]byte, 8)
+	if _, err := f.ReadAt(trailer, stat.Size()-8); err != nil {
+		return err
+	}
+	if string(header) != parquetMagic || string(trailer[4:]) != parquetMagic {
+		return fmt.Errorf("not a parquet file: missing %s magic bytes", parquetMagic)
+	}
+
+	footerLength := int64(binary.LittleEndian.Uint32(trailer[:4]))
+	if footerLength == 0 || footerLength > stat.Size()-12 {
+		return fmt.Errorf("invalid parquet footer length %d for a %d byte file", footerLength, stat.Size())
+	}
+	return nil
+}
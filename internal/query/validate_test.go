@@ -0,0 +1,64 @@
+package query
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateDataset(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "valid.parquet")
+	writeTestParquet(t, valid)
+
+	truncated := filepath.Join(dir, "truncated.parquet")
+	data, err := os.ReadFile(valid)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(truncated, data[:len(data)/2], 0644))
+
+	notParquet := filepath.Join(dir, "not.parquet")
+	require.NoError(t, os.WriteFile(notParquet, []byte("<html>rate limited</html>"), 0644))
+
+	db, err := sql.Open("duckdb", "")
+	require.NoError(t, err)
+	defer db.Close()
+
+	missingColumns := filepath.Join(dir, "missing.parquet")
+	_, err = db.Exec(fmt.Sprintf(`COPY (SELECT '1' AS code, 'x' AS product_name) TO '%s' (FORMAT parquet)`, escapeSQLString(missingColumns)))
+	require.NoError(t, err)
+
+	empty := filepath.Join(dir, "empty.parquet")
+	_, err = db.Exec(fmt.Sprintf(`COPY (SELECT * FROM read_parquet('%s') LIMIT 0) TO '%s' (FORMAT parquet)`, escapeSQLString(valid), escapeSQLString(empty)))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		path          string
+		expectedError string
+	}{
+		{name: "valid dataset", path: valid},
+		{name: "truncated download", path: truncated, expectedError: "magic bytes"},
+		{name: "not a parquet file", path: notParquet, expectedError: "magic bytes"},
+		{name: "missing columns", path: missingColumns, expectedError: "missing columns: brands"},
+		{name: "no rows", path: empty, expectedError: "no rows"},
+		{name: "missing file", path: filepath.Join(dir, "nope.parquet"), expectedError: "no such file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateDataset(context.Background(), tt.path)
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.expectedError)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package refresh
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/query"
+)
+
+// DefaultDrainTimeout bounds how long a replaced engine may keep serving in-flight queries before it is closed
+const DefaultDrainTimeout = 2 * time.Minute
+
+// Refresher brings the local dataset up to date and reports whether it changed
+type Refresher interface {
+	Refresh(ctx context.Context) (bool, error)
+}
+
+// EngineFactory builds a query engine on the current dataset file
+type EngineFactory func() (query.QueryEngine, error)
+
+// Scheduler periodically refreshes the dataset and swaps a new query engine in behind the MCP server
+type Scheduler struct {
+	refresher    Refresher
+	engine       *query.SwappableEngine
+	newEngine    EngineFactory
+	interval     time.Duration
+	drainTimeout time.Duration
+	onSwap       func(query.QueryEngine)
+	log          *slog.Logger
+}
+
+// NewScheduler creates a scheduler that checks for a new dataset every interval
+func NewScheduler(refresher Refresher, engine *query.SwappableEngine, newEngine EngineFactory, interval time.Duration, logger *slog.Logger) *Scheduler {
+	return &Scheduler{
+		refresher:    refresher,
+		engine:       engine,
+		newEngine:    newEngine,
+		interval:     interval,
+		drainTimeout: DefaultDrainTimeout,
+		log:          logger,
+	}
+}
+
+// OnSwap registers a callback that runs with the new engine after every swap
+func (s *Scheduler) OnSwap(fn func(query.QueryEngine)) {
+	s.onSwap = fn
+}
+
+// Run refreshes on every tick until ctx is cancelled; a non-positive interval disables refreshing
+func (s *Scheduler) Run(ctx context.Context) {
+	if s.interval <= 0 {
+		s.log.Info("Background dataset refresh disabled")
+		return
+	}
+
+	s.log.Info("Background dataset refresh enabled", "interval", s.interval)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.RefreshOnce(ctx); err != nil {
+				s.log.Error("Background dataset refresh failed, keeping current engine", "error", err)
+			}
+		}
+	}
+}
+
+// RefreshOnce refreshes the dataset and, if it changed, swaps in an engine built on the new file
+func (s *Scheduler) RefreshOnce(ctx context.Context) (bool, error) {
+	start := time.Now()
+
+	changed, err := s.refresher.Refresh(ctx)
+	if err != nil {
+		return false, err
+	}
+	if !changed {
+		return false, nil
+	}
+
+	next, err := s.newEngine()
+	if err != nil {
+		return false, fmt.Errorf("failed to create query engine: %w", err)
+	}
+
+	// Only swap to an engine that can actually read the new dataset
+	if err := next.TestConnection(ctx); err != nil {
+		next.Close()
+		return false, fmt.Errorf("failed to test connection: %w", err)
+	}
+
+	s.engine.Swap(next, s.drainTimeout)
+	if s.onSwap != nil {
+		s.onSwap(next)
+	}
+
+	s.log.Info("Dataset refreshed and query engine swapped", "duration", time.Since(start))
+	return true, nil
+}
@@ -0,0 +1,126 @@
+package refresh
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/config"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/query"
+	"github.com/noot-app/openfoodfacts-mcp-server/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRefresher returns a fixed refresh outcome and counts calls
+type fakeRefresher struct {
+	changed bool
+	err     error
+	calls   int
+}
+
+func (f *fakeRefresher) Refresh(ctx context.Context) (bool, error) {
+	f.calls++
+	return f.changed, f.err
+}
+
+func TestScheduler_RefreshOnce(t *testing.T) {
+	tests := []struct {
+		name            string
+		refresher       *fakeRefresher
+		factoryErr      error
+		connectionErr   error
+		expectChanged   bool
+		expectError     bool
+		expectedProduct string
+	}{
+		{
+			name:            "dataset unchanged keeps engine",
+			refresher:       &fakeRefresher{changed: false},
+			expectedProduct: "Old",
+		},
+		{
+			name:            "dataset changed swaps engine",
+			refresher:       &fakeRefresher{changed: true},
+			expectChanged:   true,
+			expectedProduct: "New",
+		},
+		{
+			name:            "refresh failure keeps engine",
+			refresher:       &fakeRefresher{err: errors.New("remote unavailable")},
+			expectError:     true,
+			expectedProduct: "Old",
+		},
+		{
+			name:            "engine creation failure keeps engine",
+			refresher:       &fakeRefresher{changed: true},
+			factoryErr:      errors.New("bad parquet"),
+			expectError:     true,
+			expectedProduct: "Old",
+		},
+		{
+			name:            "unreadable dataset keeps engine",
+			refresher:       &fakeRefresher{changed: true},
+			connectionErr:   errors.New("connection test failed"),
+			expectError:     true,
+			expectedProduct: "Old",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := config.NewTestLogger(io.Discard, "debug")
+			ctx := context.Background()
+
+			current := query.NewMockEngine(logger)
+			current.SetProducts([]types.Product{{Code: "1", ProductName: "Old"}})
+			engine := query.NewSwappableEngine(current, logger)
+
+			next := query.NewMockEngine(logger)
+			next.SetProducts([]types.Product{{Code: "1", ProductName: "New"}})
+			factory := func() (query.QueryEngine, error) {
+				if tt.factoryErr != nil {
+					return nil, tt.factoryErr
+				}
+				next.SetError(tt.connectionErr)
+				return next, nil
+			}
+
+			var swapped query.QueryEngine
+			scheduler := NewScheduler(tt.refresher, engine, factory, time.Hour, logger)
+			scheduler.OnSwap(func(e query.QueryEngine) { swapped = e })
+
+			changed, err := scheduler.RefreshOnce(ctx)
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectChanged, changed)
+
+			if tt.expectChanged {
+				assert.Same(t, next, swapped)
+			} else {
+				assert.Nil(t, swapped)
+			}
+
+			product, err := engine.SearchByBarcode(ctx, "1")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedProduct, product.ProductName)
+		})
+	}
+}
+
+func TestScheduler_RunDisabled(t *testing.T) {
+	logger := config.NewTestLogger(io.Discard, "debug")
+	refresher := &fakeRefresher{changed: true}
+	engine := query.NewSwappableEngine(query.NewMockEngine(logger), logger)
+
+	scheduler := NewScheduler(refresher, engine, nil, 0, logger)
+
+	// Returns immediately without refreshing
+	scheduler.Run(context.Background())
+	assert.Equal(t, 0, refresher.calls)
+}
@@ -0,0 +1,167 @@
+package types
+
+import "strings"
+
+// Product-level statuses rolled up from the per-ingredient attributes
+const (
+	StatusYes     = "yes"
+	StatusNo      = "no"
+	StatusMaybe   = "maybe"
+	StatusUnknown = "unknown" // Some ingredients have no value, or there is no ingredient list
+)
+
+// FlatIngredient is one ingredient of a flattened ingredient tree
+type FlatIngredient struct {
+	ID              string   `json:"id"`
+	Text            string   `json:"text"`
+	Depth           int      `json:"depth"`            // 0 for top-level ingredients, 1 for their sub-ingredients, ...
+	Parent          string   `json:"parent,omitempty"` // ID of the ingredient this one is part of
+	PercentEstimate *float64 `json:"percent_estimate,omitempty"`
+	Vegan           string   `json:"vegan,omitempty"`         // yes, no or maybe as computed by Open Food Facts
+	Vegetarian      string   `json:"vegetarian,omitempty"`    // yes, no or maybe
+	FromPalmOil     string   `json:"from_palm_oil,omitempty"` // yes, no or maybe
+	InTaxonomy      *bool    `json:"in_taxonomy,omitempty"`   // False for ingredients Open Food Facts could not recognize
+	HasChildren     bool     `json:"-"`
+}
+
+// IngredientAnalysis is a product's flattened ingredient tree with its diet statuses rolled up
+type IngredientAnalysis struct {
+	Ingredients        []FlatIngredient `json:"ingredients"`                    // Depth first, each ingredient followed by its sub-ingredients
+	Vegan              string           `json:"vegan"`                          // yes, no, maybe or unknown
+	Vegetarian         string           `json:"vegetarian"`                     // yes, no, maybe or unknown
+	FromPalmOil        string           `json:"from_palm_oil"`                  // yes if any ingredient is, otherwise no, maybe or unknown
+	NonVegan           []string         `json:"non_vegan,omitempty"`            // Ingredients marked vegan: no
+	NonVegetarian      []string         `json:"non_vegetarian,omitempty"`       // Ingredients marked vegetarian: no
+	PalmOilIngredients []string         `json:"palm_oil_ingredients,omitempty"` // Ingredients marked from_palm_oil: yes
+	UnknownIngredients []string         `json:"unknown_ingredients,omitempty"`  // Ingredient texts not found in the taxonomy
+}
+
+// FlattenIngredients lists every ingredient and sub-ingredient depth first
+func FlattenIngredients(ingredients []Ingredient) []FlatIngredient {
+	var flat []FlatIngredient
+	var walk func(list []Ingredient, depth int, parent string)
+	walk = func(list []Ingredient, depth int, parent string) {
+		for _, ingredient := range list {
+			subs := ingredient.SubIngredients()
+			item := FlatIngredient{
+				ID:              ingredient.ID,
+				Text:            ingredient.Text,
+				Depth:           depth,
+				Parent:          parent,
+				PercentEstimate: ingredient.PercentEstimate,
+				Vegan:           attribute(ingredient.Vegan),
+				Vegetarian:      attribute(ingredient.Vegetarian),
+				FromPalmOil:     attribute(ingredient.FromPalmOil),
+				HasChildren:     len(subs) > 0,
+			}
+			if ingredient.IsInTaxonomy != nil {
+				inTaxonomy := *ingredient.IsInTaxonomy != 0
+				item.InTaxonomy = &inTaxonomy
+			}
+			flat = append(flat, item)
+			walk(subs, depth+1, ingredient.ID)
+		}
+	}
+	walk(ingredients, 0, "")
+	return flat
+}
+
+// AnalyzeIngredients flattens an ingredient tree and rolls up its vegan, vegetarian and palm oil statuses
+// An ingredient without a value is covered by its sub-ingredients when it has any
+func AnalyzeIngredients(ingredients []Ingredient) *IngredientAnalysis {
+	analysis := &IngredientAnalysis{
+		Ingredients: FlattenIngredients(ingredients),
+		Vegan:       StatusUnknown,
+		Vegetarian:  StatusUnknown,
+		FromPalmOil: StatusUnknown,
+	}
+	if len(analysis.Ingredients) == 0 {
+		analysis.Ingredients = []FlatIngredient{}
+		return analysis
+	}
+
+	vegan, vegetarian, palmOil := rollup{}, rollup{}, rollup{}
+	for _, ingredient := range analysis.Ingredients {
+		name := ingredient.Name()
+		vegan.add(ingredient.Vegan, ingredient.HasChildren)
+		vegetarian.add(ingredient.Vegetarian, ingredient.HasChildren)
+		palmOil.add(ingredient.FromPalmOil, ingredient.HasChildren)
+
+		if ingredient.Vegan == StatusNo {
+			analysis.NonVegan = append(analysis.NonVegan, name)
+		}
+		if ingredient.Vegetarian == StatusNo {
+			analysis.NonVegetarian = append(analysis.NonVegetarian, name)
+		}
+		if ingredient.FromPalmOil == StatusYes {
+			analysis.PalmOilIngredients = append(analysis.PalmOilIngredients, name)
+		}
+		if ingredient.InTaxonomy != nil && !*ingredient.InTaxonomy {
+			analysis.UnknownIngredients = append(analysis.UnknownIngredients, name)
+		}
+	}
+
+	analysis.Vegan = vegan.status(StatusNo, StatusYes)
+	analysis.Vegetarian = vegetarian.status(StatusNo, StatusYes)
+	analysis.FromPalmOil = palmOil.status(StatusYes, StatusNo)
+	return analysis
+}
+
+// IngredientAnalysis analyzes the product's ingredient list, or returns nil when it has none
+func (p *Product) IngredientAnalysis() *IngredientAnalysis {
+	ingredients := p.ParsedIngredients()
+	if len(ingredients) == 0 {
+		return nil
+	}
+	return AnalyzeIngredients(ingredients)
+}
+
+// Name returns the ingredient's text, falling back to its ID
+func (f FlatIngredient) Name() string {
+	if f.Text != "" {
+		return f.Text
+	}
+	return f.ID
+}
+
+// rollup counts the attribute values of a product's ingredients
+type rollup struct {
+	values  map[string]bool
+	unknown bool
+}
+
+// add records one ingredient's value; parents without a value defer to their sub-ingredients
+func (r *rollup) add(value string, hasChildren bool) {
+	if value == "" {
+		if !hasChildren {
+			r.unknown = true
+		}
+		return
+	}
+	if r.values == nil {
+		r.values = map[string]bool{}
+	}
+	r.values[value] = true
+}
+
+// status picks the product status: decisive if any ingredient has it, then maybe, then unknown, else clear
+func (r *rollup) status(decisive, clear string) string {
+	switch {
+	case r.values[decisive]:
+		return decisive
+	case r.values[StatusMaybe]:
+		return StatusMaybe
+	case r.unknown:
+		return StatusUnknown
+	default:
+		return clear
+	}
+}
+
+// attribute normalizes a per-ingredient attribute value
+func attribute(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(*value))
+}
@@ -0,0 +1,163 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ingredient builds a dataset ingredient object with optional attributes and sub-ingredients
+func ingredient(id, text string, attributes map[string]interface{}, subs ...interface{}) map[string]interface{} {
+	values := map[string]interface{}{"id": id, "text": text}
+	for key, value := range attributes {
+		values[key] = value
+	}
+	if len(subs) > 0 {
+		values["ingredients"] = subs
+	}
+	return values
+}
+
+func TestFlattenIngredients(t *testing.T) {
+	product := Product{Ingredients: []interface{}{
+		ingredient("en:sugar", "sugar", map[string]interface{}{"percent_estimate": 50.0}),
+		ingredient("en:chocolate", "chocolate", nil,
+			ingredient("en:cocoa-mass", "cocoa mass", nil),
+			ingredient("en:emulsifier", "emulsifier", nil,
+				ingredient("en:soy-lecithin", "soy lecithin", nil),
+			),
+		),
+	}}
+
+	flat := FlattenIngredients(product.ParsedIngredients())
+	require.Len(t, flat, 5)
+
+	type entry struct {
+		id     string
+		depth  int
+		parent string
+	}
+	entries := []entry{}
+	for _, item := range flat {
+		entries = append(entries, entry{item.ID, item.Depth, item.Parent})
+	}
+	assert.Equal(t, []entry{
+		{"en:sugar", 0, ""},
+		{"en:chocolate", 0, ""},
+		{"en:cocoa-mass", 1, "en:chocolate"},
+		{"en:emulsifier", 1, "en:chocolate"},
+		{"en:soy-lecithin", 2, "en:emulsifier"},
+	}, entries)
+	require.NotNil(t, flat[0].PercentEstimate)
+	assert.Equal(t, 50.0, *flat[0].PercentEstimate)
+	assert.True(t, flat[1].HasChildren)
+	assert.False(t, flat[2].HasChildren)
+}
+
+func TestAnalyzeIngredients(t *testing.T) {
+	yes := map[string]interface{}{"vegan": "yes", "vegetarian": "yes", "from_palm_oil": "no", "is_in_taxonomy": 1}
+
+	tests := []struct {
+		name               string
+		ingredients        []interface{}
+		expectedVegan      string
+		expectedVegetarian string
+		expectedPalmOil    string
+		expectedNonVegan   []string
+		expectedPalmOils   []string
+		expectedUnknown    []string
+	}{
+		{
+			name: "all plant based",
+			ingredients: []interface{}{
+				ingredient("en:sugar", "sugar", yes),
+				ingredient("en:cocoa", "cocoa", yes),
+			},
+			expectedVegan:      StatusYes,
+			expectedVegetarian: StatusYes,
+			expectedPalmOil:    StatusNo,
+		},
+		{
+			name: "milk in a sub-ingredient",
+			ingredients: []interface{}{
+				ingredient("en:sugar", "sugar", yes),
+				ingredient("en:chocolate", "chocolate", nil,
+					ingredient("en:cocoa", "cocoa", yes),
+					ingredient("en:whole-milk-powder", "whole milk powder",
+						map[string]interface{}{"vegan": "no", "vegetarian": "yes", "from_palm_oil": "no"}),
+				),
+			},
+			expectedVegan:      StatusNo,
+			expectedVegetarian: StatusYes,
+			expectedPalmOil:    StatusNo,
+			expectedNonVegan:   []string{"whole milk powder"},
+		},
+		{
+			name: "maybe and palm oil",
+			ingredients: []interface{}{
+				ingredient("en:palm-oil", "palm oil", map[string]interface{}{"vegan": "yes", "vegetarian": "yes", "from_palm_oil": "yes"}),
+				ingredient("en:e471", "mono- and diglycerides", map[string]interface{}{"vegan": "maybe", "vegetarian": "maybe", "from_palm_oil": "maybe"}),
+			},
+			expectedVegan:      StatusMaybe,
+			expectedVegetarian: StatusMaybe,
+			expectedPalmOil:    StatusYes,
+			expectedPalmOils:   []string{"palm oil"},
+		},
+		{
+			name: "unrecognized ingredient",
+			ingredients: []interface{}{
+				ingredient("en:sugar", "sugar", yes),
+				ingredient("en:secret-blend", "secret blend", map[string]interface{}{"is_in_taxonomy": 0}),
+			},
+			expectedVegan:      StatusUnknown,
+			expectedVegetarian: StatusUnknown,
+			expectedPalmOil:    StatusUnknown,
+			expectedUnknown:    []string{"secret blend"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := Product{Ingredients: tt.ingredients}
+			analysis := product.IngredientAnalysis()
+			require.NotNil(t, analysis)
+
+			assert.Equal(t, tt.expectedVegan, analysis.Vegan)
+			assert.Equal(t, tt.expectedVegetarian, analysis.Vegetarian)
+			assert.Equal(t, tt.expectedPalmOil, analysis.FromPalmOil)
+			assert.Equal(t, tt.expectedNonVegan, analysis.NonVegan)
+			assert.Equal(t, tt.expectedPalmOils, analysis.PalmOilIngredients)
+			assert.Equal(t, tt.expectedUnknown, analysis.UnknownIngredients)
+		})
+	}
+}
+
+func TestProduct_IngredientAnalysis_NoIngredients(t *testing.T) {
+	product := Product{Ingredients: map[string]interface{}{"text": "sugar, cocoa"}}
+	assert.Nil(t, product.IngredientAnalysis())
+	assert.Nil(t, product.ToSimplified().IngredientAnalysis)
+
+	analysis := AnalyzeIngredients(nil)
+	assert.Equal(t, StatusUnknown, analysis.Vegan)
+	assert.Empty(t, analysis.Ingredients)
+}
+
+func TestProduct_ToSimplified_IngredientAnalysis(t *testing.T) {
+	product := Product{Ingredients: []interface{}{
+		ingredient("en:chocolate", "chocolate", nil,
+			ingredient("en:cocoa", "cocoa", map[string]interface{}{"vegan": "yes", "vegetarian": "yes", "from_palm_oil": "no"}),
+			ingredient("en:milk", "milk", map[string]interface{}{"vegan": "no", "vegetarian": "yes", "from_palm_oil": "no"}),
+		),
+	}}
+
+	simplified := product.ToSimplified()
+
+	// Top-level ingredients are unchanged; the nested ones are in the analysis
+	require.Len(t, simplified.Ingredients, 1)
+	require.NotNil(t, simplified.IngredientAnalysis)
+	assert.Len(t, simplified.IngredientAnalysis.Ingredients, 3)
+	assert.Equal(t, StatusNo, simplified.IngredientAnalysis.Vegan)
+	assert.Equal(t, StatusYes, simplified.IngredientAnalysis.Vegetarian)
+	assert.Equal(t, StatusNo, simplified.IngredientAnalysis.FromPalmOil)
+}
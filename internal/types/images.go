@@ -0,0 +1,144 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ImageBaseURL is the root of the Open Food Facts static image server
+const ImageBaseURL = "https://images.openfoodfacts.org/images/products"
+
+// ImageTypes are the selected image kinds exposed on products, in display order
+var ImageTypes = []string{"front", "ingredients", "nutrition", "packaging"}
+
+// ImageSizes are the resized variants generated for every selected image
+var ImageSizes = []string{"100", "200", "400", "full"}
+
+// PreferredImageSize is the size used when a single thumbnail URL is needed
+const PreferredImageSize = "400"
+
+// ProductImage is a selected product photo with its URL in each available size
+type ProductImage struct {
+	Type     string            `json:"type"`               // front, ingredients, nutrition or packaging
+	Language string            `json:"language,omitempty"` // Language code the image was selected for
+	Rev      int               `json:"rev"`                // Image revision, part of the URL
+	URLs     map[string]string `json:"urls"`               // Keyed by size: 100, 200, 400, full
+}
+
+// imageEntry is one element of the parquet images column
+type imageEntry struct {
+	Key   string                      `json:"key"`
+	Rev   *int                        `json:"rev"`
+	Sizes map[string]*json.RawMessage `json:"sizes"`
+}
+
+// BarcodePath returns the folder path for a barcode on the image server
+// Barcodes longer than 8 digits are zero-padded to 13 and split as 3/3/3/rest
+func BarcodePath(code string) string {
+	if len(code) <= 8 {
+		return code
+	}
+	if len(code) < 13 {
+		code = strings.Repeat("0", 13-len(code)) + code
+	}
+	return code[0:3] + "/" + code[3:6] + "/" + code[6:9] + "/" + code[9:]
+}
+
+// ImageURL returns the URL of a selected image, e.g. front_en.12.400.jpg
+func ImageURL(code, key string, rev int, size string) string {
+	return fmt.Sprintf("%s/%s/%s.%d.%s.jpg", ImageBaseURL, BarcodePath(code), key, rev, size)
+}
+
+// ParseImages builds selected image URLs from the JSON of the parquet images column
+// Raw uploads (numeric keys) and unknown image kinds are skipped
+func ParseImages(code, imagesJSON string) ([]ProductImage, error) {
+	if code == "" || imagesJSON == "" || imagesJSON == "null" {
+		return nil, nil
+	}
+
+	var entries []imageEntry
+	if err := json.Unmarshal([]byte(imagesJSON), &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse images: %w", err)
+	}
+
+	var images []ProductImage
+	for _, entry := range entries {
+		if entry.Rev == nil {
+			continue
+		}
+
+		imageType, language, _ := strings.Cut(entry.Key, "_")
+		if !isImageType(imageType) {
+			continue
+		}
+
+		image := ProductImage{
+			Type:     imageType,
+			Language: language,
+			Rev:      *entry.Rev,
+			URLs:     make(map[string]string, len(ImageSizes)),
+		}
+		for _, size := range ImageSizes {
+			// Older entries have no size metadata; every size is generated for selected images
+			if len(entry.Sizes) > 0 && !hasImageSize(entry.Sizes, size) {
+				continue
+			}
+			image.URLs[size] = ImageURL(code, entry.Key, *entry.Rev, size)
+		}
+		images = append(images, image)
+	}
+
+	sort.SliceStable(images, func(i, j int) bool {
+		if images[i].Type != images[j].Type {
+			return imageTypeOrder(images[i].Type) < imageTypeOrder(images[j].Type)
+		}
+		return images[i].Language < images[j].Language
+	})
+
+	return images, nil
+}
+
+// FrontImageURL returns the front image URL in the preferred size and language
+// It falls back to English, then to the first front image available
+func (p *Product) FrontImageURL(language string) string {
+	var fallback string
+	for _, image := range p.Images {
+		if image.Type != "front" {
+			continue
+		}
+		url := image.URLs[PreferredImageSize]
+		if url == "" {
+			url = image.URLs["full"]
+		}
+		if image.Language == language {
+			return url
+		}
+		if fallback == "" || image.Language == "en" {
+			fallback = url
+		}
+	}
+	return fallback
+}
+
+// hasImageSize reports whether the sizes metadata lists a non-null entry for size
+func hasImageSize(sizes map[string]*json.RawMessage, size string) bool {
+	raw, ok := sizes[size]
+	return ok && raw != nil && string(*raw) != "null"
+}
+
+// isImageType reports whether t is one of the selected image kinds
+func isImageType(t string) bool {
+	return imageTypeOrder(t) < len(ImageTypes)
+}
+
+// imageTypeOrder returns the display position of an image kind
+func imageTypeOrder(t string) int {
+	for i, known := range ImageTypes {
+		if known == t {
+			return i
+		}
+	}
+	return len(ImageTypes)
+}
@@ -0,0 +1,88 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBarcodePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{name: "EAN-13", code: "3017620422003", expected: "301/762/042/2003"},
+		{name: "UPC-A is padded to 13 digits", code: "049000028911", expected: "004/900/002/8911"},
+		{name: "longer than 13 digits", code: "12345678901234", expected: "123/456/789/01234"},
+		{name: "EAN-8 is not split", code: "12345670", expected: "12345670"},
+		{name: "short internal code", code: "123", expected: "123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, BarcodePath(tt.code))
+		})
+	}
+}
+
+func TestParseImages(t *testing.T) {
+	imagesJSON := `[
+		{"key": "nutrition_fr", "imgid": 4, "rev": 20, "sizes": {"100": {"h": 100, "w": 80}, "200": null, "400": {"h": 400, "w": 320}, "full": {"h": 1000, "w": 800}}},
+		{"key": "front_fr", "imgid": 2, "rev": 9, "sizes": null},
+		{"key": "front_en", "imgid": 1, "rev": 7, "sizes": {"400": {"h": 400, "w": 300}}},
+		{"key": "4", "imgid": 4, "rev": null, "sizes": {"100": {"h": 100, "w": 80}}},
+		{"key": "other_en", "imgid": 5, "rev": 3, "sizes": null}
+	]`
+
+	images, err := ParseImages("3017620422003", imagesJSON)
+	require.NoError(t, err)
+	require.Len(t, images, 3)
+
+	// Sorted by type order then language
+	assert.Equal(t, "front", images[0].Type)
+	assert.Equal(t, "en", images[0].Language)
+	assert.Equal(t, map[string]string{
+		"400": "https://images.openfoodfacts.org/images/products/301/762/042/2003/front_en.7.400.jpg",
+	}, images[0].URLs)
+
+	// No size metadata means every standard size
+	assert.Equal(t, "fr", images[1].Language)
+	assert.Len(t, images[1].URLs, len(ImageSizes))
+
+	assert.Equal(t, "nutrition", images[2].Type)
+	assert.Equal(t, 20, images[2].Rev)
+	assert.NotContains(t, images[2].URLs, "200")
+	assert.Equal(t, "https://images.openfoodfacts.org/images/products/301/762/042/2003/nutrition_fr.20.full.jpg", images[2].URLs["full"])
+}
+
+func TestParseImages_EmptyAndInvalid(t *testing.T) {
+	for _, input := range []string{"", "null", "[]"} {
+		images, err := ParseImages("3017620422003", input)
+		assert.NoError(t, err)
+		assert.Empty(t, images)
+	}
+
+	_, err := ParseImages("3017620422003", "{not json")
+	assert.Error(t, err)
+}
+
+func TestProduct_FrontImageURL(t *testing.T) {
+	product := Product{
+		Code: "3017620422003",
+		Images: []ProductImage{
+			{Type: "front", Language: "de", URLs: map[string]string{"full": "de-full"}},
+			{Type: "front", Language: "en", URLs: map[string]string{"400": "en-400"}},
+			{Type: "front", Language: "fr", URLs: map[string]string{"400": "fr-400"}},
+			{Type: "nutrition", Language: "it", URLs: map[string]string{"400": "nutrition-400"}},
+		},
+	}
+
+	assert.Equal(t, "fr-400", product.FrontImageURL("fr"))
+	assert.Equal(t, "en-400", product.FrontImageURL("it"))
+	assert.Equal(t, "", (&Product{}).FrontImageURL("en"))
+
+	simplified := product.ToSimplified()
+	assert.Equal(t, "en-400", simplified.ImageURL)
+}
@@ -0,0 +1,82 @@
+package types
+
+// ParsedIngredients returns the loosely typed Ingredients list as typed Ingredient values
+// Anything other than a list of ingredient objects, such as a bare text field, yields nil
+func (p *Product) ParsedIngredients() []Ingredient {
+	return parseIngredientList(p.Ingredients)
+}
+
+// SubIngredients returns the typed ingredients an ingredient is made of, e.g. the parts of "chocolate"
+func (i Ingredient) SubIngredients() []Ingredient {
+	if len(i.Ingredients) == 0 {
+		return nil
+	}
+	parsed := make([]Ingredient, 0, len(i.Ingredients))
+	for _, item := range i.Ingredients {
+		parsed = append(parsed, ingredientFromMap(item))
+	}
+	return parsed
+}
+
+// parseIngredientList converts the decoded JSON of an ingredients column
+func parseIngredientList(raw interface{}) []Ingredient {
+	var items []map[string]interface{}
+	switch list := raw.(type) {
+	case []interface{}:
+		for _, item := range list {
+			if values, ok := item.(map[string]interface{}); ok {
+				items = append(items, values)
+			}
+		}
+	case []map[string]interface{}:
+		items = list
+	default:
+		return nil
+	}
+
+	parsed := make([]Ingredient, 0, len(items))
+	for _, item := range items {
+		parsed = append(parsed, ingredientFromMap(item))
+	}
+	return parsed
+}
+
+// ingredientFromMap reads the fields of one ingredient object
+func ingredientFromMap(values map[string]interface{}) Ingredient {
+	ingredient := Ingredient{
+		FromPalmOil:     stringField(values, "from_palm_oil"),
+		Labels:          stringField(values, "labels"),
+		Origins:         stringField(values, "origins"),
+		Percent:         floatField(values, "percent"),
+		PercentEstimate: floatField(values, "percent_estimate"),
+		PercentMax:      floatField(values, "percent_max"),
+		PercentMin:      floatField(values, "percent_min"),
+		Processing:      stringField(values, "processing"),
+		Quantity:        stringField(values, "quantity"),
+		QuantityG:       floatField(values, "quantity_g"),
+		Vegan:           stringField(values, "vegan"),
+		Vegetarian:      stringField(values, "vegetarian"),
+	}
+	if id, ok := values["id"].(string); ok {
+		ingredient.ID = id
+	}
+	if text, ok := values["text"].(string); ok {
+		ingredient.Text = text
+	}
+	if inTaxonomy := floatField(values, "is_in_taxonomy"); inTaxonomy != nil {
+		value := int(*inTaxonomy)
+		ingredient.IsInTaxonomy = &value
+	}
+
+	switch nested := values["ingredients"].(type) {
+	case []interface{}:
+		for _, item := range nested {
+			if sub, ok := item.(map[string]interface{}); ok {
+				ingredient.Ingredients = append(ingredient.Ingredients, sub)
+			}
+		}
+	case []map[string]interface{}:
+		ingredient.Ingredients = nested
+	}
+	return ingredient
+}
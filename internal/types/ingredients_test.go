@@ -0,0 +1,75 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProduct_ParsedIngredients(t *testing.T) {
+	tests := []struct {
+		name        string
+		ingredients interface{}
+		expectedIDs []string
+	}{
+		{
+			name: "list of ingredient objects",
+			ingredients: []interface{}{
+				map[string]interface{}{"id": "en:sugar", "text": "sugar", "percent_estimate": 56.3, "vegan": "yes"},
+				"not an object",
+				map[string]interface{}{"id": "en:skimmed-milk-powder", "text": "skimmed milk powder", "vegan": "no", "is_in_taxonomy": 1.0},
+			},
+			expectedIDs: []string{"en:sugar", "en:skimmed-milk-powder"},
+		},
+		{name: "bare text", ingredients: map[string]interface{}{"text": "sugar, cocoa"}},
+		{name: "unparsed string", ingredients: `[{"id": "en:sugar"}]`},
+		{name: "missing", ingredients: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := Product{Ingredients: tt.ingredients}
+			parsed := product.ParsedIngredients()
+
+			ids := []string{}
+			for _, ingredient := range parsed {
+				ids = append(ids, ingredient.ID)
+			}
+			if tt.expectedIDs == nil {
+				assert.Empty(t, parsed)
+				return
+			}
+			assert.Equal(t, tt.expectedIDs, ids)
+		})
+	}
+}
+
+func TestIngredient_SubIngredients(t *testing.T) {
+	product := Product{Ingredients: []interface{}{
+		map[string]interface{}{
+			"id":   "en:chocolate",
+			"text": "chocolate",
+			"ingredients": []interface{}{
+				map[string]interface{}{"id": "en:cocoa-butter", "text": "cocoa butter", "vegan": "yes", "vegetarian": "yes"},
+				map[string]interface{}{"id": "en:whole-milk-powder", "text": "whole milk powder", "vegan": "no", "percent_estimate": "12.5"},
+			},
+		},
+	}}
+
+	parsed := product.ParsedIngredients()
+	require.Len(t, parsed, 1)
+	assert.Equal(t, "en:chocolate", parsed[0].ID)
+	assert.Nil(t, parsed[0].Vegan)
+
+	subs := parsed[0].SubIngredients()
+	require.Len(t, subs, 2)
+	assert.Equal(t, "en:cocoa-butter", subs[0].ID)
+	require.NotNil(t, subs[0].Vegetarian)
+	assert.Equal(t, "yes", *subs[0].Vegetarian)
+	require.NotNil(t, subs[1].Vegan)
+	assert.Equal(t, "no", *subs[1].Vegan)
+	require.NotNil(t, subs[1].PercentEstimate)
+	assert.Equal(t, 12.5, *subs[1].PercentEstimate)
+	assert.Empty(t, subs[1].SubIngredients())
+}
@@ -0,0 +1,78 @@
+package types
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// ParsedNutriments returns the loosely typed Nutriments map as typed Nutriment values keyed by name
+// Entries may be full nutrient objects from the dataset or bare numbers, which are read as per-100g values
+func (p *Product) ParsedNutriments() map[string]Nutriment {
+	parsed := make(map[string]Nutriment, len(p.Nutriments))
+
+	for name, raw := range p.Nutriments {
+		nutriment := Nutriment{Name: name}
+
+		switch value := raw.(type) {
+		case map[string]interface{}:
+			nutriment.Per100g = floatField(value, "100g")
+			nutriment.Serving = floatField(value, "serving")
+			nutriment.Value = floatField(value, "value")
+			nutriment.PreparedPer100g = floatField(value, "prepared_100g")
+			nutriment.PreparedServing = floatField(value, "prepared_serving")
+			nutriment.PreparedValue = floatField(value, "prepared_value")
+			nutriment.Unit = stringField(value, "unit")
+			nutriment.PreparedUnit = stringField(value, "prepared_unit")
+		default:
+			nutriment.Per100g = toFloat(value)
+		}
+
+		parsed[name] = nutriment
+	}
+
+	return parsed
+}
+
+// floatField reads a numeric field from a nutrient object
+func floatField(values map[string]interface{}, key string) *float64 {
+	return toFloat(values[key])
+}
+
+// stringField reads a non-empty string field from a nutrient object
+func stringField(values map[string]interface{}, key string) *string {
+	if s, ok := values[key].(string); ok && s != "" {
+		return &s
+	}
+	return nil
+}
+
+// toFloat converts the numeric representations found in the dataset to float64
+func toFloat(value interface{}) *float64 {
+	var f float64
+	switch v := value.(type) {
+	case float64:
+		f = v
+	case float32:
+		f = float64(v)
+	case int:
+		f = float64(v)
+	case int64:
+		f = float64(v)
+	case json.Number:
+		parsed, err := v.Float64()
+		if err != nil {
+			return nil
+		}
+		f = parsed
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil
+		}
+		f = parsed
+	default:
+		return nil
+	}
+	return &f
+}
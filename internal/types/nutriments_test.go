@@ -0,0 +1,39 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProduct_ParsedNutriments(t *testing.T) {
+	product := Product{
+		Nutriments: map[string]interface{}{
+			"sugars": map[string]interface{}{"name": "sugars", "100g": 56.3, "serving": "8.4", "value": 56.3, "unit": "g"},
+			"salt":   map[string]interface{}{"name": "salt", "100g": nil, "unit": ""},
+			"energy": 2255,
+			"broken": "n/a",
+		},
+	}
+
+	parsed := product.ParsedNutriments()
+	require.Len(t, parsed, 4)
+
+	sugars := parsed["sugars"]
+	assert.Equal(t, "sugars", sugars.Name)
+	require.NotNil(t, sugars.Per100g)
+	assert.Equal(t, 56.3, *sugars.Per100g)
+	require.NotNil(t, sugars.Serving)
+	assert.Equal(t, 8.4, *sugars.Serving)
+	require.NotNil(t, sugars.Unit)
+	assert.Equal(t, "g", *sugars.Unit)
+
+	assert.Nil(t, parsed["salt"].Per100g)
+	assert.Nil(t, parsed["salt"].Unit)
+
+	require.NotNil(t, parsed["energy"].Per100g)
+	assert.Equal(t, 2255.0, *parsed["energy"].Per100g)
+
+	assert.Nil(t, parsed["broken"].Per100g)
+}
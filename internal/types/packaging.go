@@ -0,0 +1,50 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// PackagingComponent is one packaging part of a product, e.g. a bottle or a cap
+// Material, shape and recycling are Open Food Facts taxonomy tags such as en:glass
+type PackagingComponent struct {
+	Material        string   `json:"material,omitempty"`
+	Shape           string   `json:"shape,omitempty"`
+	Recycling       string   `json:"recycling,omitempty"`
+	NumberOfUnits   *int     `json:"number_of_units,omitempty"`
+	QuantityPerUnit string   `json:"quantity_per_unit,omitempty"`
+	WeightMeasured  *float64 `json:"weight_measured,omitempty"` // Grams per unit
+}
+
+// ParsePackagings parses the JSON of the parquet packagings column
+func ParsePackagings(packagingsJSON string) ([]PackagingComponent, error) {
+	if packagingsJSON == "" || packagingsJSON == "null" {
+		return nil, nil
+	}
+
+	var components []PackagingComponent
+	if err := json.Unmarshal([]byte(packagingsJSON), &components); err != nil {
+		return nil, fmt.Errorf("failed to parse packagings: %w", err)
+	}
+
+	// Drop components that carry no information at all
+	parsed := components[:0]
+	for _, c := range components {
+		if c.Material == "" && c.Shape == "" && c.Recycling == "" && c.WeightMeasured == nil {
+			continue
+		}
+		parsed = append(parsed, c)
+	}
+	if len(parsed) == 0 {
+		return nil, nil
+	}
+	return parsed, nil
+}
+
+// Units returns the number of units of the component, defaulting to one
+func (c PackagingComponent) Units() int {
+	if c.NumberOfUnits == nil || *c.NumberOfUnits < 1 {
+		return 1
+	}
+	return *c.NumberOfUnits
+}
@@ -0,0 +1,39 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePackagings(t *testing.T) {
+	packagingsJSON := `[
+		{"material": "en:pet-1-polyethylene-terephthalate", "shape": "en:bottle", "recycling": "en:recycle", "number_of_units": 2, "quantity_per_unit": "1.5 l", "weight_measured": 32.5},
+		{"material": null, "shape": null, "recycling": null, "number_of_units": null, "quantity_per_unit": null, "weight_measured": null},
+		{"material": "en:pp-5-polypropylene", "shape": "en:bottle-cap", "recycling": null, "number_of_units": null, "quantity_per_unit": null, "weight_measured": null}
+	]`
+
+	components, err := ParsePackagings(packagingsJSON)
+	require.NoError(t, err)
+	require.Len(t, components, 2)
+
+	assert.Equal(t, "en:bottle", components[0].Shape)
+	assert.Equal(t, 2, components[0].Units())
+	require.NotNil(t, components[0].WeightMeasured)
+	assert.Equal(t, 32.5, *components[0].WeightMeasured)
+
+	assert.Equal(t, "", components[1].Recycling)
+	assert.Equal(t, 1, components[1].Units())
+}
+
+func TestParsePackagings_EmptyAndInvalid(t *testing.T) {
+	for _, input := range []string{"", "null", "[]", `[{"material": null}]`} {
+		components, err := ParsePackagings(input)
+		assert.NoError(t, err)
+		assert.Nil(t, components)
+	}
+
+	_, err := ParsePackagings("not json")
+	assert.Error(t, err)
+}
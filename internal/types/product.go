@@ -12,6 +12,13 @@ type Product struct {
 	ServingQuantity     interface{}            `json:"serving_quantity,omitempty"`
 	ServingQuantityUnit string                 `json:"serving_quantity_unit,omitempty"`
 	ServingSize         string                 `json:"serving_size,omitempty"`
+	Images              []ProductImage         `json:"images,omitempty"`
+	Packagings          []PackagingComponent   `json:"packagings,omitempty"`
+	CategoriesTags      []string               `json:"categories_tags,omitempty"`
+	LabelsTags          []string               `json:"labels_tags,omitempty"`
+	AllergensTags       []string               `json:"allergens_tags,omitempty"`
+	NutriscoreGrade     string                 `json:"nutriscore_grade,omitempty"` // Official grade from the dataset, if any
+	NutriscoreScore     *int                   `json:"nutriscore_score,omitempty"`
 }
 
 // Nutriment represents nutritional information for a product
@@ -66,6 +73,9 @@ type SimplifiedProduct struct {
 	Link        string                 `json:"link"`
 	Nutriments  map[string]interface{} `json:"nutriments"`
 	Ingredients []SimplifiedIngredient `json:"ingredients"`
+	ImageURL    string                 `json:"image_url,omitempty"` // Front image, 400px
+
+	IngredientAnalysis *IngredientAnalysis `json:"ingredient_analysis,omitempty"` // Nested ingredients and vegan/vegetarian/palm oil status
 }
 
 // ToSimplified converts a full Product to a SimplifiedProduct
@@ -79,6 +89,9 @@ func (p *Product) ToSimplified() SimplifiedProduct {
 		Link:        p.Link,
 		Nutriments:  processedNutriments,
 		Ingredients: []SimplifiedIngredient{},
+		ImageURL:    p.FrontImageURL("en"),
+
+		IngredientAnalysis: p.IngredientAnalysis(),
 	}
 
 	// Convert ingredients if they exist